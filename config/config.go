@@ -0,0 +1,291 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// everything the server needs to start; built by Load and passed down to the constructors
+type Config struct {
+	Server      ServerConfig     `yaml:"server"`
+	Storage     StorageConfig    `yaml:"storage"`
+	Mongo       MongoConfig      `yaml:"mongo"`
+	SQLite      SQLiteConfig     `yaml:"sqlite"`
+	Auth        AuthConfig       `yaml:"auth"`
+	Log         LogConfig        `yaml:"log"`
+	Trash       TrashConfig      `yaml:"trash"`
+	Attachments AttachmentConfig `yaml:"attachments"`
+}
+
+type ServerConfig struct {
+	Addr            string        `yaml:"addr"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // how long in-flight requests get to finish
+}
+
+type StorageConfig struct {
+	Backend          string        `yaml:"backend"` // mongo, memory or sqlite
+	OperationTimeout time.Duration `yaml:"operation_timeout"`
+}
+
+type MongoConfig struct {
+	URI      string `yaml:"uri"`
+	Database string `yaml:"database"`
+}
+
+type SQLiteConfig struct {
+	Path string `yaml:"path"`
+}
+
+// how long deleted tasks stay restorable, and how often the purge job looks for older ones
+type TrashConfig struct {
+	Retention     time.Duration `yaml:"retention"`
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+// where uploaded files are kept and which ones are accepted
+type AttachmentConfig struct {
+	Store        string   `yaml:"store"` // filesystem, gridfs (mongo storage only) or memory
+	Dir          string   `yaml:"dir"`   // for the filesystem store
+	MaxSize      int64    `yaml:"max_size"`
+	AllowedTypes []string `yaml:"allowed_types"` // media types such as image/png, or a whole family such as image/*
+}
+
+type LogConfig struct {
+	Level string `yaml:"level"` // debug, info, warn or error
+}
+
+// the slog level for Level; Validate has already rejected anything unparseable
+func (l LogConfig) SlogLevel() slog.Level {
+	var level slog.Level
+	level.UnmarshalText([]byte(l.Level))
+	return level
+}
+
+type AuthConfig struct {
+	JWTSecret       string        `yaml:"jwt_secret"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+	BcryptCost      int           `yaml:"bcrypt_cost"`
+}
+
+// the values used when nothing overrides them; there is deliberately no default JWT secret
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 20 * time.Second,
+		},
+		Storage: StorageConfig{Backend: "mongo", OperationTimeout: 5 * time.Second},
+		Mongo:   MongoConfig{URI: "mongodb://localhost:27017", Database: "task_manager"},
+		SQLite:  SQLiteConfig{Path: "task_manager.db"},
+		Auth: AuthConfig{
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 7 * 24 * time.Hour,
+			BcryptCost:      bcrypt.DefaultCost,
+		},
+		Log:   LogConfig{Level: "info"},
+		Trash: TrashConfig{Retention: 30 * 24 * time.Hour, PurgeInterval: time.Hour},
+		Attachments: AttachmentConfig{
+			Store:        "filesystem",
+			Dir:          "attachments",
+			MaxSize:      10 << 20,
+			AllowedTypes: []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"},
+		},
+	}
+}
+
+// builds the config from defaults, then the YAML file, then env vars, then flags (each overriding
+// the last) and validates the result. The file comes from -config or CONFIG_FILE and is optional.
+func Load(args []string, getenv func(string) string) (Config, error) {
+	fs := flag.NewFlagSet("task-manager", flag.ContinueOnError)
+	configFile := fs.String("config", getenv("CONFIG_FILE"), "path to a YAML config file")
+	addr := fs.String("addr", "", "HTTP listen address")
+	backend := fs.String("storage", "", "storage backend: mongo, memory or sqlite")
+	mongoURI := fs.String("mongo-uri", "", "MongoDB connection URI")
+	mongoDB := fs.String("mongo-db", "", "MongoDB database name")
+	sqlitePath := fs.String("sqlite-path", "", "SQLite database file")
+	logLevel := fs.String("log-level", "", "log level: debug, info, warn or error")
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	cfg := Default()
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := cfg.loadEnv(getenv); err != nil {
+		return Config{}, err
+	}
+
+	// only flags given on the command line override what came before
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			cfg.Server.Addr = *addr
+		case "storage":
+			cfg.Storage.Backend = *backend
+		case "mongo-uri":
+			cfg.Mongo.URI = *mongoURI
+		case "mongo-db":
+			cfg.Mongo.Database = *mongoDB
+		case "sqlite-path":
+			cfg.SQLite.Path = *sqlitePath
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	if err := yaml.Unmarshal(raw, c); err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv(getenv func(string) string) error {
+	texts := map[string]*string{
+		"HTTP_ADDR":        &c.Server.Addr,
+		"STORAGE_BACKEND":  &c.Storage.Backend,
+		"MONGO_URI":        &c.Mongo.URI,
+		"MONGO_DATABASE":   &c.Mongo.Database,
+		"SQLITE_PATH":      &c.SQLite.Path,
+		"JWT_SECRET":       &c.Auth.JWTSecret,
+		"LOG_LEVEL":        &c.Log.Level,
+		"ATTACHMENT_STORE": &c.Attachments.Store,
+		"ATTACHMENT_DIR":   &c.Attachments.Dir,
+	}
+	for name, field := range texts {
+		if v := getenv(name); v != "" {
+			*field = v
+		}
+	}
+
+	durations := map[string]*time.Duration{
+		"HTTP_READ_TIMEOUT":    &c.Server.ReadTimeout,
+		"HTTP_WRITE_TIMEOUT":   &c.Server.WriteTimeout,
+		"HTTP_IDLE_TIMEOUT":    &c.Server.IdleTimeout,
+		"SHUTDOWN_TIMEOUT":     &c.Server.ShutdownTimeout,
+		"OPERATION_TIMEOUT":    &c.Storage.OperationTimeout,
+		"ACCESS_TOKEN_TTL":     &c.Auth.AccessTokenTTL,
+		"REFRESH_TOKEN_TTL":    &c.Auth.RefreshTokenTTL,
+		"TRASH_RETENTION":      &c.Trash.Retention,
+		"TRASH_PURGE_INTERVAL": &c.Trash.PurgeInterval,
+	}
+	for name, field := range durations {
+		if v := getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			*field = d
+		}
+	}
+
+	if v := getenv("BCRYPT_COST"); v != "" {
+		cost, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("BCRYPT_COST: %w", err)
+		}
+		c.Auth.BcryptCost = cost
+	}
+	if v := getenv("ATTACHMENT_MAX_SIZE"); v != "" {
+		size, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("ATTACHMENT_MAX_SIZE: %w", err)
+		}
+		c.Attachments.MaxSize = size
+	}
+	return nil
+}
+
+// reports every problem at once so a misconfigured deploy fails with the full list
+func (c Config) Validate() error {
+	var errs []error
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server address is empty"))
+	}
+	if c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 || c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server timeouts must be positive"))
+	}
+	switch c.Storage.Backend {
+	case "mongo":
+		if c.Mongo.URI == "" || c.Mongo.Database == "" {
+			errs = append(errs, errors.New("mongo storage needs a URI and a database name"))
+		}
+	case "sqlite":
+		if c.SQLite.Path == "" {
+			errs = append(errs, errors.New("sqlite storage needs a path"))
+		}
+	case "memory":
+	default:
+		errs = append(errs, fmt.Errorf("unknown storage backend %q", c.Storage.Backend))
+	}
+	if c.Storage.OperationTimeout <= 0 {
+		errs = append(errs, errors.New("storage operation timeout must be positive"))
+	}
+	if c.Auth.JWTSecret == "" {
+		errs = append(errs, errors.New("JWT secret is empty; set JWT_SECRET"))
+	}
+	if c.Auth.AccessTokenTTL <= 0 || c.Auth.RefreshTokenTTL <= 0 {
+		errs = append(errs, errors.New("token TTLs must be positive"))
+	}
+	if c.Auth.BcryptCost < bcrypt.MinCost || c.Auth.BcryptCost > bcrypt.MaxCost {
+		errs = append(errs, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost))
+	}
+	if c.Trash.Retention <= 0 || c.Trash.PurgeInterval <= 0 {
+		errs = append(errs, errors.New("trash retention and purge interval must be positive"))
+	}
+	switch c.Attachments.Store {
+	case "filesystem":
+		if c.Attachments.Dir == "" {
+			errs = append(errs, errors.New("filesystem attachment store needs a directory"))
+		}
+	case "gridfs":
+		if c.Storage.Backend != "mongo" {
+			errs = append(errs, errors.New("gridfs attachment store needs mongo storage"))
+		}
+	case "memory":
+	default:
+		errs = append(errs, fmt.Errorf("unknown attachment store %q", c.Attachments.Store))
+	}
+	if c.Attachments.MaxSize <= 0 {
+		errs = append(errs, errors.New("attachment max size must be positive"))
+	}
+	if len(c.Attachments.AllowedTypes) == 0 {
+		errs = append(errs, errors.New("no attachment types are allowed"))
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("log level: %w", err))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package config_test
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"task7/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envFrom(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func writeConfigFile(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := config.Load(nil, envFrom(map[string]string{"JWT_SECRET": "s3cret"}))
+	require.NoError(t, err)
+
+	want := config.Default()
+	want.Auth.JWTSecret = "s3cret"
+	assert.Equal(t, want, cfg)
+}
+
+func TestLoad_RequiresJWTSecret(t *testing.T) {
+	_, err := config.Load(nil, envFrom(nil))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "JWT secret is empty")
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeConfigFile(t, `
+server:
+  addr: ":9000"
+storage:
+  backend: sqlite
+  operation_timeout: 2s
+sqlite:
+  path: from-file.db
+mongo:
+  database: from_file
+auth:
+  jwt_secret: file-secret
+  access_token_ttl: 30m
+  bcrypt_cost: 6
+trash:
+  retention: 168h
+attachments:
+  store: memory
+  allowed_types: [image/*]
+`)
+	env := envFrom(map[string]string{
+		"CONFIG_FILE":          path,
+		"SQLITE_PATH":          "from-env.db",
+		"MONGO_DATABASE":       "from_env",
+		"JWT_SECRET":           "env-secret",
+		"BCRYPT_COST":          "5",
+		"TRASH_PURGE_INTERVAL": "15m",
+		"ATTACHMENT_MAX_SIZE":  "2048",
+	})
+
+	cfg, err := config.Load([]string{"-sqlite-path", "from-flag.db", "-log-level", "debug"}, env)
+	require.NoError(t, err)
+
+	assert.Equal(t, ":9000", cfg.Server.Addr, "file overrides defaults")
+	assert.Equal(t, "sqlite", cfg.Storage.Backend)
+	assert.Equal(t, 2*time.Second, cfg.Storage.OperationTimeout)
+	assert.Equal(t, 30*time.Minute, cfg.Auth.AccessTokenTTL)
+	assert.Equal(t, 7*24*time.Hour, cfg.Auth.RefreshTokenTTL, "unset keys keep their defaults")
+	assert.Equal(t, "from_env", cfg.Mongo.Database, "env overrides the file")
+	assert.Equal(t, "env-secret", cfg.Auth.JWTSecret)
+	assert.Equal(t, 5, cfg.Auth.BcryptCost)
+	assert.Equal(t, "from-flag.db", cfg.SQLite.Path, "flags override env")
+	assert.Equal(t, slog.LevelDebug, cfg.Log.SlogLevel())
+	assert.Equal(t, 7*24*time.Hour, cfg.Trash.Retention)
+	assert.Equal(t, 15*time.Minute, cfg.Trash.PurgeInterval)
+	assert.Equal(t, "memory", cfg.Attachments.Store)
+	assert.Equal(t, []string{"image/*"}, cfg.Attachments.AllowedTypes)
+	assert.Equal(t, int64(2048), cfg.Attachments.MaxSize)
+}
+
+func TestLoad_ConfigFileFlag(t *testing.T) {
+	path := writeConfigFile(t, "auth:\n  jwt_secret: file-secret\nstorage:\n  backend: memory\n")
+
+	cfg, err := config.Load([]string{"-config", path}, envFrom(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "memory", cfg.Storage.Backend)
+	assert.Equal(t, "file-secret", cfg.Auth.JWTSecret)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		wantErr string
+	}{
+		{name: "Missing file", args: []string{"-config", "/nonexistent/config.yaml"}, env: map[string]string{"JWT_SECRET": "x"}, wantErr: "reading config file"},
+		{name: "Bad duration", env: map[string]string{"JWT_SECRET": "x", "ACCESS_TOKEN_TTL": "soon"}, wantErr: "ACCESS_TOKEN_TTL"},
+		{name: "Bad bcrypt cost", env: map[string]string{"JWT_SECRET": "x", "BCRYPT_COST": "99"}, wantErr: "bcrypt cost"},
+		{name: "Unknown backend", args: []string{"-storage", "redis"}, env: map[string]string{"JWT_SECRET": "x"}, wantErr: `unknown storage backend "redis"`},
+		{name: "Bad log level", args: []string{"-log-level", "chatty"}, env: map[string]string{"JWT_SECRET": "x"}, wantErr: "log level"},
+		{name: "Bad attachment size", env: map[string]string{"JWT_SECRET": "x", "ATTACHMENT_MAX_SIZE": "10MB"}, wantErr: "ATTACHMENT_MAX_SIZE"},
+		{name: "GridFS without mongo", args: []string{"-storage", "memory"}, env: map[string]string{"JWT_SECRET": "x", "ATTACHMENT_STORE": "gridfs"}, wantErr: "gridfs attachment store needs mongo storage"},
+		{name: "Unknown attachment store", env: map[string]string{"JWT_SECRET": "x", "ATTACHMENT_STORE": "s3"}, wantErr: `unknown attachment store "s3"`},
+		{name: "Unknown flag", args: []string{"-port", "80"}, env: map[string]string{"JWT_SECRET": "x"}, wantErr: "flag provided but not defined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := config.Load(tt.args, envFrom(tt.env))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidate_ReportsEveryProblem(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Addr = ""
+	cfg.Auth.RefreshTokenTTL = 0
+	cfg.Trash.Retention = 0
+	cfg.Attachments.MaxSize = 0
+	cfg.Attachments.AllowedTypes = nil
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server address is empty")
+	assert.Contains(t, err.Error(), "JWT secret is empty")
+	assert.Contains(t, err.Error(), "token TTLs must be positive")
+	assert.Contains(t, err.Error(), "trash retention and purge interval must be positive")
+	assert.Contains(t, err.Error(), "attachment max size must be positive")
+	assert.Contains(t, err.Error(), "no attachment types are allowed")
+}
@@ -2,23 +2,22 @@ package data
 
 import (
 	"context"
-	"log"
+	"fmt"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func InitMongo() (*mongo.Collection, *mongo.Collection) {
-	clientOptions := options.Client().ApplyURI("mongodb://localhost:27017")
-	client, err := mongo.Connect(context.TODO(), clientOptions)
+// connects and pings; the caller owns the client behind the returned database and must Disconnect it
+func InitMongo(ctx context.Context, uri, database string) (*mongo.Database, error) {
+	clientOptions := options.Client().ApplyURI(uri)
+	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("connecting to mongo: %w", err)
 	}
-	err = client.Ping(context.TODO(), nil)
-	if err != nil {
-		log.Fatal(err)
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		return nil, fmt.Errorf("pinging mongo: %w", err)
 	}
-	taskCol := client.Database("task_manager").Collection("tasks")
-	userCol := client.Database("task_manager").Collection("users")
-	return userCol, taskCol
+	return client.Database(database), nil
 }
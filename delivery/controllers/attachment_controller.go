@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"task7/domain"
+	services "task7/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AttachmentController struct {
+	attachmentService services.AttachmentService
+	logger            *slog.Logger
+}
+
+func NewAttachmentController(as services.AttachmentService, logger *slog.Logger) *AttachmentController {
+	return &AttachmentController{
+		attachmentService: as,
+		logger:            logger,
+	}
+}
+
+// the multipart field that carries the uploaded file
+const attachmentField = "file"
+
+var errNoAttachmentFile = domain.NewBadRequest("expected a multipart/form-data body with a %q file field", attachmentField)
+
+// a multipart part whose read failures are the client's fault, not the store's
+type formFileReader struct {
+	part *multipart.Part
+}
+
+func (r formFileReader) Read(p []byte) (int, error) {
+	n, err := r.part.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		err = domain.NewBadRequest("reading the uploaded file: %v", err)
+	}
+	return n, err
+}
+
+// the first part of the form that is the file field; other fields are skipped
+func nextFilePart(form *multipart.Reader) (*multipart.Part, error) {
+	for {
+		part, err := form.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, errNoAttachmentFile
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errNoAttachmentFile, err)
+		}
+		if part.FormName() == attachmentField {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+// stores the file field of a multipart upload; the body is streamed, never held in memory
+func (ac AttachmentController) UploadAttachment(c *gin.Context) {
+	id, err := parseTaskID(c)
+	if err != nil {
+		writeError(c, ac.logger, err)
+		return
+	}
+	form, err := c.Request.MultipartReader()
+	if err != nil {
+		writeError(c, ac.logger, errNoAttachmentFile)
+		return
+	}
+	part, err := nextFilePart(form)
+	if err != nil {
+		writeError(c, ac.logger, err)
+		return
+	}
+	defer part.Close()
+	attachment, err := ac.attachmentService.Upload(c.Request.Context(), id, part.FileName(), formFileReader{part: part})
+	if err != nil {
+		writeError(c, ac.logger, err)
+		return
+	}
+	c.JSON(201, attachment)
+}
+
+func (ac AttachmentController) ListAttachments(c *gin.Context) {
+	id, err := parseTaskID(c)
+	if err != nil {
+		writeError(c, ac.logger, err)
+		return
+	}
+	attachments, err := ac.attachmentService.ListAttachments(c.Request.Context(), id)
+	if err != nil {
+		writeError(c, ac.logger, err)
+		return
+	}
+	c.JSON(200, gin.H{"attachments": attachments})
+}
+
+// sends the content as a download; nosniff keeps browsers from second-guessing the stored type
+func (ac AttachmentController) DownloadAttachment(c *gin.Context) {
+	id, err := parseTaskID(c)
+	if err != nil {
+		writeError(c, ac.logger, err)
+		return
+	}
+	attachment, content, err := ac.attachmentService.OpenAttachment(c.Request.Context(), id, c.Param("attachment"))
+	if err != nil {
+		writeError(c, ac.logger, err)
+		return
+	}
+	defer content.Close()
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+func (ac AttachmentController) DeleteAttachment(c *gin.Context) {
+	id, err := parseTaskID(c)
+	if err != nil {
+		writeError(c, ac.logger, err)
+		return
+	}
+	if err := ac.attachmentService.DeleteAttachment(c.Request.Context(), id, c.Param("attachment")); err != nil {
+		writeError(c, ac.logger, err)
+		return
+	}
+	c.Status(204)
+}
@@ -0,0 +1,183 @@
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task7/delivery/controllers"
+	"task7/domain"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockAttachmentService struct {
+	mock.Mock
+}
+
+// Upload reads the content, as the real service does, and passes it to the mock as a string
+func (m *MockAttachmentService) Upload(ctx context.Context, taskID int, filename string, content io.Reader) (domain.Attachment, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+	args := m.Called(ctx, taskID, filename, string(data))
+	return args.Get(0).(domain.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentService) ListAttachments(ctx context.Context, taskID int) ([]domain.Attachment, error) {
+	args := m.Called(ctx, taskID)
+	return args.Get(0).([]domain.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentService) OpenAttachment(ctx context.Context, taskID int, id string) (domain.Attachment, io.ReadCloser, error) {
+	args := m.Called(ctx, taskID, id)
+	if args.Get(1) == nil {
+		return args.Get(0).(domain.Attachment), nil, args.Error(2)
+	}
+	return args.Get(0).(domain.Attachment), args.Get(1).(io.ReadCloser), args.Error(2)
+}
+
+func (m *MockAttachmentService) DeleteAttachment(ctx context.Context, taskID int, id string) error {
+	args := m.Called(ctx, taskID, id)
+	return args.Error(0)
+}
+
+type AttachmentControllerSuite struct {
+	suite.Suite
+	router                *gin.Engine
+	mockAttachmentService *MockAttachmentService
+}
+
+func (s *AttachmentControllerSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.mockAttachmentService = new(MockAttachmentService)
+	controller := controllers.NewAttachmentController(s.mockAttachmentService, slog.New(slog.DiscardHandler))
+
+	s.router = gin.New()
+	s.router.GET("/tasks/:id/attachments", controller.ListAttachments)
+	s.router.POST("/tasks/:id/attachments", controller.UploadAttachment)
+	s.router.GET("/tasks/:id/attachments/:attachment", controller.DownloadAttachment)
+	s.router.DELETE("/tasks/:id/attachments/:attachment", controller.DeleteAttachment)
+}
+
+func TestAttachmentControllerSuite(t *testing.T) {
+	suite.Run(t, new(AttachmentControllerSuite))
+}
+
+// posts a multipart form with a description field and, unless field is empty, a file
+func (s *AttachmentControllerSuite) upload(path, field, filename, content string) *httptest.ResponseRecorder {
+	body := new(bytes.Buffer)
+	form := multipart.NewWriter(body)
+	s.Require().NoError(form.WriteField("description", "ignored"))
+	if field != "" {
+		part, err := form.CreateFormFile(field, filename)
+		s.Require().NoError(err)
+		_, err = part.Write([]byte(content))
+		s.Require().NoError(err)
+	}
+	s.Require().NoError(form.Close())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", path, body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *AttachmentControllerSuite) perform(method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *AttachmentControllerSuite) TestUploadAttachment() {
+	at := time.Date(2025, 7, 2, 8, 30, 0, 0, time.UTC)
+	attachment := domain.Attachment{ID: "a1", TaskID: 4, Filename: "notes.txt", ContentType: "text/plain; charset=utf-8",
+		Size: 5, SHA256: "2cf2", UploadedBy: "bob", UploadedAt: at}
+	s.mockAttachmentService.On("Upload", mock.Anything, 4, "notes.txt", "hello").Return(attachment, nil).Once()
+
+	w := s.upload("/tasks/4/attachments", "file", "notes.txt", "hello")
+
+	s.Equal(http.StatusCreated, w.Code)
+	s.JSONEq(`{"id":"a1","task_id":4,"filename":"notes.txt","content_type":"text/plain; charset=utf-8","size":5,
+		"sha256":"2cf2","uploaded_by":"bob","uploaded_at":"2025-07-02T08:30:00Z"}`, w.Body.String())
+}
+
+func (s *AttachmentControllerSuite) TestUploadAttachment_BadForms() {
+	requireProblem(s.T(), s.upload("/tasks/4/attachments", "", "", ""), http.StatusBadRequest, "bad_request")
+	requireProblem(s.T(), s.upload("/tasks/4/attachments", "upload", "notes.txt", "hello"), http.StatusBadRequest, "bad_request")
+	requireProblem(s.T(), s.upload("/tasks/x/attachments", "file", "notes.txt", "hello"), http.StatusBadRequest, "bad_request")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks/4/attachments", strings.NewReader(`{"file":"hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	s.router.ServeHTTP(w, req)
+	requireProblem(s.T(), w, http.StatusBadRequest, "bad_request")
+
+	s.mockAttachmentService.AssertNotCalled(s.T(), "Upload", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *AttachmentControllerSuite) TestUploadAttachment_TruncatedBody() {
+	body := "--frontier\r\nContent-Disposition: form-data; name=\"file\"; filename=\"a.txt\"\r\n\r\nhello"
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks/4/attachments", strings.NewReader(body))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=frontier")
+	s.router.ServeHTTP(w, req)
+
+	requireProblem(s.T(), w, http.StatusBadRequest, "bad_request")
+}
+
+func (s *AttachmentControllerSuite) TestUploadAttachment_Rejected() {
+	s.mockAttachmentService.On("Upload", mock.Anything, 4, "big.bin", mock.Anything).Return(domain.Attachment{}, domain.ErrAttachmentTooLarge).Once()
+	s.mockAttachmentService.On("Upload", mock.Anything, 4, "run.exe", mock.Anything).Return(domain.Attachment{}, domain.ErrAttachmentType).Once()
+
+	requireProblem(s.T(), s.upload("/tasks/4/attachments", "file", "big.bin", "data"), http.StatusRequestEntityTooLarge, "too_large")
+	requireProblem(s.T(), s.upload("/tasks/4/attachments", "file", "run.exe", "MZ"), http.StatusUnsupportedMediaType, "unsupported_media_type")
+}
+
+func (s *AttachmentControllerSuite) TestListAttachments() {
+	s.mockAttachmentService.On("ListAttachments", mock.Anything, 4).Return([]domain.Attachment{}, nil).Once()
+
+	w := s.perform("GET", "/tasks/4/attachments")
+
+	s.Equal(http.StatusOK, w.Code)
+	s.JSONEq(`{"attachments":[]}`, w.Body.String())
+}
+
+func (s *AttachmentControllerSuite) TestDownloadAttachment() {
+	attachment := domain.Attachment{ID: "a1", TaskID: 4, Filename: "résumé \"final\".pdf", ContentType: "application/pdf", Size: 9}
+	s.mockAttachmentService.On("OpenAttachment", mock.Anything, 4, "a1").Return(attachment, io.NopCloser(strings.NewReader("%PDF-1.7\n")), nil).Once()
+
+	w := s.perform("GET", "/tasks/4/attachments/a1")
+
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal("%PDF-1.7\n", w.Body.String())
+	s.Equal("application/pdf", w.Header().Get("Content-Type"))
+	s.Equal("9", w.Header().Get("Content-Length"))
+	s.Equal("nosniff", w.Header().Get("X-Content-Type-Options"))
+	s.Equal(`attachment; filename*=utf-8''r%C3%A9sum%C3%A9%20%22final%22.pdf`, w.Header().Get("Content-Disposition"))
+}
+
+func (s *AttachmentControllerSuite) TestDownloadAttachment_NotFound() {
+	s.mockAttachmentService.On("OpenAttachment", mock.Anything, 4, "gone").Return(domain.Attachment{}, nil, domain.NewNotFound("no attachment found with id gone")).Once()
+
+	requireProblem(s.T(), s.perform("GET", "/tasks/4/attachments/gone"), http.StatusNotFound, "not_found")
+}
+
+func (s *AttachmentControllerSuite) TestDeleteAttachment() {
+	s.mockAttachmentService.On("DeleteAttachment", mock.Anything, 4, "a1").Return(nil).Once()
+	s.mockAttachmentService.On("DeleteAttachment", mock.Anything, 4, "a2").Return(domain.ErrForbidden).Once()
+
+	s.Equal(http.StatusNoContent, s.perform("DELETE", "/tasks/4/attachments/a1").Code)
+	requireProblem(s.T(), s.perform("DELETE", "/tasks/4/attachments/a2"), http.StatusForbidden, "forbidden")
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"log/slog"
+	"strconv"
+	"task7/domain"
+	services "task7/usecases"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditController struct {
+	auditService services.AuditService
+	logger       *slog.Logger
+}
+
+func NewAuditController(as services.AuditService, logger *slog.Logger) *AuditController {
+	return &AuditController{
+		auditService: as,
+		logger:       logger,
+	}
+}
+
+// lists audit entries matching the query string, newest first, one page at a time
+func (a AuditController) ListAudit(c *gin.Context) {
+	query, err := parseAuditQuery(c)
+	if err != nil {
+		writeError(c, a.logger, err)
+		return
+	}
+	page, err := a.auditService.QueryAudit(c.Request.Context(), query)
+	if err != nil {
+		writeError(c, a.logger, err)
+		return
+	}
+	c.JSON(200, page)
+}
+
+// reads ?actor=&action=&target_type=&target_id=&since=&until=&limit=&offset=
+func parseAuditQuery(c *gin.Context) (domain.AuditQuery, error) {
+	query := domain.AuditQuery{
+		Actor:      c.Query("actor"),
+		Action:     domain.AuditAction(c.Query("action")),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+	}
+
+	var err error
+	if v := c.Query("since"); v != "" {
+		if query.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			return query, domain.NewBadRequest("since must be an RFC3339 timestamp")
+		}
+	}
+	if v := c.Query("until"); v != "" {
+		if query.Until, err = time.Parse(time.RFC3339, v); err != nil {
+			return query, domain.NewBadRequest("until must be an RFC3339 timestamp")
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if query.Limit, err = strconv.Atoi(v); err != nil {
+			return query, domain.NewBadRequest("limit must be an integer")
+		}
+	}
+	if v := c.Query("offset"); v != "" {
+		if query.Offset, err = strconv.Atoi(v); err != nil {
+			return query, domain.NewBadRequest("offset must be an integer")
+		}
+	}
+	return query, nil
+}
@@ -0,0 +1,97 @@
+package controllers_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"task7/delivery/controllers"
+	"task7/domain"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) QueryAudit(ctx context.Context, query domain.AuditQuery) (domain.AuditPage, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(domain.AuditPage), args.Error(1)
+}
+
+type AuditControllerSuite struct {
+	suite.Suite
+	router           *gin.Engine
+	mockAuditService *MockAuditService
+}
+
+func (s *AuditControllerSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.mockAuditService = new(MockAuditService)
+	controller := controllers.NewAuditController(s.mockAuditService, slog.New(slog.DiscardHandler))
+
+	s.router = gin.New()
+	s.router.GET("/audit", controller.ListAudit)
+}
+
+func TestAuditControllerSuite(t *testing.T) {
+	suite.Run(t, new(AuditControllerSuite))
+}
+
+func (s *AuditControllerSuite) get(path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *AuditControllerSuite) TestListAudit_ParsesQueryParameters() {
+	since, _ := time.Parse(time.RFC3339, "2025-07-01T00:00:00Z")
+	expectedQuery := domain.AuditQuery{
+		Actor:      "alice",
+		Action:     domain.AuditTaskUpdate,
+		TargetType: "task",
+		TargetID:   "7",
+		Since:      since,
+		Limit:      10,
+		Offset:     5,
+	}
+	at := time.Date(2025, 7, 2, 8, 30, 0, 0, time.UTC)
+	page := domain.AuditPage{
+		Entries: []domain.AuditEntry{{
+			ID: "12", Action: domain.AuditTaskUpdate, Actor: "alice", ActorRole: "regular",
+			TargetType: "task", TargetID: "7", Timestamp: at,
+			Changes: []domain.FieldChange{{Field: "duedate", Before: "2025-07-01T12:00:00Z", After: "2025-07-03T12:00:00Z"}},
+		}},
+		Total: 6, Limit: 10, Offset: 5,
+	}
+	s.mockAuditService.On("QueryAudit", mock.Anything, expectedQuery).Return(page, nil).Once()
+
+	w := s.get("/audit?actor=alice&action=task.update&target_type=task&target_id=7&since=2025-07-01T00:00:00Z&limit=10&offset=5")
+
+	s.Equal(http.StatusOK, w.Code)
+	s.JSONEq(`{"entries":[{"id":"12","action":"task.update","actor":"alice","actor_role":"regular","target_type":"task","target_id":"7",
+		"timestamp":"2025-07-02T08:30:00Z","changes":[{"field":"duedate","before":"2025-07-01T12:00:00Z","after":"2025-07-03T12:00:00Z"}]}],
+		"total":6,"limit":10,"offset":5}`, w.Body.String())
+	s.mockAuditService.AssertExpectations(s.T())
+}
+
+func (s *AuditControllerSuite) TestListAudit_BadParameters() {
+	for _, path := range []string{"/audit?since=yesterday", "/audit?until=soon", "/audit?limit=ten", "/audit?offset=x"} {
+		w := s.get(path)
+		requireProblem(s.T(), w, http.StatusBadRequest, "bad_request")
+	}
+	s.mockAuditService.AssertNotCalled(s.T(), "QueryAudit", mock.Anything, mock.Anything)
+}
+
+func (s *AuditControllerSuite) TestListAudit_InvalidQuery() {
+	s.mockAuditService.On("QueryAudit", mock.Anything, mock.Anything).Return(domain.AuditPage{}, domain.ErrInvalidAuditQuery).Once()
+
+	w := s.get("/audit?action=task.explode")
+	requireProblem(s.T(), w, http.StatusBadRequest, "bad_request")
+}
@@ -1,22 +1,33 @@
 package controllers
 
 import (
+	"errors"
+	"io"
+	"log/slog"
 	"task7/domain"
-	"task7/infrastructure"
 	services "task7/usecases"
 
 	"github.com/gin-gonic/gin"
 )
 
+// counts login outcomes; infrastructure.Metrics is the implementation
+type LoginObserver interface {
+	ObserveLogin(success bool)
+}
+
 type AuthController struct {
-	userService    services.UserService
-	tokenGenerator infrastructure.TokenGenerator
+	userService  services.UserService
+	tokenService services.TokenService
+	logins       LoginObserver
+	logger       *slog.Logger
 }
 
-func NewAuthController(us services.UserService, tg infrastructure.TokenGenerator) *AuthController {
+func NewAuthController(us services.UserService, ts services.TokenService, logins LoginObserver, logger *slog.Logger) *AuthController {
 	return &AuthController{
-		userService:    us,
-		tokenGenerator: tg,
+		userService:  us,
+		tokenService: ts,
+		logins:       logins,
+		logger:       logger,
 	}
 }
 
@@ -24,18 +35,18 @@ func (a *AuthController) RegisterUser(c *gin.Context) {
 	var newUser domain.User
 
 	if err := c.ShouldBindJSON(&newUser); err != nil {
-		c.JSON(400, gin.H{"error": "Bad Request"})
+		writeError(c, a.logger, errInvalidJSON)
 		return
 	}
 
 	if len(newUser.PasswordHash) < 8 {
-		c.JSON(400, gin.H{"error": "Password must be at least 8 characters"})
+		writeError(c, a.logger, domain.NewValidation("Password must be at least 8 characters"))
 		return
 	}
 
-	err := a.userService.RegisterUser(&newUser)
+	err := a.userService.RegisterUser(c.Request.Context(), &newUser)
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		writeError(c, a.logger, err)
 		return
 	}
 
@@ -45,23 +56,60 @@ func (a *AuthController) RegisterUser(c *gin.Context) {
 func (a AuthController) LoginUser(c *gin.Context) {
 	var existingUser domain.User
 	if err := c.ShouldBindJSON(&existingUser); err != nil {
-		c.JSON(400, gin.H{"error": "Bad Request"})
+		writeError(c, a.logger, errInvalidJSON)
 		return
 	}
 	// authenticate user
-	user, err := a.userService.LoginUser(&existingUser)
+	user, err := a.userService.LoginUser(c.Request.Context(), &existingUser)
+	if err != nil {
+		a.logins.ObserveLogin(false)
+		writeError(c, a.logger, err)
+		return
+	}
+	// issue an access token plus a refresh token to renew it with
+	tokens, err := a.tokenService.IssueTokens(c.Request.Context(), &user)
+	if err != nil {
+		writeError(c, a.logger, err)
+		return
+	}
+	a.logins.ObserveLogin(true)
+	c.JSON(200, tokens)
+}
+
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// swaps a refresh token for a new access/refresh pair; the old refresh token is spent
+func (a AuthController) RefreshToken(c *gin.Context) {
+	var req refreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		writeError(c, a.logger, domain.NewBadRequest("refresh_token is required"))
+		return
+	}
+	tokens, err := a.tokenService.RefreshTokens(c.Request.Context(), req.RefreshToken)
 	if err != nil {
-		c.JSON(401, gin.H{"message": "Invalid username or password"})
+		writeError(c, a.logger, err)
 		return
 	}
-	// generate jwt token for user
+	c.JSON(200, tokens)
+}
 
-	token, err := a.tokenGenerator.GenerateToken(&user)
+// revokes the access token used for this request and the refresh token in the body, if any
+func (a AuthController) Logout(c *gin.Context) {
+	var req refreshTokenRequest
+	if c.Request.ContentLength != 0 { // the body is optional
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			writeError(c, a.logger, errInvalidJSON)
+			return
+		}
+	}
+	err := a.tokenService.Logout(c.Request.Context(), req.RefreshToken, c.GetString("jti"), c.GetTime("token_expires_at"))
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Could not generate token"})
+		writeError(c, a.logger, err)
 		return
 	}
-	c.JSON(200, gin.H{"token": token})
+	c.JSON(200, gin.H{"message": "Logged out"})
 }
 
 func (a AuthController) PromoteUser(c *gin.Context) {
@@ -69,12 +117,12 @@ func (a AuthController) PromoteUser(c *gin.Context) {
 		Username string `json:"username"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request"})
+		writeError(c, a.logger, errInvalidJSON)
 		return
 	}
-	err := a.userService.PromoteUser(req.Username)
+	err := a.userService.PromoteUser(c.Request.Context(), req.Username)
 	if err != nil {
-		c.JSON(404, gin.H{"error": err.Error()})
+		writeError(c, a.logger, err)
 		return
 	}
 	c.JSON(200, gin.H{"message": "User promoted to admin"})
@@ -2,12 +2,15 @@ package controllers_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"task7/delivery/controllers"
 	"task7/domain"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/mock"
@@ -19,38 +22,62 @@ type MockUserService struct {
 	mock.Mock
 }
 
-func (m *MockUserService) RegisterUser(user *domain.User) error {
-	args := m.Called(user)
+func (m *MockUserService) RegisterUser(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
-func (m *MockUserService) LoginUser(user *domain.User) (domain.User, error) {
-	args := m.Called(user)
+func (m *MockUserService) LoginUser(ctx context.Context, user *domain.User) (domain.User, error) {
+	args := m.Called(ctx, user)
 	if args.Get(0) == nil {
 		return domain.User{}, args.Error(1)
 	}
 	return *(args.Get(0).(*domain.User)), args.Error(1)
 }
 
-func (m *MockUserService) PromoteUser(username string) error {
-	args := m.Called(username)
+func (m *MockUserService) PromoteUser(ctx context.Context, username string) error {
+	args := m.Called(ctx, username)
 	return args.Error(0)
 }
 
-type MockTokenGenerator struct {
+type MockTokenService struct {
 	mock.Mock
 }
 
-func (m *MockTokenGenerator) GenerateToken(user *domain.User) (string, error) { // <-- CORRECTED SIGNATURE
-	args := m.Called(user)
-	return args.String(0), args.Error(1)
+func (m *MockTokenService) IssueTokens(ctx context.Context, user *domain.User) (domain.TokenPair, error) {
+	args := m.Called(ctx, user)
+	return args.Get(0).(domain.TokenPair), args.Error(1)
+}
+
+func (m *MockTokenService) RefreshTokens(ctx context.Context, refreshToken string) (domain.TokenPair, error) {
+	args := m.Called(ctx, refreshToken)
+	return args.Get(0).(domain.TokenPair), args.Error(1)
+}
+
+func (m *MockTokenService) Logout(ctx context.Context, refreshToken, accessJTI string, accessExpiresAt time.Time) error {
+	args := m.Called(ctx, refreshToken, accessJTI, accessExpiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTokenService) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockLoginObserver struct {
+	mock.Mock
+}
+
+func (m *MockLoginObserver) ObserveLogin(success bool) {
+	m.Called(success)
 }
 
 type AuthControllerTestSuite struct {
 	suite.Suite
 
-	mockUserService    *MockUserService
-	mockTokenGenerator *MockTokenGenerator
+	mockUserService  *MockUserService
+	mockTokenService *MockTokenService
+	mockLogins       *MockLoginObserver
 
 	authController *controllers.AuthController
 
@@ -66,14 +93,16 @@ func (s *AuthControllerTestSuite) SetupTest() {
 	s.ginContext, _ = gin.CreateTestContext(s.recorder)
 
 	s.mockUserService = new(MockUserService)
-	s.mockTokenGenerator = new(MockTokenGenerator)
+	s.mockTokenService = new(MockTokenService)
+	s.mockLogins = new(MockLoginObserver)
 
-	s.authController = controllers.NewAuthController(s.mockUserService, s.mockTokenGenerator)
+	s.authController = controllers.NewAuthController(s.mockUserService, s.mockTokenService, s.mockLogins, slog.New(slog.DiscardHandler))
 }
 
 func (s *AuthControllerTestSuite) TearDownTest() {
 	s.mockUserService.AssertExpectations(s.T())
-	s.mockTokenGenerator.AssertExpectations(s.T())
+	s.mockTokenService.AssertExpectations(s.T())
+	s.mockLogins.AssertExpectations(s.T())
 }
 
 func (s *AuthControllerTestSuite) TestRegisterUser_Success() {
@@ -85,7 +114,7 @@ func (s *AuthControllerTestSuite) TestRegisterUser_Success() {
 
 	s.ginContext.Request = req
 
-	s.mockUserService.On("RegisterUser", mock.AnythingOfType("*domain.User")).Return(nil).Once()
+	s.mockUserService.On("RegisterUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil).Once()
 
 	s.authController.RegisterUser(s.ginContext)
 
@@ -103,8 +132,8 @@ func (s *AuthControllerTestSuite) TestRegisterUser_InvalidJSON() {
 
 	s.authController.RegisterUser(s.ginContext)
 
-	s.Equal(http.StatusBadRequest, s.recorder.Code)
-	s.Contains(s.recorder.Body.String(), `{"error":"Bad Request"}`)
+	problem := requireProblem(s.T(), s.recorder, http.StatusBadRequest, "bad_request")
+	s.Equal("Error binding JSON", problem.Detail)
 }
 
 func (s *AuthControllerTestSuite) TestRegisterUser_ShortPassword() {
@@ -117,8 +146,8 @@ func (s *AuthControllerTestSuite) TestRegisterUser_ShortPassword() {
 
 	s.authController.RegisterUser(s.ginContext)
 
-	s.Equal(http.StatusBadRequest, s.recorder.Code)
-	s.Contains(s.recorder.Body.String(), `{"error":"Password must be at least 8 characters"}`)
+	problem := requireProblem(s.T(), s.recorder, http.StatusUnprocessableEntity, "validation")
+	s.Equal("Password must be at least 8 characters", problem.Detail)
 }
 
 func (s *AuthControllerTestSuite) TestRegisterUser_ServiceError() {
@@ -129,13 +158,13 @@ func (s *AuthControllerTestSuite) TestRegisterUser_ServiceError() {
 	req.Header.Set("Content-Type", "application/json")
 	s.ginContext.Request = req
 
-	expectedServiceError := errors.New("user 'existinguser' already exists")
-	s.mockUserService.On("RegisterUser", mock.AnythingOfType("*domain.User")).Return(expectedServiceError).Once()
+	expectedServiceError := domain.NewConflict("username already exists")
+	s.mockUserService.On("RegisterUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(expectedServiceError).Once()
 
 	s.authController.RegisterUser(s.ginContext)
 
-	s.Equal(http.StatusInternalServerError, s.recorder.Code)
-	s.Contains(s.recorder.Body.String(), `{"error":"user 'existinguser' already exists"}`)
+	problem := requireProblem(s.T(), s.recorder, http.StatusConflict, "conflict")
+	s.Equal("username already exists", problem.Detail)
 }
 
 func (s *AuthControllerTestSuite) TestLoginUser_Success() {
@@ -153,15 +182,16 @@ func (s *AuthControllerTestSuite) TestLoginUser_Success() {
 		Role:         "user",
 	}
 
-	s.mockUserService.On("LoginUser", mock.AnythingOfType("*domain.User")).Return(authenticatedUserPtr, nil).Once() // <-- Returns POINTER
+	s.mockUserService.On("LoginUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(authenticatedUserPtr, nil).Once() // <-- Returns POINTER
 
-	expectedToken := "mock_jwt_token_for_user123"
-	s.mockTokenGenerator.On("GenerateToken", mock.AnythingOfType("*domain.User")).Return(expectedToken, nil).Once() // <-- Expects POINTER
+	expectedTokens := domain.TokenPair{AccessToken: "mock_jwt_token_for_user123", RefreshToken: "mock_refresh_token"}
+	s.mockTokenService.On("IssueTokens", mock.Anything, mock.AnythingOfType("*domain.User")).Return(expectedTokens, nil).Once()
+	s.mockLogins.On("ObserveLogin", true).Once()
 
 	s.authController.LoginUser(s.ginContext)
 
 	s.Equal(http.StatusOK, s.recorder.Code)
-	s.Contains(s.recorder.Body.String(), `{"token":"`+expectedToken+`"}`)
+	s.JSONEq(`{"token":"mock_jwt_token_for_user123","refresh_token":"mock_refresh_token"}`, s.recorder.Body.String())
 }
 
 func (s *AuthControllerTestSuite) TestLoginUser_InvalidJSON() {
@@ -174,8 +204,8 @@ func (s *AuthControllerTestSuite) TestLoginUser_InvalidJSON() {
 
 	s.authController.LoginUser(s.ginContext)
 
-	s.Equal(http.StatusBadRequest, s.recorder.Code)
-	s.Contains(s.recorder.Body.String(), `{"error":"Bad Request"}`)
+	problem := requireProblem(s.T(), s.recorder, http.StatusBadRequest, "bad_request")
+	s.Equal("Error binding JSON", problem.Detail)
 }
 
 func (s *AuthControllerTestSuite) TestLoginUser_AuthenticationFailed() {
@@ -186,13 +216,14 @@ func (s *AuthControllerTestSuite) TestLoginUser_AuthenticationFailed() {
 	req.Header.Set("Content-Type", "application/json")
 	s.ginContext.Request = req
 
-	authError := errors.New("username or password mismatch")
-	s.mockUserService.On("LoginUser", mock.AnythingOfType("*domain.User")).Return(nil, authError).Once()
+	authError := &domain.Error{Kind: domain.KindUnauthorized, Message: "invalid username or password", Err: errors.New("incorrect password")}
+	s.mockUserService.On("LoginUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil, authError).Once()
+	s.mockLogins.On("ObserveLogin", false).Once()
 
 	s.authController.LoginUser(s.ginContext)
 
-	s.Equal(http.StatusUnauthorized, s.recorder.Code)
-	s.Contains(s.recorder.Body.String(), `{"message":"Invalid username or password"}`)
+	problem := requireProblem(s.T(), s.recorder, http.StatusUnauthorized, "unauthorized")
+	s.Equal("invalid username or password", problem.Detail)
 }
 
 func (s *AuthControllerTestSuite) TestLoginUser_TokenGenerationFailed() {
@@ -210,15 +241,15 @@ func (s *AuthControllerTestSuite) TestLoginUser_TokenGenerationFailed() {
 		Role:         "user",
 	}
 
-	s.mockUserService.On("LoginUser", mock.AnythingOfType("*domain.User")).Return(authenticatedUserPtr, nil).Once() // <-- Returns POINTER
+	s.mockUserService.On("LoginUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(authenticatedUserPtr, nil).Once() // <-- Returns POINTER
 
 	tokenGenError := errors.New("internal server error during token signing")
-	s.mockTokenGenerator.On("GenerateToken", mock.AnythingOfType("*domain.User")).Return("", tokenGenError).Once() // <-- Expects POINTER
+	s.mockTokenService.On("IssueTokens", mock.Anything, mock.AnythingOfType("*domain.User")).Return(domain.TokenPair{}, tokenGenError).Once()
 
 	s.authController.LoginUser(s.ginContext)
 
-	s.Equal(http.StatusInternalServerError, s.recorder.Code)
-	s.Contains(s.recorder.Body.String(), `{"error":"Could not generate token"}`)
+	problem := requireProblem(s.T(), s.recorder, http.StatusInternalServerError, "internal")
+	s.NotContains(problem.Detail, "token signing")
 }
 
 func (s *AuthControllerTestSuite) TestPromoteUser_Success() {
@@ -229,7 +260,7 @@ func (s *AuthControllerTestSuite) TestPromoteUser_Success() {
 	req.Header.Set("Content-Type", "application/json")
 	s.ginContext.Request = req
 
-	s.mockUserService.On("PromoteUser", "user_to_promote").Return(nil).Once()
+	s.mockUserService.On("PromoteUser", mock.Anything, "user_to_promote").Return(nil).Once()
 
 	s.authController.PromoteUser(s.ginContext)
 
@@ -247,8 +278,8 @@ func (s *AuthControllerTestSuite) TestPromoteUser_InvalidJSON() {
 
 	s.authController.PromoteUser(s.ginContext)
 
-	s.Equal(http.StatusBadRequest, s.recorder.Code)
-	s.Contains(s.recorder.Body.String(), `{"error":"Invalid request"}`)
+	problem := requireProblem(s.T(), s.recorder, http.StatusBadRequest, "bad_request")
+	s.Equal("Error binding JSON", problem.Detail)
 }
 
 func (s *AuthControllerTestSuite) TestPromoteUser_ServiceError() {
@@ -259,13 +290,90 @@ func (s *AuthControllerTestSuite) TestPromoteUser_ServiceError() {
 	req.Header.Set("Content-Type", "application/json")
 	s.ginContext.Request = req
 
-	expectedServiceError := errors.New("user 'nonexistent_user' not found")
-	s.mockUserService.On("PromoteUser", "nonexistent_user").Return(expectedServiceError).Once()
+	expectedServiceError := domain.NewNotFound("user not found")
+	s.mockUserService.On("PromoteUser", mock.Anything, "nonexistent_user").Return(expectedServiceError).Once()
 
 	s.authController.PromoteUser(s.ginContext)
 
-	s.Equal(http.StatusNotFound, s.recorder.Code)
-	s.Contains(s.recorder.Body.String(), `{"error":"user 'nonexistent_user' not found"}`)
+	problem := requireProblem(s.T(), s.recorder, http.StatusNotFound, "not_found")
+	s.Equal("user not found", problem.Detail)
+}
+
+func (s *AuthControllerTestSuite) TestRefreshToken_Success() {
+	req, _ := http.NewRequest(http.MethodPost, "/token/refresh", bytes.NewBufferString(`{"refresh_token": "old_refresh"}`))
+	req.Header.Set("Content-Type", "application/json")
+	s.ginContext.Request = req
+
+	newTokens := domain.TokenPair{AccessToken: "new_access", RefreshToken: "new_refresh"}
+	s.mockTokenService.On("RefreshTokens", mock.Anything, "old_refresh").Return(newTokens, nil).Once()
+
+	s.authController.RefreshToken(s.ginContext)
+
+	s.Equal(http.StatusOK, s.recorder.Code)
+	s.JSONEq(`{"token":"new_access","refresh_token":"new_refresh"}`, s.recorder.Body.String())
+}
+
+func (s *AuthControllerTestSuite) TestRefreshToken_MissingToken() {
+	req, _ := http.NewRequest(http.MethodPost, "/token/refresh", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	s.ginContext.Request = req
+
+	s.authController.RefreshToken(s.ginContext)
+
+	problem := requireProblem(s.T(), s.recorder, http.StatusBadRequest, "bad_request")
+	s.Equal("refresh_token is required", problem.Detail)
+}
+
+func (s *AuthControllerTestSuite) TestRefreshToken_Invalid() {
+	req, _ := http.NewRequest(http.MethodPost, "/token/refresh", bytes.NewBufferString(`{"refresh_token": "spent"}`))
+	req.Header.Set("Content-Type", "application/json")
+	s.ginContext.Request = req
+
+	s.mockTokenService.On("RefreshTokens", mock.Anything, "spent").Return(domain.TokenPair{}, domain.ErrInvalidRefreshToken).Once()
+
+	s.authController.RefreshToken(s.ginContext)
+
+	problem := requireProblem(s.T(), s.recorder, http.StatusUnauthorized, "unauthorized")
+	s.Equal("invalid or expired refresh token", problem.Detail)
+}
+
+func (s *AuthControllerTestSuite) TestLogout_Success() {
+	req, _ := http.NewRequest(http.MethodPost, "/logout", bytes.NewBufferString(`{"refresh_token": "my_refresh"}`))
+	req.Header.Set("Content-Type", "application/json")
+	s.ginContext.Request = req
+	expiresAt := time.Now().Add(10 * time.Minute)
+	s.ginContext.Set("jti", "access-jti")
+	s.ginContext.Set("token_expires_at", expiresAt)
+
+	s.mockTokenService.On("Logout", mock.Anything, "my_refresh", "access-jti", expiresAt).Return(nil).Once()
+
+	s.authController.Logout(s.ginContext)
+
+	s.Equal(http.StatusOK, s.recorder.Code)
+	s.Contains(s.recorder.Body.String(), `{"message":"Logged out"}`)
+}
+
+func (s *AuthControllerTestSuite) TestLogout_WithoutBody() {
+	req, _ := http.NewRequest(http.MethodPost, "/logout", nil)
+	s.ginContext.Request = req
+	s.ginContext.Set("jti", "access-jti")
+
+	s.mockTokenService.On("Logout", mock.Anything, "", "access-jti", time.Time{}).Return(nil).Once()
+
+	s.authController.Logout(s.ginContext)
+
+	s.Equal(http.StatusOK, s.recorder.Code)
+}
+
+func (s *AuthControllerTestSuite) TestLogout_ServiceError() {
+	req, _ := http.NewRequest(http.MethodPost, "/logout", nil)
+	s.ginContext.Request = req
+
+	s.mockTokenService.On("Logout", mock.Anything, "", "", time.Time{}).Return(errors.New("store down")).Once()
+
+	s.authController.Logout(s.ginContext)
+
+	requireProblem(s.T(), s.recorder, http.StatusInternalServerError, "internal")
 }
 
 func TestAuthController(t *testing.T) {
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"log/slog"
+	"strconv"
+	"task7/domain"
+	services "task7/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommentController struct {
+	commentService services.CommentService
+	logger         *slog.Logger
+}
+
+func NewCommentController(cs services.CommentService, logger *slog.Logger) *CommentController {
+	return &CommentController{
+		commentService: cs,
+		logger:         logger,
+	}
+}
+
+type commentBody struct {
+	Body string `json:"body"`
+}
+
+// lists a task's comments, oldest first, one page at a time (?limit=&offset=)
+func (cc CommentController) ListComments(c *gin.Context) {
+	id, err := parseTaskID(c)
+	if err != nil {
+		writeError(c, cc.logger, err)
+		return
+	}
+	query := domain.CommentQuery{TaskID: id}
+	if v := c.Query("limit"); v != "" {
+		if query.Limit, err = strconv.Atoi(v); err != nil {
+			writeError(c, cc.logger, domain.NewBadRequest("limit must be an integer"))
+			return
+		}
+	}
+	if v := c.Query("offset"); v != "" {
+		if query.Offset, err = strconv.Atoi(v); err != nil {
+			writeError(c, cc.logger, domain.NewBadRequest("offset must be an integer"))
+			return
+		}
+	}
+	page, err := cc.commentService.ListComments(c.Request.Context(), query)
+	if err != nil {
+		writeError(c, cc.logger, err)
+		return
+	}
+	c.JSON(200, page)
+}
+
+func (cc CommentController) AddComment(c *gin.Context) {
+	id, err := parseTaskID(c)
+	if err != nil {
+		writeError(c, cc.logger, err)
+		return
+	}
+	var body commentBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		writeError(c, cc.logger, errInvalidJSON)
+		return
+	}
+	comment, err := cc.commentService.AddComment(c.Request.Context(), id, body.Body)
+	if err != nil {
+		writeError(c, cc.logger, err)
+		return
+	}
+	c.JSON(201, comment)
+}
+
+func (cc CommentController) EditComment(c *gin.Context) {
+	id, err := parseTaskID(c)
+	if err != nil {
+		writeError(c, cc.logger, err)
+		return
+	}
+	var body commentBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		writeError(c, cc.logger, errInvalidJSON)
+		return
+	}
+	comment, err := cc.commentService.EditComment(c.Request.Context(), id, c.Param("comment"), body.Body)
+	if err != nil {
+		writeError(c, cc.logger, err)
+		return
+	}
+	c.JSON(200, comment)
+}
+
+func (cc CommentController) DeleteComment(c *gin.Context) {
+	id, err := parseTaskID(c)
+	if err != nil {
+		writeError(c, cc.logger, err)
+		return
+	}
+	if err := cc.commentService.DeleteComment(c.Request.Context(), id, c.Param("comment")); err != nil {
+		writeError(c, cc.logger, err)
+		return
+	}
+	c.Status(204)
+}
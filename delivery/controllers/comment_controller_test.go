@@ -0,0 +1,137 @@
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"task7/delivery/controllers"
+	"task7/domain"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockCommentService struct {
+	mock.Mock
+}
+
+func (m *MockCommentService) ListComments(ctx context.Context, query domain.CommentQuery) (domain.CommentPage, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(domain.CommentPage), args.Error(1)
+}
+
+func (m *MockCommentService) AddComment(ctx context.Context, taskID int, body string) (domain.Comment, error) {
+	args := m.Called(ctx, taskID, body)
+	return args.Get(0).(domain.Comment), args.Error(1)
+}
+
+func (m *MockCommentService) EditComment(ctx context.Context, taskID int, commentID string, body string) (domain.Comment, error) {
+	args := m.Called(ctx, taskID, commentID, body)
+	return args.Get(0).(domain.Comment), args.Error(1)
+}
+
+func (m *MockCommentService) DeleteComment(ctx context.Context, taskID int, commentID string) error {
+	args := m.Called(ctx, taskID, commentID)
+	return args.Error(0)
+}
+
+type CommentControllerSuite struct {
+	suite.Suite
+	router             *gin.Engine
+	mockCommentService *MockCommentService
+}
+
+func (s *CommentControllerSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.mockCommentService = new(MockCommentService)
+	controller := controllers.NewCommentController(s.mockCommentService, slog.New(slog.DiscardHandler))
+
+	s.router = gin.New()
+	s.router.GET("/tasks/:id/comments", controller.ListComments)
+	s.router.POST("/tasks/:id/comments", controller.AddComment)
+	s.router.PATCH("/tasks/:id/comments/:comment", controller.EditComment)
+	s.router.DELETE("/tasks/:id/comments/:comment", controller.DeleteComment)
+}
+
+func TestCommentControllerSuite(t *testing.T) {
+	suite.Run(t, new(CommentControllerSuite))
+}
+
+func (s *CommentControllerSuite) perform(method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *CommentControllerSuite) TestListComments() {
+	at := time.Date(2025, 7, 2, 8, 30, 0, 0, time.UTC)
+	next := 1
+	page := domain.CommentPage{
+		Comments: []domain.Comment{{ID: "c1", TaskID: 4, Author: "bob", Body: "cc @alice", Mentions: []string{"alice"}, CreatedAt: at}},
+		Total:    2, Limit: 1, NextOffset: &next,
+	}
+	s.mockCommentService.On("ListComments", mock.Anything, domain.CommentQuery{TaskID: 4, Limit: 1}).Return(page, nil).Once()
+
+	w := s.perform("GET", "/tasks/4/comments?limit=1", "")
+
+	s.Equal(http.StatusOK, w.Code)
+	s.JSONEq(`{"comments":[{"id":"c1","task_id":4,"author":"bob","body":"cc @alice","mentions":["alice"],"created_at":"2025-07-02T08:30:00Z"}],
+		"total":2,"limit":1,"offset":0,"next_offset":1}`, w.Body.String())
+}
+
+func (s *CommentControllerSuite) TestListComments_BadParameters() {
+	for _, path := range []string{"/tasks/x/comments", "/tasks/4/comments?limit=ten", "/tasks/4/comments?offset=-"} {
+		requireProblem(s.T(), s.perform("GET", path, ""), http.StatusBadRequest, "bad_request")
+	}
+	s.mockCommentService.AssertNotCalled(s.T(), "ListComments", mock.Anything, mock.Anything)
+}
+
+func (s *CommentControllerSuite) TestAddComment() {
+	comment := domain.Comment{ID: "c2", TaskID: 4, Author: "alice", Body: "**Shipped**"}
+	s.mockCommentService.On("AddComment", mock.Anything, 4, "**Shipped**").Return(comment, nil).Once()
+
+	w := s.perform("POST", "/tasks/4/comments", `{"body":"**Shipped**"}`)
+
+	s.Equal(http.StatusCreated, w.Code)
+	s.Contains(w.Body.String(), `"id":"c2"`)
+}
+
+func (s *CommentControllerSuite) TestAddComment_Errors() {
+	requireProblem(s.T(), s.perform("POST", "/tasks/4/comments", `{"body":`), http.StatusBadRequest, "bad_request")
+
+	s.mockCommentService.On("AddComment", mock.Anything, 4, "").Return(domain.Comment{}, domain.ErrInvalidComment).Once()
+	requireProblem(s.T(), s.perform("POST", "/tasks/4/comments", `{}`), http.StatusUnprocessableEntity, "validation")
+}
+
+func (s *CommentControllerSuite) TestEditComment() {
+	edited := time.Date(2025, 7, 3, 0, 0, 0, 0, time.UTC)
+	comment := domain.Comment{ID: "c1", TaskID: 4, Author: "bob", Body: "Fixed typo", EditedAt: &edited}
+	s.mockCommentService.On("EditComment", mock.Anything, 4, "c1", "Fixed typo").Return(comment, nil).Once()
+
+	w := s.perform("PATCH", "/tasks/4/comments/c1", `{"body":"Fixed typo"}`)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.Contains(w.Body.String(), `"edited_at":"2025-07-03T00:00:00Z"`)
+}
+
+func (s *CommentControllerSuite) TestEditComment_NotAuthor() {
+	s.mockCommentService.On("EditComment", mock.Anything, 4, "c1", "Mine now").Return(domain.Comment{}, domain.ErrForbidden).Once()
+
+	requireProblem(s.T(), s.perform("PATCH", "/tasks/4/comments/c1", `{"body":"Mine now"}`), http.StatusForbidden, "forbidden")
+}
+
+func (s *CommentControllerSuite) TestDeleteComment() {
+	s.mockCommentService.On("DeleteComment", mock.Anything, 4, "c1").Return(nil).Once()
+	s.mockCommentService.On("DeleteComment", mock.Anything, 4, "c9").Return(domain.NewNotFound("no comment found with id c9")).Once()
+
+	w := s.perform("DELETE", "/tasks/4/comments/c1", "")
+	s.Equal(http.StatusNoContent, w.Code)
+	requireProblem(s.T(), s.perform("DELETE", "/tasks/4/comments/c9", ""), http.StatusNotFound, "not_found")
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"net/http"
+	"task7/domain"
+	services "task7/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	healthService services.HealthService
+}
+
+func NewHealthController(hs services.HealthService) *HealthController {
+	return &HealthController{
+		healthService: hs,
+	}
+}
+
+// the process is up and serving HTTP; it deliberately checks nothing else
+func (h HealthController) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": domain.HealthOK})
+}
+
+// 200 when every dependency answers, 503 otherwise so the instance is taken out of rotation
+func (h HealthController) Readiness(c *gin.Context) {
+	report := h.healthService.CheckReadiness(c.Request.Context())
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,84 @@
+package controllers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"task7/delivery/controllers"
+	"task7/domain"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockHealthService struct {
+	mock.Mock
+}
+
+func (m *MockHealthService) CheckReadiness(ctx context.Context) domain.HealthReport {
+	args := m.Called(ctx)
+	return args.Get(0).(domain.HealthReport)
+}
+
+type HealthControllerSuite struct {
+	suite.Suite
+	router            *gin.Engine
+	mockHealthService *MockHealthService
+}
+
+func (s *HealthControllerSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.mockHealthService = new(MockHealthService)
+	controller := controllers.NewHealthController(s.mockHealthService)
+
+	s.router = gin.New()
+	s.router.GET("/healthz", controller.Liveness)
+	s.router.GET("/readyz", controller.Readiness)
+}
+
+func TestHealthControllerSuite(t *testing.T) {
+	suite.Run(t, new(HealthControllerSuite))
+}
+
+func (s *HealthControllerSuite) get(path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *HealthControllerSuite) TestLiveness() {
+	w := s.get("/healthz")
+
+	s.Equal(http.StatusOK, w.Code)
+	s.JSONEq(`{"status":"ok"}`, w.Body.String())
+	s.mockHealthService.AssertNotCalled(s.T(), "CheckReadiness", mock.Anything)
+}
+
+func (s *HealthControllerSuite) TestReadiness_Ready() {
+	report := domain.HealthReport{Status: domain.HealthOK, Checks: map[string]domain.DependencyHealth{
+		"mongo": {Status: domain.HealthOK, LatencyMS: 3},
+	}}
+	s.mockHealthService.On("CheckReadiness", mock.Anything).Return(report).Once()
+
+	w := s.get("/readyz")
+
+	s.Equal(http.StatusOK, w.Code)
+	s.JSONEq(`{"status":"ok","checks":{"mongo":{"status":"ok","latency_ms":3}}}`, w.Body.String())
+	s.mockHealthService.AssertExpectations(s.T())
+}
+
+func (s *HealthControllerSuite) TestReadiness_NotReady() {
+	report := domain.HealthReport{Status: domain.HealthUnavailable, Checks: map[string]domain.DependencyHealth{
+		"mongo": {Status: domain.HealthUnavailable, Error: "connection refused", LatencyMS: 2000},
+	}}
+	s.mockHealthService.On("CheckReadiness", mock.Anything).Return(report).Once()
+
+	w := s.get("/readyz")
+
+	s.Equal(http.StatusServiceUnavailable, w.Code)
+	s.JSONEq(`{"status":"unavailable","checks":{"mongo":{"status":"unavailable","error":"connection refused","latency_ms":2000}}}`, w.Body.String())
+	s.mockHealthService.AssertExpectations(s.T())
+}
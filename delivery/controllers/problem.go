@@ -0,0 +1,15 @@
+package controllers
+
+import (
+	"log/slog"
+	"task7/infrastructure"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RFC 7807 problem details, as written by every handler and the auth middleware
+type Problem = infrastructure.Problem
+
+func writeError(c *gin.Context, logger *slog.Logger, err error) {
+	infrastructure.WriteProblem(c, logger, err)
+}
@@ -0,0 +1,26 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"task7/delivery/controllers"
+
+	"github.com/stretchr/testify/require"
+)
+
+// checks that w holds an RFC 7807 problem with the given status and code, and returns it
+func requireProblem(t *testing.T, w *httptest.ResponseRecorder, status int, code string) controllers.Problem {
+	t.Helper()
+	require.Equal(t, status, w.Code)
+	require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+	var problem controllers.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	require.Equal(t, "about:blank", problem.Type)
+	require.Equal(t, status, problem.Status)
+	require.Equal(t, code, problem.Code)
+	require.NotEmpty(t, problem.Title)
+	return problem
+}
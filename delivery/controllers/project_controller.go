@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"fmt"
+	"log/slog"
+	"task7/domain"
+	services "task7/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProjectController struct {
+	projectService services.ProjectService
+	logger         *slog.Logger
+}
+
+func NewProjectController(ps services.ProjectService, logger *slog.Logger) *ProjectController {
+	return &ProjectController{
+		projectService: ps,
+		logger:         logger,
+	}
+}
+
+// the request's project: the :project path parameter, or ?project= on task routes
+func projectParam(c *gin.Context) string {
+	if id := c.Param("project"); id != "" {
+		return id
+	}
+	return c.Query("project")
+}
+
+// a middleware, run after AuthMiddleware, that lets the request through only if the caller's role in
+// the request's project is at least min, and records that role on the request context for the
+// services. Requests that name no project pass untouched.
+func (pc ProjectController) RequireRole(min domain.ProjectRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := projectParam(c)
+		if id == "" {
+			c.Next()
+			return
+		}
+		ctx := c.Request.Context()
+		role, err := pc.projectService.RoleIn(ctx, id)
+		if err != nil {
+			writeError(c, pc.logger, err)
+			return
+		}
+		if !role.AtLeast(min) {
+			pc.logger.WarnContext(ctx, "project access denied", slog.String("project_id", id), slog.String("role", string(role)))
+			writeError(c, pc.logger, fmt.Errorf("%w: this needs the %s role in the project; you are a %s", domain.ErrForbidden, min, role))
+			return
+		}
+		c.Request = c.Request.WithContext(domain.WithProjectRole(ctx, id, role))
+		c.Next()
+	}
+}
+
+type projectBody struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// fields left out of a PATCH keep their value
+type projectPatch struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+type memberBody struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func (pc ProjectController) ListProjects(c *gin.Context) {
+	projects, err := pc.projectService.ListProjects(c.Request.Context())
+	if err != nil {
+		writeError(c, pc.logger, err)
+		return
+	}
+	c.JSON(200, gin.H{"projects": projects})
+}
+
+func (pc ProjectController) CreateProject(c *gin.Context) {
+	var body projectBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		writeError(c, pc.logger, errInvalidJSON)
+		return
+	}
+	project := domain.Project{Name: body.Name, Description: body.Description}
+	if err := pc.projectService.CreateProject(c.Request.Context(), &project); err != nil {
+		writeError(c, pc.logger, err)
+		return
+	}
+	c.JSON(201, project)
+}
+
+func (pc ProjectController) GetProject(c *gin.Context) {
+	project, err := pc.projectService.GetProject(c.Request.Context(), c.Param("project"))
+	if err != nil {
+		writeError(c, pc.logger, err)
+		return
+	}
+	c.JSON(200, project)
+}
+
+func (pc ProjectController) UpdateProject(c *gin.Context) {
+	var patch projectPatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		writeError(c, pc.logger, errInvalidJSON)
+		return
+	}
+	project, err := pc.projectService.UpdateProject(c.Request.Context(), c.Param("project"), patch.Name, patch.Description)
+	if err != nil {
+		writeError(c, pc.logger, err)
+		return
+	}
+	c.JSON(200, project)
+}
+
+func (pc ProjectController) DeleteProject(c *gin.Context) {
+	if err := pc.projectService.DeleteProject(c.Request.Context(), c.Param("project")); err != nil {
+		writeError(c, pc.logger, err)
+		return
+	}
+	c.Status(204)
+}
+
+func (pc ProjectController) SetMember(c *gin.Context) {
+	var body memberBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		writeError(c, pc.logger, errInvalidJSON)
+		return
+	}
+	project, err := pc.projectService.SetMember(c.Request.Context(), c.Param("project"), c.Param("username"), domain.ProjectRole(body.Role))
+	if err != nil {
+		writeError(c, pc.logger, err)
+		return
+	}
+	c.JSON(200, project)
+}
+
+func (pc ProjectController) RemoveMember(c *gin.Context) {
+	if err := pc.projectService.RemoveMember(c.Request.Context(), c.Param("project"), c.Param("username")); err != nil {
+		writeError(c, pc.logger, err)
+		return
+	}
+	c.Status(204)
+}
@@ -0,0 +1,201 @@
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"task7/delivery/controllers"
+	"task7/domain"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockProjectService struct {
+	mock.Mock
+}
+
+func (m *MockProjectService) ListProjects(ctx context.Context) ([]domain.Project, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Project), args.Error(1)
+}
+
+func (m *MockProjectService) CreateProject(ctx context.Context, project *domain.Project) error {
+	args := m.Called(ctx, project)
+	return args.Error(0)
+}
+
+func (m *MockProjectService) GetProject(ctx context.Context, id string) (domain.Project, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Project), args.Error(1)
+}
+
+func (m *MockProjectService) UpdateProject(ctx context.Context, id string, name, description *string) (domain.Project, error) {
+	args := m.Called(ctx, id, name, description)
+	return args.Get(0).(domain.Project), args.Error(1)
+}
+
+func (m *MockProjectService) DeleteProject(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockProjectService) SetMember(ctx context.Context, id, username string, role domain.ProjectRole) (domain.Project, error) {
+	args := m.Called(ctx, id, username, role)
+	return args.Get(0).(domain.Project), args.Error(1)
+}
+
+func (m *MockProjectService) RemoveMember(ctx context.Context, id, username string) error {
+	args := m.Called(ctx, id, username)
+	return args.Error(0)
+}
+
+func (m *MockProjectService) RoleIn(ctx context.Context, id string) (domain.ProjectRole, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.ProjectRole), args.Error(1)
+}
+
+type ProjectControllerSuite struct {
+	suite.Suite
+	router             *gin.Engine
+	mockProjectService *MockProjectService
+	seenRole           domain.ProjectRole // the role a handler behind RequireRole found on its context
+}
+
+func (s *ProjectControllerSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.mockProjectService = new(MockProjectService)
+	s.seenRole = ""
+	controller := controllers.NewProjectController(s.mockProjectService, slog.New(slog.DiscardHandler))
+
+	s.router = gin.New()
+	s.router.GET("/projects", controller.ListProjects)
+	s.router.POST("/projects", controller.CreateProject)
+	s.router.GET("/projects/:project", controller.GetProject)
+	s.router.PATCH("/projects/:project", controller.UpdateProject)
+	s.router.DELETE("/projects/:project", controller.DeleteProject)
+	s.router.PUT("/projects/:project/members/:username", controller.SetMember)
+	s.router.DELETE("/projects/:project/members/:username", controller.RemoveMember)
+	s.router.POST("/tasks", controller.RequireRole(domain.ProjectRoleMember), func(c *gin.Context) {
+		s.seenRole, _ = domain.ProjectRoleFromContext(c.Request.Context(), c.Query("project"))
+		c.Status(http.StatusCreated)
+	})
+}
+
+func TestProjectControllerSuite(t *testing.T) {
+	suite.Run(t, new(ProjectControllerSuite))
+}
+
+func (s *ProjectControllerSuite) perform(method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *ProjectControllerSuite) TestRequireRole() {
+	s.mockProjectService.On("RoleIn", mock.Anything, "p1").Return(domain.ProjectRoleMember, nil).Once()
+
+	w := s.perform("POST", "/tasks?project=p1", "")
+
+	s.Equal(http.StatusCreated, w.Code)
+	s.Equal(domain.ProjectRoleMember, s.seenRole, "The role should be recorded for the handlers behind the middleware")
+}
+
+func (s *ProjectControllerSuite) TestRequireRole_Rejected() {
+	s.mockProjectService.On("RoleIn", mock.Anything, "p1").Return(domain.ProjectRoleViewer, nil).Once()
+	s.mockProjectService.On("RoleIn", mock.Anything, "p2").Return(domain.ProjectRole(""), domain.NewNotFound("no project found with id p2")).Once()
+
+	problem := requireProblem(s.T(), s.perform("POST", "/tasks?project=p1", ""), http.StatusForbidden, "forbidden")
+	s.Contains(problem.Detail, "needs the member role")
+	requireProblem(s.T(), s.perform("POST", "/tasks?project=p2", ""), http.StatusNotFound, "not_found")
+	s.Empty(s.seenRole, "The handler should not run")
+}
+
+func (s *ProjectControllerSuite) TestRequireRole_NoProject() {
+	s.Equal(http.StatusCreated, s.perform("POST", "/tasks", "").Code)
+	s.mockProjectService.AssertNotCalled(s.T(), "RoleIn", mock.Anything, mock.Anything)
+}
+
+func (s *ProjectControllerSuite) TestListProjects() {
+	s.mockProjectService.On("ListProjects", mock.Anything).Return([]domain.Project{}, nil).Once()
+
+	w := s.perform("GET", "/projects", "")
+
+	s.Equal(http.StatusOK, w.Code)
+	s.JSONEq(`{"projects":[]}`, w.Body.String())
+}
+
+func (s *ProjectControllerSuite) TestCreateProject() {
+	at := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	s.mockProjectService.On("CreateProject", mock.Anything, &domain.Project{Name: "Website", Description: "Relaunch"}).Run(func(args mock.Arguments) {
+		project := args.Get(1).(*domain.Project)
+		project.ID, project.CreatedBy, project.CreatedAt, project.UpdatedAt = "p1", "alice", at, at
+		project.Members = []domain.ProjectMember{{Username: "alice", Role: domain.ProjectRoleOwner}}
+	}).Return(nil).Once()
+
+	w := s.perform("POST", "/projects", `{"name":"Website","description":"Relaunch","members":[{"username":"mallory","role":"owner"}]}`)
+
+	s.Equal(http.StatusCreated, w.Code)
+	s.JSONEq(`{"id":"p1","name":"Website","description":"Relaunch","members":[{"username":"alice","role":"owner"}],
+		"created_by":"alice","created_at":"2025-07-01T09:00:00Z","updated_at":"2025-07-01T09:00:00Z"}`, w.Body.String())
+	s.mockProjectService.AssertExpectations(s.T())
+}
+
+func (s *ProjectControllerSuite) TestCreateProject_Invalid() {
+	s.mockProjectService.On("CreateProject", mock.Anything, mock.Anything).Return(domain.ErrInvalidProject).Once()
+
+	requireProblem(s.T(), s.perform("POST", "/projects", `{"name":""}`), http.StatusUnprocessableEntity, "validation")
+	requireProblem(s.T(), s.perform("POST", "/projects", `not json`), http.StatusBadRequest, "bad_request")
+}
+
+func (s *ProjectControllerSuite) TestGetProject_NotFound() {
+	s.mockProjectService.On("GetProject", mock.Anything, "p9").Return(domain.Project{}, domain.NewNotFound("no project found with id p9")).Once()
+
+	requireProblem(s.T(), s.perform("GET", "/projects/p9", ""), http.StatusNotFound, "not_found")
+}
+
+func (s *ProjectControllerSuite) TestUpdateProject_OnlyGivenFields() {
+	s.mockProjectService.On("UpdateProject", mock.Anything, "p1", (*string)(nil), mock.MatchedBy(func(d *string) bool {
+		return d != nil && *d == ""
+	})).Return(domain.Project{ID: "p1", Name: "Website"}, nil).Once()
+
+	w := s.perform("PATCH", "/projects/p1", `{"description":""}`)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.mockProjectService.AssertExpectations(s.T())
+}
+
+func (s *ProjectControllerSuite) TestDeleteProject() {
+	s.mockProjectService.On("DeleteProject", mock.Anything, "p1").Return(nil).Once()
+	s.mockProjectService.On("DeleteProject", mock.Anything, "p2").Return(domain.ErrProjectNotEmpty).Once()
+
+	s.Equal(http.StatusNoContent, s.perform("DELETE", "/projects/p1", "").Code)
+	requireProblem(s.T(), s.perform("DELETE", "/projects/p2", ""), http.StatusConflict, "conflict")
+}
+
+func (s *ProjectControllerSuite) TestSetMember() {
+	project := domain.Project{ID: "p1", Members: []domain.ProjectMember{{Username: "alice", Role: domain.ProjectRoleOwner}, {Username: "bob", Role: domain.ProjectRoleViewer}}}
+	s.mockProjectService.On("SetMember", mock.Anything, "p1", "bob", domain.ProjectRole("viewer")).Return(project, nil).Once()
+
+	w := s.perform("PUT", "/projects/p1/members/bob", `{"role":"viewer"}`)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.Contains(w.Body.String(), `{"username":"bob","role":"viewer"}`)
+	requireProblem(s.T(), s.perform("PUT", "/projects/p1/members/bob", `{}`), http.StatusBadRequest, "bad_request")
+	s.mockProjectService.AssertExpectations(s.T())
+}
+
+func (s *ProjectControllerSuite) TestRemoveMember() {
+	s.mockProjectService.On("RemoveMember", mock.Anything, "p1", "bob").Return(nil).Once()
+	s.mockProjectService.On("RemoveMember", mock.Anything, "p1", "alice").Return(domain.ErrLastProjectOwner).Once()
+
+	s.Equal(http.StatusNoContent, s.perform("DELETE", "/projects/p1/members/bob", "").Code)
+	requireProblem(s.T(), s.perform("DELETE", "/projects/p1/members/alice", ""), http.StatusConflict, "conflict")
+}
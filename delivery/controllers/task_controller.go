@@ -1,93 +1,454 @@
 package controllers
 
 import (
-	"fmt"
+	"log/slog"
 	"strconv"
+	"strings"
 	"task7/domain"
 	services "task7/usecases"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type TaskController struct {
 	taskService services.TaskService
+	logger      *slog.Logger
 }
 
-func NewTaskController(ts services.TaskService) *TaskController {
+func NewTaskController(ts services.TaskService, logger *slog.Logger) *TaskController {
 	return &TaskController{
 		taskService: ts,
+		logger:      logger,
 	}
 }
 
+// lists tasks matching the query string, one page at a time; ?project= lists that project's board
 func (t TaskController) GetAllTasks(c *gin.Context) {
-	tasks, err := t.taskService.GetAllTasks()
+	query, err := parseTaskQuery(c)
 	if err != nil {
-		c.JSON(400, gin.H{"message": "Error getting documents"})
+		writeError(c, t.logger, err)
 		return
 	}
-	c.JSON(200, tasks)
+	query.ProjectID = c.Query("project")
+	page, err := t.taskService.QueryTasks(c.Request.Context(), query)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	c.JSON(200, page)
 }
 
-func (t TaskController) GetTasksById(c *gin.Context) {
+// lists deleted tasks, with the same query string as GetAllTasks
+func (t TaskController) GetTrash(c *gin.Context) {
+	query, err := parseTaskQuery(c)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	page, err := t.taskService.QueryTrash(c.Request.Context(), query)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	c.JSON(200, page)
+}
+
+// reads ?status=&priority=&label=&title=&due_after=&due_before=&sort=&order=&limit=&offset=
+func parseTaskQuery(c *gin.Context) (domain.TaskQuery, error) {
+	query := domain.TaskQuery{
+		Status:        domain.TaskStatus(c.Query("status")),
+		Priority:      domain.TaskPriority(c.Query("priority")),
+		TitleContains: c.Query("title"),
+		SortBy:        c.Query("sort"),
+	}
+	// label may be repeated or comma-separated; a task must carry every one
+	for _, v := range c.QueryArray("label") {
+		query.Labels = append(query.Labels, strings.Split(v, ",")...)
+	}
+
+	switch strings.ToLower(c.DefaultQuery("order", "asc")) {
+	case "asc":
+	case "desc":
+		query.SortDesc = true
+	default:
+		return query, domain.NewBadRequest("order must be asc or desc")
+	}
+
+	var err error
+	if v := c.Query("due_after"); v != "" {
+		if query.DueAfter, err = time.Parse(time.RFC3339, v); err != nil {
+			return query, domain.NewBadRequest("due_after must be an RFC3339 timestamp")
+		}
+	}
+	if v := c.Query("due_before"); v != "" {
+		if query.DueBefore, err = time.Parse(time.RFC3339, v); err != nil {
+			return query, domain.NewBadRequest("due_before must be an RFC3339 timestamp")
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if query.Limit, err = strconv.Atoi(v); err != nil {
+			return query, domain.NewBadRequest("limit must be an integer")
+		}
+	}
+	if v := c.Query("offset"); v != "" {
+		if query.Offset, err = strconv.Atoi(v); err != nil {
+			return query, domain.NewBadRequest("offset must be an integer")
+		}
+	}
+	return query, nil
+}
+
+var errInvalidJSON = domain.NewBadRequest("Error binding JSON")
+
+func parseTaskID(c *gin.Context) (int, error) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(400, gin.H{"message": "Invalid Task ID"})
+		return 0, domain.NewBadRequest("Invalid Task ID")
+	}
+	return id, nil
+}
+
+var (
+	errIfMatchRequired = domain.NewPreconditionRequired("send the task's ETag in an If-Match header; fetch the task to get it")
+	errInvalidIfMatch  = domain.NewBadRequest(`If-Match must be a single task ETag such as "3"`)
+)
+
+// the strong ETag of a task version, e.g. "3"
+func taskETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// the task version a write is conditional on; writes without one are refused so edits cannot clobber each other
+func parseIfMatch(c *gin.Context) (int64, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	switch {
+	case header == "":
+		return 0, errIfMatchRequired
+	case strings.HasPrefix(header, "W/"):
+		// a weak tag never matches under the strong comparison If-Match uses
+		return 0, domain.ErrTaskVersionMismatch
+	case len(header) < 3 || header[0] != '"' || header[len(header)-1] != '"':
+		return 0, errInvalidIfMatch
+	}
+	version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil || version < 1 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+func (t TaskController) GetTasksById(c *gin.Context) {
+	id, err := parseTaskID(c)
+	if err != nil {
+		writeError(c, t.logger, err)
 		return
 	}
-	task, err := t.taskService.GetTaskById(id)
+	task, err := t.taskService.GetTaskById(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(404, gin.H{"message": "Task not found"})
+		writeError(c, t.logger, err)
 		return
 	}
+	c.Header("ETag", taskETag(task.Version))
 	c.JSON(200, task)
 }
 
+// ?project= puts the task on that project's board; a project_id in the body is ignored, as the
+// project middleware only checks the one in the query string
 func (t TaskController) PostTasks(c *gin.Context) {
 	var newTask domain.Task
-	err := c.BindJSON(&newTask)
-	if err != nil {
-		c.JSON(400, gin.H{"message": "Error binding JSON"})
+	if err := c.ShouldBindJSON(&newTask); err != nil {
+		writeError(c, t.logger, errInvalidJSON)
 		return
 	}
-	err = t.taskService.CreateTask(&newTask)
-	if err != nil {
-		fmt.Println(err)
-		c.JSON(400, gin.H{"message": fmt.Sprintf("Error %v", err)})
+	newTask.ProjectID = c.Query("project")
+	if err := t.taskService.CreateTask(c.Request.Context(), &newTask); err != nil {
+		writeError(c, t.logger, err)
 		return
 	}
+	c.Header("ETag", taskETag(newTask.Version))
 	c.JSON(201, newTask)
 }
 
 func (t TaskController) PutTasksById(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseTaskID(c)
 	if err != nil {
-		c.JSON(400, gin.H{"message": "Invalid Task ID"})
+		writeError(c, t.logger, err)
 		return
 	}
-	var updatedTask domain.Task
-	err = c.BindJSON(&updatedTask)
+	version, err := parseIfMatch(c)
 	if err != nil {
-		c.JSON(400, gin.H{"message": "Error binding JSON"})
+		writeError(c, t.logger, err)
 		return
 	}
-	err = t.taskService.UpdateTask(id, &updatedTask)
-	if err != nil {
-		c.JSON(404, gin.H{"message": "Error updating task"})
+	var updatedTask domain.Task
+	if err := c.ShouldBindJSON(&updatedTask); err != nil {
+		writeError(c, t.logger, errInvalidJSON)
 		return
 	}
+	if err := t.taskService.UpdateTask(c.Request.Context(), id, version, &updatedTask); err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	c.Header("ETag", taskETag(updatedTask.Version))
 	c.JSON(200, gin.H{"message": "Task updated successfully"})
 }
 
 func (t TaskController) DeleteTaskById(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseTaskID(c)
 	if err != nil {
-		c.JSON(400, gin.H{"message": "Invalid Task ID"})
+		writeError(c, t.logger, err)
 		return
 	}
-	err = t.taskService.DeleteTaskById(id)
+	version, err := parseIfMatch(c)
 	if err != nil {
-		c.JSON(404, gin.H{"message": "Error deleting task"})
+		writeError(c, t.logger, err)
+		return
+	}
+	cascade, err := strconv.ParseBool(c.DefaultQuery("cascade", "false"))
+	if err != nil {
+		writeError(c, t.logger, domain.NewBadRequest("cascade must be true or false"))
+		return
+	}
+	if err := t.taskService.DeleteTaskById(c.Request.Context(), id, version, cascade); err != nil {
+		writeError(c, t.logger, err)
 		return
 	}
 	c.Status(204)
 }
+
+func (t TaskController) RestoreTask(c *gin.Context) {
+	id, err := parseTaskID(c)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	task, err := t.taskService.RestoreTask(c.Request.Context(), id)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	c.Header("ETag", taskETag(task.Version))
+	c.JSON(200, task)
+}
+
+// the labels to add to and remove from a task in one PATCH
+type labelEdit struct {
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+// adds and removes labels without an If-Match: the edit is merged into whatever labels the task has now
+func (t TaskController) EditLabels(c *gin.Context) {
+	id, err := parseTaskID(c)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	var edit labelEdit
+	if err := c.ShouldBindJSON(&edit); err != nil {
+		writeError(c, t.logger, errInvalidJSON)
+		return
+	}
+	if len(edit.Add) == 0 && len(edit.Remove) == 0 {
+		writeError(c, t.logger, domain.NewBadRequest("give at least one label to add or remove"))
+		return
+	}
+	task, err := t.taskService.EditLabels(c.Request.Context(), id, edit.Add, edit.Remove)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	c.Header("ETag", taskETag(task.Version))
+	c.JSON(200, task)
+}
+
+// lists the labels on the caller's visible tasks, most used first
+func (t TaskController) ListLabels(c *gin.Context) {
+	labels, err := t.taskService.ListLabels(c.Request.Context())
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	if labels == nil {
+		labels = []domain.LabelCount{}
+	}
+	c.JSON(200, gin.H{"labels": labels})
+}
+
+// lists a task's direct subtasks, with the same query string as GetAllTasks
+func (t TaskController) GetSubtasks(c *gin.Context) {
+	id, err := parseTaskID(c)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	query, err := parseTaskQuery(c)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	page, err := t.taskService.ListSubtasks(c.Request.Context(), id, query)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	c.JSON(200, page)
+}
+
+func parseChecklistItemID(c *gin.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("item"))
+	if err != nil {
+		return 0, domain.NewBadRequest("Invalid checklist item ID")
+	}
+	return id, nil
+}
+
+// runs an edit on the task in the path and responds with the task as it now is; like label edits,
+// checklist and dependency edits need no If-Match
+func (t TaskController) editTask(c *gin.Context, status int, edit func(id int) (domain.Task, error)) {
+	id, err := parseTaskID(c)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	task, err := edit(id)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	c.Header("ETag", taskETag(task.Version))
+	c.JSON(status, task)
+}
+
+func (t TaskController) AddChecklistItem(c *gin.Context) {
+	var body struct {
+		Text string `json:"text"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		writeError(c, t.logger, errInvalidJSON)
+		return
+	}
+	t.editTask(c, 201, func(id int) (domain.Task, error) {
+		return t.taskService.AddChecklistItem(c.Request.Context(), id, body.Text)
+	})
+}
+
+func (t TaskController) UpdateChecklistItem(c *gin.Context) {
+	itemID, err := parseChecklistItemID(c)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	var body struct {
+		Done *bool `json:"done"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		writeError(c, t.logger, errInvalidJSON)
+		return
+	}
+	if body.Done == nil {
+		writeError(c, t.logger, domain.NewBadRequest("done must be true or false"))
+		return
+	}
+	t.editTask(c, 200, func(id int) (domain.Task, error) {
+		return t.taskService.SetChecklistItemDone(c.Request.Context(), id, itemID, *body.Done)
+	})
+}
+
+func (t TaskController) DeleteChecklistItem(c *gin.Context) {
+	itemID, err := parseChecklistItemID(c)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	t.editTask(c, 200, func(id int) (domain.Task, error) {
+		return t.taskService.RemoveChecklistItem(c.Request.Context(), id, itemID)
+	})
+}
+
+func (t TaskController) ReorderChecklist(c *gin.Context) {
+	var body struct {
+		IDs []int `json:"ids"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		writeError(c, t.logger, errInvalidJSON)
+		return
+	}
+	t.editTask(c, 200, func(id int) (domain.Task, error) {
+		return t.taskService.ReorderChecklist(c.Request.Context(), id, body.IDs)
+	})
+}
+
+// reads the {"blocked_by": [...]} body shared by adding and removing dependencies
+func bindBlockers(c *gin.Context) ([]int, error) {
+	var body struct {
+		BlockedBy []int `json:"blocked_by"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		return nil, errInvalidJSON
+	}
+	if len(body.BlockedBy) == 0 {
+		return nil, domain.NewBadRequest("give at least one task id in blocked_by")
+	}
+	return body.BlockedBy, nil
+}
+
+func (t TaskController) AddDependencies(c *gin.Context) {
+	blockerIDs, err := bindBlockers(c)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	t.editTask(c, 200, func(id int) (domain.Task, error) {
+		return t.taskService.AddDependencies(c.Request.Context(), id, blockerIDs)
+	})
+}
+
+func (t TaskController) RemoveDependencies(c *gin.Context) {
+	blockerIDs, err := bindBlockers(c)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	t.editTask(c, 200, func(id int) (domain.Task, error) {
+		return t.taskService.RemoveDependencies(c.Request.Context(), id, blockerIDs)
+	})
+}
+
+// the tasks the task in the path waits on and the tasks waiting on it, with the edges between them
+func (t TaskController) GetDependencyGraph(c *gin.Context) {
+	id, err := parseTaskID(c)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	graph, err := t.taskService.DependencyGraph(c.Request.Context(), id)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	c.JSON(200, graph)
+}
+
+// previews the next occurrences of a recurring task, starting with the task itself
+func (t TaskController) GetOccurrences(c *gin.Context) {
+	id, err := parseTaskID(c)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil {
+			writeError(c, t.logger, domain.NewBadRequest("limit must be a number"))
+			return
+		}
+	}
+	occurrences, err := t.taskService.PreviewOccurrences(c.Request.Context(), id, limit)
+	if err != nil {
+		writeError(c, t.logger, err)
+		return
+	}
+	c.JSON(200, gin.H{"occurrences": occurrences})
+}
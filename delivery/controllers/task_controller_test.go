@@ -2,9 +2,11 @@ package controllers_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"task7/delivery/controllers"
@@ -21,37 +23,102 @@ type MockTaskService struct {
 	mock.Mock
 }
 
-func (m *MockTaskService) GetAllTasks() ([]domain.Task, error) {
-	args := m.Called()
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).([]domain.Task), args.Error(1)
+func (m *MockTaskService) QueryTasks(ctx context.Context, query domain.TaskQuery) (domain.TaskPage, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(domain.TaskPage), args.Error(1)
 }
 
-func (m *MockTaskService) GetTaskById(id int) (domain.Task, error) {
-	args := m.Called(id)
+func (m *MockTaskService) GetTaskById(ctx context.Context, id int) (domain.Task, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return domain.Task{}, args.Error(1)
 	}
 	return args.Get(0).(domain.Task), args.Error(1)
 }
 
-func (m *MockTaskService) CreateTask(newTask *domain.Task) error {
-	args := m.Called(newTask)
+func (m *MockTaskService) CreateTask(ctx context.Context, newTask *domain.Task) error {
+	args := m.Called(ctx, newTask)
 	return args.Error(0)
 }
 
-func (m *MockTaskService) UpdateTask(id int, updatedTask *domain.Task) error {
-	args := m.Called(id, updatedTask)
+func (m *MockTaskService) UpdateTask(ctx context.Context, id int, version int64, updatedTask *domain.Task) error {
+	args := m.Called(ctx, id, version, updatedTask)
 	return args.Error(0)
 }
 
-func (m *MockTaskService) DeleteTaskById(id int) error {
-	args := m.Called(id)
+func (m *MockTaskService) DeleteTaskById(ctx context.Context, id int, version int64, cascade bool) error {
+	args := m.Called(ctx, id, version, cascade)
 	return args.Error(0)
 }
 
+func (m *MockTaskService) QueryTrash(ctx context.Context, query domain.TaskQuery) (domain.TaskPage, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(domain.TaskPage), args.Error(1)
+}
+
+func (m *MockTaskService) RestoreTask(ctx context.Context, id int) (domain.Task, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Task), args.Error(1)
+}
+
+func (m *MockTaskService) EditLabels(ctx context.Context, id int, add, remove []string) (domain.Task, error) {
+	args := m.Called(ctx, id, add, remove)
+	return args.Get(0).(domain.Task), args.Error(1)
+}
+
+func (m *MockTaskService) ListLabels(ctx context.Context) ([]domain.LabelCount, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.LabelCount), args.Error(1)
+}
+
+func (m *MockTaskService) ListSubtasks(ctx context.Context, id int, query domain.TaskQuery) (domain.TaskPage, error) {
+	args := m.Called(ctx, id, query)
+	return args.Get(0).(domain.TaskPage), args.Error(1)
+}
+
+func (m *MockTaskService) AddChecklistItem(ctx context.Context, id int, text string) (domain.Task, error) {
+	args := m.Called(ctx, id, text)
+	return args.Get(0).(domain.Task), args.Error(1)
+}
+
+func (m *MockTaskService) SetChecklistItemDone(ctx context.Context, id, itemID int, done bool) (domain.Task, error) {
+	args := m.Called(ctx, id, itemID, done)
+	return args.Get(0).(domain.Task), args.Error(1)
+}
+
+func (m *MockTaskService) RemoveChecklistItem(ctx context.Context, id, itemID int) (domain.Task, error) {
+	args := m.Called(ctx, id, itemID)
+	return args.Get(0).(domain.Task), args.Error(1)
+}
+
+func (m *MockTaskService) ReorderChecklist(ctx context.Context, id int, itemIDs []int) (domain.Task, error) {
+	args := m.Called(ctx, id, itemIDs)
+	return args.Get(0).(domain.Task), args.Error(1)
+}
+
+func (m *MockTaskService) AddDependencies(ctx context.Context, id int, blockerIDs []int) (domain.Task, error) {
+	args := m.Called(ctx, id, blockerIDs)
+	return args.Get(0).(domain.Task), args.Error(1)
+}
+
+func (m *MockTaskService) RemoveDependencies(ctx context.Context, id int, blockerIDs []int) (domain.Task, error) {
+	args := m.Called(ctx, id, blockerIDs)
+	return args.Get(0).(domain.Task), args.Error(1)
+}
+
+func (m *MockTaskService) DependencyGraph(ctx context.Context, id int) (domain.DependencyGraph, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.DependencyGraph), args.Error(1)
+}
+
+func (m *MockTaskService) PreviewOccurrences(ctx context.Context, id int, limit int) ([]domain.Occurrence, error) {
+	args := m.Called(ctx, id, limit)
+	return args.Get(0).([]domain.Occurrence), args.Error(1)
+}
+
 type TaskControllerSuite struct {
 	suite.Suite
 	router          *gin.Engine
@@ -61,14 +128,27 @@ type TaskControllerSuite struct {
 func (s *TaskControllerSuite) SetupTest() {
 	gin.SetMode(gin.TestMode)
 	s.mockTaskService = new(MockTaskService)
-	taskController := controllers.NewTaskController(s.mockTaskService)
+	taskController := controllers.NewTaskController(s.mockTaskService, slog.New(slog.DiscardHandler))
 
 	s.router = gin.New()
 	s.router.GET("/tasks", taskController.GetAllTasks)
+	s.router.GET("/tasks/trash", taskController.GetTrash)
 	s.router.GET("/tasks/:id", taskController.GetTasksById)
 	s.router.POST("/tasks", taskController.PostTasks)
 	s.router.PUT("/tasks/:id", taskController.PutTasksById)
 	s.router.DELETE("/tasks/:id", taskController.DeleteTaskById)
+	s.router.POST("/tasks/:id/restore", taskController.RestoreTask)
+	s.router.PATCH("/tasks/:id/labels", taskController.EditLabels)
+	s.router.GET("/labels", taskController.ListLabels)
+	s.router.GET("/tasks/:id/subtasks", taskController.GetSubtasks)
+	s.router.POST("/tasks/:id/checklist", taskController.AddChecklistItem)
+	s.router.PUT("/tasks/:id/checklist/order", taskController.ReorderChecklist)
+	s.router.PATCH("/tasks/:id/checklist/:item", taskController.UpdateChecklistItem)
+	s.router.DELETE("/tasks/:id/checklist/:item", taskController.DeleteChecklistItem)
+	s.router.POST("/tasks/:id/dependencies", taskController.AddDependencies)
+	s.router.DELETE("/tasks/:id/dependencies", taskController.RemoveDependencies)
+	s.router.GET("/tasks/:id/graph", taskController.GetDependencyGraph)
+	s.router.GET("/tasks/:id/occurrences", taskController.GetOccurrences)
 }
 
 func TestTaskControllerSuite(t *testing.T) {
@@ -76,6 +156,11 @@ func TestTaskControllerSuite(t *testing.T) {
 }
 
 func (s *TaskControllerSuite) performRequest(method, path string, body interface{}) *httptest.ResponseRecorder {
+	return s.performConditionalRequest(method, path, "", body)
+}
+
+// sends ifMatch as the If-Match header unless it is empty
+func (s *TaskControllerSuite) performConditionalRequest(method, path, ifMatch string, body interface{}) *httptest.ResponseRecorder {
 	var reqBody []byte
 	if body != nil {
 		reqBody, _ = json.Marshal(body)
@@ -84,6 +169,9 @@ func (s *TaskControllerSuite) performRequest(method, path string, body interface
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(method, path, bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
 	s.router.ServeHTTP(w, req)
 	return w
 }
@@ -96,59 +184,129 @@ func (s *TaskControllerSuite) TestGetAllTasks_SuccessWithTasks() {
 		{ID: 2, Title: "Task 2", Description: "Desc 2", DueDate: time.Now().UTC().Truncate(timePrecision), Status: "completed"},
 	}
 
-	s.mockTaskService.On("GetAllTasks").Return(expectedTasks, nil).Once()
+	page := domain.TaskPage{Tasks: expectedTasks, Total: 2, Limit: domain.DefaultTaskPageSize}
+	s.mockTaskService.On("QueryTasks", mock.Anything, domain.TaskQuery{}).Return(page, nil).Once()
 
 	w := s.performRequest("GET", "/tasks", nil)
 
 	s.Equal(http.StatusOK, w.Code)
-	var tasks []domain.Task
-	err := json.Unmarshal(w.Body.Bytes(), &tasks)
+	var got domain.TaskPage
+	err := json.Unmarshal(w.Body.Bytes(), &got)
 	s.NoError(err)
 
-	for i := range tasks {
-		tasks[i].DueDate = tasks[i].DueDate.UTC().Truncate(timePrecision)
+	for i := range got.Tasks {
+		got.Tasks[i].DueDate = got.Tasks[i].DueDate.UTC().Truncate(timePrecision)
 	}
 
-	s.Equal(expectedTasks, tasks)
+	s.Equal(expectedTasks, got.Tasks)
+	s.EqualValues(2, got.Total)
+	s.Nil(got.NextOffset)
 	s.mockTaskService.AssertExpectations(s.T())
 }
 
 func (s *TaskControllerSuite) TestGetAllTasks_SuccessNoTasks() {
-	expectedTasks := []domain.Task{}
-
-	s.mockTaskService.On("GetAllTasks").Return(expectedTasks, nil).Once()
+	page := domain.TaskPage{Tasks: []domain.Task{}, Limit: domain.DefaultTaskPageSize}
+	s.mockTaskService.On("QueryTasks", mock.Anything, domain.TaskQuery{}).Return(page, nil).Once()
 
 	w := s.performRequest("GET", "/tasks", nil)
 
 	s.Equal(http.StatusOK, w.Code)
-	var tasks []domain.Task
-	err := json.Unmarshal(w.Body.Bytes(), &tasks)
-	s.NoError(err)
-	s.Empty(tasks)
+	s.JSONEq(`{"tasks":[],"total":0,"limit":50,"offset":0}`, w.Body.String())
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestGetAllTasks_ParsesQueryParameters() {
+	dueAfter, _ := time.Parse(time.RFC3339, "2025-07-01T00:00:00Z")
+	dueBefore, _ := time.Parse(time.RFC3339, "2025-07-31T00:00:00Z")
+	expectedQuery := domain.TaskQuery{
+		Status:        "pending",
+		TitleContains: "report",
+		SortBy:        "duedate",
+		SortDesc:      true,
+		DueAfter:      dueAfter,
+		DueBefore:     dueBefore,
+		Limit:         10,
+		Offset:        20,
+	}
+	next := 30
+	page := domain.TaskPage{Tasks: []domain.Task{}, Total: 45, Limit: 10, Offset: 20, NextOffset: &next}
+	s.mockTaskService.On("QueryTasks", mock.Anything, expectedQuery).Return(page, nil).Once()
+
+	w := s.performRequest("GET", "/tasks?status=pending&title=report&sort=duedate&order=desc&due_after=2025-07-01T00:00:00Z&due_before=2025-07-31T00:00:00Z&limit=10&offset=20", nil)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.JSONEq(`{"tasks":[],"total":45,"limit":10,"offset":20,"next_offset":30}`, w.Body.String())
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestGetAllTasks_ProjectBoard() {
+	page := domain.TaskPage{Tasks: []domain.Task{}, Limit: domain.DefaultTaskPageSize}
+	s.mockTaskService.On("QueryTasks", mock.Anything, domain.TaskQuery{ProjectID: "p1", Status: "todo"}).Return(page, nil).Once()
+
+	w := s.performRequest("GET", "/tasks?project=p1&status=todo", nil)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestGetAllTasks_MalformedQueryParameters() {
+	for _, rawQuery := range []string{"limit=abc", "offset=-x", "order=sideways", "due_after=yesterday", "due_before=2025-13-01"} {
+		w := s.performRequest("GET", "/tasks?"+rawQuery, nil)
+		s.Equal(http.StatusBadRequest, w.Code, rawQuery)
+	}
+	s.mockTaskService.AssertNotCalled(s.T(), "QueryTasks", mock.Anything, mock.Anything)
+}
+
+func (s *TaskControllerSuite) TestGetAllTasks_InvalidQuery() {
+	queryError := fmt.Errorf("%w: cannot sort by \"nope\"", domain.ErrInvalidTaskQuery)
+	s.mockTaskService.On("QueryTasks", mock.Anything, domain.TaskQuery{SortBy: "nope"}).Return(domain.TaskPage{}, queryError).Once()
+
+	w := s.performRequest("GET", "/tasks?sort=nope", nil)
+
+	problem := requireProblem(s.T(), w, http.StatusBadRequest, "bad_request")
+	s.Contains(problem.Detail, "cannot sort by")
 	s.mockTaskService.AssertExpectations(s.T())
 }
 
 func (s *TaskControllerSuite) TestGetAllTasks_ServiceError() {
-	serviceError := errors.New("database connection failed")
+	serviceError := domain.NewInternal(errors.New("database connection failed"), "querying tasks")
 
-	s.mockTaskService.On("GetAllTasks").Return(nil, serviceError).Once()
+	s.mockTaskService.On("QueryTasks", mock.Anything, domain.TaskQuery{}).Return(domain.TaskPage{}, serviceError).Once()
 
 	w := s.performRequest("GET", "/tasks", nil)
 
-	s.Equal(http.StatusBadRequest, w.Code)
-	s.Contains(w.Body.String(), `{"message":"Error getting documents"}`)
+	problem := requireProblem(s.T(), w, http.StatusInternalServerError, "internal")
+	s.NotContains(problem.Detail, "database connection failed")
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestGetAllTasks_PropagatesRequestContext() {
+	type ctxKey struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "request-scoped"))
+	cancel()
+
+	s.mockTaskService.On("QueryTasks", mock.MatchedBy(func(got context.Context) bool {
+		return got.Value(ctxKey{}) == "request-scoped" && got.Err() == context.Canceled
+	}), mock.Anything).Return(domain.TaskPage{Tasks: []domain.Task{}}, nil).Once()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/tasks", nil)
+	s.router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusOK, w.Code)
 	s.mockTaskService.AssertExpectations(s.T())
 }
 
 func (s *TaskControllerSuite) TestGetTasksById_Success() {
 	const timePrecision = time.Millisecond
-	expectedTask := domain.Task{ID: 1, Title: "Test Task", Description: "Desc", DueDate: time.Now().UTC().Truncate(timePrecision), Status: "pending"}
+	expectedTask := domain.Task{ID: 1, Title: "Test Task", Description: "Desc", DueDate: time.Now().UTC().Truncate(timePrecision), Status: "pending", Version: 4}
 
-	s.mockTaskService.On("GetTaskById", 1).Return(expectedTask, nil).Once()
+	s.mockTaskService.On("GetTaskById", mock.Anything, 1).Return(expectedTask, nil).Once()
 
 	w := s.performRequest("GET", "/tasks/1", nil)
 
 	s.Equal(http.StatusOK, w.Code)
+	s.Equal(`"4"`, w.Header().Get("ETag"))
 	var task domain.Task
 	err := json.Unmarshal(w.Body.Bytes(), &task)
 	s.NoError(err)
@@ -160,20 +318,21 @@ func (s *TaskControllerSuite) TestGetTasksById_Success() {
 func (s *TaskControllerSuite) TestGetTasksById_InvalidID() {
 	w := s.performRequest("GET", "/tasks/abc", nil)
 
-	s.Equal(http.StatusBadRequest, w.Code)
-	s.Contains(w.Body.String(), `{"message":"Invalid Task ID"}`)
-	s.mockTaskService.AssertNotCalled(s.T(), "GetTaskById", mock.Anything)
+	problem := requireProblem(s.T(), w, http.StatusBadRequest, "bad_request")
+	s.Equal("Invalid Task ID", problem.Detail)
+	s.mockTaskService.AssertNotCalled(s.T(), "GetTaskById", mock.Anything, mock.Anything)
 }
 
 func (s *TaskControllerSuite) TestGetTasksById_NotFound() {
-	serviceError := errors.New("task not found")
+	serviceError := domain.NewNotFound("no task found with id 999")
 
-	s.mockTaskService.On("GetTaskById", 999).Return(domain.Task{}, serviceError).Once()
+	s.mockTaskService.On("GetTaskById", mock.Anything, 999).Return(domain.Task{}, serviceError).Once()
 
 	w := s.performRequest("GET", "/tasks/999", nil)
 
-	s.Equal(http.StatusNotFound, w.Code)
-	s.Contains(w.Body.String(), `{"message":"Task not found"}`)
+	problem := requireProblem(s.T(), w, http.StatusNotFound, "not_found")
+	s.Equal("no task found with id 999", problem.Detail)
+	s.Equal("/tasks/999", problem.Instance)
 	s.mockTaskService.AssertExpectations(s.T())
 }
 
@@ -181,17 +340,21 @@ func (s *TaskControllerSuite) TestPostTasks_Success() {
 	const timePrecision = time.Millisecond
 	newTask := domain.Task{Title: "New Task", Description: "Details", DueDate: time.Now().UTC().Add(24 * time.Hour).Truncate(timePrecision), Status: "pending"}
 
-	s.mockTaskService.On("CreateTask", mock.AnythingOfType("*domain.Task")).Return(nil).Once()
+	s.mockTaskService.On("CreateTask", mock.Anything, mock.AnythingOfType("*domain.Task")).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.Task).ID = 7
+		args.Get(1).(*domain.Task).Version = 1
+	}).Return(nil).Once()
 
 	w := s.performRequest("POST", "/tasks", newTask)
 
 	s.Equal(http.StatusCreated, w.Code)
+	s.Equal(`"1"`, w.Header().Get("ETag"))
 	var createdTask domain.Task
 	err := json.Unmarshal(w.Body.Bytes(), &createdTask)
 	s.NoError(err)
 
 	createdTask.DueDate = createdTask.DueDate.UTC().Truncate(timePrecision)
-	newTask.ID = createdTask.ID
+	s.Equal(7, createdTask.ID, "Response should carry the server-assigned ID")
 	s.Equal(newTask.Title, createdTask.Title)
 	s.Equal(newTask.Description, createdTask.Description)
 	s.Equal(newTask.Status, createdTask.Status)
@@ -199,24 +362,44 @@ func (s *TaskControllerSuite) TestPostTasks_Success() {
 	s.mockTaskService.AssertExpectations(s.T())
 }
 
+func (s *TaskControllerSuite) TestPostTasks_ProjectFromQuery() {
+	s.mockTaskService.On("CreateTask", mock.Anything, mock.MatchedBy(func(t *domain.Task) bool { return t.ProjectID == "p1" })).Return(nil).Once()
+	s.mockTaskService.On("CreateTask", mock.Anything, mock.MatchedBy(func(t *domain.Task) bool { return t.ProjectID == "" })).Return(nil).Once()
+
+	s.Equal(http.StatusCreated, s.performRequest("POST", "/tasks?project=p1", domain.Task{Title: "Launch"}).Code)
+	s.Equal(http.StatusCreated, s.performRequest("POST", "/tasks", domain.Task{Title: "Sneak", ProjectID: "p2"}).Code,
+		"A project named only in the body is ignored")
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
 func (s *TaskControllerSuite) TestPostTasks_InvalidJSON() {
 	w := s.performRequest("POST", "/tasks", "not json")
 
-	s.Equal(http.StatusBadRequest, w.Code)
-	s.Contains(w.Body.String(), `{"message":"Error binding JSON"}`)
-	s.mockTaskService.AssertNotCalled(s.T(), "CreateTask", mock.Anything)
+	problem := requireProblem(s.T(), w, http.StatusBadRequest, "bad_request")
+	s.Equal("Error binding JSON", problem.Detail)
+	s.mockTaskService.AssertNotCalled(s.T(), "CreateTask", mock.Anything, mock.Anything)
 }
 
 func (s *TaskControllerSuite) TestPostTasks_ServiceError() {
 	newTask := domain.Task{Title: "Failed Task", Description: "Will fail", DueDate: time.Now(), Status: "pending"}
 	serviceError := errors.New("database write error")
 
-	s.mockTaskService.On("CreateTask", mock.AnythingOfType("*domain.Task")).Return(serviceError).Once()
+	s.mockTaskService.On("CreateTask", mock.Anything, mock.AnythingOfType("*domain.Task")).Return(serviceError).Once()
 
 	w := s.performRequest("POST", "/tasks", newTask)
 
-	s.Equal(http.StatusBadRequest, w.Code)
-	s.Contains(w.Body.String(), fmt.Sprintf(`{"message":"Error %v"}`, serviceError))
+	requireProblem(s.T(), w, http.StatusInternalServerError, "internal")
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestPostTasks_UnknownStatus() {
+	_, statusError := domain.ParseTaskStatus("someday")
+	s.mockTaskService.On("CreateTask", mock.Anything, mock.AnythingOfType("*domain.Task")).Return(statusError).Once()
+
+	w := s.performRequest("POST", "/tasks", domain.Task{Title: "Later", Status: "someday"})
+
+	problem := requireProblem(s.T(), w, http.StatusUnprocessableEntity, "validation")
+	s.Contains(problem.Detail, "expected one of todo, in_progress, blocked, done, archived")
 	s.mockTaskService.AssertExpectations(s.T())
 }
 
@@ -224,12 +407,54 @@ func (s *TaskControllerSuite) TestPutTasksById_Success() {
 	const timePrecision = time.Millisecond
 	updatedTask := domain.Task{ID: 1, Title: "Updated Task", Description: "New Desc", DueDate: time.Now().UTC().Truncate(timePrecision), Status: "completed"}
 
-	s.mockTaskService.On("UpdateTask", 1, mock.AnythingOfType("*domain.Task")).Return(nil).Once()
+	s.mockTaskService.On("UpdateTask", mock.Anything, 1, int64(1), mock.AnythingOfType("*domain.Task")).Run(func(args mock.Arguments) {
+		args.Get(3).(*domain.Task).Version = 2
+	}).Return(nil).Once()
 
-	w := s.performRequest("PUT", "/tasks/1", updatedTask)
+	w := s.performConditionalRequest("PUT", "/tasks/1", `"1"`, updatedTask)
 
 	s.Equal(http.StatusOK, w.Code)
 	s.Contains(w.Body.String(), `{"message":"Task updated successfully"}`)
+	s.Equal(`"2"`, w.Header().Get("ETag"), "The response carries the new version")
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestPutTasksById_MissingIfMatch() {
+	w := s.performRequest("PUT", "/tasks/1", domain.Task{Title: "Blind write"})
+
+	requireProblem(s.T(), w, http.StatusPreconditionRequired, "precondition_required")
+	s.mockTaskService.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TaskControllerSuite) TestPutTasksById_BadIfMatch() {
+	tests := []struct {
+		name    string
+		ifMatch string
+		status  int
+		kind    string
+	}{
+		{name: "Unquoted", ifMatch: "1", status: http.StatusBadRequest, kind: "bad_request"},
+		{name: "Not a version", ifMatch: `"abc"`, status: http.StatusBadRequest, kind: "bad_request"},
+		{name: "Wildcard", ifMatch: "*", status: http.StatusBadRequest, kind: "bad_request"},
+		{name: "Several tags", ifMatch: `"1", "2"`, status: http.StatusBadRequest, kind: "bad_request"},
+		{name: "Weak tag", ifMatch: `W/"1"`, status: http.StatusPreconditionFailed, kind: "precondition_failed"},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			w := s.performConditionalRequest("PUT", "/tasks/1", tt.ifMatch, domain.Task{Title: "x"})
+			requireProblem(s.T(), w, tt.status, tt.kind)
+		})
+	}
+	s.mockTaskService.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TaskControllerSuite) TestPutTasksById_StaleVersion() {
+	s.mockTaskService.On("UpdateTask", mock.Anything, 1, int64(3), mock.AnythingOfType("*domain.Task")).Return(domain.ErrTaskVersionMismatch).Once()
+
+	w := s.performConditionalRequest("PUT", "/tasks/1", `"3"`, domain.Task{Title: "Late edit"})
+
+	problem := requireProblem(s.T(), w, http.StatusPreconditionFailed, "precondition_failed")
+	s.Contains(problem.Detail, "modified since it was read")
 	s.mockTaskService.AssertExpectations(s.T())
 }
 
@@ -238,36 +463,66 @@ func (s *TaskControllerSuite) TestPutTasksById_InvalidID() {
 
 	w := s.performRequest("PUT", "/tasks/xyz", updatedTask)
 
-	s.Equal(http.StatusBadRequest, w.Code)
-	s.Contains(w.Body.String(), `{"message":"Invalid Task ID"}`)
-	s.mockTaskService.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything)
+	problem := requireProblem(s.T(), w, http.StatusBadRequest, "bad_request")
+	s.Equal("Invalid Task ID", problem.Detail)
+	s.mockTaskService.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func (s *TaskControllerSuite) TestPutTasksById_InvalidJSON() {
-	w := s.performRequest("PUT", "/tasks/1", "not json")
+	w := s.performConditionalRequest("PUT", "/tasks/1", `"1"`, "not json")
 
-	s.Equal(http.StatusBadRequest, w.Code)
-	s.Contains(w.Body.String(), `{"message":"Error binding JSON"}`)
-	s.mockTaskService.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything)
+	problem := requireProblem(s.T(), w, http.StatusBadRequest, "bad_request")
+	s.Equal("Error binding JSON", problem.Detail)
+	s.mockTaskService.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func (s *TaskControllerSuite) TestPutTasksById_ServiceError() {
 	updatedTask := domain.Task{ID: 1, Title: "Updated Task", Description: "New Desc", DueDate: time.Now(), Status: "completed"}
-	serviceError := errors.New("task not found for update")
+	serviceError := domain.NewNotFound("no task found with id 1")
+
+	s.mockTaskService.On("UpdateTask", mock.Anything, 1, int64(1), mock.AnythingOfType("*domain.Task")).Return(serviceError).Once()
+
+	w := s.performConditionalRequest("PUT", "/tasks/1", `"1"`, updatedTask)
+
+	requireProblem(s.T(), w, http.StatusNotFound, "not_found")
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestPutTasksById_Forbidden() {
+	s.mockTaskService.On("UpdateTask", mock.Anything, 3, int64(1), mock.AnythingOfType("*domain.Task")).Return(domain.ErrForbidden).Once()
+
+	w := s.performConditionalRequest("PUT", "/tasks/3", `"1"`, domain.Task{Status: "completed"})
+
+	requireProblem(s.T(), w, http.StatusForbidden, "forbidden")
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestPutTasksById_IllegalTransition() {
+	transitionError := domain.StatusArchived.TransitionTo(domain.StatusTodo)
+	s.mockTaskService.On("UpdateTask", mock.Anything, 1, int64(1), mock.AnythingOfType("*domain.Task")).Return(transitionError).Once()
+
+	w := s.performConditionalRequest("PUT", "/tasks/1", `"1"`, domain.Task{Status: domain.StatusTodo})
+
+	problem := requireProblem(s.T(), w, http.StatusConflict, "conflict")
+	s.Contains(problem.Detail, "archived tasks cannot change status")
+	s.mockTaskService.AssertExpectations(s.T())
+}
 
-	s.mockTaskService.On("UpdateTask", 1, mock.AnythingOfType("*domain.Task")).Return(serviceError).Once()
+func (s *TaskControllerSuite) TestPutTasksById_UnknownStatus() {
+	_, statusError := domain.ParseTaskStatus("someday")
+	s.mockTaskService.On("UpdateTask", mock.Anything, 1, int64(1), mock.AnythingOfType("*domain.Task")).Return(statusError).Once()
 
-	w := s.performRequest("PUT", "/tasks/1", updatedTask)
+	w := s.performConditionalRequest("PUT", "/tasks/1", `"1"`, domain.Task{Status: "someday"})
 
-	s.Equal(http.StatusNotFound, w.Code)
-	s.Contains(w.Body.String(), `{"message":"Error updating task"}`)
+	problem := requireProblem(s.T(), w, http.StatusUnprocessableEntity, "validation")
+	s.Contains(problem.Detail, "invalid task status")
 	s.mockTaskService.AssertExpectations(s.T())
 }
 
 func (s *TaskControllerSuite) TestDeleteTaskById_Success() {
-	s.mockTaskService.On("DeleteTaskById", 1).Return(nil).Once()
+	s.mockTaskService.On("DeleteTaskById", mock.Anything, 1, int64(1), false).Return(nil).Once()
 
-	w := s.performRequest("DELETE", "/tasks/1", nil)
+	w := s.performConditionalRequest("DELETE", "/tasks/1", `"1"`, nil)
 
 	s.Equal(http.StatusNoContent, w.Code)
 	s.Empty(w.Body.String())
@@ -277,19 +532,303 @@ func (s *TaskControllerSuite) TestDeleteTaskById_Success() {
 func (s *TaskControllerSuite) TestDeleteTaskById_InvalidID() {
 	w := s.performRequest("DELETE", "/tasks/abc", nil)
 
-	s.Equal(http.StatusBadRequest, w.Code)
-	s.Contains(w.Body.String(), `{"message":"Invalid Task ID"}`)
-	s.mockTaskService.AssertNotCalled(s.T(), "DeleteTaskById", mock.Anything)
+	problem := requireProblem(s.T(), w, http.StatusBadRequest, "bad_request")
+	s.Equal("Invalid Task ID", problem.Detail)
+	s.mockTaskService.AssertNotCalled(s.T(), "DeleteTaskById", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func (s *TaskControllerSuite) TestDeleteTaskById_ServiceError() {
-	serviceError := errors.New("task not found for deletion")
+	serviceError := domain.NewNotFound("no task found with id 999")
+
+	s.mockTaskService.On("DeleteTaskById", mock.Anything, 999, int64(1), false).Return(serviceError).Once()
+
+	w := s.performConditionalRequest("DELETE", "/tasks/999", `"1"`, nil)
+
+	requireProblem(s.T(), w, http.StatusNotFound, "not_found")
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestDeleteTaskById_Forbidden() {
+	s.mockTaskService.On("DeleteTaskById", mock.Anything, 3, int64(1), false).Return(domain.ErrForbidden).Once()
+
+	w := s.performConditionalRequest("DELETE", "/tasks/3", `"1"`, nil)
+
+	requireProblem(s.T(), w, http.StatusForbidden, "forbidden")
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestDeleteTaskById_MissingIfMatch() {
+	w := s.performRequest("DELETE", "/tasks/1", nil)
+
+	requireProblem(s.T(), w, http.StatusPreconditionRequired, "precondition_required")
+	s.mockTaskService.AssertNotCalled(s.T(), "DeleteTaskById", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
 
-	s.mockTaskService.On("DeleteTaskById", 999).Return(serviceError).Once()
+func (s *TaskControllerSuite) TestDeleteTaskById_StaleVersion() {
+	s.mockTaskService.On("DeleteTaskById", mock.Anything, 1, int64(2), false).Return(domain.ErrTaskVersionMismatch).Once()
 
-	w := s.performRequest("DELETE", "/tasks/999", nil)
+	w := s.performConditionalRequest("DELETE", "/tasks/1", `"2"`, nil)
 
-	s.Equal(http.StatusNotFound, w.Code)
-	s.Contains(w.Body.String(), `{"message":"Error deleting task"}`)
+	requireProblem(s.T(), w, http.StatusPreconditionFailed, "precondition_failed")
 	s.mockTaskService.AssertExpectations(s.T())
 }
+
+func (s *TaskControllerSuite) TestGetTrash_Success() {
+	deletedAt := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	page := domain.TaskPage{Tasks: []domain.Task{{ID: 4, Title: "Gone", Version: 2, DeletedAt: &deletedAt}}, Total: 1, Limit: 10}
+	s.mockTaskService.On("QueryTrash", mock.Anything, domain.TaskQuery{Limit: 10}).Return(page, nil).Once()
+
+	w := s.performRequest("GET", "/tasks/trash?limit=10", nil)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.Contains(w.Body.String(), `"deleted_at":"2025-07-01T09:00:00Z"`)
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestGetTrash_Forbidden() {
+	s.mockTaskService.On("QueryTrash", mock.Anything, domain.TaskQuery{}).Return(domain.TaskPage{}, domain.ErrForbidden).Once()
+
+	w := s.performRequest("GET", "/tasks/trash", nil)
+
+	requireProblem(s.T(), w, http.StatusForbidden, "forbidden")
+}
+
+func (s *TaskControllerSuite) TestRestoreTask_Success() {
+	s.mockTaskService.On("RestoreTask", mock.Anything, 4).Return(domain.Task{ID: 4, Title: "Back", Version: 3}, nil).Once()
+
+	w := s.performRequest("POST", "/tasks/4/restore", nil)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal(`"3"`, w.Header().Get("ETag"))
+	var task domain.Task
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &task))
+	s.Equal(4, task.ID)
+	s.NotContains(w.Body.String(), "deleted_at", "Live tasks carry no deletion time")
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestRestoreTask_NotInTrash() {
+	s.mockTaskService.On("RestoreTask", mock.Anything, 4).Return(domain.Task{}, domain.NewNotFound("no deleted task found with id 4")).Once()
+
+	w := s.performRequest("POST", "/tasks/4/restore", nil)
+
+	requireProblem(s.T(), w, http.StatusNotFound, "not_found")
+}
+
+func (s *TaskControllerSuite) TestRestoreTask_InvalidID() {
+	w := s.performRequest("POST", "/tasks/abc/restore", nil)
+
+	requireProblem(s.T(), w, http.StatusBadRequest, "bad_request")
+	s.mockTaskService.AssertNotCalled(s.T(), "RestoreTask", mock.Anything, mock.Anything)
+}
+
+func (s *TaskControllerSuite) TestGetAllTasks_PriorityAndLabelFilters() {
+	expectedQuery := domain.TaskQuery{Priority: "high", Labels: []string{"backend", "ui", "billing"}}
+	s.mockTaskService.On("QueryTasks", mock.Anything, expectedQuery).Return(domain.TaskPage{Tasks: []domain.Task{}}, nil).Once()
+
+	w := s.performRequest("GET", "/tasks?priority=high&label=backend,ui&label=billing", nil)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestEditLabels_Success() {
+	updated := domain.Task{ID: 2, Labels: []string{"backend", "billing"}, Version: 6}
+	s.mockTaskService.On("EditLabels", mock.Anything, 2, []string{"billing"}, []string{"ui"}).Return(updated, nil).Once()
+
+	w := s.performRequest("PATCH", "/tasks/2/labels", gin.H{"add": []string{"billing"}, "remove": []string{"ui"}})
+
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal(`"6"`, w.Header().Get("ETag"))
+	var task domain.Task
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &task))
+	s.Equal(updated.Labels, task.Labels)
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestEditLabels_EmptyEdit() {
+	w := s.performRequest("PATCH", "/tasks/2/labels", gin.H{})
+
+	requireProblem(s.T(), w, http.StatusBadRequest, "bad_request")
+	s.mockTaskService.AssertNotCalled(s.T(), "EditLabels", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TaskControllerSuite) TestEditLabels_InvalidLabel() {
+	s.mockTaskService.On("EditLabels", mock.Anything, 2, []string{"a,b"}, []string(nil)).Return(domain.Task{}, domain.ErrInvalidLabel).Once()
+
+	w := s.performRequest("PATCH", "/tasks/2/labels", gin.H{"add": []string{"a,b"}})
+
+	requireProblem(s.T(), w, http.StatusUnprocessableEntity, "validation")
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestListLabels() {
+	counts := []domain.LabelCount{{Label: "backend", Count: 3}, {Label: "ui", Count: 1}}
+	s.mockTaskService.On("ListLabels", mock.Anything).Return(counts, nil).Once()
+
+	w := s.performRequest("GET", "/labels", nil)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.JSONEq(`{"labels":[{"label":"backend","count":3},{"label":"ui","count":1}]}`, w.Body.String())
+}
+
+func (s *TaskControllerSuite) TestListLabels_None() {
+	s.mockTaskService.On("ListLabels", mock.Anything).Return(nil, nil).Once()
+
+	w := s.performRequest("GET", "/labels", nil)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.JSONEq(`{"labels":[]}`, w.Body.String())
+}
+
+func (s *TaskControllerSuite) TestDeleteTaskById_Cascade() {
+	s.mockTaskService.On("DeleteTaskById", mock.Anything, 1, int64(1), true).Return(nil).Once()
+
+	w := s.performConditionalRequest("DELETE", "/tasks/1?cascade=true", `"1"`, nil)
+
+	s.Equal(http.StatusNoContent, w.Code)
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestDeleteTaskById_OpenSubtasks() {
+	err := fmt.Errorf("%w: task 1 has 2 open subtask(s)", domain.ErrTaskHasOpenSubtasks)
+	s.mockTaskService.On("DeleteTaskById", mock.Anything, 1, int64(1), false).Return(err).Once()
+
+	w := s.performConditionalRequest("DELETE", "/tasks/1", `"1"`, nil)
+
+	problem := requireProblem(s.T(), w, http.StatusConflict, "conflict")
+	s.Contains(problem.Detail, "open subtask")
+}
+
+func (s *TaskControllerSuite) TestDeleteTaskById_BadCascade() {
+	w := s.performConditionalRequest("DELETE", "/tasks/1?cascade=maybe", `"1"`, nil)
+
+	requireProblem(s.T(), w, http.StatusBadRequest, "bad_request")
+	s.mockTaskService.AssertNotCalled(s.T(), "DeleteTaskById", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TaskControllerSuite) TestGetSubtasks() {
+	page := domain.TaskPage{Tasks: []domain.Task{{ID: 2, ParentID: 1, Progress: &domain.TaskProgress{}}}, Total: 1, Limit: 50}
+	s.mockTaskService.On("ListSubtasks", mock.Anything, 1, domain.TaskQuery{Status: "done"}).Return(page, nil).Once()
+
+	w := s.performRequest("GET", "/tasks/1/subtasks?status=done", nil)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.Contains(w.Body.String(), `"parent_id":1`)
+	s.Contains(w.Body.String(), `"progress":{"checklist_done":0,"checklist_total":0,"subtasks_done":0,"subtasks_total":0,"percent":0}`)
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestAddChecklistItem() {
+	task := domain.Task{ID: 1, Version: 2, Checklist: []domain.ChecklistItem{{ID: 1, Text: "Draft"}}}
+	s.mockTaskService.On("AddChecklistItem", mock.Anything, 1, "Draft").Return(task, nil).Once()
+
+	w := s.performRequest("POST", "/tasks/1/checklist", gin.H{"text": "Draft"})
+
+	s.Equal(http.StatusCreated, w.Code)
+	s.Equal(`"2"`, w.Header().Get("ETag"))
+	s.Contains(w.Body.String(), `"checklist":[{"id":1,"text":"Draft","done":false}]`)
+}
+
+func (s *TaskControllerSuite) TestUpdateChecklistItem() {
+	s.mockTaskService.On("SetChecklistItemDone", mock.Anything, 1, 3, true).Return(domain.Task{ID: 1, Version: 5}, nil).Once()
+
+	w := s.performRequest("PATCH", "/tasks/1/checklist/3", gin.H{"done": true})
+
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal(`"5"`, w.Header().Get("ETag"))
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestUpdateChecklistItem_BadRequests() {
+	for name, req := range map[string]struct {
+		path string
+		body any
+	}{
+		"Missing done":    {path: "/tasks/1/checklist/3", body: gin.H{}},
+		"Invalid item ID": {path: "/tasks/1/checklist/abc", body: gin.H{"done": true}},
+		"Invalid task ID": {path: "/tasks/abc/checklist/3", body: gin.H{"done": true}},
+	} {
+		w := s.performRequest("PATCH", req.path, req.body)
+		s.Equal(http.StatusBadRequest, w.Code, name)
+	}
+	s.mockTaskService.AssertNotCalled(s.T(), "SetChecklistItemDone", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TaskControllerSuite) TestDeleteChecklistItem_NotFound() {
+	s.mockTaskService.On("RemoveChecklistItem", mock.Anything, 1, 9).Return(domain.Task{}, domain.NewNotFound("task 1 has no checklist item 9")).Once()
+
+	w := s.performRequest("DELETE", "/tasks/1/checklist/9", nil)
+
+	requireProblem(s.T(), w, http.StatusNotFound, "not_found")
+}
+
+func (s *TaskControllerSuite) TestReorderChecklist() {
+	s.mockTaskService.On("ReorderChecklist", mock.Anything, 1, []int{2, 1}).Return(domain.Task{ID: 1, Version: 4}, nil).Once()
+
+	w := s.performRequest("PUT", "/tasks/1/checklist/order", gin.H{"ids": []int{2, 1}})
+
+	s.Equal(http.StatusOK, w.Code)
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestAddDependencies() {
+	task := domain.Task{ID: 3, Version: 2, BlockedBy: []int{1, 2}}
+	s.mockTaskService.On("AddDependencies", mock.Anything, 3, []int{2, 1}).Return(task, nil).Once()
+
+	w := s.performRequest("POST", "/tasks/3/dependencies", gin.H{"blocked_by": []int{2, 1}})
+
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal(`"2"`, w.Header().Get("ETag"))
+	s.Contains(w.Body.String(), `"blocked_by":[1,2]`)
+}
+
+func (s *TaskControllerSuite) TestAddDependencies_Errors() {
+	s.mockTaskService.On("AddDependencies", mock.Anything, 1, []int{2}).Return(domain.Task{}, domain.ErrDependencyCycle).Once()
+	s.mockTaskService.On("AddDependencies", mock.Anything, 1, []int{404}).Return(domain.Task{}, domain.ErrInvalidDependency).Once()
+
+	requireProblem(s.T(), s.performRequest("POST", "/tasks/1/dependencies", gin.H{"blocked_by": []int{2}}), http.StatusConflict, "conflict")
+	requireProblem(s.T(), s.performRequest("POST", "/tasks/1/dependencies", gin.H{"blocked_by": []int{404}}), http.StatusUnprocessableEntity, "validation")
+	requireProblem(s.T(), s.performRequest("POST", "/tasks/1/dependencies", gin.H{}), http.StatusBadRequest, "bad_request")
+}
+
+func (s *TaskControllerSuite) TestRemoveDependencies() {
+	s.mockTaskService.On("RemoveDependencies", mock.Anything, 3, []int{1}).Return(domain.Task{ID: 3, Version: 5}, nil).Once()
+
+	w := s.performRequest("DELETE", "/tasks/3/dependencies", gin.H{"blocked_by": []int{1}})
+
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal(`"5"`, w.Header().Get("ETag"))
+	s.mockTaskService.AssertExpectations(s.T())
+}
+
+func (s *TaskControllerSuite) TestGetDependencyGraph() {
+	graph := domain.DependencyGraph{
+		Task:       domain.DependencyNode{ID: 3, Title: "Release"},
+		Upstream:   []domain.DependencyNode{{ID: 2, Hidden: true}},
+		Downstream: []domain.DependencyNode{},
+		Edges:      []domain.DependencyEdge{{Blocker: 2, Blocked: 3}},
+	}
+	s.mockTaskService.On("DependencyGraph", mock.Anything, 3).Return(graph, nil).Once()
+
+	w := s.performRequest("GET", "/tasks/3/graph", nil)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.JSONEq(`{"task":{"id":3,"title":"Release"},"upstream":[{"id":2,"hidden":true}],"downstream":[],"edges":[{"blocker":2,"blocked":3}]}`, w.Body.String())
+}
+
+func (s *TaskControllerSuite) TestGetOccurrences() {
+	due := time.Date(2025, 7, 7, 9, 0, 0, 0, time.UTC)
+	occurrences := []domain.Occurrence{{Occurrence: 2, DueDate: due}, {Occurrence: 3, DueDate: due.AddDate(0, 0, 7)}}
+	s.mockTaskService.On("PreviewOccurrences", mock.Anything, 4, 2).Return(occurrences, nil).Once()
+
+	w := s.performRequest("GET", "/tasks/4/occurrences?limit=2", nil)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.JSONEq(`{"occurrences":[{"occurrence":2,"duedate":"2025-07-07T09:00:00Z"},{"occurrence":3,"duedate":"2025-07-14T09:00:00Z"}]}`, w.Body.String())
+}
+
+func (s *TaskControllerSuite) TestGetOccurrences_BadLimit() {
+	requireProblem(s.T(), s.performRequest("GET", "/tasks/4/occurrences?limit=lots", nil), http.StatusBadRequest, "bad_request")
+	s.mockTaskService.AssertNotCalled(s.T(), "PreviewOccurrences", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -1,23 +1,278 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"task7/config"
 	"task7/data"
 	"task7/delivery/controllers"
 	"task7/delivery/router"
+	"task7/domain"
 	"task7/infrastructure"
+	"task7/repository/filesystem"
+	"task7/repository/instrumented"
+	"task7/repository/interfaces"
+	memoryRepo "task7/repository/memory"
 	mongoRepo "task7/repository/mongo"
+	sqliteRepo "task7/repository/sqlite"
 	services "task7/usecases"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// the storage the services run on, plus whatever has to be released on shutdown
+type repositories struct {
+	users       interfaces.UserRepository
+	tasks       interfaces.TaskRepository
+	tokens      interfaces.TokenRepository
+	audit       interfaces.AuditRepository
+	comments    interfaces.CommentRepository
+	attachments interfaces.AttachmentRepository
+	projects    interfaces.ProjectRepository
+	blobs       interfaces.BlobStore
+	health      interfaces.HealthChecker
+	close       func(ctx context.Context) error
+}
+
+// picks where attachment content is kept; the gridfs store needs the mongo database, which
+// config.Validate guarantees is there when it is chosen
+func openBlobStore(cfg config.AttachmentConfig, db *mongo.Database) (interfaces.BlobStore, error) {
+	switch cfg.Store {
+	case "gridfs":
+		if db == nil {
+			return nil, errors.New("gridfs attachment store needs mongo storage")
+		}
+		return mongoRepo.NewGridFSBlobStore(db, "blobs"), nil
+	case "memory":
+		return memoryRepo.NewMemoryBlobStore(), nil
+	case "filesystem":
+		return filesystem.NewFileSystemBlobStore(cfg.Dir)
+	default:
+		return nil, fmt.Errorf("unknown attachment store %q", cfg.Store)
+	}
+}
+
+// picks the repository implementation from the configured storage backend
+func openRepositories(ctx context.Context, cfg config.Config, logger *slog.Logger) (repositories, error) {
+	timeout := cfg.Storage.OperationTimeout
+	switch cfg.Storage.Backend {
+	case "mongo":
+		db, err := data.InitMongo(ctx, cfg.Mongo.URI, cfg.Mongo.Database)
+		if err != nil {
+			return repositories{}, err
+		}
+		disconnect := func(ctx context.Context) error { return db.Client().Disconnect(ctx) }
+
+		taskRepo := mongoRepo.NewMongoTaskRepository(db.Collection("tasks"))
+		taskRepo.OperationTimeout = timeout
+		taskRepo.Logger = logger
+		tokenRepo := mongoRepo.NewMongoTokenRepository(db.Collection("refresh_tokens"))
+		tokenRepo.OperationTimeout = timeout
+		userRepo := mongoRepo.NewMongoUserRepository(db.Collection("users"))
+		userRepo.OperationTimeout = timeout
+		userRepo.BcryptCost = cfg.Auth.BcryptCost
+		auditRepo := mongoRepo.NewMongoAuditRepository(db.Collection("audit_log"))
+		auditRepo.OperationTimeout = timeout
+		commentRepo := mongoRepo.NewMongoCommentRepository(db.Collection("comments"))
+		commentRepo.OperationTimeout = timeout
+		attachmentRepo := mongoRepo.NewMongoAttachmentRepository(db.Collection("attachments"))
+		attachmentRepo.OperationTimeout = timeout
+		projectRepo := mongoRepo.NewMongoProjectRepository(db.Collection("projects"))
+		projectRepo.OperationTimeout = timeout
+		blobs, err := openBlobStore(cfg.Attachments, db)
+		if err != nil {
+			disconnect(context.Background())
+			return repositories{}, err
+		}
+
+		for _, prepare := range []func(context.Context) error{taskRepo.EnsureIndexes, taskRepo.SeedIDCounter, taskRepo.NormalizeStatuses, taskRepo.BackfillVersions, taskRepo.BackfillPriorities, tokenRepo.EnsureIndexes, auditRepo.EnsureIndexes, commentRepo.EnsureIndexes, attachmentRepo.EnsureIndexes, projectRepo.EnsureIndexes, projectRepo.BackfillVersions} {
+			if err := prepare(ctx); err != nil {
+				disconnect(context.Background())
+				return repositories{}, err
+			}
+		}
+		health := mongoRepo.NewMongoHealthChecker(db.Client())
+		health.OperationTimeout = timeout
+		return repositories{users: userRepo, tasks: taskRepo, tokens: tokenRepo, audit: auditRepo, comments: commentRepo,
+			attachments: attachmentRepo, projects: projectRepo, blobs: blobs, health: health, close: disconnect}, nil
+	case "memory":
+		userRepo := memoryRepo.NewMemoryUserRepository()
+		userRepo.BcryptCost = cfg.Auth.BcryptCost
+		blobs, err := openBlobStore(cfg.Attachments, nil)
+		if err != nil {
+			return repositories{}, err
+		}
+		return repositories{
+			users:       userRepo,
+			tasks:       memoryRepo.NewMemoryTaskRepository(),
+			tokens:      memoryRepo.NewMemoryTokenRepository(),
+			audit:       memoryRepo.NewMemoryAuditRepository(),
+			comments:    memoryRepo.NewMemoryCommentRepository(),
+			attachments: memoryRepo.NewMemoryAttachmentRepository(),
+			projects:    memoryRepo.NewMemoryProjectRepository(),
+			blobs:       blobs,
+			health:      memoryRepo.NewMemoryHealthChecker(),
+			close:       func(context.Context) error { return nil },
+		}, nil
+	case "sqlite":
+		blobs, err := openBlobStore(cfg.Attachments, nil)
+		if err != nil {
+			return repositories{}, err
+		}
+		db, err := sqliteRepo.Open(cfg.SQLite.Path)
+		if err != nil {
+			return repositories{}, err
+		}
+		taskRepo := sqliteRepo.NewSQLiteTaskRepository(db)
+		taskRepo.OperationTimeout = timeout
+		tokenRepo := sqliteRepo.NewSQLiteTokenRepository(db)
+		tokenRepo.OperationTimeout = timeout
+		userRepo := sqliteRepo.NewSQLiteUserRepository(db)
+		userRepo.OperationTimeout = timeout
+		userRepo.BcryptCost = cfg.Auth.BcryptCost
+		auditRepo := sqliteRepo.NewSQLiteAuditRepository(db)
+		auditRepo.OperationTimeout = timeout
+		commentRepo := sqliteRepo.NewSQLiteCommentRepository(db)
+		commentRepo.OperationTimeout = timeout
+		attachmentRepo := sqliteRepo.NewSQLiteAttachmentRepository(db)
+		attachmentRepo.OperationTimeout = timeout
+		projectRepo := sqliteRepo.NewSQLiteProjectRepository(db)
+		projectRepo.OperationTimeout = timeout
+		health := sqliteRepo.NewSQLiteHealthChecker(db)
+		health.OperationTimeout = timeout
+		return repositories{
+			users:       userRepo,
+			tasks:       taskRepo,
+			tokens:      tokenRepo,
+			audit:       auditRepo,
+			comments:    commentRepo,
+			attachments: attachmentRepo,
+			projects:    projectRepo,
+			blobs:       blobs,
+			health:      health,
+			close:       func(context.Context) error { return db.Close() },
+		}, nil
+	default:
+		// config.Validate rejects anything else
+		return repositories{}, fmt.Errorf("unknown storage backend %q", cfg.Storage.Backend)
+	}
+}
+
+// serves on ln until ctx is cancelled, then stops accepting connections and waits up to
+// shutdownTimeout for in-flight requests to finish
+func serve(ctx context.Context, srv *http.Server, ln net.Listener, shutdownTimeout time.Duration, logger *slog.Logger) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down, draining in-flight requests", slog.Duration("timeout", shutdownTimeout))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutting down http server: %w", err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func run(ctx context.Context, cfg config.Config, logger *slog.Logger) error {
+	repos, err := openRepositories(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+		if err := repos.close(closeCtx); err != nil {
+			logger.Error("closing storage", slog.Any("error", err))
+		}
+	}()
+
+	metrics := infrastructure.NewMetrics()
+	auditRepo := instrumented.NewAuditRepository(repos.audit, metrics, logger)
+	userRepo := instrumented.NewUserRepository(repos.users, metrics, logger)
+	userService := services.NewUserService(userRepo, auditRepo, logger)
+	taskRepo := instrumented.NewTaskRepository(repos.tasks, metrics, logger)
+	projectRepo := instrumented.NewProjectRepository(repos.projects, metrics, logger)
+	taskService := services.NewTaskService(taskRepo, projectRepo, auditRepo, logger)
+	commentRepo := instrumented.NewCommentRepository(repos.comments, metrics, logger)
+	commentService := services.NewCommentService(commentRepo, taskRepo, projectRepo, userRepo, logger)
+	attachmentRepo := instrumented.NewAttachmentRepository(repos.attachments, metrics, logger)
+	blobs := instrumented.NewBlobStore(repos.blobs, metrics, logger)
+	attachmentPolicy := domain.AttachmentPolicy{MaxSize: cfg.Attachments.MaxSize, AllowedTypes: cfg.Attachments.AllowedTypes}
+	attachmentService := services.NewAttachmentService(attachmentRepo, blobs, taskRepo, projectRepo, attachmentPolicy, logger)
+	projectService := services.NewProjectService(projectRepo, taskRepo, userRepo, logger)
+	jwt_token := infrastructure.NewJwtToken([]byte(cfg.Auth.JWTSecret), cfg.Auth.AccessTokenTTL)
+	tokenService := services.NewTokenService(repos.tokens, repos.users, jwt_token, cfg.Auth.RefreshTokenTTL, logger)
+	authController := controllers.NewAuthController(userService, tokenService, metrics, logger)
+	taskController := controllers.NewTaskController(taskService, logger)
+	healthController := controllers.NewHealthController(services.NewHealthService(services.DefaultHealthCheckTimeout, repos.health))
+	auditController := controllers.NewAuditController(services.NewAuditService(auditRepo), logger)
+	commentController := controllers.NewCommentController(commentService, logger)
+	attachmentController := controllers.NewAttachmentController(attachmentService, logger)
+	projectController := controllers.NewProjectController(projectService, logger)
+	r := router.SetupRouter(authController, taskController, healthController, auditController, commentController, attachmentController, projectController, []byte(cfg.Auth.JWTSecret), tokenService, metrics, logger)
+
+	// deferred after the storage close above, so the purge job has stopped before storage goes away
+	jobsCtx, stopJobs := context.WithCancel(ctx)
+	purgeDone := make(chan struct{})
+	go func() {
+		defer close(purgeDone)
+		services.NewTrashPurger(taskRepo, projectRepo, commentRepo, attachmentRepo, blobs, cfg.Trash.Retention, logger).Run(jobsCtx, cfg.Trash.PurgeInterval)
+	}()
+	defer func() {
+		stopJobs()
+		<-purgeDone
+	}()
+
+	srv := &http.Server{
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	ln, err := net.Listen("tcp", cfg.Server.Addr)
+	if err != nil {
+		return err
+	}
+	logger.Info("listening", slog.String("addr", ln.Addr().String()))
+	return serve(ctx, srv, ln, cfg.Server.ShutdownTimeout, logger)
+}
+
 func main() {
-	userCol, taskCol := data.InitMongo()
-	userRepo := mongoRepo.NewMongoUserRepository(userCol)
-	taskRepo := mongoRepo.NewMongoTaskRepository(taskCol)
-	userService := services.NewUserService(userRepo)
-	taskService := services.NewTaskService(taskRepo)
-	jwt_token := infrastructure.NewJwtToken()
-	authController := controllers.NewAuthController(userService, jwt_token)
-	taskController := controllers.NewTaskController(taskService)
-	r := router.SetupRouter(authController, taskController)
-	r.Run(":8080")
+	godotenv.Load()
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if err != nil {
+		slog.Error("loading config", slog.Any("error", err))
+		os.Exit(1)
+	}
+	logger := infrastructure.NewLogger(os.Stdout, cfg.Log.SlogLevel())
+	slog.SetDefault(logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, cfg, logger); err != nil {
+		stop()
+		logger.Error("server stopped", slog.Any("error", err))
+		os.Exit(1)
+	}
 }
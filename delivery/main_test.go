@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"task7/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe_DrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "finished")
+	})}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, srv, ln, 5*time.Second, slog.New(slog.DiscardHandler)) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	response := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		response <- result{body: string(body), err: err}
+	}()
+
+	<-started
+	cancel() // the signal arrives while the request is still being handled
+
+	select {
+	case err := <-served:
+		t.Fatalf("serve returned before the in-flight request finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	got := <-response
+	require.NoError(t, got.err)
+	assert.Equal(t, "finished", got.body)
+	assert.NoError(t, <-served)
+
+	_, err = net.DialTimeout("tcp", ln.Addr().String(), time.Second)
+	assert.Error(t, err, "the listener should be closed after shutdown")
+}
+
+func TestServe_GivesUpAfterShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, srv, ln, 20*time.Millisecond, slog.New(slog.DiscardHandler)) }()
+	go http.Get("http://" + ln.Addr().String())
+
+	<-started
+	cancel()
+	err = <-served
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestOpenRepositories(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.JWTSecret = "test"
+	cfg.Attachments.Dir = t.TempDir()
+
+	for _, backend := range []string{"memory", "sqlite"} {
+		t.Run(backend, func(t *testing.T) {
+			cfg.Storage.Backend = backend
+			cfg.SQLite.Path = ":memory:"
+
+			repos, err := openRepositories(context.Background(), cfg, slog.New(slog.DiscardHandler))
+			require.NoError(t, err)
+			assert.NotNil(t, repos.users)
+			assert.NotNil(t, repos.tasks)
+			assert.NotNil(t, repos.tokens)
+			assert.NotNil(t, repos.audit)
+			assert.NotNil(t, repos.comments)
+			assert.NotNil(t, repos.attachments)
+			assert.NotNil(t, repos.projects)
+			assert.NotNil(t, repos.blobs)
+			assert.NoError(t, repos.health.Ping(context.Background()))
+			assert.NoError(t, repos.close(context.Background()))
+		})
+	}
+
+	cfg.Storage.Backend = "redis"
+	_, err := openRepositories(context.Background(), cfg, slog.New(slog.DiscardHandler))
+	assert.EqualError(t, err, `unknown storage backend "redis"`)
+
+	cfg.Storage.Backend = "memory"
+	cfg.Attachments.Store = "gridfs"
+	_, err = openRepositories(context.Background(), cfg, slog.New(slog.DiscardHandler))
+	assert.EqualError(t, err, "gridfs attachment store needs mongo storage")
+}
@@ -1,29 +1,89 @@
 package router
 
 import (
+	"log/slog"
 	"task7/delivery/controllers"
+	"task7/domain"
 	"task7/infrastructure"
+
 	"github.com/gin-gonic/gin"
 )
 
 func SetupRouter(
 	authController *controllers.AuthController,
 	taskController *controllers.TaskController,
+	healthController *controllers.HealthController,
+	auditController *controllers.AuditController,
+	commentController *controllers.CommentController,
+	attachmentController *controllers.AttachmentController,
+	projectController *controllers.ProjectController,
+	jwtSecret []byte,
+	revocations infrastructure.RevocationChecker,
+	metrics *infrastructure.Metrics,
+	logger *slog.Logger,
 ) *gin.Engine {
-	router := gin.Default()
+	router := gin.New()
+	// metrics sit outside Recovery so requests that end in a panic are still counted as 500s
+	router.Use(infrastructure.RequestIDMiddleware(), infrastructure.RequestLogger(logger), metrics.GinMiddleware(), gin.Recovery())
+	auth := infrastructure.AuthMiddleware(jwtSecret, revocations, metrics, logger)
+
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// probes for the orchestrator; public so they work without credentials
+	router.GET("/healthz", healthController.Liveness)
+	router.GET("/readyz", healthController.Readiness)
+
 	router.POST("/register", authController.RegisterUser)
 	router.POST("/login", authController.LoginUser)
+	router.POST("/token/refresh", authController.RefreshToken)
+	router.POST("/logout", auth, authController.Logout)
 
-	router.PUT("/promote", infrastructure.AuthMiddleware(), infrastructure.AdminAuth(), authController.PromoteUser)
+	router.PUT("/promote", auth, infrastructure.AdminAuth(logger), authController.PromoteUser)
+	router.GET("/audit", auth, infrastructure.AdminAuth(logger), auditController.ListAudit)
+	router.GET("/labels", auth, taskController.ListLabels)
+
+	// each project route needs at least the role named, checked once AuthMiddleware knows the caller
+	p := router.Group("/projects")
+	p.Use(auth)
+	{
+		p.GET("", projectController.ListProjects)
+		p.POST("", projectController.CreateProject)
+		p.GET("/:project", projectController.RequireRole(domain.ProjectRoleViewer), projectController.GetProject)
+		p.PATCH("/:project", projectController.RequireRole(domain.ProjectRoleOwner), projectController.UpdateProject)
+		p.DELETE("/:project", projectController.RequireRole(domain.ProjectRoleOwner), projectController.DeleteProject)
+		p.PUT("/:project/members/:username", projectController.RequireRole(domain.ProjectRoleOwner), projectController.SetMember)
+		p.DELETE("/:project/members/:username", projectController.RequireRole(domain.ProjectRoleOwner), projectController.RemoveMember)
+	}
 
 	r := router.Group("/tasks")
-	r.Use(infrastructure.AuthMiddleware())
+	r.Use(auth)
 	{
-		r.GET("", taskController.GetAllTasks)
+		// ?project= scopes the listing to a project's board and puts new tasks on it
+		r.GET("", projectController.RequireRole(domain.ProjectRoleViewer), taskController.GetAllTasks)
+		r.GET("/trash", infrastructure.AdminAuth(logger), taskController.GetTrash)
 		r.GET("/:id", taskController.GetTasksById)
-		r.POST("", infrastructure.AdminAuth(), taskController.PostTasks)
-		r.PUT("/:id", infrastructure.AdminAuth(), taskController.PutTasksById)
-		r.DELETE("/:id", infrastructure.AdminAuth(), taskController.DeleteTaskById)
+		r.POST("", projectController.RequireRole(domain.ProjectRoleMember), taskController.PostTasks)
+		r.PUT("/:id", taskController.PutTasksById)
+		r.DELETE("/:id", taskController.DeleteTaskById)
+		r.POST("/:id/restore", infrastructure.AdminAuth(logger), taskController.RestoreTask)
+		r.PATCH("/:id/labels", taskController.EditLabels)
+		r.GET("/:id/subtasks", taskController.GetSubtasks)
+		r.POST("/:id/checklist", taskController.AddChecklistItem)
+		r.PUT("/:id/checklist/order", taskController.ReorderChecklist)
+		r.PATCH("/:id/checklist/:item", taskController.UpdateChecklistItem)
+		r.DELETE("/:id/checklist/:item", taskController.DeleteChecklistItem)
+		r.POST("/:id/dependencies", taskController.AddDependencies)
+		r.DELETE("/:id/dependencies", taskController.RemoveDependencies)
+		r.GET("/:id/graph", taskController.GetDependencyGraph)
+		r.GET("/:id/occurrences", taskController.GetOccurrences)
+		r.GET("/:id/comments", commentController.ListComments)
+		r.POST("/:id/comments", commentController.AddComment)
+		r.PATCH("/:id/comments/:comment", commentController.EditComment)
+		r.DELETE("/:id/comments/:comment", commentController.DeleteComment)
+		r.GET("/:id/attachments", attachmentController.ListAttachments)
+		r.POST("/:id/attachments", attachmentController.UploadAttachment)
+		r.GET("/:id/attachments/:attachment", attachmentController.DownloadAttachment)
+		r.DELETE("/:id/attachments/:attachment", attachmentController.DeleteAttachment)
 	}
 	return router
 }
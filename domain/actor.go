@@ -0,0 +1,30 @@
+package domain
+
+import "context"
+
+var (
+	ErrUnauthenticated = NewUnauthorized("authentication required")
+	ErrForbidden       = NewForbidden("forbidden")
+)
+
+// the authenticated user a request is made on behalf of
+type Actor struct {
+	Username string
+	Role     string
+}
+
+func (a Actor) IsAdmin() bool {
+	return a.Role == "admin"
+}
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// returns the actor stored by WithActor; ok is false for unauthenticated contexts
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
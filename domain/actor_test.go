@@ -0,0 +1,40 @@
+package domain_test
+
+import (
+	"context"
+	"task7/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActorFromContext(t *testing.T) {
+	_, ok := domain.ActorFromContext(context.Background())
+	assert.False(t, ok, "a bare context carries no actor")
+
+	want := domain.Actor{Username: "alice", Role: "regular"}
+	got, ok := domain.ActorFromContext(domain.WithActor(context.Background(), want))
+	assert.True(t, ok)
+	assert.Equal(t, want, got)
+}
+
+func TestTaskVisibleTo(t *testing.T) {
+	task := domain.Task{CreatedBy: "alice", AssignedTo: "bob"}
+	tests := []struct {
+		name    string
+		actor   domain.Actor
+		visible bool
+	}{
+		{name: "Creator", actor: domain.Actor{Username: "alice", Role: "regular"}, visible: true},
+		{name: "Assignee", actor: domain.Actor{Username: "bob", Role: "regular"}, visible: true},
+		{name: "Admin", actor: domain.Actor{Username: "root", Role: "admin"}, visible: true},
+		{name: "Someone else", actor: domain.Actor{Username: "carol", Role: "regular"}, visible: false},
+		{name: "Anonymous on unowned task", actor: domain.Actor{}, visible: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.visible, task.VisibleTo(tt.actor))
+		})
+	}
+	assert.False(t, domain.Task{}.VisibleTo(domain.Actor{Role: "regular"}), "an empty username never matches an unowned task")
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"mime"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidAttachment  = NewValidation("invalid attachment")
+	ErrAttachmentTooLarge = NewTooLarge("attachment is too large")
+	ErrAttachmentType     = NewUnsupportedType("attachment type is not allowed")
+)
+
+const (
+	MaxFilenameLength = 255 // bytes
+	defaultFilename   = "attachment"
+)
+
+// a file attached to a task. Only the metadata lives in the attachment repository; the content is
+// kept in a blob store under the attachment's ID.
+type Attachment struct {
+	ID          string    `bson:"id" json:"id"` // assigned by the service
+	TaskID      int       `bson:"taskid" json:"task_id"`
+	Filename    string    `bson:"filename" json:"filename"`
+	ContentType string    `bson:"contenttype" json:"content_type"` // detected from the content, not taken from the client
+	Size        int64     `bson:"size" json:"size"`                // bytes
+	SHA256      string    `bson:"sha256" json:"sha256"`            // hex digest of the content
+	UploadedBy  string    `bson:"uploadedby" json:"uploaded_by"`
+	UploadedAt  time.Time `bson:"uploadedat" json:"uploaded_at"`
+}
+
+// what uploads are accepted
+type AttachmentPolicy struct {
+	MaxSize      int64    // bytes
+	AllowedTypes []string // media types such as image/png, or a whole family such as image/*
+}
+
+// whether content of contentType may be attached; parameters such as charset are ignored
+func (p AttachmentPolicy) Allows(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	family, _, _ := strings.Cut(mediaType, "/")
+	for _, allowed := range p.AllowedTypes {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if allowed == mediaType || allowed == family+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// the last element of a client-supplied file name, without control characters and cut to
+// MaxFilenameLength; "attachment" if nothing usable is left
+func CleanFilename(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == unicode.ReplacementChar {
+			return -1
+		}
+		return r
+	}, name))
+	for len(name) > MaxFilenameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == ".." {
+		return defaultFilename
+	}
+	return name
+}
@@ -0,0 +1,41 @@
+package domain_test
+
+import (
+	"strings"
+	"task7/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachmentPolicyAllows(t *testing.T) {
+	policy := domain.AttachmentPolicy{AllowedTypes: []string{"image/*", "application/pdf", " Text/Plain "}}
+
+	assert.True(t, policy.Allows("image/png"))
+	assert.True(t, policy.Allows("application/pdf"))
+	assert.True(t, policy.Allows("text/plain; charset=utf-8"), "parameters are ignored")
+	assert.False(t, policy.Allows("text/html; charset=utf-8"))
+	assert.False(t, policy.Allows("application/octet-stream"))
+	assert.False(t, policy.Allows("not a type"))
+}
+
+func TestCleanFilename(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{name: "Plain", in: "screenshot.png", want: "screenshot.png"},
+		{name: "Unix path", in: "../../etc/passwd", want: "passwd"},
+		{name: "Windows path", in: `C:\Users\bob\bug report.png`, want: "bug report.png"},
+		{name: "Control characters", in: "evil\r\nname.txt", want: "evilname.txt"},
+		{name: "Empty", in: "  ", want: "attachment"},
+		{name: "Dot dot", in: "a/..", want: "attachment"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, domain.CleanFilename(tt.in))
+		})
+	}
+
+	long := domain.CleanFilename(strings.Repeat("é", domain.MaxFilenameLength))
+	assert.Len(t, long, domain.MaxFilenameLength-1, "a name is cut at a character boundary")
+}
@@ -0,0 +1,204 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidAuditQuery = NewBadRequest("invalid audit query")
+
+type AuditAction string
+
+const (
+	AuditTaskCreate   AuditAction = "task.create"
+	AuditTaskUpdate   AuditAction = "task.update"
+	AuditTaskDelete   AuditAction = "task.delete"
+	AuditTaskRestore  AuditAction = "task.restore"
+	AuditUserRegister AuditAction = "user.register"
+	AuditUserLogin    AuditAction = "user.login"
+	AuditLoginFailed  AuditAction = "user.login_failed"
+	AuditUserPromote  AuditAction = "user.promote"
+)
+
+var auditActions = map[AuditAction]bool{
+	AuditTaskCreate: true, AuditTaskUpdate: true, AuditTaskDelete: true, AuditTaskRestore: true,
+	AuditUserRegister: true, AuditUserLogin: true, AuditLoginFailed: true, AuditUserPromote: true,
+}
+
+const (
+	AuditTargetTask = "task"
+	AuditTargetUser = "user"
+)
+
+// one field of a task as it was before and after an update, both rendered as text
+type FieldChange struct {
+	Field  string `bson:"field" json:"field"`
+	Before string `bson:"before" json:"before"`
+	After  string `bson:"after" json:"after"`
+}
+
+// an append-only record of who did what to which task or user, and when
+type AuditEntry struct {
+	ID         string        `bson:"-" json:"id"` // assigned by the repository
+	Action     AuditAction   `bson:"action" json:"action"`
+	Actor      string        `bson:"actor" json:"actor"`
+	ActorRole  string        `bson:"actorrole" json:"actor_role,omitempty"`
+	TargetType string        `bson:"targettype" json:"target_type"`
+	TargetID   string        `bson:"targetid" json:"target_id"`
+	Timestamp  time.Time     `bson:"timestamp" json:"timestamp"`
+	Changes    []FieldChange `bson:"changes,omitempty" json:"changes,omitempty"`
+}
+
+// an entry about task id, attributed to actor
+func NewTaskAuditEntry(action AuditAction, actor Actor, id int) AuditEntry {
+	return AuditEntry{
+		Action:     action,
+		Actor:      actor.Username,
+		ActorRole:  actor.Role,
+		TargetType: AuditTargetTask,
+		TargetID:   strconv.Itoa(id),
+		Timestamp:  time.Now().UTC(),
+	}
+}
+
+// an entry about the user called username, attributed to actor
+func NewUserAuditEntry(action AuditAction, actor Actor, username string) AuditEntry {
+	return AuditEntry{
+		Action:     action,
+		Actor:      actor.Username,
+		ActorRole:  actor.Role,
+		TargetType: AuditTargetUser,
+		TargetID:   username,
+		Timestamp:  time.Now().UTC(),
+	}
+}
+
+// the fields that differ between two versions of a task, in a fixed order
+func DiffTasks(before, after Task) []FieldChange {
+	var changes []FieldChange
+	add := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, FieldChange{Field: field, Before: from, After: to})
+		}
+	}
+	add("title", before.Title, after.Title)
+	add("description", before.Description, after.Description)
+	add("duedate", formatAuditTime(before.DueDate), formatAuditTime(after.DueDate))
+	add("status", string(before.Status), string(after.Status))
+	add("assignedto", before.AssignedTo, after.AssignedTo)
+	add("priority", string(before.Priority), string(after.Priority))
+	add("labels", strings.Join(before.Labels, labelListSeparator), strings.Join(after.Labels, labelListSeparator))
+	add("checklist", formatAuditChecklist(before.Checklist), formatAuditChecklist(after.Checklist))
+	add("blockedby", formatAuditIDs(before.BlockedBy), formatAuditIDs(after.BlockedBy))
+	add("recurrence", before.Recurrence.String(), after.Recurrence.String())
+	return changes
+}
+
+func formatAuditIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+// items in order, each as "[x] text" or "[ ] text", separated by newlines
+func formatAuditChecklist(items []ChecklistItem) string {
+	lines := make([]string, len(items))
+	for i, item := range items {
+		box := "[ ] "
+		if item.Done {
+			box = "[x] "
+		}
+		lines[i] = box + item.Text
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatAuditTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+const (
+	DefaultAuditPageSize = 50
+	MaxAuditPageSize     = 200
+)
+
+// filters and window for reading the audit log, which is always listed newest first
+type AuditQuery struct {
+	Actor      string
+	Action     AuditAction
+	TargetType string
+	TargetID   string
+	Since      time.Time // inclusive
+	Until      time.Time // inclusive
+	Limit      int
+	Offset     int
+}
+
+// one window of the audit log; NextOffset is nil on the last page
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	Total      int64        `json:"total"`
+	Limit      int          `json:"limit"`
+	Offset     int          `json:"offset"`
+	NextOffset *int         `json:"next_offset,omitempty"`
+}
+
+// fills in defaults and rejects values no backend can serve
+func (q *AuditQuery) Normalize() error {
+	if q.Action != "" && !auditActions[q.Action] {
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidAuditQuery, q.Action)
+	}
+	if q.TargetType != "" && q.TargetType != AuditTargetTask && q.TargetType != AuditTargetUser {
+		return fmt.Errorf("%w: target_type must be task or user", ErrInvalidAuditQuery)
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultAuditPageSize
+	}
+	if q.Limit < 0 || q.Limit > MaxAuditPageSize {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidAuditQuery, MaxAuditPageSize)
+	}
+	if q.Offset < 0 {
+		return fmt.Errorf("%w: offset cannot be negative", ErrInvalidAuditQuery)
+	}
+	if !q.Since.IsZero() && !q.Until.IsZero() && q.Since.After(q.Until) {
+		return fmt.Errorf("%w: since must not be later than until", ErrInvalidAuditQuery)
+	}
+	return nil
+}
+
+// whether entry passes the query's filters; for backends that filter in Go
+func (q AuditQuery) Matches(entry AuditEntry) bool {
+	switch {
+	case q.Actor != "" && entry.Actor != q.Actor:
+		return false
+	case q.Action != "" && entry.Action != q.Action:
+		return false
+	case q.TargetType != "" && entry.TargetType != q.TargetType:
+		return false
+	case q.TargetID != "" && entry.TargetID != q.TargetID:
+		return false
+	case !q.Since.IsZero() && entry.Timestamp.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && entry.Timestamp.After(q.Until):
+		return false
+	}
+	return true
+}
+
+func NewAuditPage(q AuditQuery, entries []AuditEntry, total int64) AuditPage {
+	if entries == nil {
+		entries = []AuditEntry{}
+	}
+	page := AuditPage{Entries: entries, Total: total, Limit: q.Limit, Offset: q.Offset}
+	if next := q.Offset + len(entries); int64(next) < total {
+		page.NextOffset = &next
+	}
+	return page
+}
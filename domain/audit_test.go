@@ -0,0 +1,99 @@
+package domain_test
+
+import (
+	"task7/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffTasks(t *testing.T) {
+	due := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	before := domain.Task{ID: 1, Title: "Report", Description: "Q3", DueDate: due, Status: domain.StatusTodo}
+	after := before
+	after.DueDate = due.Add(48 * time.Hour)
+	after.Status = domain.StatusInProgress
+
+	assert.Equal(t, []domain.FieldChange{
+		{Field: "duedate", Before: "2025-07-01T12:00:00Z", After: "2025-07-03T12:00:00Z"},
+		{Field: "status", Before: "todo", After: "in_progress"},
+	}, domain.DiffTasks(before, after))
+	assert.Empty(t, domain.DiffTasks(before, before))
+}
+
+func TestAuditQueryNormalize(t *testing.T) {
+	q := domain.AuditQuery{}
+	require.NoError(t, q.Normalize())
+	assert.Equal(t, domain.DefaultAuditPageSize, q.Limit)
+
+	now := time.Now()
+	tests := []struct {
+		name  string
+		query domain.AuditQuery
+	}{
+		{name: "Unknown action", query: domain.AuditQuery{Action: "task.explode"}},
+		{name: "Unknown target type", query: domain.AuditQuery{TargetType: "project"}},
+		{name: "Limit too large", query: domain.AuditQuery{Limit: domain.MaxAuditPageSize + 1}},
+		{name: "Negative offset", query: domain.AuditQuery{Offset: -1}},
+		{name: "Inverted time range", query: domain.AuditQuery{Since: now, Until: now.Add(-time.Hour)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.query.Normalize(), domain.ErrInvalidAuditQuery)
+		})
+	}
+}
+
+func TestAuditQueryMatches(t *testing.T) {
+	entry := domain.NewTaskAuditEntry(domain.AuditTaskUpdate, domain.Actor{Username: "alice"}, 7)
+
+	assert.True(t, domain.AuditQuery{}.Matches(entry))
+	assert.True(t, domain.AuditQuery{Actor: "alice", TargetType: "task", TargetID: "7"}.Matches(entry))
+	assert.False(t, domain.AuditQuery{Action: domain.AuditTaskDelete}.Matches(entry))
+	assert.False(t, domain.AuditQuery{Since: entry.Timestamp.Add(time.Second)}.Matches(entry))
+}
+
+func TestDiffTasks_WithUpdate(t *testing.T) {
+	before := domain.Task{ID: 1, Title: "Report", Description: "Q3", Status: domain.StatusTodo, CreatedBy: "alice"}
+	after := before.WithUpdate(domain.Task{Title: "Quarterly report", AssignedTo: "bob"})
+
+	assert.Equal(t, "Q3", after.Description, "empty fields of the update are left alone")
+	assert.Equal(t, []domain.FieldChange{
+		{Field: "title", Before: "Report", After: "Quarterly report"},
+		{Field: "assignedto", Before: "", After: "bob"},
+	}, domain.DiffTasks(before, after))
+}
+
+func TestDiffTasks_PriorityAndLabels(t *testing.T) {
+	before := domain.Task{ID: 1, Priority: domain.PriorityMedium, Labels: []string{"backend"}}
+	after := before.WithUpdate(domain.Task{Priority: domain.PriorityUrgent, Labels: []string{"backend", "billing"}})
+
+	assert.Equal(t, []domain.FieldChange{
+		{Field: "priority", Before: "medium", After: "urgent"},
+		{Field: "labels", Before: "backend", After: "backend,billing"},
+	}, domain.DiffTasks(before, after))
+	assert.Nil(t, before.WithUpdate(domain.Task{Labels: []string{}}).Labels, "an empty label set clears the labels")
+	assert.Equal(t, before.Labels, before.WithUpdate(domain.Task{Title: "x"}).Labels, "nil labels leave them alone")
+}
+
+func TestDiffTasks_Checklist(t *testing.T) {
+	before := domain.Task{ID: 1, Checklist: []domain.ChecklistItem{{ID: 1, Text: "Draft"}}}
+	after := before.WithUpdate(domain.Task{Checklist: []domain.ChecklistItem{{ID: 1, Text: "Draft", Done: true}, {ID: 2, Text: "Review"}}})
+
+	assert.Equal(t, []domain.FieldChange{
+		{Field: "checklist", Before: "[ ] Draft", After: "[x] Draft\n[ ] Review"},
+	}, domain.DiffTasks(before, after))
+	assert.Nil(t, before.WithUpdate(domain.Task{Checklist: []domain.ChecklistItem{}}).Checklist, "an empty checklist clears it")
+}
+
+func TestDiffTasks_BlockedBy(t *testing.T) {
+	before := domain.Task{ID: 3, BlockedBy: []int{1}}
+	after := before.WithUpdate(domain.Task{BlockedBy: []int{1, 2}})
+
+	assert.Equal(t, []domain.FieldChange{
+		{Field: "blockedby", Before: "1", After: "1,2"},
+	}, domain.DiffTasks(before, after))
+	assert.Nil(t, before.WithUpdate(domain.Task{BlockedBy: []int{}}).BlockedBy, "an empty list clears the blockers")
+}
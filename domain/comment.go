@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrInvalidComment = NewValidation("invalid comment")
+
+var ErrInvalidCommentQuery = NewBadRequest("invalid comment query")
+
+const (
+	MaxCommentLength       = 10000 // characters of markdown
+	DefaultCommentPageSize = 50
+	MaxCommentPageSize     = 200
+)
+
+// a remark on a task. Body is markdown, stored exactly as written for clients to render.
+type Comment struct {
+	ID        string     `bson:"-" json:"id"` // assigned by the repository
+	TaskID    int        `bson:"taskid" json:"task_id"`
+	Author    string     `bson:"author" json:"author"`
+	Body      string     `bson:"body" json:"body"`
+	Mentions  []string   `bson:"mentions,omitempty" json:"mentions,omitempty"` // registered users @mentioned in Body, sorted
+	CreatedAt time.Time  `bson:"createdat" json:"created_at"`
+	EditedAt  *time.Time `bson:"editedat,omitempty" json:"edited_at,omitempty"` // set once the body has been changed
+}
+
+// a comment may be changed or removed by whoever wrote it, or by an admin
+func (c Comment) EditableBy(actor Actor) bool {
+	return actor.IsAdmin() || (actor.Username != "" && c.Author == actor.Username)
+}
+
+func (c Comment) Clone() Comment {
+	c.Mentions = slices.Clone(c.Mentions)
+	if c.EditedAt != nil {
+		edited := *c.EditedAt
+		c.EditedAt = &edited
+	}
+	return c
+}
+
+// rejects a body that is blank or too long
+func ValidateCommentBody(body string) error {
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("%w: body cannot be empty", ErrInvalidComment)
+	}
+	if utf8.RuneCountInString(body) > MaxCommentLength {
+		return fmt.Errorf("%w: body cannot be longer than %d characters", ErrInvalidComment, MaxCommentLength)
+	}
+	return nil
+}
+
+var (
+	// an @ that does not follow a word character, so e-mail addresses are not mentions
+	mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@(\w[\w.-]*)`)
+	codeSpan       = regexp.MustCompile("`+[^`\n]*`+")
+)
+
+// the usernames @mentioned in a markdown body, sorted and without duplicates. Mentions inside code
+// blocks and code spans are not mentions; trailing sentence punctuation is not part of the name.
+func ParseMentions(body string) []string {
+	var mentions []string
+	fenced := false
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		for _, match := range mentionPattern.FindAllStringSubmatch(codeSpan.ReplaceAllString(line, " "), -1) {
+			if name := strings.TrimRight(match[1], ".-"); name != "" {
+				mentions = append(mentions, name)
+			}
+		}
+	}
+	slices.Sort(mentions)
+	return slices.Compact(mentions)
+}
+
+// the window of a task's comments to read; comments are always listed oldest first
+type CommentQuery struct {
+	TaskID int
+	Limit  int
+	Offset int
+}
+
+// one window of a task's comments; NextOffset is nil on the last page
+type CommentPage struct {
+	Comments   []Comment `json:"comments"`
+	Total      int64     `json:"total"`
+	Limit      int       `json:"limit"`
+	Offset     int       `json:"offset"`
+	NextOffset *int      `json:"next_offset,omitempty"`
+}
+
+// fills in the default page size and rejects values no backend can serve
+func (q *CommentQuery) Normalize() error {
+	if q.Limit == 0 {
+		q.Limit = DefaultCommentPageSize
+	}
+	if q.Limit < 0 || q.Limit > MaxCommentPageSize {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidCommentQuery, MaxCommentPageSize)
+	}
+	if q.Offset < 0 {
+		return fmt.Errorf("%w: offset cannot be negative", ErrInvalidCommentQuery)
+	}
+	return nil
+}
+
+func NewCommentPage(q CommentQuery, comments []Comment, total int64) CommentPage {
+	if comments == nil {
+		comments = []Comment{}
+	}
+	page := CommentPage{Comments: comments, Total: total, Limit: q.Limit, Offset: q.Offset}
+	if next := q.Offset + len(comments); int64(next) < total {
+		page.NextOffset = &next
+	}
+	return page
+}
@@ -0,0 +1,59 @@
+package domain_test
+
+import (
+	"strings"
+	"task7/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{name: "None", body: "Looks good to me", want: nil},
+		{name: "Sorted and deduplicated", body: "@carol can you pair with @bob? cc @bob", want: []string{"bob", "carol"}},
+		{name: "Start of line and punctuation", body: "@alice.\n(@dave_2) thanks @e.ve!", want: []string{"alice", "dave_2", "e.ve"}},
+		{name: "E-mail addresses", body: "mail bob@example.com", want: nil},
+		{name: "Code span", body: "run `@decorator` then ping @alice", want: []string{"alice"}},
+		{name: "Fenced block", body: "```go\n// @bob\n```\n@carol", want: []string{"carol"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, domain.ParseMentions(tt.body))
+		})
+	}
+}
+
+func TestValidateCommentBody(t *testing.T) {
+	assert.NoError(t, domain.ValidateCommentBody("**done**, see #4"))
+	assert.NoError(t, domain.ValidateCommentBody(strings.Repeat("é", domain.MaxCommentLength)), "the limit counts characters, not bytes")
+	assert.ErrorIs(t, domain.ValidateCommentBody(" \n\t"), domain.ErrInvalidComment)
+	assert.ErrorIs(t, domain.ValidateCommentBody(strings.Repeat("a", domain.MaxCommentLength+1)), domain.ErrInvalidComment)
+}
+
+func TestCommentEditableBy(t *testing.T) {
+	comment := domain.Comment{Author: "alice"}
+	assert.True(t, comment.EditableBy(domain.Actor{Username: "alice", Role: "regular"}))
+	assert.True(t, comment.EditableBy(domain.Actor{Username: "root", Role: "admin"}))
+	assert.False(t, comment.EditableBy(domain.Actor{Username: "bob", Role: "regular"}))
+	assert.False(t, domain.Comment{}.EditableBy(domain.Actor{Role: "regular"}))
+}
+
+func TestCommentQueryNormalize(t *testing.T) {
+	q := domain.CommentQuery{TaskID: 1}
+	require.NoError(t, q.Normalize())
+	assert.Equal(t, domain.DefaultCommentPageSize, q.Limit)
+
+	assert.ErrorIs(t, (&domain.CommentQuery{Limit: domain.MaxCommentPageSize + 1}).Normalize(), domain.ErrInvalidCommentQuery)
+	assert.ErrorIs(t, (&domain.CommentQuery{Offset: -1}).Normalize(), domain.ErrInvalidCommentQuery)
+
+	page := domain.NewCommentPage(domain.CommentQuery{Limit: 2}, []domain.Comment{{ID: "1"}, {ID: "2"}}, 3)
+	require.NotNil(t, page.NextOffset)
+	assert.Equal(t, 2, *page.NextOffset)
+	assert.NotNil(t, domain.NewCommentPage(q, nil, 0).Comments)
+}
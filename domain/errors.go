@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// what went wrong, independent of transport; the delivery layer picks the status code
+type ErrorKind string
+
+const (
+	KindBadRequest   ErrorKind = "bad_request"  // the request itself could not be understood
+	KindValidation   ErrorKind = "validation"   // well-formed, but the values are not acceptable
+	KindUnauthorized ErrorKind = "unauthorized" // missing or wrong credentials
+	KindForbidden    ErrorKind = "forbidden"    // authenticated, but not allowed
+	KindNotFound     ErrorKind = "not_found"
+	KindConflict     ErrorKind = "conflict" // clashes with the current state of the resource
+	KindInternal     ErrorKind = "internal"
+
+	KindPreconditionFailed   ErrorKind = "precondition_failed"   // the resource changed since the client read it
+	KindPreconditionRequired ErrorKind = "precondition_required" // a conditional request was expected
+
+	KindTooLarge        ErrorKind = "too_large"              // the content is over a size limit
+	KindUnsupportedType ErrorKind = "unsupported_media_type" // the content is of a type that is not accepted
+)
+
+// an error with a kind; Message is safe to show to clients, Err is the underlying cause
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func newError(kind ErrorKind, format string, args ...any) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+func NewBadRequest(format string, args ...any) *Error {
+	return newError(KindBadRequest, format, args...)
+}
+
+func NewValidation(format string, args ...any) *Error {
+	return newError(KindValidation, format, args...)
+}
+
+func NewUnauthorized(format string, args ...any) *Error {
+	return newError(KindUnauthorized, format, args...)
+}
+
+func NewForbidden(format string, args ...any) *Error {
+	return newError(KindForbidden, format, args...)
+}
+
+func NewNotFound(format string, args ...any) *Error {
+	return newError(KindNotFound, format, args...)
+}
+
+func NewConflict(format string, args ...any) *Error {
+	return newError(KindConflict, format, args...)
+}
+
+func NewPreconditionFailed(format string, args ...any) *Error {
+	return newError(KindPreconditionFailed, format, args...)
+}
+
+func NewPreconditionRequired(format string, args ...any) *Error {
+	return newError(KindPreconditionRequired, format, args...)
+}
+
+func NewTooLarge(format string, args ...any) *Error {
+	return newError(KindTooLarge, format, args...)
+}
+
+func NewUnsupportedType(format string, args ...any) *Error {
+	return newError(KindUnsupportedType, format, args...)
+}
+
+// wraps an unexpected failure; its details are for logs, not clients
+func NewInternal(err error, format string, args ...any) *Error {
+	e := newError(KindInternal, format, args...)
+	e.Err = err
+	return e
+}
+
+// the kind of the first *Error in err's chain; anything untyped counts as internal
+func KindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindInternal
+}
@@ -0,0 +1,37 @@
+package domain_test
+
+import (
+	"errors"
+	"fmt"
+	"task7/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind domain.ErrorKind
+	}{
+		{name: "Typed", err: domain.NewNotFound("no task found with id %d", 7), kind: domain.KindNotFound},
+		{name: "Wrapped", err: fmt.Errorf("%w: archived", domain.ErrInvalidStatusTransition), kind: domain.KindConflict},
+		{name: "Untyped", err: errors.New("disk on fire"), kind: domain.KindInternal},
+		{name: "Internal", err: domain.NewInternal(errors.New("disk on fire"), "saving task"), kind: domain.KindInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.kind, domain.KindOf(tt.err))
+		})
+	}
+}
+
+func TestError_MessageAndCause(t *testing.T) {
+	cause := errors.New("connection reset")
+	err := domain.NewInternal(cause, "loading task %d", 3)
+
+	assert.Equal(t, "loading task 3: connection reset", err.Error())
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "no task found with id 3", domain.NewNotFound("no task found with id %d", 3).Error())
+}
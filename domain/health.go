@@ -0,0 +1,23 @@
+package domain
+
+const (
+	HealthOK          = "ok"
+	HealthUnavailable = "unavailable"
+)
+
+// the result of probing one dependency
+type DependencyHealth struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// the readiness report; Status is ok only when every dependency is
+type HealthReport struct {
+	Status string                      `json:"status"`
+	Checks map[string]DependencyHealth `json:"checks"`
+}
+
+func (r HealthReport) Healthy() bool {
+	return r.Status == HealthOK
+}
@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidProject     = NewValidation("invalid project")
+	ErrInvalidProjectRole = NewValidation("invalid project role")
+	ErrLastProjectOwner   = NewConflict("a project must keep at least one owner")
+	ErrProjectNotEmpty    = NewConflict("the project still has tasks; move or delete them first")
+	// a write raced another one on the same project; the services retry on a fresh read
+	ErrProjectVersionMismatch = NewPreconditionFailed("the project has been modified since it was read; fetch it again and retry")
+)
+
+const (
+	MaxProjectNameLength        = 100  // characters
+	MaxProjectDescriptionLength = 2000 // characters
+)
+
+// what a member may do in a project; each role includes everything the ones below it may do
+type ProjectRole string
+
+const (
+	ProjectRoleViewer ProjectRole = "viewer" // sees the project and its board
+	ProjectRoleMember ProjectRole = "member" // also adds tasks to the board
+	ProjectRoleOwner  ProjectRole = "owner"  // also edits or deletes the project and manages its members
+)
+
+var projectRoleRank = map[ProjectRole]int{ProjectRoleViewer: 1, ProjectRoleMember: 2, ProjectRoleOwner: 3}
+
+// validates a role given by a client; matching is case-insensitive
+func ParseProjectRole(s string) (ProjectRole, error) {
+	role := ProjectRole(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := projectRoleRank[role]; !ok {
+		return "", fmt.Errorf("%w: %q; expected owner, member or viewer", ErrInvalidProjectRole, s)
+	}
+	return role, nil
+}
+
+// whether r grants at least what min does
+func (r ProjectRole) AtLeast(min ProjectRole) bool {
+	return projectRoleRank[r] >= projectRoleRank[min] && projectRoleRank[r] > 0
+}
+
+type ProjectMember struct {
+	Username string      `bson:"username" json:"username"`
+	Role     ProjectRole `bson:"role" json:"role"`
+}
+
+// a board that groups tasks; who may do what with it is decided by its members' roles
+type Project struct {
+	ID          string          `bson:"-" json:"id"` // assigned by the repository
+	Name        string          `bson:"name" json:"name"`
+	Description string          `bson:"description" json:"description"`
+	Members     []ProjectMember `bson:"members" json:"members"` // sorted by username
+	CreatedBy   string          `bson:"createdby" json:"created_by"`
+	CreatedAt   time.Time       `bson:"createdat" json:"created_at"`
+	UpdatedAt   time.Time       `bson:"updatedat" json:"updated_at"`
+	Version     int64           `bson:"version" json:"-"` // bumped by the repository on every update; edits retry on a mismatch rather than asking the client
+}
+
+func (p Project) Clone() Project {
+	p.Members = slices.Clone(p.Members)
+	return p
+}
+
+// the role of username in the project; ok is false for anyone who is not a member
+func (p Project) RoleOf(username string) (role ProjectRole, ok bool) {
+	for _, m := range p.Members {
+		if m.Username == username {
+			return m.Role, true
+		}
+	}
+	return "", false
+}
+
+// the actor's role in the project; admins act as owners of every project
+func (p Project) RoleFor(actor Actor) (ProjectRole, bool) {
+	if actor.IsAdmin() {
+		return ProjectRoleOwner, true
+	}
+	if actor.Username == "" {
+		return "", false
+	}
+	return p.RoleOf(actor.Username)
+}
+
+// gives username role, adding them if they are not a member yet. Fails if that would leave the
+// project without an owner.
+func (p *Project) SetMember(username string, role ProjectRole) error {
+	i := slices.IndexFunc(p.Members, func(m ProjectMember) bool { return m.Username == username })
+	if i < 0 {
+		p.Members = append(p.Members, ProjectMember{Username: username, Role: role})
+		slices.SortFunc(p.Members, func(a, b ProjectMember) int { return strings.Compare(a.Username, b.Username) })
+		return nil
+	}
+	if p.Members[i].Role == ProjectRoleOwner && role != ProjectRoleOwner && p.owners() == 1 {
+		return ErrLastProjectOwner
+	}
+	p.Members[i].Role = role
+	return nil
+}
+
+// removes username from the members; fails if they are the last owner, and reports whether they were a member
+func (p *Project) RemoveMember(username string) (bool, error) {
+	i := slices.IndexFunc(p.Members, func(m ProjectMember) bool { return m.Username == username })
+	if i < 0 {
+		return false, nil
+	}
+	if p.Members[i].Role == ProjectRoleOwner && p.owners() == 1 {
+		return false, ErrLastProjectOwner
+	}
+	p.Members = slices.Delete(p.Members, i, i+1)
+	return true, nil
+}
+
+func (p Project) owners() int {
+	n := 0
+	for _, m := range p.Members {
+		if m.Role == ProjectRoleOwner {
+			n++
+		}
+	}
+	return n
+}
+
+// trims the name and description and rejects a blank name or overlong values
+func (p *Project) Normalize() error {
+	p.Name = strings.TrimSpace(p.Name)
+	p.Description = strings.TrimSpace(p.Description)
+	if p.Name == "" {
+		return fmt.Errorf("%w: name cannot be empty", ErrInvalidProject)
+	}
+	if utf8.RuneCountInString(p.Name) > MaxProjectNameLength {
+		return fmt.Errorf("%w: name cannot be longer than %d characters", ErrInvalidProject, MaxProjectNameLength)
+	}
+	if utf8.RuneCountInString(p.Description) > MaxProjectDescriptionLength {
+		return fmt.Errorf("%w: description cannot be longer than %d characters", ErrInvalidProject, MaxProjectDescriptionLength)
+	}
+	return nil
+}
+
+// the caller's role in one project, as established for the current request
+type projectAccess struct {
+	projectID string
+	role      ProjectRole
+}
+
+type projectAccessKey struct{}
+
+// records that the caller holds role in project projectID for the rest of the request
+func WithProjectRole(ctx context.Context, projectID string, role ProjectRole) context.Context {
+	return context.WithValue(ctx, projectAccessKey{}, projectAccess{projectID: projectID, role: role})
+}
+
+// the role stored by WithProjectRole for projectID; ok is false if none was stored for that project
+func ProjectRoleFromContext(ctx context.Context, projectID string) (ProjectRole, bool) {
+	access, ok := ctx.Value(projectAccessKey{}).(projectAccess)
+	if !ok || access.projectID != projectID {
+		return "", false
+	}
+	return access.role, true
+}
@@ -0,0 +1,95 @@
+package domain_test
+
+import (
+	"context"
+	"strings"
+	"task7/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProjectRole(t *testing.T) {
+	role, err := domain.ParseProjectRole(" Member ")
+	require.NoError(t, err)
+	assert.Equal(t, domain.ProjectRoleMember, role)
+
+	_, err = domain.ParseProjectRole("admin")
+	assert.ErrorIs(t, err, domain.ErrInvalidProjectRole)
+}
+
+func TestProjectRoleAtLeast(t *testing.T) {
+	assert.True(t, domain.ProjectRoleOwner.AtLeast(domain.ProjectRoleMember))
+	assert.True(t, domain.ProjectRoleMember.AtLeast(domain.ProjectRoleMember))
+	assert.False(t, domain.ProjectRoleViewer.AtLeast(domain.ProjectRoleMember))
+	assert.False(t, domain.ProjectRole("").AtLeast(domain.ProjectRoleViewer))
+}
+
+func TestProjectRoleFor(t *testing.T) {
+	project := domain.Project{Members: []domain.ProjectMember{{Username: "alice", Role: domain.ProjectRoleOwner}, {Username: "bob", Role: domain.ProjectRoleViewer}}}
+
+	role, ok := project.RoleFor(domain.Actor{Username: "bob", Role: "regular"})
+	assert.True(t, ok)
+	assert.Equal(t, domain.ProjectRoleViewer, role)
+
+	role, ok = project.RoleFor(domain.Actor{Username: "root", Role: "admin"})
+	assert.True(t, ok, "Admins act as owners")
+	assert.Equal(t, domain.ProjectRoleOwner, role)
+
+	_, ok = project.RoleFor(domain.Actor{Username: "carol", Role: "regular"})
+	assert.False(t, ok)
+}
+
+func TestProjectMembers(t *testing.T) {
+	project := domain.Project{Members: []domain.ProjectMember{{Username: "bob", Role: domain.ProjectRoleOwner}}}
+
+	require.NoError(t, project.SetMember("alice", domain.ProjectRoleMember))
+	assert.Equal(t, []domain.ProjectMember{{Username: "alice", Role: domain.ProjectRoleMember}, {Username: "bob", Role: domain.ProjectRoleOwner}}, project.Members, "Members stay sorted")
+
+	assert.ErrorIs(t, project.SetMember("bob", domain.ProjectRoleViewer), domain.ErrLastProjectOwner)
+	_, err := project.RemoveMember("bob")
+	assert.ErrorIs(t, err, domain.ErrLastProjectOwner)
+
+	require.NoError(t, project.SetMember("alice", domain.ProjectRoleOwner))
+	removed, err := project.RemoveMember("bob")
+	require.NoError(t, err)
+	assert.True(t, removed, "Another owner remains")
+
+	removed, err = project.RemoveMember("nobody")
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestProjectNormalize(t *testing.T) {
+	project := domain.Project{Name: "  Website  ", Description: " relaunch "}
+	require.NoError(t, project.Normalize())
+	assert.Equal(t, "Website", project.Name)
+	assert.Equal(t, "relaunch", project.Description)
+
+	assert.ErrorIs(t, (&domain.Project{Name: " "}).Normalize(), domain.ErrInvalidProject)
+	assert.ErrorIs(t, (&domain.Project{Name: strings.Repeat("n", domain.MaxProjectNameLength+1)}).Normalize(), domain.ErrInvalidProject)
+}
+
+func TestProjectRoleFromContext(t *testing.T) {
+	ctx := domain.WithProjectRole(context.Background(), "p1", domain.ProjectRoleMember)
+
+	role, ok := domain.ProjectRoleFromContext(ctx, "p1")
+	assert.True(t, ok)
+	assert.Equal(t, domain.ProjectRoleMember, role)
+
+	_, ok = domain.ProjectRoleFromContext(ctx, "p2")
+	assert.False(t, ok, "A role is only good for the project it was established for")
+}
+
+func TestTaskProjectAccess(t *testing.T) {
+	task := domain.Task{CreatedBy: "alice", ProjectID: "p1"}
+	carol := domain.Actor{Username: "carol", Role: "regular"}
+
+	assert.True(t, task.ReadableBy(carol, domain.ProjectRoleViewer))
+	assert.False(t, task.ChangeableBy(carol, domain.ProjectRoleViewer), "Viewers only read")
+	assert.True(t, task.ChangeableBy(carol, domain.ProjectRoleMember))
+	assert.False(t, task.ReadableBy(carol, ""), "Non-members see only their own tasks")
+	assert.True(t, task.ChangeableBy(domain.Actor{Username: "alice", Role: "regular"}, ""), "The creator keeps access")
+	assert.False(t, domain.Task{CreatedBy: "alice"}.ReadableBy(carol, domain.ProjectRoleOwner), "A role means nothing for a task on no project")
+}
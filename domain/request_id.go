@@ -0,0 +1,16 @@
+package domain
+
+import "context"
+
+type requestIDKey struct{}
+
+// tags ctx with the id of the HTTP request it serves, so every layer can log it
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// returns the id stored by WithRequestID, or "" outside a request
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
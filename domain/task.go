@@ -2,10 +2,96 @@ package domain
 
 import "time"
 
+// returned when a write names a version of the task that is no longer the stored one
+var ErrTaskVersionMismatch = NewPreconditionFailed("the task has been modified since it was read; fetch it again and retry")
+
 type Task struct {
-	ID          int       `bson:"id" json:"id"`
-	Title       string    `bson:"title" json:"title"`
-	Description string    `bson:"description" json:"description"`
-	DueDate     time.Time `bson:"duedate" json:"duedate"`
-	Status      string    `bson:"status" json:"status"`
+	ID          int             `bson:"id" json:"id"`
+	Title       string          `bson:"title" json:"title"`
+	Description string          `bson:"description" json:"description"`
+	DueDate     time.Time       `bson:"duedate" json:"duedate"`
+	Status      TaskStatus      `bson:"status" json:"status"`
+	CreatedBy   string          `bson:"createdby" json:"createdby"`
+	AssignedTo  string          `bson:"assignedto" json:"assignedto"`
+	Priority    TaskPriority    `bson:"priority" json:"priority"`
+	Labels      []string        `bson:"labels,omitempty" json:"labels,omitempty"`         // normalised and sorted; see NormalizeLabels
+	ParentID    int             `bson:"parentid,omitempty" json:"parent_id,omitempty"`    // the task this is a subtask of; fixed at creation
+	ProjectID   string          `bson:"projectid,omitempty" json:"project_id,omitempty"`  // the board the task is on, if any; fixed at creation
+	Checklist   []ChecklistItem `bson:"checklist,omitempty" json:"checklist,omitempty"`   // in display order
+	BlockedBy   []int           `bson:"blockedby,omitempty" json:"blocked_by,omitempty"`  // ids of tasks that must be done first, sorted
+	Recurrence  *Recurrence     `bson:"recurrence,omitempty" json:"recurrence,omitempty"` // nil for a one-off task
+	Version     int64           `bson:"version" json:"version"`                           // starts at 1, bumped by the repository on every write
+	DeletedAt   *time.Time      `bson:"deletedat,omitempty" json:"deleted_at,omitempty"`  // set while the task is in the trash
+	Progress    *TaskProgress   `bson:"-" json:"progress,omitempty"`                      // filled in by the service for responses
+}
+
+// whether the task has been deleted and is waiting in the trash to be restored or purged
+func (t Task) IsDeleted() bool {
+	return t.DeletedAt != nil
+}
+
+// admins see everything; everyone else sees tasks they created or are assigned
+func (t Task) VisibleTo(actor Actor) bool {
+	return actor.IsAdmin() || (actor.Username != "" && (t.CreatedBy == actor.Username || t.AssignedTo == actor.Username))
+}
+
+// whether actor may read the task, holding role in its project ("" for none): besides VisibleTo, any
+// role in the task's project lets them read it
+func (t Task) ReadableBy(actor Actor, role ProjectRole) bool {
+	return t.VisibleTo(actor) || (t.ProjectID != "" && role.AtLeast(ProjectRoleViewer))
+}
+
+// whether actor may change the task, holding role in its project: besides VisibleTo, members and owners of
+// the task's project may change it
+func (t Task) ChangeableBy(actor Actor, role ProjectRole) bool {
+	return t.VisibleTo(actor) || (t.ProjectID != "" && role.AtLeast(ProjectRoleMember))
+}
+
+// the task as UpdateTask leaves it: every non-empty field of update replaces the stored one, and
+// non-nil Labels, Checklist or BlockedBy replace the stored ones (an empty slice clears them). A
+// non-nil Recurrence replaces the stored rule; one without a frequency clears it.
+func (t Task) WithUpdate(update Task) Task {
+	if update.Title != "" {
+		t.Title = update.Title
+	}
+	if update.Description != "" {
+		t.Description = update.Description
+	}
+	if !update.DueDate.IsZero() {
+		t.DueDate = update.DueDate
+	}
+	if update.Status != "" {
+		t.Status = update.Status
+	}
+	if update.AssignedTo != "" {
+		t.AssignedTo = update.AssignedTo
+	}
+	if update.Priority != "" {
+		t.Priority = update.Priority
+	}
+	if update.Labels != nil {
+		t.Labels = nil
+		if len(update.Labels) > 0 {
+			t.Labels = update.Labels
+		}
+	}
+	if update.Checklist != nil {
+		t.Checklist = nil
+		if len(update.Checklist) > 0 {
+			t.Checklist = update.Checklist
+		}
+	}
+	if update.BlockedBy != nil {
+		t.BlockedBy = nil
+		if len(update.BlockedBy) > 0 {
+			t.BlockedBy = update.BlockedBy
+		}
+	}
+	if update.Recurrence != nil {
+		t.Recurrence = nil
+		if update.Recurrence.Frequency != "" {
+			t.Recurrence = update.Recurrence
+		}
+	}
+	return t
 }
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrInvalidChecklist = NewValidation("invalid checklist")
+
+const (
+	MaxChecklistItems      = 100
+	MaxChecklistTextLength = 200
+)
+
+// one step of a task's checklist; ids are assigned per task and never reused while the item exists
+type ChecklistItem struct {
+	ID   int    `bson:"id" json:"id"`
+	Text string `bson:"text" json:"text"`
+	Done bool   `bson:"done" json:"done"`
+}
+
+func normalizeChecklistText(raw string) (string, error) {
+	text := strings.TrimSpace(raw)
+	switch {
+	case text == "":
+		return "", fmt.Errorf("%w: checklist items need some text", ErrInvalidChecklist)
+	case utf8.RuneCountInString(text) > MaxChecklistTextLength:
+		return "", fmt.Errorf("%w: checklist items are at most %d characters", ErrInvalidChecklist, MaxChecklistTextLength)
+	}
+	return text, nil
+}
+
+// the checklist a new task starts with: texts are trimmed and items numbered from 1, whatever ids were sent
+func NewChecklist(items []ChecklistItem) ([]ChecklistItem, error) {
+	if len(items) > MaxChecklistItems {
+		return nil, fmt.Errorf("%w: a task can have at most %d checklist items", ErrInvalidChecklist, MaxChecklistItems)
+	}
+	var checklist []ChecklistItem
+	for i, item := range items {
+		text, err := normalizeChecklistText(item.Text)
+		if err != nil {
+			return nil, err
+		}
+		checklist = append(checklist, ChecklistItem{ID: i + 1, Text: text, Done: item.Done})
+	}
+	return checklist, nil
+}
+
+func (t Task) checklistIndex(itemID int) (int, error) {
+	i := slices.IndexFunc(t.Checklist, func(item ChecklistItem) bool { return item.ID == itemID })
+	if i < 0 {
+		return 0, NewNotFound("task %d has no checklist item %d", t.ID, itemID)
+	}
+	return i, nil
+}
+
+// the task's checklist with a new, unticked item at the end
+func (t Task) AddChecklistItem(text string) ([]ChecklistItem, error) {
+	text, err := normalizeChecklistText(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Checklist) >= MaxChecklistItems {
+		return nil, fmt.Errorf("%w: a task can have at most %d checklist items", ErrInvalidChecklist, MaxChecklistItems)
+	}
+	next := 1
+	for _, item := range t.Checklist {
+		next = max(next, item.ID+1)
+	}
+	return append(slices.Clone(t.Checklist), ChecklistItem{ID: next, Text: text}), nil
+}
+
+// the task's checklist with item itemID ticked or unticked
+func (t Task) SetChecklistItemDone(itemID int, done bool) ([]ChecklistItem, error) {
+	i, err := t.checklistIndex(itemID)
+	if err != nil {
+		return nil, err
+	}
+	checklist := slices.Clone(t.Checklist)
+	checklist[i].Done = done
+	return checklist, nil
+}
+
+// the task's checklist without item itemID
+func (t Task) RemoveChecklistItem(itemID int) ([]ChecklistItem, error) {
+	i, err := t.checklistIndex(itemID)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Delete(slices.Clone(t.Checklist), i, i+1), nil
+}
+
+// the task's checklist in the order of itemIDs, which must name every item exactly once
+func (t Task) ReorderChecklist(itemIDs []int) ([]ChecklistItem, error) {
+	if len(itemIDs) != len(t.Checklist) {
+		return nil, fmt.Errorf("%w: the new order must list all %d checklist items", ErrInvalidChecklist, len(t.Checklist))
+	}
+	checklist := make([]ChecklistItem, 0, len(itemIDs))
+	for _, id := range itemIDs {
+		i, err := t.checklistIndex(id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: there is no checklist item %d", ErrInvalidChecklist, id)
+		}
+		if slices.ContainsFunc(checklist, func(item ChecklistItem) bool { return item.ID == id }) {
+			return nil, fmt.Errorf("%w: checklist item %d is listed twice", ErrInvalidChecklist, id)
+		}
+		checklist = append(checklist, t.Checklist[i])
+	}
+	return checklist, nil
+}
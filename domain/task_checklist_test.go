@@ -0,0 +1,76 @@
+package domain_test
+
+import (
+	"strings"
+	"task7/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChecklist(t *testing.T) {
+	checklist, err := domain.NewChecklist([]domain.ChecklistItem{{ID: 9, Text: " Draft "}, {ID: 9, Text: "Review", Done: true}})
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ChecklistItem{{ID: 1, Text: "Draft"}, {ID: 2, Text: "Review", Done: true}}, checklist)
+
+	checklist, err = domain.NewChecklist(nil)
+	require.NoError(t, err)
+	assert.Nil(t, checklist)
+
+	for name, items := range map[string][]domain.ChecklistItem{
+		"Blank text": {{Text: "  "}},
+		"Too long":   {{Text: strings.Repeat("x", domain.MaxChecklistTextLength+1)}},
+		"Too many":   make([]domain.ChecklistItem, domain.MaxChecklistItems+1),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := domain.NewChecklist(items)
+			assert.ErrorIs(t, err, domain.ErrInvalidChecklist)
+		})
+	}
+}
+
+func TestTask_ChecklistEdits(t *testing.T) {
+	task := domain.Task{ID: 7, Checklist: []domain.ChecklistItem{{ID: 1, Text: "One"}, {ID: 3, Text: "Three"}}}
+
+	added, err := task.AddChecklistItem(" Four ")
+	require.NoError(t, err)
+	assert.Equal(t, domain.ChecklistItem{ID: 4, Text: "Four"}, added[2], "New items get the next id after the highest")
+	assert.Len(t, task.Checklist, 2, "The task's own checklist is left alone")
+
+	ticked, err := task.SetChecklistItemDone(3, true)
+	require.NoError(t, err)
+	assert.True(t, ticked[1].Done)
+	assert.False(t, task.Checklist[1].Done)
+
+	removed, err := task.RemoveChecklistItem(1)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ChecklistItem{{ID: 3, Text: "Three"}}, removed)
+	assert.Len(t, task.Checklist, 2)
+
+	_, err = task.SetChecklistItemDone(2, true)
+	assert.Equal(t, domain.KindNotFound, domain.KindOf(err))
+	_, err = task.RemoveChecklistItem(2)
+	assert.Equal(t, domain.KindNotFound, domain.KindOf(err))
+	_, err = task.AddChecklistItem("")
+	assert.ErrorIs(t, err, domain.ErrInvalidChecklist)
+}
+
+func TestTask_ReorderChecklist(t *testing.T) {
+	task := domain.Task{Checklist: []domain.ChecklistItem{{ID: 1, Text: "One"}, {ID: 2, Text: "Two", Done: true}, {ID: 3, Text: "Three"}}}
+
+	reordered, err := task.ReorderChecklist([]int{3, 1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ChecklistItem{{ID: 3, Text: "Three"}, {ID: 1, Text: "One"}, {ID: 2, Text: "Two", Done: true}}, reordered)
+
+	for name, ids := range map[string][]int{
+		"Missing item":  {3, 1},
+		"Unknown item":  {3, 1, 4},
+		"Repeated item": {3, 1, 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := task.ReorderChecklist(ids)
+			assert.ErrorIs(t, err, domain.ErrInvalidChecklist)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+var (
+	ErrInvalidDependency = NewValidation("invalid task dependency")
+	ErrDependencyCycle   = NewConflict("task dependency cycle")
+	ErrTaskBlocked       = NewConflict("task is blocked")
+)
+
+const MaxBlockersPerTask = 50
+
+// the sorted, de-duplicated task ids; nil when there are none
+func NormalizeTaskIDs(ids []int) ([]int, error) {
+	var normalized []int
+	for _, id := range ids {
+		if id < 1 {
+			return nil, fmt.Errorf("%w: %d is not a task id", ErrInvalidDependency, id)
+		}
+		normalized = append(normalized, id)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
+
+// the task's blockers with add added and remove removed; both must already be normalised
+func (t Task) EditBlockers(add, remove []int) ([]int, error) {
+	blockers := slices.DeleteFunc(slices.Concat(t.BlockedBy, add), func(id int) bool {
+		return slices.Contains(remove, id)
+	})
+	blockers, err := NormalizeTaskIDs(blockers)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(blockers, t.ID) {
+		return nil, fmt.Errorf("%w: a task cannot be blocked by itself", ErrInvalidDependency)
+	}
+	if len(blockers) > MaxBlockersPerTask {
+		return nil, fmt.Errorf("%w: a task can be blocked by at most %d tasks", ErrInvalidDependency, MaxBlockersPerTask)
+	}
+	return blockers, nil
+}
+
+// one task in a dependency graph; tasks the caller cannot see show only their id
+type DependencyNode struct {
+	ID      int        `json:"id"`
+	Title   string     `json:"title,omitempty"`
+	Status  TaskStatus `json:"status,omitempty"`
+	DueDate *time.Time `json:"duedate,omitempty"`
+	Hidden  bool       `json:"hidden,omitempty"`
+}
+
+func NewDependencyNode(task Task, visible bool) DependencyNode {
+	if !visible {
+		return DependencyNode{ID: task.ID, Hidden: true}
+	}
+	node := DependencyNode{ID: task.ID, Title: task.Title, Status: task.Status}
+	if !task.DueDate.IsZero() {
+		due := task.DueDate
+		node.DueDate = &due
+	}
+	return node
+}
+
+// Blocked cannot be done before Blocker is
+type DependencyEdge struct {
+	Blocker int `json:"blocker"`
+	Blocked int `json:"blocked"`
+}
+
+// everything a task waits on (upstream) and everything waiting on it (downstream), directly or not,
+// with the edges between them; nodes are ordered by id
+type DependencyGraph struct {
+	Task       DependencyNode   `json:"task"`
+	Upstream   []DependencyNode `json:"upstream"`
+	Downstream []DependencyNode `json:"downstream"`
+	Edges      []DependencyEdge `json:"edges"`
+}
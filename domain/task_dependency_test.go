@@ -0,0 +1,55 @@
+package domain_test
+
+import (
+	"task7/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTaskIDs(t *testing.T) {
+	ids, err := domain.NormalizeTaskIDs([]int{5, 2, 5, 9, 2})
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 5, 9}, ids)
+
+	ids, err = domain.NormalizeTaskIDs(nil)
+	require.NoError(t, err)
+	assert.Nil(t, ids)
+
+	_, err = domain.NormalizeTaskIDs([]int{3, 0})
+	assert.ErrorIs(t, err, domain.ErrInvalidDependency)
+}
+
+func TestEditBlockers(t *testing.T) {
+	task := domain.Task{ID: 4, BlockedBy: []int{1, 2}}
+
+	blockers, err := task.EditBlockers([]int{3, 1}, []int{2})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, blockers)
+	assert.Equal(t, []int{1, 2}, task.BlockedBy, "the task itself is left alone")
+
+	blockers, err = task.EditBlockers(nil, []int{1, 2})
+	require.NoError(t, err)
+	assert.Empty(t, blockers)
+
+	_, err = task.EditBlockers([]int{4}, nil)
+	assert.ErrorIs(t, err, domain.ErrInvalidDependency, "a task cannot block itself")
+
+	many := make([]int, domain.MaxBlockersPerTask)
+	for i := range many {
+		many[i] = 100 + i
+	}
+	_, err = task.EditBlockers(many, nil)
+	assert.ErrorIs(t, err, domain.ErrInvalidDependency)
+}
+
+func TestNewDependencyNode(t *testing.T) {
+	due := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	task := domain.Task{ID: 7, Title: "Deploy", Status: domain.StatusTodo, DueDate: due}
+
+	assert.Equal(t, domain.DependencyNode{ID: 7, Title: "Deploy", Status: domain.StatusTodo, DueDate: &due}, domain.NewDependencyNode(task, true))
+	assert.Equal(t, domain.DependencyNode{ID: 7, Hidden: true}, domain.NewDependencyNode(task, false))
+	assert.Nil(t, domain.NewDependencyNode(domain.Task{ID: 8}, true).DueDate)
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrInvalidLabel = NewValidation("invalid task label")
+
+const (
+	MaxLabelLength     = 50
+	MaxLabelsPerTask   = 20
+	labelListSeparator = ","
+)
+
+// how many live tasks carry a label
+type LabelCount struct {
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+// trims and lowercases one label, rejecting empty, overlong and unprintable ones. Commas are
+// rejected too, since label filters may be given as a comma-separated list.
+func NormalizeLabel(raw string) (string, error) {
+	label := strings.ToLower(strings.TrimSpace(raw))
+	switch {
+	case label == "":
+		return "", fmt.Errorf("%w: labels cannot be empty", ErrInvalidLabel)
+	case utf8.RuneCountInString(label) > MaxLabelLength:
+		return "", fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidLabel, raw, MaxLabelLength)
+	case strings.Contains(label, labelListSeparator):
+		return "", fmt.Errorf("%w: %q contains a comma", ErrInvalidLabel, raw)
+	case strings.IndexFunc(label, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0:
+		return "", fmt.Errorf("%w: %q contains unprintable characters", ErrInvalidLabel, raw)
+	}
+	return label, nil
+}
+
+// the normalised, de-duplicated and sorted set of labels; nil when there are none
+func NormalizeLabels(raw []string) ([]string, error) {
+	var labels []string
+	for _, r := range raw {
+		label, err := NormalizeLabel(r)
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	slices.Sort(labels)
+	labels = slices.Compact(labels)
+	if len(labels) > MaxLabelsPerTask {
+		return nil, fmt.Errorf("%w: a task can have at most %d labels", ErrInvalidLabel, MaxLabelsPerTask)
+	}
+	return labels, nil
+}
+
+// the task's labels with add added and remove removed; both must already be normalised
+func (t Task) EditLabels(add, remove []string) ([]string, error) {
+	labels := slices.DeleteFunc(slices.Concat(t.Labels, add), func(label string) bool {
+		return slices.Contains(remove, label)
+	})
+	return NormalizeLabels(labels)
+}
+
+// whether the task carries every one of labels
+func (t Task) HasLabels(labels []string) bool {
+	for _, label := range labels {
+		if !slices.Contains(t.Labels, label) {
+			return false
+		}
+	}
+	return true
+}
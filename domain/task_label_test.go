@@ -0,0 +1,52 @@
+package domain_test
+
+import (
+	"strings"
+	"task7/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeLabels(t *testing.T) {
+	labels, err := domain.NormalizeLabels([]string{" Backend", "ui", "backend", "Area/Billing"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"area/billing", "backend", "ui"}, labels)
+
+	labels, err = domain.NormalizeLabels([]string{})
+	require.NoError(t, err)
+	assert.Nil(t, labels)
+
+	tooMany := make([]string, domain.MaxLabelsPerTask+1)
+	for i := range tooMany {
+		tooMany[i] = strings.Repeat("x", i+1)
+	}
+	for name, raw := range map[string][]string{
+		"Empty":       {"  "},
+		"Comma":       {"a,b"},
+		"Too long":    {strings.Repeat("x", domain.MaxLabelLength+1)},
+		"Unprintable": {"tab\there"},
+		"Too many":    tooMany,
+	} {
+		_, err := domain.NormalizeLabels(raw)
+		assert.ErrorIs(t, err, domain.ErrInvalidLabel, name)
+	}
+}
+
+func TestTaskEditLabels(t *testing.T) {
+	task := domain.Task{Labels: []string{"backend", "ui"}}
+
+	labels, err := task.EditLabels([]string{"billing", "backend"}, []string{"ui", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backend", "billing"}, labels)
+	assert.Equal(t, []string{"backend", "ui"}, task.Labels, "the task itself is left alone")
+
+	labels, err = task.EditLabels(nil, []string{"backend", "ui"})
+	require.NoError(t, err)
+	assert.Empty(t, labels)
+
+	assert.True(t, task.HasLabels([]string{"ui"}))
+	assert.True(t, task.HasLabels(nil))
+	assert.False(t, task.HasLabels([]string{"ui", "billing"}))
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidPriority = NewValidation("invalid task priority")
+
+type TaskPriority string
+
+const (
+	PriorityLow    TaskPriority = "low"
+	PriorityMedium TaskPriority = "medium"
+	PriorityHigh   TaskPriority = "high"
+	PriorityUrgent TaskPriority = "urgent"
+)
+
+// new tasks, and tasks stored before priorities existed, have this one
+const DefaultPriority = PriorityMedium
+
+// every priority, lowest first
+func AllTaskPriorities() []TaskPriority {
+	return []TaskPriority{PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+}
+
+// canonicalises s ("High", " urgent ") or fails with ErrInvalidPriority
+func ParseTaskPriority(s string) (TaskPriority, error) {
+	p := TaskPriority(strings.ToLower(strings.TrimSpace(s)))
+	if p.Valid() {
+		return p, nil
+	}
+	names := make([]string, 0, 4)
+	for _, known := range AllTaskPriorities() {
+		names = append(names, string(known))
+	}
+	return "", fmt.Errorf("%w: %q (expected one of %s)", ErrInvalidPriority, s, strings.Join(names, ", "))
+}
+
+func (p TaskPriority) Valid() bool {
+	switch p {
+	case PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent:
+		return true
+	}
+	return false
+}
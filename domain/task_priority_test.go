@@ -0,0 +1,29 @@
+package domain_test
+
+import (
+	"task7/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTaskPriority(t *testing.T) {
+	tests := map[string]domain.TaskPriority{
+		"low":    domain.PriorityLow,
+		"Medium": domain.PriorityMedium,
+		" HIGH ": domain.PriorityHigh,
+		"urgent": domain.PriorityUrgent,
+	}
+	for input, want := range tests {
+		got, err := domain.ParseTaskPriority(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	for _, input := range []string{"", "p1", "critical"} {
+		_, err := domain.ParseTaskPriority(input)
+		assert.ErrorIs(t, err, domain.ErrInvalidPriority, input)
+		assert.Equal(t, domain.KindValidation, domain.KindOf(err))
+	}
+}
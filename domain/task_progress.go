@@ -0,0 +1,46 @@
+package domain
+
+var (
+	ErrInvalidParent       = NewValidation("invalid parent task")
+	ErrTaskHasOpenSubtasks = NewConflict("task has open subtasks")
+)
+
+// how many live subtasks a task has, and how many of them are done or archived
+type SubtaskCount struct {
+	Total  int64
+	Closed int64
+}
+
+func (c SubtaskCount) Open() int64 {
+	return c.Total - c.Closed
+}
+
+// how far a task has got, worked out from its checklist and subtasks; never stored
+type TaskProgress struct {
+	ChecklistDone  int `json:"checklist_done"`
+	ChecklistTotal int `json:"checklist_total"`
+	SubtasksDone   int `json:"subtasks_done"`
+	SubtasksTotal  int `json:"subtasks_total"`
+	// ticked items and closed subtasks as a share of all of them; a task with neither is at 0 or,
+	// once closed itself, 100
+	Percent int `json:"percent"`
+}
+
+func (t Task) ComputeProgress(subtasks SubtaskCount) TaskProgress {
+	p := TaskProgress{
+		ChecklistTotal: len(t.Checklist),
+		SubtasksDone:   int(subtasks.Closed),
+		SubtasksTotal:  int(subtasks.Total),
+	}
+	for _, item := range t.Checklist {
+		if item.Done {
+			p.ChecklistDone++
+		}
+	}
+	if total := p.ChecklistTotal + p.SubtasksTotal; total > 0 {
+		p.Percent = 100 * (p.ChecklistDone + p.SubtasksDone) / total
+	} else if t.Status.IsClosed() {
+		p.Percent = 100
+	}
+	return p
+}
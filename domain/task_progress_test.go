@@ -0,0 +1,35 @@
+package domain_test
+
+import (
+	"task7/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeProgress(t *testing.T) {
+	checklist := []domain.ChecklistItem{{ID: 1, Done: true}, {ID: 2}, {ID: 3, Done: true}}
+	tests := []struct {
+		name     string
+		task     domain.Task
+		subtasks domain.SubtaskCount
+		want     domain.TaskProgress
+	}{
+		{name: "Nothing to track", task: domain.Task{Status: domain.StatusInProgress}, want: domain.TaskProgress{}},
+		{name: "Closed with nothing to track", task: domain.Task{Status: domain.StatusDone}, want: domain.TaskProgress{Percent: 100}},
+		{name: "Checklist only", task: domain.Task{Checklist: checklist}, want: domain.TaskProgress{ChecklistDone: 2, ChecklistTotal: 3, Percent: 66}},
+		{name: "Subtasks only", subtasks: domain.SubtaskCount{Total: 4, Closed: 1}, want: domain.TaskProgress{SubtasksDone: 1, SubtasksTotal: 4, Percent: 25}},
+		{
+			name:     "Both",
+			task:     domain.Task{Checklist: checklist},
+			subtasks: domain.SubtaskCount{Total: 1, Closed: 1},
+			want:     domain.TaskProgress{ChecklistDone: 2, ChecklistTotal: 3, SubtasksDone: 1, SubtasksTotal: 1, Percent: 75},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.task.ComputeProgress(tt.subtasks))
+		})
+	}
+	assert.EqualValues(t, 3, domain.SubtaskCount{Total: 4, Closed: 1}.Open())
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+var ErrInvalidTaskQuery = NewBadRequest("invalid task query")
+
+const (
+	DefaultTaskPageSize = 50
+	MaxTaskPageSize     = 200
+)
+
+// fields a task listing can be sorted by, mapped to their stored (bson) names
+var TaskSortFields = map[string]string{
+	"id":      "id",
+	"title":   "title",
+	"duedate": "duedate",
+	"status":  "status",
+}
+
+// filters, ordering and window for listing tasks; zero values mean "no constraint"
+type TaskQuery struct {
+	Status        TaskStatus
+	Priority      TaskPriority
+	Labels        []string  // tasks carrying every one of these
+	ParentID      int       // only direct subtasks of this task
+	ProjectID     string    // only tasks on this project's board
+	IDs           []int     // only these tasks
+	WaitingOn     []int     // only tasks blocked by at least one of these
+	DueAfter      time.Time // inclusive
+	DueBefore     time.Time // inclusive
+	TitleContains string    // case-insensitive substring
+	VisibleTo     string    // only tasks created by or assigned to this username
+	Trashed       bool      // list deleted tasks instead of live ones
+	SortBy        string
+	SortDesc      bool
+	Limit         int
+	Offset        int
+}
+
+// one window of a task listing; NextOffset is nil on the last page
+type TaskPage struct {
+	Tasks      []Task `json:"tasks"`
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextOffset *int   `json:"next_offset,omitempty"`
+}
+
+// fills in defaults and rejects values no backend can serve
+func (q *TaskQuery) Normalize() error {
+	if q.Status != "" {
+		status, err := ParseTaskStatus(string(q.Status))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTaskQuery, err)
+		}
+		q.Status = status
+	}
+	if q.Priority != "" {
+		priority, err := ParseTaskPriority(string(q.Priority))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTaskQuery, err)
+		}
+		q.Priority = priority
+	}
+	if len(q.Labels) > 0 {
+		labels, err := NormalizeLabels(q.Labels)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTaskQuery, err)
+		}
+		q.Labels = labels
+	}
+	if q.SortBy == "" {
+		q.SortBy = "id"
+	}
+	if _, ok := TaskSortFields[q.SortBy]; !ok {
+		return fmt.Errorf("%w: cannot sort by %q", ErrInvalidTaskQuery, q.SortBy)
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultTaskPageSize
+	}
+	if q.Limit < 0 || q.Limit > MaxTaskPageSize {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidTaskQuery, MaxTaskPageSize)
+	}
+	if q.Offset < 0 {
+		return fmt.Errorf("%w: offset cannot be negative", ErrInvalidTaskQuery)
+	}
+	if !q.DueAfter.IsZero() && !q.DueBefore.IsZero() && q.DueAfter.After(q.DueBefore) {
+		return fmt.Errorf("%w: due_after must not be later than due_before", ErrInvalidTaskQuery)
+	}
+	return nil
+}
+
+// wraps one window of results in a page, working out where the next one starts
+func NewTaskPage(q TaskQuery, tasks []Task, total int64) TaskPage {
+	if tasks == nil {
+		tasks = []Task{}
+	}
+	page := TaskPage{Tasks: tasks, Total: total, Limit: q.Limit, Offset: q.Offset}
+	if next := q.Offset + len(tasks); int64(next) < total {
+		page.NextOffset = &next
+	}
+	return page
+}
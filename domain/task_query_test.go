@@ -0,0 +1,60 @@
+package domain_test
+
+import (
+	"task7/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskQueryNormalize_Defaults(t *testing.T) {
+	q := domain.TaskQuery{}
+	require.NoError(t, q.Normalize())
+	assert.Equal(t, "id", q.SortBy)
+	assert.Equal(t, domain.DefaultTaskPageSize, q.Limit)
+}
+
+func TestTaskQueryNormalize_CanonicalisesFilters(t *testing.T) {
+	q := domain.TaskQuery{Priority: "High", Labels: []string{"UI", " backend", "ui"}}
+	require.NoError(t, q.Normalize())
+	assert.Equal(t, domain.PriorityHigh, q.Priority)
+	assert.Equal(t, []string{"backend", "ui"}, q.Labels)
+}
+
+func TestTaskQueryNormalize_Invalid(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name  string
+		query domain.TaskQuery
+	}{
+		{name: "Unknown sort field", query: domain.TaskQuery{SortBy: "passwordhash"}},
+		{name: "Limit too large", query: domain.TaskQuery{Limit: domain.MaxTaskPageSize + 1}},
+		{name: "Negative limit", query: domain.TaskQuery{Limit: -1}},
+		{name: "Negative offset", query: domain.TaskQuery{Offset: -1}},
+		{name: "Inverted due range", query: domain.TaskQuery{DueAfter: now, DueBefore: now.Add(-time.Hour)}},
+		{name: "Unknown priority", query: domain.TaskQuery{Priority: "p0"}},
+		{name: "Bad label", query: domain.TaskQuery{Labels: []string{"a,b"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Normalize()
+			assert.ErrorIs(t, err, domain.ErrInvalidTaskQuery)
+		})
+	}
+}
+
+func TestNewTaskPage(t *testing.T) {
+	q := domain.TaskQuery{Limit: 2, Offset: 2}
+
+	page := domain.NewTaskPage(q, []domain.Task{{ID: 3}, {ID: 4}}, 5)
+	require.NotNil(t, page.NextOffset)
+	assert.Equal(t, 4, *page.NextOffset)
+
+	last := domain.NewTaskPage(domain.TaskQuery{Limit: 2, Offset: 4}, []domain.Task{{ID: 5}}, 5)
+	assert.Nil(t, last.NextOffset, "The last page has no next offset")
+
+	empty := domain.NewTaskPage(q, nil, 0)
+	assert.NotNil(t, empty.Tasks, "Tasks should serialize as an empty list, not null")
+}
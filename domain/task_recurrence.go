@@ -0,0 +1,218 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRecurrence = NewValidation("invalid recurrence rule")
+
+type RecurrenceFrequency string
+
+const (
+	FrequencyDaily   RecurrenceFrequency = "daily"
+	FrequencyWeekly  RecurrenceFrequency = "weekly"
+	FrequencyMonthly RecurrenceFrequency = "monthly"
+)
+
+const (
+	MaxRecurrenceInterval    = 100
+	DefaultOccurrencePreview = 5
+	MaxOccurrencePreview     = 52
+)
+
+// weekday codes as in iCalendar's BYDAY, Monday first
+var weekdayCodes = []string{"MO", "TU", "WE", "TH", "FR", "SA", "SU"}
+
+// when a task repeats, in the spirit of an iCalendar RRULE; the series starts at the task's due date.
+// Until and Count both end the series and cannot be combined.
+type Recurrence struct {
+	Frequency  RecurrenceFrequency `bson:"frequency" json:"frequency"`
+	Interval   int                 `bson:"interval" json:"interval"`                        // every Interval days, weeks or months
+	ByWeekday  []string            `bson:"byweekday,omitempty" json:"by_weekday,omitempty"` // weekly only: MO..SU, Monday first
+	Until      *time.Time          `bson:"until,omitempty" json:"until,omitempty"`          // no occurrence is due after this
+	Count      int                 `bson:"count,omitempty" json:"count,omitempty"`          // occurrences in the whole series
+	Occurrence int                 `bson:"occurrence" json:"occurrence"`                    // this task's place in the series, from 1; set by the service
+}
+
+// one upcoming occurrence of a task's series
+type Occurrence struct {
+	Occurrence int       `json:"occurrence"`
+	DueDate    time.Time `json:"duedate"`
+}
+
+// canonicalises the frequency and weekdays and fills in the default interval
+func (r *Recurrence) Normalize() error {
+	frequency := RecurrenceFrequency(strings.ToLower(strings.TrimSpace(string(r.Frequency))))
+	switch frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+		r.Frequency = frequency
+	default:
+		return fmt.Errorf("%w: frequency %q (expected daily, weekly or monthly)", ErrInvalidRecurrence, r.Frequency)
+	}
+	if r.Interval == 0 {
+		r.Interval = 1
+	}
+	if r.Interval < 1 || r.Interval > MaxRecurrenceInterval {
+		return fmt.Errorf("%w: interval must be between 1 and %d", ErrInvalidRecurrence, MaxRecurrenceInterval)
+	}
+	if len(r.ByWeekday) > 0 && r.Frequency != FrequencyWeekly {
+		return fmt.Errorf("%w: by_weekday only applies to weekly rules", ErrInvalidRecurrence)
+	}
+	var days []int
+	for _, code := range r.ByWeekday {
+		day := slices.Index(weekdayCodes, strings.ToUpper(strings.TrimSpace(code)))
+		if day < 0 {
+			return fmt.Errorf("%w: weekday %q (expected one of %s)", ErrInvalidRecurrence, code, strings.Join(weekdayCodes, ", "))
+		}
+		days = append(days, day)
+	}
+	slices.Sort(days)
+	r.ByWeekday = nil
+	for _, day := range slices.Compact(days) {
+		r.ByWeekday = append(r.ByWeekday, weekdayCodes[day])
+	}
+	if r.Count < 0 {
+		return fmt.Errorf("%w: count cannot be negative", ErrInvalidRecurrence)
+	}
+	if r.Count > 0 && r.Until != nil {
+		return fmt.Errorf("%w: give until or count, not both", ErrInvalidRecurrence)
+	}
+	return nil
+}
+
+// the rule as an iCalendar RRULE value, e.g. FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10
+func (r *Recurrence) String() string {
+	if r == nil {
+		return ""
+	}
+	parts := []string{"FREQ=" + strings.ToUpper(string(r.Frequency)), "INTERVAL=" + strconv.Itoa(r.Interval)}
+	if len(r.ByWeekday) > 0 {
+		parts = append(parts, "BYDAY="+strings.Join(r.ByWeekday, ","))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+func (r *Recurrence) Clone() *Recurrence {
+	if r == nil {
+		return nil
+	}
+	clone := *r
+	clone.ByWeekday = slices.Clone(r.ByWeekday)
+	if r.Until != nil {
+		until := *r.Until
+		clone.Until = &until
+	}
+	return &clone
+}
+
+// the due date of the occurrence after the one due at due, keeping its time of day; false once the
+// series is over. A monthly series skips months too short for its day, as RRULE does.
+func (r *Recurrence) Next(due time.Time) (time.Time, bool) {
+	if r.Count > 0 && r.Occurrence >= r.Count {
+		return time.Time{}, false
+	}
+	var next time.Time
+	switch r.Frequency {
+	case FrequencyDaily:
+		next = due.AddDate(0, 0, r.Interval)
+	case FrequencyWeekly:
+		next = r.nextWeekly(due)
+	case FrequencyMonthly:
+		// any day that exists comes round well within 96 steps, even Feb 29 every 12 months
+		for months := r.Interval; months <= 12*8*r.Interval; months += r.Interval {
+			candidate := time.Date(due.Year(), due.Month()+time.Month(months), due.Day(), due.Hour(), due.Minute(), due.Second(), due.Nanosecond(), due.Location())
+			if candidate.Day() == due.Day() {
+				next = candidate
+				break
+			}
+		}
+	}
+	if next.IsZero() || (r.Until != nil && next.After(*r.Until)) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// the next listed weekday later in due's week, or else the first listed weekday Interval weeks on;
+// without weekdays, the same weekday Interval weeks on
+func (r *Recurrence) nextWeekly(due time.Time) time.Time {
+	if len(r.ByWeekday) == 0 {
+		return due.AddDate(0, 0, 7*r.Interval)
+	}
+	today := (int(due.Weekday()) + 6) % 7 // Monday is 0
+	days := make([]int, len(r.ByWeekday))
+	for i, code := range r.ByWeekday {
+		days[i] = slices.Index(weekdayCodes, code)
+	}
+	for _, day := range days {
+		if day > today {
+			return due.AddDate(0, 0, day-today)
+		}
+	}
+	return due.AddDate(0, 0, 7*r.Interval-today+days[0])
+}
+
+// the task that takes over t's series once t is done: a fresh copy due at the next occurrence, with the
+// checklist unticked. False when t does not repeat or its series is over.
+func (t Task) NextOccurrence() (Task, bool) {
+	if t.Recurrence == nil || t.DueDate.IsZero() {
+		return Task{}, false
+	}
+	due, ok := t.Recurrence.Next(t.DueDate)
+	if !ok {
+		return Task{}, false
+	}
+	next := Task{
+		Title:       t.Title,
+		Description: t.Description,
+		DueDate:     due,
+		Status:      StatusTodo,
+		CreatedBy:   t.CreatedBy,
+		AssignedTo:  t.AssignedTo,
+		Priority:    t.Priority,
+		Labels:      slices.Clone(t.Labels),
+		ParentID:    t.ParentID,
+		ProjectID:   t.ProjectID,
+		Recurrence:  t.Recurrence.Clone(),
+	}
+	next.Recurrence.Occurrence++
+	for _, item := range t.Checklist {
+		item.Done = false
+		next.Checklist = append(next.Checklist, item)
+	}
+	return next, true
+}
+
+// up to n occurrences of t's series, starting with t itself; a task without a due date has none
+func (t Task) UpcomingOccurrences(n int) []Occurrence {
+	occurrences := []Occurrence{}
+	if t.DueDate.IsZero() || n < 1 {
+		return occurrences
+	}
+	rule := t.Recurrence.Clone()
+	if rule == nil {
+		return append(occurrences, Occurrence{Occurrence: 1, DueDate: t.DueDate})
+	}
+	rule.Occurrence = max(rule.Occurrence, 1)
+	occurrences = append(occurrences, Occurrence{Occurrence: rule.Occurrence, DueDate: t.DueDate})
+	for due := t.DueDate; len(occurrences) < n; {
+		next, ok := rule.Next(due)
+		if !ok {
+			break
+		}
+		rule.Occurrence++
+		due = next
+		occurrences = append(occurrences, Occurrence{Occurrence: rule.Occurrence, DueDate: due})
+	}
+	return occurrences
+}
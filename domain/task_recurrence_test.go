@@ -0,0 +1,124 @@
+package domain_test
+
+import (
+	"task7/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecurrenceNormalize(t *testing.T) {
+	rule := domain.Recurrence{Frequency: " Weekly", ByWeekday: []string{"th", "MO", "mo"}}
+	require.NoError(t, rule.Normalize())
+	assert.Equal(t, domain.FrequencyWeekly, rule.Frequency)
+	assert.Equal(t, 1, rule.Interval)
+	assert.Equal(t, []string{"MO", "TH"}, rule.ByWeekday)
+
+	until := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		rule domain.Recurrence
+	}{
+		{name: "Unknown frequency", rule: domain.Recurrence{Frequency: "yearly"}},
+		{name: "Interval too large", rule: domain.Recurrence{Frequency: domain.FrequencyDaily, Interval: domain.MaxRecurrenceInterval + 1}},
+		{name: "Negative interval", rule: domain.Recurrence{Frequency: domain.FrequencyDaily, Interval: -1}},
+		{name: "Weekdays on a monthly rule", rule: domain.Recurrence{Frequency: domain.FrequencyMonthly, ByWeekday: []string{"MO"}}},
+		{name: "Unknown weekday", rule: domain.Recurrence{Frequency: domain.FrequencyWeekly, ByWeekday: []string{"Monday"}}},
+		{name: "Negative count", rule: domain.Recurrence{Frequency: domain.FrequencyDaily, Count: -1}},
+		{name: "Until and count", rule: domain.Recurrence{Frequency: domain.FrequencyDaily, Count: 3, Until: &until}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.rule.Normalize(), domain.ErrInvalidRecurrence)
+		})
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	wednesday := time.Date(2025, 7, 2, 9, 30, 0, 0, time.UTC)
+	until := time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		rule domain.Recurrence
+		due  time.Time
+		want time.Time // zero when the series is over
+	}{
+		{name: "Daily", rule: domain.Recurrence{Frequency: domain.FrequencyDaily, Interval: 3}, due: wednesday, want: time.Date(2025, 7, 5, 9, 30, 0, 0, time.UTC)},
+		{name: "Weekly", rule: domain.Recurrence{Frequency: domain.FrequencyWeekly, Interval: 2}, due: wednesday, want: time.Date(2025, 7, 16, 9, 30, 0, 0, time.UTC)},
+		{name: "Weekly, later the same week", rule: domain.Recurrence{Frequency: domain.FrequencyWeekly, Interval: 2, ByWeekday: []string{"MO", "FR"}}, due: wednesday, want: time.Date(2025, 7, 4, 9, 30, 0, 0, time.UTC)},
+		{name: "Weekly, wrapping to a later week", rule: domain.Recurrence{Frequency: domain.FrequencyWeekly, Interval: 2, ByWeekday: []string{"MO", "WE"}}, due: wednesday, want: time.Date(2025, 7, 14, 9, 30, 0, 0, time.UTC)},
+		{name: "Monthly", rule: domain.Recurrence{Frequency: domain.FrequencyMonthly, Interval: 1}, due: wednesday, want: time.Date(2025, 8, 2, 9, 30, 0, 0, time.UTC)},
+		{name: "Monthly skips short months", rule: domain.Recurrence{Frequency: domain.FrequencyMonthly, Interval: 1}, due: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), want: time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "Within until", rule: domain.Recurrence{Frequency: domain.FrequencyWeekly, Interval: 1, Until: &until}, due: wednesday, want: time.Date(2025, 7, 9, 9, 30, 0, 0, time.UTC)},
+		{name: "Past until", rule: domain.Recurrence{Frequency: domain.FrequencyWeekly, Interval: 2, Until: &until}, due: wednesday},
+		{name: "Count used up", rule: domain.Recurrence{Frequency: domain.FrequencyDaily, Interval: 1, Count: 3, Occurrence: 3}, due: wednesday},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, ok := tt.rule.Next(tt.due)
+			assert.Equal(t, !tt.want.IsZero(), ok)
+			assert.Equal(t, tt.want, next)
+		})
+	}
+}
+
+func TestRecurrenceString(t *testing.T) {
+	until := time.Date(2025, 12, 31, 23, 0, 0, 0, time.UTC)
+	weekly := &domain.Recurrence{Frequency: domain.FrequencyWeekly, Interval: 2, ByWeekday: []string{"MO", "TH"}, Until: &until}
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;UNTIL=20251231T230000Z", weekly.String())
+	assert.Equal(t, "FREQ=DAILY;INTERVAL=1;COUNT=5", (&domain.Recurrence{Frequency: domain.FrequencyDaily, Interval: 1, Count: 5}).String())
+	assert.Empty(t, (*domain.Recurrence)(nil).String())
+}
+
+func TestNextOccurrence(t *testing.T) {
+	due := time.Date(2025, 7, 7, 9, 0, 0, 0, time.UTC)
+	task := domain.Task{
+		ID: 4, Title: "Ops review", DueDate: due, Status: domain.StatusDone, CreatedBy: "alice", AssignedTo: "bob",
+		Priority: domain.PriorityHigh, Labels: []string{"ops"}, BlockedBy: []int{2}, Version: 3, ProjectID: "p1",
+		Checklist:  []domain.ChecklistItem{{ID: 1, Text: "Check backups", Done: true}, {ID: 3, Text: "Rotate keys"}},
+		Recurrence: &domain.Recurrence{Frequency: domain.FrequencyWeekly, Interval: 1, Count: 4, Occurrence: 2},
+	}
+
+	next, ok := task.NextOccurrence()
+	require.True(t, ok)
+	assert.Equal(t, domain.Task{
+		Title: "Ops review", DueDate: due.AddDate(0, 0, 7), Status: domain.StatusTodo, CreatedBy: "alice", AssignedTo: "bob",
+		Priority: domain.PriorityHigh, Labels: []string{"ops"}, ProjectID: "p1",
+		Checklist:  []domain.ChecklistItem{{ID: 1, Text: "Check backups"}, {ID: 3, Text: "Rotate keys"}},
+		Recurrence: &domain.Recurrence{Frequency: domain.FrequencyWeekly, Interval: 1, Count: 4, Occurrence: 3},
+	}, next)
+	assert.Equal(t, 2, task.Recurrence.Occurrence, "the finished task is left alone")
+	assert.True(t, task.Checklist[0].Done)
+
+	task.Recurrence.Occurrence = 4
+	_, ok = task.NextOccurrence()
+	assert.False(t, ok, "the series is over after Count occurrences")
+	_, ok = domain.Task{DueDate: due}.NextOccurrence()
+	assert.False(t, ok, "a one-off task has no next occurrence")
+}
+
+func TestUpcomingOccurrences(t *testing.T) {
+	due := time.Date(2025, 7, 7, 9, 0, 0, 0, time.UTC)
+	task := domain.Task{DueDate: due, Recurrence: &domain.Recurrence{Frequency: domain.FrequencyDaily, Interval: 2, Count: 4, Occurrence: 2}}
+
+	assert.Equal(t, []domain.Occurrence{
+		{Occurrence: 2, DueDate: due},
+		{Occurrence: 3, DueDate: due.AddDate(0, 0, 2)},
+		{Occurrence: 4, DueDate: due.AddDate(0, 0, 4)},
+	}, task.UpcomingOccurrences(10), "the series ends after Count occurrences")
+	assert.Len(t, task.UpcomingOccurrences(2), 2)
+	assert.Equal(t, []domain.Occurrence{{Occurrence: 1, DueDate: due}}, domain.Task{DueDate: due}.UpcomingOccurrences(5))
+	assert.Empty(t, domain.Task{Recurrence: task.Recurrence}.UpcomingOccurrences(5))
+}
+
+func TestDiffTasks_Recurrence(t *testing.T) {
+	before := domain.Task{ID: 1, Recurrence: &domain.Recurrence{Frequency: domain.FrequencyWeekly, Interval: 1, Occurrence: 1}}
+	after := before.WithUpdate(domain.Task{Recurrence: &domain.Recurrence{}})
+
+	assert.Nil(t, after.Recurrence, "a rule without a frequency clears it")
+	assert.Equal(t, []domain.FieldChange{
+		{Field: "recurrence", Before: "FREQ=WEEKLY;INTERVAL=1", After: ""},
+	}, domain.DiffTasks(before, after))
+}
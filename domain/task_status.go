@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInvalidStatus           = NewValidation("invalid task status")
+	ErrInvalidStatusTransition = NewConflict("invalid task status transition")
+)
+
+type TaskStatus string
+
+const (
+	StatusTodo       TaskStatus = "todo"
+	StatusInProgress TaskStatus = "in_progress"
+	StatusBlocked    TaskStatus = "blocked"
+	StatusDone       TaskStatus = "done"
+	StatusArchived   TaskStatus = "archived"
+)
+
+// where a task may go from each status; archived is terminal
+var statusTransitions = map[TaskStatus][]TaskStatus{
+	StatusTodo:       {StatusInProgress, StatusBlocked, StatusArchived},
+	StatusInProgress: {StatusTodo, StatusBlocked, StatusDone},
+	StatusBlocked:    {StatusTodo, StatusInProgress},
+	StatusDone:       {StatusInProgress, StatusArchived},
+	StatusArchived:   {},
+}
+
+// spellings seen in older data and clients, mapped to the canonical status
+var statusAliases = map[string]TaskStatus{
+	"pending":     StatusTodo,
+	"open":        StatusTodo,
+	"started":     StatusInProgress,
+	"in_progress": StatusInProgress,
+	"completed":   StatusDone,
+	"complete":    StatusDone,
+	"closed":      StatusDone,
+}
+
+// canonicalises s ("Done", " in-progress ", "completed" ...) or fails with ErrInvalidStatus
+func ParseTaskStatus(s string) (TaskStatus, error) {
+	key := strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := statusTransitions[TaskStatus(key)]; ok {
+		return TaskStatus(key), nil
+	}
+	if status, ok := statusAliases[key]; ok {
+		return status, nil
+	}
+	return "", fmt.Errorf("%w: %q (expected one of %s)", ErrInvalidStatus, s, strings.Join(statusNames(AllTaskStatuses()), ", "))
+}
+
+// every canonical status, in workflow order
+func AllTaskStatuses() []TaskStatus {
+	return []TaskStatus{StatusTodo, StatusInProgress, StatusBlocked, StatusDone, StatusArchived}
+}
+
+func (s TaskStatus) Valid() bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+// whether work on the task is over: done or archived
+func (s TaskStatus) IsClosed() bool {
+	return s == StatusDone || s == StatusArchived
+}
+
+// staying put is always allowed
+func (s TaskStatus) CanTransitionTo(next TaskStatus) bool {
+	if s == next {
+		return true
+	}
+	for _, allowed := range statusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// checks s -> next, explaining what is allowed when it is not
+func (s TaskStatus) TransitionTo(next TaskStatus) error {
+	if s.CanTransitionTo(next) {
+		return nil
+	}
+	allowed := statusNames(statusTransitions[s])
+	sort.Strings(allowed)
+	if len(allowed) == 0 {
+		return fmt.Errorf("%w: %s tasks cannot change status", ErrInvalidStatusTransition, s)
+	}
+	return fmt.Errorf("%w: cannot move a task from %s to %s (allowed: %s)", ErrInvalidStatusTransition, s, next, strings.Join(allowed, ", "))
+}
+
+func statusNames(statuses []TaskStatus) []string {
+	names := make([]string, len(statuses))
+	for i, status := range statuses {
+		names[i] = string(status)
+	}
+	return names
+}
@@ -0,0 +1,75 @@
+package domain_test
+
+import (
+	"task7/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTaskStatus(t *testing.T) {
+	tests := map[string]domain.TaskStatus{
+		"todo":        domain.StatusTodo,
+		"Done":        domain.StatusDone,
+		"  DONE ":     domain.StatusDone,
+		"completed":   domain.StatusDone,
+		"pending":     domain.StatusTodo,
+		"in-progress": domain.StatusInProgress,
+		"In Progress": domain.StatusInProgress,
+		"in_progress": domain.StatusInProgress,
+		"blocked":     domain.StatusBlocked,
+		"archived":    domain.StatusArchived,
+	}
+	for input, want := range tests {
+		got, err := domain.ParseTaskStatus(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	for _, input := range []string{"", "someday", "done!"} {
+		_, err := domain.ParseTaskStatus(input)
+		assert.ErrorIs(t, err, domain.ErrInvalidStatus, input)
+	}
+}
+
+func TestTaskStatusTransitions(t *testing.T) {
+	tests := []struct {
+		from, to domain.TaskStatus
+		allowed  bool
+	}{
+		{domain.StatusTodo, domain.StatusInProgress, true},
+		{domain.StatusInProgress, domain.StatusDone, true},
+		{domain.StatusInProgress, domain.StatusBlocked, true},
+		{domain.StatusBlocked, domain.StatusInProgress, true},
+		{domain.StatusDone, domain.StatusArchived, true},
+		{domain.StatusDone, domain.StatusInProgress, true},
+		{domain.StatusDone, domain.StatusDone, true},
+		{domain.StatusTodo, domain.StatusDone, false},
+		{domain.StatusBlocked, domain.StatusDone, false},
+		{domain.StatusArchived, domain.StatusTodo, false},
+	}
+	for _, tt := range tests {
+		err := tt.from.TransitionTo(tt.to)
+		if tt.allowed {
+			assert.NoError(t, err, "%s -> %s", tt.from, tt.to)
+		} else {
+			assert.ErrorIs(t, err, domain.ErrInvalidStatusTransition, "%s -> %s", tt.from, tt.to)
+		}
+	}
+}
+
+func TestTaskStatusTransition_Message(t *testing.T) {
+	err := domain.StatusTodo.TransitionTo(domain.StatusDone)
+	assert.EqualError(t, err, "invalid task status transition: cannot move a task from todo to done (allowed: archived, blocked, in_progress)")
+
+	err = domain.StatusArchived.TransitionTo(domain.StatusTodo)
+	assert.EqualError(t, err, "invalid task status transition: archived tasks cannot change status")
+}
+
+func TestTaskStatus_IsClosed(t *testing.T) {
+	for _, status := range domain.AllTaskStatuses() {
+		closed := status == domain.StatusDone || status == domain.StatusArchived
+		assert.Equal(t, closed, status.IsClosed(), status)
+	}
+}
@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+var ErrInvalidRefreshToken = NewUnauthorized("invalid or expired refresh token")
+
+// a refresh token as stored; only its hash is kept, the raw value is handed to the client once. It
+// carries no role: a refresh signs the user's role as it is at that moment.
+type RefreshToken struct {
+	TokenHash string    `bson:"tokenhash"`
+	Username  string    `bson:"username"`
+	ExpiresAt time.Time `bson:"expiresat"`
+}
+
+// what a client gets back from login or refresh
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
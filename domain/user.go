@@ -7,4 +7,4 @@ type User struct {
 	Username     string             `bson:"username" json:"username"`
 	PasswordHash string             `bson:"passwordhash" json:"passwordhash"`
 	Role         string             `bson:"role" json:"role"`
-}
\ No newline at end of file
+}
@@ -1,46 +1,50 @@
 package infrastructure
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"strings"
+	"task7/domain"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GetJWTSecret() []byte {
-	return jwtSecret
-}
-
-func SetJWTSecret(secret []byte) {
-	jwtSecret = secret
-}
-
-func AdminAuth() gin.HandlerFunc {
+func AdminAuth(logger *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
 		if !exists || role != "admin" {
-			c.JSON(403, gin.H{"error": "Admin access only"})
-			c.Abort()
+			WriteProblem(c, logger, fmt.Errorf("%w: admin access only", domain.ErrForbidden))
 			return
 		}
 		c.Next()
 	}
 }
 
-func AuthMiddleware() gin.HandlerFunc {
+// looks up whether an access token id has been revoked (by logout)
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// validates the bearer token against secret; a nil checker skips the revocation lookup.
+// Rejections are counted in metrics by reason and answered as problem details.
+func AuthMiddleware(secret []byte, checker RevocationChecker, metrics *Metrics, logger *slog.Logger) gin.HandlerFunc {
+	unauthenticated := func(c *gin.Context, reason string) {
+		WriteProblem(c, logger, fmt.Errorf("%w: %s", domain.ErrUnauthenticated, reason))
+	}
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(401, gin.H{"error": "Authorization header missing"})
-			c.Abort()
+			metrics.ObserveTokenValidationFailure("missing_header")
+			unauthenticated(c, "authorization header missing")
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.JSON(401, gin.H{"error": "Invalid Authorization header format"})
-			c.Abort()
+			metrics.ObserveTokenValidationFailure("malformed_header")
+			unauthenticated(c, "invalid authorization header format")
 			return
 		}
 
@@ -49,24 +53,47 @@ func AuthMiddleware() gin.HandlerFunc {
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 			}
-			return jwtSecret, nil
+			return secret, nil
 		})
 
 		if err != nil || token == nil || !token.Valid {
-			c.JSON(401, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+			metrics.ObserveTokenValidationFailure("invalid_token")
+			unauthenticated(c, "invalid or expired token")
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.JSON(401, gin.H{"error": "Failed to parse claims"})
-			c.Abort()
+			metrics.ObserveTokenValidationFailure("invalid_claims")
+			unauthenticated(c, "failed to parse claims")
 			return
 		}
 
+		jti, _ := claims["jti"].(string)
+		if checker != nil && jti != "" {
+			revoked, err := checker.IsRevoked(c.Request.Context(), jti)
+			if err != nil {
+				WriteProblem(c, logger, domain.NewInternal(err, "could not verify token"))
+				return
+			}
+			if revoked {
+				metrics.ObserveTokenValidationFailure("revoked")
+				unauthenticated(c, "invalid or expired token")
+				return
+			}
+		}
+
 		c.Set("username", claims["username"])
 		c.Set("role", claims["role"])
+		c.Set("jti", jti)
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			c.Set("token_expires_at", exp.Time)
+		}
+
+		// services read the caller from the request context rather than from gin
+		username, _ := claims["username"].(string)
+		role, _ := claims["role"].(string)
+		c.Request = c.Request.WithContext(domain.WithActor(c.Request.Context(), domain.Actor{Username: username, Role: role}))
 
 		c.Next()
 
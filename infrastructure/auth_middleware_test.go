@@ -1,11 +1,14 @@
 package infrastructure_test
 
 import (
+	"context"
 	"encoding/base64"
-	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"task7/domain"
 	"task7/infrastructure"
 	"testing"
 	"time"
@@ -18,6 +21,8 @@ import (
 
 var testSecret = []byte("supersecretkeyforunittests123")
 
+var discardLogger = slog.New(slog.DiscardHandler)
+
 func createTestContext(w *httptest.ResponseRecorder) *gin.Context {
 	c, _ := gin.CreateTestContext(w)
 	return c
@@ -38,47 +43,42 @@ func generateTestToken(t *testing.T, username, role string, expiration time.Time
 func TestAuthMiddleware(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	originalSecret := infrastructure.GetJWTSecret()
-	defer func() {
-		infrastructure.SetJWTSecret(originalSecret)
-	}()
-
 	tests := []struct {
-		name         string
-		authHeader   string
-		expectedCode int
-		expectedBody string
-		assertNext   bool
-		expectedUser string
-		expectedRole string
+		name           string
+		authHeader     string
+		expectedCode   int
+		expectedDetail string
+		assertNext     bool
+		expectedUser   string
+		expectedRole   string
 	}{
 		{
-			name:         "Missing Authorization Header",
-			authHeader:   "",
-			expectedCode: http.StatusUnauthorized,
-			expectedBody: `{"error":"Authorization header missing"}`,
-			assertNext:   false,
+			name:           "Missing Authorization Header",
+			authHeader:     "",
+			expectedCode:   http.StatusUnauthorized,
+			expectedDetail: "authentication required: authorization header missing",
+			assertNext:     false,
 		},
 		{
-			name:         "Invalid Authorization Header Format - No Bearer",
-			authHeader:   "InvalidToken",
-			expectedCode: http.StatusUnauthorized,
-			expectedBody: `{"error":"Invalid Authorization header format"}`,
-			assertNext:   false,
+			name:           "Invalid Authorization Header Format - No Bearer",
+			authHeader:     "InvalidToken",
+			expectedCode:   http.StatusUnauthorized,
+			expectedDetail: "authentication required: invalid authorization header format",
+			assertNext:     false,
 		},
 		{
-			name:         "Invalid Authorization Header Format - Missing Token",
-			authHeader:   "Bearer",
-			expectedCode: http.StatusUnauthorized,
-			expectedBody: `{"error":"Invalid Authorization header format"}`,
-			assertNext:   false,
+			name:           "Invalid Authorization Header Format - Missing Token",
+			authHeader:     "Bearer",
+			expectedCode:   http.StatusUnauthorized,
+			expectedDetail: "authentication required: invalid authorization header format",
+			assertNext:     false,
 		},
 		{
-			name:         "Malformed JWT",
-			authHeader:   "Bearer abc.def.ghi",
-			expectedCode: http.StatusUnauthorized,
-			expectedBody: `{"error":"Invalid or expired token"}`,
-			assertNext:   false,
+			name:           "Malformed JWT",
+			authHeader:     "Bearer abc.def.ghi",
+			expectedCode:   http.StatusUnauthorized,
+			expectedDetail: "authentication required: invalid or expired token",
+			assertNext:     false,
 		},
 		{
 			name: "Expired JWT",
@@ -86,9 +86,9 @@ func TestAuthMiddleware(t *testing.T) {
 				token := generateTestToken(t, "testuser", "regular", time.Now().Add(-1*time.Hour))
 				return "Bearer " + token
 			}(),
-			expectedCode: http.StatusUnauthorized,
-			expectedBody: `{"error":"Invalid or expired token"}`,
-			assertNext:   false,
+			expectedCode:   http.StatusUnauthorized,
+			expectedDetail: "authentication required: invalid or expired token",
+			assertNext:     false,
 		},
 		{
 			name: "Valid JWT - User Role",
@@ -125,9 +125,9 @@ func TestAuthMiddleware(t *testing.T) {
 				tokenString, _ := token.SignedString(otherSecret)
 				return "Bearer " + tokenString
 			}(),
-			expectedCode: http.StatusUnauthorized,
-			expectedBody: `{"error":"Invalid or expired token"}`,
-			assertNext:   false,
+			expectedCode:   http.StatusUnauthorized,
+			expectedDetail: "authentication required: invalid or expired token",
+			assertNext:     false,
 		},
 		{
 			name: "JWT with Missing Username Claim",
@@ -168,26 +168,27 @@ func TestAuthMiddleware(t *testing.T) {
 				payload := `{"username":"testuser","role":"regular","exp":` + `1234567890` + `}` // dummy exp
 				return "Bearer " + base64Encode(header) + "." + base64Encode(payload) + ".fakesignature"
 			}(),
-			expectedCode: http.StatusUnauthorized,
-			expectedBody: `{"error":"Invalid or expired token"}`,
-			assertNext:   false,
+			expectedCode:   http.StatusUnauthorized,
+			expectedDetail: "authentication required: invalid or expired token",
+			assertNext:     false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			infrastructure.SetJWTSecret(testSecret)
-
 			w := httptest.NewRecorder()
 			r := gin.New()
 
 			nextCalled := false
-			r.Use(infrastructure.AuthMiddleware())
+			r.Use(infrastructure.AuthMiddleware(testSecret, nil, nil, discardLogger))
 			r.GET("/", func(c *gin.Context) {
 				nextCalled = true
 				if tt.assertNext {
 					assert.Equal(t, tt.expectedUser, c.GetString("username"))
 					assert.Equal(t, tt.expectedRole, c.GetString("role"))
+					actor, ok := domain.ActorFromContext(c.Request.Context())
+					assert.True(t, ok, "actor should be stored on the request context")
+					assert.Equal(t, domain.Actor{Username: tt.expectedUser, Role: tt.expectedRole}, actor)
 					c.Status(http.StatusOK)
 				}
 			})
@@ -197,16 +198,8 @@ func TestAuthMiddleware(t *testing.T) {
 			r.ServeHTTP(w, req)
 
 			if !tt.assertNext {
-				assert.Equal(t, tt.expectedCode, w.Code)
-				var actualBody map[string]string
-				err := json.Unmarshal(w.Body.Bytes(), &actualBody)
-				require.NoError(t, err, "Failed to unmarshal response body")
-
-				var expectedBody map[string]string
-				err = json.Unmarshal([]byte(tt.expectedBody), &expectedBody)
-				require.NoError(t, err, "Failed to unmarshal expected body string")
-
-				assert.Equal(t, expectedBody, actualBody)
+				problem := requireProblem(t, w, tt.expectedCode, string(domain.KindUnauthorized))
+				assert.Equal(t, tt.expectedDetail, problem.Detail)
 				assert.False(t, nextCalled, "next handler should not be called")
 			} else {
 				assert.True(t, nextCalled, "next handler should be called")
@@ -225,46 +218,46 @@ func TestAdminAuth(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	tests := []struct {
-		name         string
-		setRole      interface{}
-		expectedCode int
-		expectedBody string
-		assertNext   bool
+		name           string
+		setRole        interface{}
+		expectedCode   int
+		expectedDetail string
+		assertNext     bool
 	}{
 		{
-			name:         "No role in context",
-			setRole:      nil,
-			expectedCode: http.StatusForbidden,
-			expectedBody: `{"error":"Admin access only"}`,
-			assertNext:   false,
+			name:           "No role in context",
+			setRole:        nil,
+			expectedCode:   http.StatusForbidden,
+			expectedDetail: "forbidden: admin access only",
+			assertNext:     false,
 		},
 		{
-			name:         "Role is not admin (regular user)",
-			setRole:      "regular",
-			expectedCode: http.StatusForbidden,
-			expectedBody: `{"error":"Admin access only"}`,
-			assertNext:   false,
+			name:           "Role is not admin (regular user)",
+			setRole:        "regular",
+			expectedCode:   http.StatusForbidden,
+			expectedDetail: "forbidden: admin access only",
+			assertNext:     false,
 		},
 		{
-			name:         "Role is admin",
-			setRole:      "admin",
-			expectedCode: http.StatusOK,
-			expectedBody: "",
-			assertNext:   true,
+			name:           "Role is admin",
+			setRole:        "admin",
+			expectedCode:   http.StatusOK,
+			expectedDetail: "",
+			assertNext:     true,
 		},
 		{
-			name:         "Role is wrong type (e.g., int)",
-			setRole:      123,
-			expectedCode: http.StatusForbidden,
-			expectedBody: `{"error":"Admin access only"}`,
-			assertNext:   false,
+			name:           "Role is wrong type (e.g., int)",
+			setRole:        123,
+			expectedCode:   http.StatusForbidden,
+			expectedDetail: "forbidden: admin access only",
+			assertNext:     false,
 		},
 		{
-			name:         "Role is empty string",
-			setRole:      "",
-			expectedCode: http.StatusForbidden,
-			expectedBody: `{"error":"Admin access only"}`,
-			assertNext:   false,
+			name:           "Role is empty string",
+			setRole:        "",
+			expectedCode:   http.StatusForbidden,
+			expectedDetail: "forbidden: admin access only",
+			assertNext:     false,
 		},
 	}
 
@@ -280,7 +273,7 @@ func TestAdminAuth(t *testing.T) {
 				}
 				c.Next()
 			})
-			r.Use(infrastructure.AdminAuth())
+			r.Use(infrastructure.AdminAuth(discardLogger))
 			r.GET("/", func(c *gin.Context) {
 				nextCalled = true
 				c.Status(http.StatusOK)
@@ -290,16 +283,8 @@ func TestAdminAuth(t *testing.T) {
 			r.ServeHTTP(w, req)
 
 			if !tt.assertNext {
-				assert.Equal(t, tt.expectedCode, w.Code)
-				var actualBody map[string]string
-				err := json.Unmarshal(w.Body.Bytes(), &actualBody)
-				require.NoError(t, err, "Failed to unmarshal response body")
-
-				var expectedBody map[string]string
-				err = json.Unmarshal([]byte(tt.expectedBody), &expectedBody)
-				require.NoError(t, err, "Failed to unmarshal expected body string")
-
-				assert.Equal(t, expectedBody, actualBody)
+				problem := requireProblem(t, w, tt.expectedCode, string(domain.KindForbidden))
+				assert.Equal(t, tt.expectedDetail, problem.Detail)
 				assert.False(t, nextCalled, "next handler should not be called")
 			} else {
 				assert.True(t, nextCalled, "next handler should be called")
@@ -309,3 +294,80 @@ func TestAdminAuth(t *testing.T) {
 		})
 	}
 }
+
+type fakeRevocationChecker struct {
+	revoked map[string]bool
+	err     error
+}
+
+func (f fakeRevocationChecker) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return f.revoked[jti], f.err
+}
+
+func TestAuthMiddleware_Revocation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	signWithJTI := func(jti string) string {
+		claims := jwt.MapClaims{"username": "user1", "role": "regular", "jti": jti, "exp": time.Now().Add(time.Hour).Unix()}
+		tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testSecret)
+		require.NoError(t, err)
+		return "Bearer " + tokenString
+	}
+
+	tests := []struct {
+		name         string
+		checker      fakeRevocationChecker
+		jti          string
+		expectedCode int
+		expectedKind domain.ErrorKind
+	}{
+		{name: "Not revoked", checker: fakeRevocationChecker{revoked: map[string]bool{"other": true}}, jti: "live", expectedCode: http.StatusOK},
+		{name: "Revoked", checker: fakeRevocationChecker{revoked: map[string]bool{"dead": true}}, jti: "dead", expectedCode: http.StatusUnauthorized, expectedKind: domain.KindUnauthorized},
+		{name: "Checker fails", checker: fakeRevocationChecker{err: errors.New("store down")}, jti: "live", expectedCode: http.StatusInternalServerError, expectedKind: domain.KindInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := gin.New()
+			r.Use(infrastructure.AuthMiddleware(testSecret, tt.checker, nil, discardLogger))
+			r.GET("/", func(c *gin.Context) {
+				assert.Equal(t, tt.jti, c.GetString("jti"))
+				assert.False(t, c.GetTime("token_expires_at").IsZero(), "expiry should be exposed for logout")
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", signWithJTI(tt.jti))
+			r.ServeHTTP(w, req)
+
+			if tt.expectedKind == "" {
+				assert.Equal(t, tt.expectedCode, w.Code)
+				return
+			}
+			problem := requireProblem(t, w, tt.expectedCode, string(tt.expectedKind))
+			assert.NotContains(t, problem.Detail, "store down", "Store failures are logged, not shown")
+		})
+	}
+}
+
+func TestAuthMiddleware_CountsValidationFailures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	metrics := infrastructure.NewMetrics()
+	r := gin.New()
+	r.Use(infrastructure.AuthMiddleware(testSecret, nil, metrics, discardLogger))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, header := range []string{"", "Token abc", "Bearer not.a.jwt", "Bearer " + generateTestToken(t, "user1", "regular", time.Now().Add(-time.Hour)), "Bearer " + generateTestToken(t, "user1", "regular", time.Now().Add(time.Hour))} {
+		req, _ := http.NewRequest("GET", "/", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	out := scrape(t, metrics)
+	assert.Contains(t, out, `taskmanager_token_validation_failures_total{reason="missing_header"} 1`)
+	assert.Contains(t, out, `taskmanager_token_validation_failures_total{reason="malformed_header"} 1`)
+	assert.Contains(t, out, `taskmanager_token_validation_failures_total{reason="invalid_token"} 2`, "garbage and expired tokens")
+}
@@ -1,33 +1,40 @@
 package infrastructure
 
 import (
-	"os"
+	"crypto/rand"
+	"encoding/hex"
 	"task7/domain"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/joho/godotenv"
 )
 
-func init() {
-	godotenv.Load()
+// signs access tokens; they are short-lived and clients renew them with a refresh token
+type JwtToken struct {
+	secret []byte
+	ttl    time.Duration
 }
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
-
-type JwtToken struct{}
-
-func NewJwtToken() *JwtToken {
-	return &JwtToken{}
+func NewJwtToken(secret []byte, ttl time.Duration) *JwtToken {
+	return &JwtToken{
+		secret: secret,
+		ttl:    ttl,
+	}
 }
 
+// every token gets a random jti so it can be revoked individually
 func (j *JwtToken) GenerateToken(user *domain.User) (string, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
 	claims := jwt.MapClaims{
 		"username": user.Username,
 		"role":     user.Role,
-		"exp":      time.Now().Add(2 * time.Hour).Unix(),
+		"jti":      hex.EncodeToString(jti),
+		"exp":      time.Now().Add(j.ttl).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return token.SignedString(j.secret)
 }
@@ -0,0 +1,42 @@
+package infrastructure_test
+
+import (
+	"task7/domain"
+	"task7/infrastructure"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseTestToken(t *testing.T, tokenString string) jwt.MapClaims {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(*jwt.Token) (interface{}, error) {
+		return testSecret, nil
+	})
+	require.NoError(t, err, "Generated token should verify with the configured secret")
+	return claims
+}
+
+func TestJwtToken_GenerateToken(t *testing.T) {
+	signer := infrastructure.NewJwtToken(testSecret, 20*time.Minute)
+
+	user := &domain.User{Username: "user1", Role: "regular"}
+	first, err := signer.GenerateToken(user)
+	require.NoError(t, err)
+	second, err := signer.GenerateToken(user)
+	require.NoError(t, err)
+
+	claims := parseTestToken(t, first)
+	assert.Equal(t, "user1", claims["username"])
+	assert.Equal(t, "regular", claims["role"])
+
+	exp, err := claims.GetExpirationTime()
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(20*time.Minute), exp.Time, 5*time.Second)
+
+	assert.NotEmpty(t, claims["jti"])
+	assert.NotEqual(t, claims["jti"], parseTestToken(t, second)["jti"], "each token needs its own jti")
+}
@@ -0,0 +1,126 @@
+package infrastructure_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task7/domain"
+	"task7/infrastructure"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the JSON log lines written to buf, one map per record
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	for _, raw := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if raw == "" {
+			continue
+		}
+		var line map[string]any
+		require.NoError(t, json.Unmarshal([]byte(raw), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestNewLogger_AddsRequestContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := infrastructure.NewLogger(&buf, slog.LevelInfo)
+
+	ctx := domain.WithRequestID(context.Background(), "req-1")
+	ctx = domain.WithActor(ctx, domain.Actor{Username: "alice", Role: "user"})
+	logger.InfoContext(ctx, "task created", slog.Int("task_id", 3))
+	logger.With(slog.String("component", "test")).InfoContext(context.Background(), "no request")
+	logger.DebugContext(ctx, "below the level")
+
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "task created", lines[0]["msg"])
+	assert.Equal(t, "req-1", lines[0]["request_id"])
+	assert.Equal(t, "alice", lines[0]["username"])
+	assert.EqualValues(t, 3, lines[0]["task_id"])
+
+	assert.Equal(t, "test", lines[1]["component"], "derived loggers keep the handler")
+	assert.NotContains(t, lines[1], "request_id")
+	assert.NotContains(t, lines[1], "username")
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var seen string
+	r := gin.New()
+	r.Use(infrastructure.RequestIDMiddleware())
+	r.GET("/", func(c *gin.Context) {
+		seen = domain.RequestIDFromContext(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "Valid id is propagated", incoming: "abc-123", keep: true},
+		{name: "Missing id is generated", incoming: ""},
+		{name: "Id with spaces is replaced", incoming: "abc 123"},
+		{name: "Overlong id is replaced", incoming: strings.Repeat("a", 129)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(infrastructure.RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			echoed := w.Header().Get(infrastructure.RequestIDHeader)
+			assert.Equal(t, seen, echoed, "the response carries the id the handlers saw")
+			if tt.keep {
+				assert.Equal(t, tt.incoming, echoed)
+			} else {
+				assert.Len(t, echoed, 32)
+				assert.NotEqual(t, tt.incoming, echoed)
+			}
+		})
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	r := gin.New()
+	r.Use(infrastructure.RequestIDMiddleware(), infrastructure.RequestLogger(infrastructure.NewLogger(&buf, slog.LevelInfo)))
+	r.GET("/tasks/:id", func(c *gin.Context) {
+		// stands in for AuthMiddleware, which runs after the logger
+		c.Request = c.Request.WithContext(domain.WithActor(c.Request.Context(), domain.Actor{Username: "bob"}))
+		c.Status(http.StatusOK)
+	})
+	r.GET("/boom", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	req := httptest.NewRequest("GET", "/tasks/7", nil)
+	req.Header.Set(infrastructure.RequestIDHeader, "req-7")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/boom", nil))
+
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "http request", lines[0]["msg"])
+	assert.Equal(t, "INFO", lines[0]["level"])
+	assert.Equal(t, "/tasks/7", lines[0]["path"])
+	assert.Equal(t, "/tasks/:id", lines[0]["route"])
+	assert.EqualValues(t, 200, lines[0]["status"])
+	assert.Equal(t, "req-7", lines[0]["request_id"])
+	assert.Equal(t, "bob", lines[0]["username"])
+
+	assert.Equal(t, "ERROR", lines[1]["level"], "server errors are logged at error level")
+	assert.NotEmpty(t, lines[1]["request_id"])
+}
@@ -0,0 +1,122 @@
+package infrastructure
+
+import (
+	"net/http"
+	"strconv"
+	"task7/domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "taskmanager"
+
+// the service's Prometheus collectors, kept on their own registry; a nil *Metrics records nothing,
+// so the pieces that report into it work unchanged in tests
+type Metrics struct {
+	registry                *prometheus.Registry
+	httpRequests            *prometheus.CounterVec
+	httpDuration            *prometheus.HistogramVec
+	logins                  *prometheus.CounterVec
+	tokenValidationFailures *prometheus.CounterVec
+	repositoryDuration      *prometheus.HistogramVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route template and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "logins_total",
+			Help:      "Login attempts by result (success or failure).",
+		}, []string{"result"}),
+		tokenValidationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "token_validation_failures_total",
+			Help:      "Requests rejected by the auth middleware, by reason.",
+		}, []string{"reason"}),
+		repositoryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "repository_call_duration_seconds",
+			Help:      "Repository call latency by repository, method and result (ok, or the domain error kind).",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"repository", "method", "result"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.logins,
+		m.tokenValidationFailures,
+		m.repositoryDuration,
+	)
+	return m
+}
+
+// serves the registry in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// times every request; routes are labelled by template (/tasks/:id) to keep cardinality bounded
+func (m *Metrics) GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		if m == nil {
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		m.httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		m.httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (m *Metrics) ObserveLogin(success bool) {
+	if m == nil {
+		return
+	}
+	result := "failure"
+	if success {
+		result = "success"
+	}
+	m.logins.WithLabelValues(result).Inc()
+}
+
+func (m *Metrics) ObserveTokenValidationFailure(reason string) {
+	if m == nil {
+		return
+	}
+	m.tokenValidationFailures.WithLabelValues(reason).Inc()
+}
+
+func (m *Metrics) ObserveRepositoryCall(repository, method string, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	result := "ok"
+	if err != nil {
+		result = string(domain.KindOf(err)) // not_found is routine, internal is not
+	}
+	m.repositoryDuration.WithLabelValues(repository, method, result).Observe(duration.Seconds())
+}
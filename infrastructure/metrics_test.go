@@ -0,0 +1,87 @@
+package infrastructure_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"task7/domain"
+	"task7/infrastructure"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the /metrics output, in the Prometheus text format
+func scrape(t *testing.T, metrics *infrastructure.Metrics) string {
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+func TestMetrics_GinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	metrics := infrastructure.NewMetrics()
+	r := gin.New()
+	r.Use(metrics.GinMiddleware())
+	r.GET("/tasks/:id", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+
+	for _, path := range []string{"/tasks/1", "/tasks/2", "/nope"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	out := scrape(t, metrics)
+	assert.Contains(t, out, `taskmanager_http_requests_total{method="GET",route="/tasks/:id",status="404"} 2`, "routes are labelled by template, not by path")
+	assert.Contains(t, out, `taskmanager_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, out, `taskmanager_http_request_duration_seconds_count{method="GET",route="/tasks/:id",status="404"} 2`)
+}
+
+func TestMetrics_GinMiddlewareCountsPanics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	metrics := infrastructure.NewMetrics()
+	r := gin.New()
+	r.Use(metrics.GinMiddleware(), gin.Recovery()) // the order the router uses
+	r.GET("/boom", func(c *gin.Context) { panic("handler bug") })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/boom", nil))
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	out := scrape(t, metrics)
+	assert.Contains(t, out, `taskmanager_http_requests_total{method="GET",route="/boom",status="500"} 1`, "a request that panicked is still counted")
+	assert.Contains(t, out, `taskmanager_http_request_duration_seconds_count{method="GET",route="/boom",status="500"} 1`)
+}
+
+func TestMetrics_Observers(t *testing.T) {
+	metrics := infrastructure.NewMetrics()
+
+	metrics.ObserveLogin(true)
+	metrics.ObserveLogin(false)
+	metrics.ObserveLogin(false)
+	metrics.ObserveTokenValidationFailure("revoked")
+	metrics.ObserveRepositoryCall("task", "GetTaskById", time.Millisecond, nil)
+	metrics.ObserveRepositoryCall("task", "GetTaskById", time.Millisecond, domain.NewNotFound("no task found with id 9"))
+
+	out := scrape(t, metrics)
+	assert.Contains(t, out, `taskmanager_logins_total{result="success"} 1`)
+	assert.Contains(t, out, `taskmanager_logins_total{result="failure"} 2`)
+	assert.Contains(t, out, `taskmanager_token_validation_failures_total{reason="revoked"} 1`)
+	assert.Contains(t, out, `taskmanager_repository_call_duration_seconds_count{method="GetTaskById",repository="task",result="ok"} 1`)
+	assert.Contains(t, out, `taskmanager_repository_call_duration_seconds_count{method="GetTaskById",repository="task",result="not_found"} 1`)
+}
+
+func TestMetrics_NilIsANoOp(t *testing.T) {
+	var metrics *infrastructure.Metrics
+
+	assert.NotPanics(t, func() {
+		metrics.ObserveLogin(true)
+		metrics.ObserveTokenValidationFailure("revoked")
+		metrics.ObserveRepositoryCall("task", "GetAllTasks", time.Millisecond, nil)
+
+		r := gin.New()
+		r.Use(metrics.GinMiddleware())
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+}
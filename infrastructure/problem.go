@@ -0,0 +1,74 @@
+package infrastructure
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"task7/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+const problemContentType = "application/problem+json"
+
+// RFC 7807 problem details; Code repeats the domain error kind for clients that switch on it
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+}
+
+var kindStatus = map[domain.ErrorKind]int{
+	domain.KindBadRequest:   http.StatusBadRequest,
+	domain.KindValidation:   http.StatusUnprocessableEntity,
+	domain.KindUnauthorized: http.StatusUnauthorized,
+	domain.KindForbidden:    http.StatusForbidden,
+	domain.KindNotFound:     http.StatusNotFound,
+	domain.KindConflict:     http.StatusConflict,
+	domain.KindInternal:     http.StatusInternalServerError,
+
+	domain.KindPreconditionFailed:   http.StatusPreconditionFailed,
+	domain.KindPreconditionRequired: http.StatusPreconditionRequired,
+
+	domain.KindTooLarge:        http.StatusRequestEntityTooLarge,
+	domain.KindUnsupportedType: http.StatusUnsupportedMediaType,
+}
+
+// the one place errors become HTTP responses, for handlers and middleware alike; internal errors are
+// logged and never shown to clients
+func WriteProblem(c *gin.Context, logger *slog.Logger, err error) {
+	kind := domain.KindOf(err)
+	status, ok := kindStatus[kind]
+	if !ok {
+		kind, status = domain.KindInternal, http.StatusInternalServerError
+	}
+
+	detail := "An unexpected error occurred"
+	if kind == domain.KindInternal {
+		logger.ErrorContext(c.Request.Context(), "request failed", slog.String("method", c.Request.Method), slog.String("path", c.Request.URL.Path), slog.Any("error", err))
+	} else {
+		detail = problemDetail(err)
+	}
+
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(status, Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+		Code:     string(kind),
+	})
+}
+
+// the client-facing text of err: the whole chain, unless a typed error hides its cause
+func problemDetail(err error) string {
+	var typed *domain.Error
+	if errors.As(err, &typed) && typed.Err != nil {
+		return typed.Message
+	}
+	return err.Error()
+}
@@ -1,7 +0,0 @@
-package infrastructure
-
-import "task7/domain"
-
-type TokenGenerator interface {
-	GenerateToken(user *domain.User) (string, error)
-}
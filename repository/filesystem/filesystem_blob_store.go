@@ -0,0 +1,103 @@
+// Package filesystem keeps blobs as files in a local directory.
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"task7/domain"
+)
+
+type FileSystemBlobStore struct { // local-filesystem implementer, one file per blob
+	Dir string
+}
+
+// a store rooted at dir, which is created if it does not exist yet
+func NewFileSystemBlobStore(dir string) (*FileSystemBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("creating blob directory: %w", err)
+	}
+	return &FileSystemBlobStore{Dir: dir}, nil
+}
+
+// keys become file names, so anything that could step outside Dir is refused
+var validKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func (s *FileSystemBlobStore) path(key string) (string, error) {
+	if !validKey.MatchString(key) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.Dir, key), nil
+}
+
+// stops a copy once ctx is done, so a cancelled upload does not keep writing
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// writes to a temporary file first, so a failed upload never leaves a partial blob behind
+func (s *FileSystemBlobStore) Put(ctx context.Context, key string, content io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.Dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly once the file has been renamed
+
+	if _, err := io.Copy(tmp, contextReader{ctx: ctx, r: content}); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *FileSystemBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, domain.NewNotFound("no blob stored under %s", key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *FileSystemBlobStore) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package filesystem_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"task7/repository/filesystem"
+	"task7/repository/interfaces"
+	"task7/repository/repotest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestFileSystemBlobStoreConformance(t *testing.T) {
+	suite.Run(t, &repotest.BlobStoreSuite{
+		NewStore: func() interfaces.BlobStore {
+			store, err := filesystem.NewFileSystemBlobStore(t.TempDir())
+			require.NoError(t, err)
+			return store
+		},
+	})
+}
+
+func TestFileSystemBlobStore_CreatesDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "blobs")
+
+	store, err := filesystem.NewFileSystemBlobStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(context.Background(), "k", bytes.NewBufferString("hello")))
+
+	content, err := os.ReadFile(filepath.Join(dir, "k"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+}
+
+func TestFileSystemBlobStore_RefusesKeysOutsideDir(t *testing.T) {
+	parent := t.TempDir()
+	store, err := filesystem.NewFileSystemBlobStore(filepath.Join(parent, "blobs"))
+	require.NoError(t, err)
+
+	for _, key := range []string{"../escape", "a/b", "", "."} {
+		assert.Error(t, store.Put(context.Background(), key, bytes.NewBufferString("x")), "key %q", key)
+	}
+	_, err = os.Stat(filepath.Join(parent, "escape"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,62 @@
+package instrumented
+
+import (
+	"context"
+	"log/slog"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+)
+
+// an AttachmentRepository decorator that times every call of the repository it wraps and logs it at debug level
+type AttachmentRepository struct {
+	next     interfaces.AttachmentRepository
+	observer Observer
+	logger   *slog.Logger
+}
+
+func NewAttachmentRepository(next interfaces.AttachmentRepository, observer Observer, logger *slog.Logger) *AttachmentRepository {
+	return &AttachmentRepository{
+		next:     next,
+		observer: observer,
+		logger:   logger,
+	}
+}
+
+func (r *AttachmentRepository) observe(ctx context.Context, method string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	r.observer.ObserveRepositoryCall("attachment", method, elapsed, err)
+	attrs := []slog.Attr{slog.String("repository", "attachment"), slog.String("method", method), slog.Duration("duration", elapsed)}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	r.logger.LogAttrs(ctx, slog.LevelDebug, "repository call", attrs...)
+}
+
+func (r *AttachmentRepository) CreateAttachment(ctx context.Context, attachment domain.Attachment) error {
+	start := time.Now()
+	err := r.next.CreateAttachment(ctx, attachment)
+	r.observe(ctx, "CreateAttachment", start, err)
+	return err
+}
+
+func (r *AttachmentRepository) GetAttachment(ctx context.Context, id string) (domain.Attachment, error) {
+	start := time.Now()
+	attachment, err := r.next.GetAttachment(ctx, id)
+	r.observe(ctx, "GetAttachment", start, err)
+	return attachment, err
+}
+
+func (r *AttachmentRepository) ListAttachments(ctx context.Context, taskID int) ([]domain.Attachment, error) {
+	start := time.Now()
+	attachments, err := r.next.ListAttachments(ctx, taskID)
+	r.observe(ctx, "ListAttachments", start, err)
+	return attachments, err
+}
+
+func (r *AttachmentRepository) DeleteAttachment(ctx context.Context, id string) error {
+	start := time.Now()
+	err := r.next.DeleteAttachment(ctx, id)
+	r.observe(ctx, "DeleteAttachment", start, err)
+	return err
+}
@@ -0,0 +1,48 @@
+package instrumented
+
+import (
+	"context"
+	"log/slog"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+)
+
+// an AuditRepository decorator that times every call of the repository it wraps and logs it at debug level
+type AuditRepository struct {
+	next     interfaces.AuditRepository
+	observer Observer
+	logger   *slog.Logger
+}
+
+func NewAuditRepository(next interfaces.AuditRepository, observer Observer, logger *slog.Logger) *AuditRepository {
+	return &AuditRepository{
+		next:     next,
+		observer: observer,
+		logger:   logger,
+	}
+}
+
+func (r *AuditRepository) observe(ctx context.Context, method string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	r.observer.ObserveRepositoryCall("audit", method, elapsed, err)
+	attrs := []slog.Attr{slog.String("repository", "audit"), slog.String("method", method), slog.Duration("duration", elapsed)}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	r.logger.LogAttrs(ctx, slog.LevelDebug, "repository call", attrs...)
+}
+
+func (r *AuditRepository) Append(ctx context.Context, entry *domain.AuditEntry) error {
+	start := time.Now()
+	err := r.next.Append(ctx, entry)
+	r.observe(ctx, "Append", start, err)
+	return err
+}
+
+func (r *AuditRepository) QueryAudit(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEntry, int64, error) {
+	start := time.Now()
+	entries, total, err := r.next.QueryAudit(ctx, query)
+	r.observe(ctx, "QueryAudit", start, err)
+	return entries, total, err
+}
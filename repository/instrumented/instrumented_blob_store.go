@@ -0,0 +1,56 @@
+package instrumented
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"task7/repository/interfaces"
+	"time"
+)
+
+// a BlobStore decorator that times every call of the store it wraps and logs it at debug level.
+// Open is timed until the blob is ready to read, not until the caller has read it.
+type BlobStore struct {
+	next     interfaces.BlobStore
+	observer Observer
+	logger   *slog.Logger
+}
+
+func NewBlobStore(next interfaces.BlobStore, observer Observer, logger *slog.Logger) *BlobStore {
+	return &BlobStore{
+		next:     next,
+		observer: observer,
+		logger:   logger,
+	}
+}
+
+func (r *BlobStore) observe(ctx context.Context, method string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	r.observer.ObserveRepositoryCall("blob", method, elapsed, err)
+	attrs := []slog.Attr{slog.String("repository", "blob"), slog.String("method", method), slog.Duration("duration", elapsed)}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	r.logger.LogAttrs(ctx, slog.LevelDebug, "repository call", attrs...)
+}
+
+func (r *BlobStore) Put(ctx context.Context, key string, content io.Reader) error {
+	start := time.Now()
+	err := r.next.Put(ctx, key, content)
+	r.observe(ctx, "Put", start, err)
+	return err
+}
+
+func (r *BlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	start := time.Now()
+	blob, err := r.next.Open(ctx, key)
+	r.observe(ctx, "Open", start, err)
+	return blob, err
+}
+
+func (r *BlobStore) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	err := r.next.Delete(ctx, key)
+	r.observe(ctx, "Delete", start, err)
+	return err
+}
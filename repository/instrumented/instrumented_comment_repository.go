@@ -0,0 +1,76 @@
+package instrumented
+
+import (
+	"context"
+	"log/slog"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+)
+
+// a CommentRepository decorator that times every call of the repository it wraps and logs it at debug level
+type CommentRepository struct {
+	next     interfaces.CommentRepository
+	observer Observer
+	logger   *slog.Logger
+}
+
+func NewCommentRepository(next interfaces.CommentRepository, observer Observer, logger *slog.Logger) *CommentRepository {
+	return &CommentRepository{
+		next:     next,
+		observer: observer,
+		logger:   logger,
+	}
+}
+
+func (r *CommentRepository) observe(ctx context.Context, method string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	r.observer.ObserveRepositoryCall("comment", method, elapsed, err)
+	attrs := []slog.Attr{slog.String("repository", "comment"), slog.String("method", method), slog.Duration("duration", elapsed)}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	r.logger.LogAttrs(ctx, slog.LevelDebug, "repository call", attrs...)
+}
+
+func (r *CommentRepository) CreateComment(ctx context.Context, comment *domain.Comment) error {
+	start := time.Now()
+	err := r.next.CreateComment(ctx, comment)
+	r.observe(ctx, "CreateComment", start, err)
+	return err
+}
+
+func (r *CommentRepository) GetComment(ctx context.Context, id string) (domain.Comment, error) {
+	start := time.Now()
+	comment, err := r.next.GetComment(ctx, id)
+	r.observe(ctx, "GetComment", start, err)
+	return comment, err
+}
+
+func (r *CommentRepository) ListComments(ctx context.Context, query domain.CommentQuery) ([]domain.Comment, int64, error) {
+	start := time.Now()
+	comments, total, err := r.next.ListComments(ctx, query)
+	r.observe(ctx, "ListComments", start, err)
+	return comments, total, err
+}
+
+func (r *CommentRepository) UpdateComment(ctx context.Context, comment domain.Comment) error {
+	start := time.Now()
+	err := r.next.UpdateComment(ctx, comment)
+	r.observe(ctx, "UpdateComment", start, err)
+	return err
+}
+
+func (r *CommentRepository) DeleteComment(ctx context.Context, id string) error {
+	start := time.Now()
+	err := r.next.DeleteComment(ctx, id)
+	r.observe(ctx, "DeleteComment", start, err)
+	return err
+}
+
+func (r *CommentRepository) DeleteTaskComments(ctx context.Context, taskID int) error {
+	start := time.Now()
+	err := r.next.DeleteTaskComments(ctx, taskID)
+	r.observe(ctx, "DeleteTaskComments", start, err)
+	return err
+}
@@ -0,0 +1,76 @@
+package instrumented
+
+import (
+	"context"
+	"log/slog"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+)
+
+// a ProjectRepository decorator that times every call of the repository it wraps and logs it at debug level
+type ProjectRepository struct {
+	next     interfaces.ProjectRepository
+	observer Observer
+	logger   *slog.Logger
+}
+
+func NewProjectRepository(next interfaces.ProjectRepository, observer Observer, logger *slog.Logger) *ProjectRepository {
+	return &ProjectRepository{
+		next:     next,
+		observer: observer,
+		logger:   logger,
+	}
+}
+
+func (r *ProjectRepository) observe(ctx context.Context, method string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	r.observer.ObserveRepositoryCall("project", method, elapsed, err)
+	attrs := []slog.Attr{slog.String("repository", "project"), slog.String("method", method), slog.Duration("duration", elapsed)}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	r.logger.LogAttrs(ctx, slog.LevelDebug, "repository call", attrs...)
+}
+
+func (r *ProjectRepository) CreateProject(ctx context.Context, project *domain.Project) error {
+	start := time.Now()
+	err := r.next.CreateProject(ctx, project)
+	r.observe(ctx, "CreateProject", start, err)
+	return err
+}
+
+func (r *ProjectRepository) GetProject(ctx context.Context, id string) (domain.Project, error) {
+	start := time.Now()
+	project, err := r.next.GetProject(ctx, id)
+	r.observe(ctx, "GetProject", start, err)
+	return project, err
+}
+
+func (r *ProjectRepository) ListProjects(ctx context.Context, username string) ([]domain.Project, error) {
+	start := time.Now()
+	projects, err := r.next.ListProjects(ctx, username)
+	r.observe(ctx, "ListProjects", start, err)
+	return projects, err
+}
+
+func (r *ProjectRepository) UpdateProject(ctx context.Context, project *domain.Project) error {
+	start := time.Now()
+	err := r.next.UpdateProject(ctx, project)
+	r.observe(ctx, "UpdateProject", start, err)
+	return err
+}
+
+func (r *ProjectRepository) AdjustTaskCount(ctx context.Context, id string, delta int) error {
+	start := time.Now()
+	err := r.next.AdjustTaskCount(ctx, id, delta)
+	r.observe(ctx, "AdjustTaskCount", start, err)
+	return err
+}
+
+func (r *ProjectRepository) DeleteProject(ctx context.Context, id string) error {
+	start := time.Now()
+	err := r.next.DeleteProject(ctx, id)
+	r.observe(ctx, "DeleteProject", start, err)
+	return err
+}
@@ -0,0 +1,104 @@
+package instrumented
+
+import (
+	"context"
+	"log/slog"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+)
+
+// a TaskRepository decorator that times every call of the repository it wraps and logs it at debug level
+type TaskRepository struct {
+	next     interfaces.TaskRepository
+	observer Observer
+	logger   *slog.Logger
+}
+
+func NewTaskRepository(next interfaces.TaskRepository, observer Observer, logger *slog.Logger) *TaskRepository {
+	return &TaskRepository{
+		next:     next,
+		observer: observer,
+		logger:   logger,
+	}
+}
+
+func (r *TaskRepository) observe(ctx context.Context, method string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	r.observer.ObserveRepositoryCall("task", method, elapsed, err)
+	attrs := []slog.Attr{slog.String("repository", "task"), slog.String("method", method), slog.Duration("duration", elapsed)}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	r.logger.LogAttrs(ctx, slog.LevelDebug, "repository call", attrs...)
+}
+
+func (r *TaskRepository) GetAllTasks(ctx context.Context) ([]domain.Task, error) {
+	start := time.Now()
+	tasks, err := r.next.GetAllTasks(ctx)
+	r.observe(ctx, "GetAllTasks", start, err)
+	return tasks, err
+}
+
+func (r *TaskRepository) QueryTasks(ctx context.Context, query domain.TaskQuery) ([]domain.Task, int64, error) {
+	start := time.Now()
+	tasks, total, err := r.next.QueryTasks(ctx, query)
+	r.observe(ctx, "QueryTasks", start, err)
+	return tasks, total, err
+}
+
+func (r *TaskRepository) GetTaskById(ctx context.Context, id int) (domain.Task, error) {
+	start := time.Now()
+	task, err := r.next.GetTaskById(ctx, id)
+	r.observe(ctx, "GetTaskById", start, err)
+	return task, err
+}
+
+func (r *TaskRepository) CreateTask(ctx context.Context, newTask *domain.Task) error {
+	start := time.Now()
+	err := r.next.CreateTask(ctx, newTask)
+	r.observe(ctx, "CreateTask", start, err)
+	return err
+}
+
+func (r *TaskRepository) UpdateTask(ctx context.Context, id int, version int64, updatedTask *domain.Task) error {
+	start := time.Now()
+	err := r.next.UpdateTask(ctx, id, version, updatedTask)
+	r.observe(ctx, "UpdateTask", start, err)
+	return err
+}
+
+func (r *TaskRepository) DeleteTaskById(ctx context.Context, id int, version int64) error {
+	start := time.Now()
+	err := r.next.DeleteTaskById(ctx, id, version)
+	r.observe(ctx, "DeleteTaskById", start, err)
+	return err
+}
+
+func (r *TaskRepository) RestoreTask(ctx context.Context, id int) (domain.Task, error) {
+	start := time.Now()
+	task, err := r.next.RestoreTask(ctx, id)
+	r.observe(ctx, "RestoreTask", start, err)
+	return task, err
+}
+
+func (r *TaskRepository) PurgeDeletedTasks(ctx context.Context, deletedBefore time.Time) ([]domain.Task, error) {
+	start := time.Now()
+	purged, err := r.next.PurgeDeletedTasks(ctx, deletedBefore)
+	r.observe(ctx, "PurgeDeletedTasks", start, err)
+	return purged, err
+}
+
+func (r *TaskRepository) CountLabels(ctx context.Context, visibleTo string) ([]domain.LabelCount, error) {
+	start := time.Now()
+	labels, err := r.next.CountLabels(ctx, visibleTo)
+	r.observe(ctx, "CountLabels", start, err)
+	return labels, err
+}
+
+func (r *TaskRepository) CountSubtasks(ctx context.Context, parentIDs []int) (map[int]domain.SubtaskCount, error) {
+	start := time.Now()
+	counts, err := r.next.CountSubtasks(ctx, parentIDs)
+	r.observe(ctx, "CountSubtasks", start, err)
+	return counts, err
+}
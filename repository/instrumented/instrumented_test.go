@@ -0,0 +1,130 @@
+package instrumented_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"task7/domain"
+	"task7/repository/instrumented"
+	"task7/repository/interfaces"
+	"task7/repository/memory"
+	"task7/repository/repotest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type call struct {
+	repository, method string
+	err                error
+}
+
+type recordingObserver struct {
+	mu    sync.Mutex
+	calls []call
+}
+
+func (o *recordingObserver) ObserveRepositoryCall(repository, method string, duration time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.calls = append(o.calls, call{repository: repository, method: method, err: err})
+}
+
+var discard = slog.New(slog.DiscardHandler)
+
+// the decorators must be invisible: wrapped repositories still pass the conformance suites
+func TestInstrumentedTaskRepositoryConformance(t *testing.T) {
+	suite.Run(t, &repotest.TaskRepositorySuite{
+		NewRepository: func() interfaces.TaskRepository {
+			return instrumented.NewTaskRepository(memory.NewMemoryTaskRepository(), &recordingObserver{}, discard)
+		},
+	})
+}
+
+func TestInstrumentedUserRepositoryConformance(t *testing.T) {
+	suite.Run(t, &repotest.UserRepositorySuite{
+		NewRepository: func() interfaces.UserRepository {
+			return instrumented.NewUserRepository(memory.NewMemoryUserRepository(), &recordingObserver{}, discard)
+		},
+	})
+}
+
+func TestInstrumentedAuditRepositoryConformance(t *testing.T) {
+	suite.Run(t, &repotest.AuditRepositorySuite{
+		NewRepository: func() interfaces.AuditRepository {
+			return instrumented.NewAuditRepository(memory.NewMemoryAuditRepository(), &recordingObserver{}, discard)
+		},
+	})
+}
+
+func TestInstrumentedCommentRepositoryConformance(t *testing.T) {
+	suite.Run(t, &repotest.CommentRepositorySuite{
+		NewRepository: func() interfaces.CommentRepository {
+			return instrumented.NewCommentRepository(memory.NewMemoryCommentRepository(), &recordingObserver{}, discard)
+		},
+	})
+}
+
+func TestInstrumentedAttachmentRepositoryConformance(t *testing.T) {
+	suite.Run(t, &repotest.AttachmentRepositorySuite{
+		NewRepository: func() interfaces.AttachmentRepository {
+			return instrumented.NewAttachmentRepository(memory.NewMemoryAttachmentRepository(), &recordingObserver{}, discard)
+		},
+	})
+}
+
+func TestInstrumentedBlobStoreConformance(t *testing.T) {
+	suite.Run(t, &repotest.BlobStoreSuite{
+		NewStore: func() interfaces.BlobStore {
+			return instrumented.NewBlobStore(memory.NewMemoryBlobStore(), &recordingObserver{}, discard)
+		},
+	})
+}
+
+func TestInstrumentedTaskRepository_ObservesCalls(t *testing.T) {
+	ctx := context.Background()
+	observer := &recordingObserver{}
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := instrumented.NewTaskRepository(memory.NewMemoryTaskRepository(), observer, logger)
+
+	require.NoError(t, repo.CreateTask(ctx, &domain.Task{Title: "Write report", Description: "Q3 numbers", DueDate: time.Now().Add(time.Hour), Status: domain.StatusTodo}))
+	_, err := repo.GetTaskById(ctx, 404)
+	require.Error(t, err)
+
+	require.Len(t, observer.calls, 2)
+	assert.Equal(t, call{repository: "task", method: "CreateTask"}, observer.calls[0])
+	assert.Equal(t, "GetTaskById", observer.calls[1].method)
+	assert.Equal(t, err, observer.calls[1].err, "the error reaches the observer unchanged")
+
+	assert.Contains(t, logs.String(), `"msg":"repository call","repository":"task","method":"CreateTask"`)
+	assert.Contains(t, logs.String(), `"method":"GetTaskById"`)
+	assert.Contains(t, logs.String(), `"error":`)
+}
+
+func TestInstrumentedUserRepository_ObservesCalls(t *testing.T) {
+	ctx := context.Background()
+	observer := &recordingObserver{}
+	repo := instrumented.NewUserRepository(memory.NewMemoryUserRepository(), observer, discard)
+
+	require.NoError(t, repo.RegisterUser(ctx, &domain.User{Username: "alice", PasswordHash: "password123"}))
+	_, err := repo.LoginUser(ctx, &domain.User{Username: "alice", PasswordHash: "wrong"})
+	require.Error(t, err)
+
+	require.Len(t, observer.calls, 2)
+	assert.Equal(t, call{repository: "user", method: "RegisterUser"}, observer.calls[0])
+	assert.Equal(t, "LoginUser", observer.calls[1].method)
+	assert.Equal(t, domain.KindUnauthorized, domain.KindOf(observer.calls[1].err))
+}
+
+func TestInstrumentedProjectRepositoryConformance(t *testing.T) {
+	suite.Run(t, &repotest.ProjectRepositorySuite{
+		NewRepository: func() interfaces.ProjectRepository {
+			return instrumented.NewProjectRepository(memory.NewMemoryProjectRepository(), &recordingObserver{}, discard)
+		},
+	})
+}
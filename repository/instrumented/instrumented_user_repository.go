@@ -0,0 +1,69 @@
+package instrumented
+
+import (
+	"context"
+	"log/slog"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+)
+
+// a UserRepository decorator that times every call of the repository it wraps and logs it at debug level
+type UserRepository struct {
+	next     interfaces.UserRepository
+	observer Observer
+	logger   *slog.Logger
+}
+
+func NewUserRepository(next interfaces.UserRepository, observer Observer, logger *slog.Logger) *UserRepository {
+	return &UserRepository{
+		next:     next,
+		observer: observer,
+		logger:   logger,
+	}
+}
+
+func (r *UserRepository) observe(ctx context.Context, method string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	r.observer.ObserveRepositoryCall("user", method, elapsed, err)
+	attrs := []slog.Attr{slog.String("repository", "user"), slog.String("method", method), slog.Duration("duration", elapsed)}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	r.logger.LogAttrs(ctx, slog.LevelDebug, "repository call", attrs...)
+}
+
+func (r *UserRepository) RegisterUser(ctx context.Context, user *domain.User) error {
+	start := time.Now()
+	err := r.next.RegisterUser(ctx, user)
+	r.observe(ctx, "RegisterUser", start, err)
+	return err
+}
+
+func (r *UserRepository) LoginUser(ctx context.Context, user *domain.User) (domain.User, error) {
+	start := time.Now()
+	found, err := r.next.LoginUser(ctx, user)
+	r.observe(ctx, "LoginUser", start, err)
+	return found, err
+}
+
+func (r *UserRepository) PromoteUser(ctx context.Context, username string) error {
+	start := time.Now()
+	err := r.next.PromoteUser(ctx, username)
+	r.observe(ctx, "PromoteUser", start, err)
+	return err
+}
+
+func (r *UserRepository) GetRole(ctx context.Context, username string) (string, error) {
+	start := time.Now()
+	role, err := r.next.GetRole(ctx, username)
+	r.observe(ctx, "GetRole", start, err)
+	return role, err
+}
+
+func (r *UserRepository) FindUsernames(ctx context.Context, usernames []string) ([]string, error) {
+	start := time.Now()
+	found, err := r.next.FindUsernames(ctx, usernames)
+	r.observe(ctx, "FindUsernames", start, err)
+	return found, err
+}
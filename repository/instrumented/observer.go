@@ -0,0 +1,8 @@
+package instrumented
+
+import "time"
+
+// receives one observation per repository call; infrastructure.Metrics is the implementation
+type Observer interface {
+	ObserveRepositoryCall(repository, method string, duration time.Duration, err error)
+}
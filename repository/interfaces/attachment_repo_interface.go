@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"task7/domain"
+)
+
+// attachment metadata; the content itself is kept in a BlobStore
+type AttachmentRepository interface {
+	// stores attachment under the ID it already has; domain.KindConflict if that ID is taken
+	CreateAttachment(ctx context.Context, attachment domain.Attachment) error
+	GetAttachment(ctx context.Context, id string) (domain.Attachment, error)
+	// the attachments on task taskID, oldest first
+	ListAttachments(ctx context.Context, taskID int) ([]domain.Attachment, error)
+	DeleteAttachment(ctx context.Context, id string) error
+}
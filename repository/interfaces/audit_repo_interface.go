@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"task7/domain"
+)
+
+// the audit trail; entries can be added and read but never changed or removed
+type AuditRepository interface {
+	// stores entry and sets its ID
+	Append(ctx context.Context, entry *domain.AuditEntry) error
+	// one window of matching entries, newest first, plus the total match count
+	QueryAudit(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEntry, int64, error)
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+)
+
+// opaque binary content under string keys; keys are chosen by the caller and use only letters,
+// digits, '-' and '_'
+type BlobStore interface {
+	// stores everything read from content under key, replacing any blob already there
+	Put(ctx context.Context, key string, content io.Reader) error
+	// the blob under key, which the caller must close; domain.KindNotFound if there is none
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// removes the blob under key; a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+	"task7/domain"
+)
+
+// comments on tasks; the repository does not check that the task exists
+type CommentRepository interface {
+	// stores comment and sets its ID
+	CreateComment(ctx context.Context, comment *domain.Comment) error
+	// domain.KindNotFound for an unknown id, including one the backend could never have issued
+	GetComment(ctx context.Context, id string) (domain.Comment, error)
+	// one window of the comments on query.TaskID, oldest first, plus their total
+	ListComments(ctx context.Context, query domain.CommentQuery) ([]domain.Comment, int64, error)
+	// stores comment's body, mentions and edit time over those of the comment with its ID
+	UpdateComment(ctx context.Context, comment domain.Comment) error
+	DeleteComment(ctx context.Context, id string) error
+	// removes the whole thread on task taskID; a task without comments is not an error
+	DeleteTaskComments(ctx context.Context, taskID int) error
+}
@@ -0,0 +1,9 @@
+package interfaces
+
+import "context"
+
+// reports whether a storage backend can serve requests right now; used by the readiness probe
+type HealthChecker interface {
+	Name() string
+	Ping(ctx context.Context) error
+}
@@ -0,0 +1,27 @@
+package interfaces
+
+import (
+	"context"
+	"task7/domain"
+)
+
+// projects and their members. The repository does not look at the tasks on a project's board; it
+// keeps a count of them that the services maintain, so that a project is never deleted under a task
+// being added to it.
+type ProjectRepository interface {
+	// stores project and sets its ID, and its Version to 1
+	CreateProject(ctx context.Context, project *domain.Project) error
+	// domain.KindNotFound for an unknown id, including one the backend could never have issued
+	GetProject(ctx context.Context, id string) (domain.Project, error)
+	// the projects username is a member of, or every project for an empty username, oldest first
+	ListProjects(ctx context.Context, username string) ([]domain.Project, error)
+	// stores project's name, description, members and update time over those of the project with its ID,
+	// only if the stored version is still project.Version; then bumps it and sets project.Version.
+	// domain.ErrProjectVersionMismatch otherwise
+	UpdateProject(ctx context.Context, project *domain.Project) error
+	// adds delta to the number of tasks counted on project id, in the same step as checking that it
+	// exists; domain.KindNotFound if it does not. The count never drops below zero.
+	AdjustTaskCount(ctx context.Context, id string, delta int) error
+	// domain.ErrProjectNotEmpty while any task is counted on the project, checked in the same step as the delete
+	DeleteProject(ctx context.Context, id string) error
+}
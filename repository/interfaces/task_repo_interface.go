@@ -1,20 +1,30 @@
-package interfaces 
+package interfaces
 
 import (
+	"context"
 	"task7/domain"
+	"time"
 )
 
-type TaskRepository interface {            // choose any db that implements register and login
-	GetAllTasks() ([]domain.Task,error)
-	GetTaskById(id int) (domain.Task,error)
-	CreateTask(newTask *domain.Task)  error
-	UpdateTask(id int, updatedTask *domain.Task)  error
-	DeleteTaskById(id int) error
+// deleted tasks stay stored, with DeletedAt set, until they are restored or purged. Every method
+// except QueryTasks with query.Trashed, RestoreTask and PurgeDeletedTasks treats them as missing.
+type TaskRepository interface { // choose any db that implements register and login
+	GetAllTasks(ctx context.Context) ([]domain.Task, error)
+	QueryTasks(ctx context.Context, query domain.TaskQuery) ([]domain.Task, int64, error) // one window of matches plus the total match count
+	GetTaskById(ctx context.Context, id int) (domain.Task, error)
+	CreateTask(ctx context.Context, newTask *domain.Task) error
+	// writes only if the stored version is still version, then bumps it and sets updatedTask.Version;
+	// domain.ErrTaskVersionMismatch otherwise
+	UpdateTask(ctx context.Context, id int, version int64, updatedTask *domain.Task) error
+	// moves the task to the trash, with the same version check as UpdateTask
+	DeleteTaskById(ctx context.Context, id int, version int64) error
+	// takes a task out of the trash and returns it as now stored
+	RestoreTask(ctx context.Context, id int) (domain.Task, error)
+	// permanently removes tasks deleted before the cutoff and returns them, by ascending id, so that
+	// what hangs off them can be removed too
+	PurgeDeletedTasks(ctx context.Context, deletedBefore time.Time) ([]domain.Task, error)
+	// every label on a live task, most used first (ties by name); visibleTo limits the count as in domain.TaskQuery
+	CountLabels(ctx context.Context, visibleTo string) ([]domain.LabelCount, error)
+	// the live subtasks of each of parentIDs, whoever can see them; parents without any are left out
+	CountSubtasks(ctx context.Context, parentIDs []int) (map[int]domain.SubtaskCount, error)
 }
-
-
- 
-
-
-
-
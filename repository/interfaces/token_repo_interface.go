@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"task7/domain"
+	"time"
+)
+
+type TokenRepository interface {
+	StoreRefreshToken(ctx context.Context, token domain.RefreshToken) error
+	// removes the token with this hash and returns it, so each refresh token works once;
+	// domain.ErrInvalidRefreshToken if it is unknown or expired
+	ConsumeRefreshToken(ctx context.Context, tokenHash string) (domain.RefreshToken, error)
+	// remembers a revoked access token id until the token would have expired anyway
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
@@ -1,16 +1,16 @@
 package interfaces
 
 import (
+	"context"
 	"task7/domain"
 )
 
-type UserRepository interface {            // choose any db that implements register and login
-	RegisterUser(user *domain.User) error
-	LoginUser(user *domain.User) (domain.User,error)
-	PromoteUser(username string) error
+type UserRepository interface { // choose any db that implements register and login
+	RegisterUser(ctx context.Context, user *domain.User) error
+	LoginUser(ctx context.Context, user *domain.User) (domain.User, error)
+	PromoteUser(ctx context.Context, username string) error
+	// the current role of username; NotFound if there is no such user
+	GetRole(ctx context.Context, username string) (string, error)
+	// the ones of usernames that belong to registered users, sorted
+	FindUsernames(ctx context.Context, usernames []string) ([]string, error)
 }
-
-
-
-
-
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"task7/domain"
+)
+
+type MemoryAttachmentRepository struct { // in-memory implementer, attachments in the order they were created
+	mu          sync.RWMutex
+	attachments []domain.Attachment
+}
+
+func NewMemoryAttachmentRepository() *MemoryAttachmentRepository {
+	return &MemoryAttachmentRepository{}
+}
+
+// the position of the attachment with this id, or -1; callers hold the lock
+func (m *MemoryAttachmentRepository) indexOf(id string) int {
+	return slices.IndexFunc(m.attachments, func(a domain.Attachment) bool { return a.ID == id })
+}
+
+func (m *MemoryAttachmentRepository) CreateAttachment(ctx context.Context, attachment domain.Attachment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.indexOf(attachment.ID) >= 0 {
+		return domain.NewConflict("attachment %s already exists", attachment.ID)
+	}
+	m.attachments = append(m.attachments, attachment)
+	return nil
+}
+
+func (m *MemoryAttachmentRepository) GetAttachment(ctx context.Context, id string) (domain.Attachment, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Attachment{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := m.indexOf(id)
+	if i < 0 {
+		return domain.Attachment{}, domain.NewNotFound("no attachment found with id %s", id)
+	}
+	return m.attachments[i], nil
+}
+
+func (m *MemoryAttachmentRepository) ListAttachments(ctx context.Context, taskID int) ([]domain.Attachment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	var matches []domain.Attachment
+	for _, attachment := range m.attachments {
+		if attachment.TaskID == taskID {
+			matches = append(matches, attachment)
+		}
+	}
+	m.mu.RUnlock()
+
+	// creation order is oldest first; a stable sort keeps it for equal timestamps
+	slices.SortStableFunc(matches, func(a, b domain.Attachment) int {
+		return a.UploadedAt.Compare(b.UploadedAt)
+	})
+	return matches, nil
+}
+
+func (m *MemoryAttachmentRepository) DeleteAttachment(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexOf(id)
+	if i < 0 {
+		return domain.NewNotFound("no attachment found with id %s", id)
+	}
+	m.attachments = slices.Delete(m.attachments, i, i+1)
+	return nil
+}
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"task7/domain"
+)
+
+type MemoryAuditRepository struct { // in-memory implementer, entries in the order they were appended
+	mu      sync.RWMutex
+	entries []domain.AuditEntry
+}
+
+func NewMemoryAuditRepository() *MemoryAuditRepository {
+	return &MemoryAuditRepository{}
+}
+
+func (m *MemoryAuditRepository) Append(ctx context.Context, entry *domain.AuditEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry.ID = strconv.Itoa(len(m.entries) + 1)
+	stored := *entry
+	stored.Changes = slices.Clone(entry.Changes)
+	m.entries = append(m.entries, stored)
+	return nil
+}
+
+func (m *MemoryAuditRepository) QueryAudit(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEntry, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	m.mu.RLock()
+	var matches []domain.AuditEntry
+	for i := len(m.entries) - 1; i >= 0; i-- {
+		if query.Matches(m.entries[i]) {
+			matches = append(matches, m.entries[i])
+		}
+	}
+	m.mu.RUnlock()
+
+	// appended order is newest last; a stable sort keeps it for equal timestamps
+	slices.SortStableFunc(matches, func(a, b domain.AuditEntry) int {
+		return b.Timestamp.Compare(a.Timestamp)
+	})
+
+	total := int64(len(matches))
+	if query.Offset >= len(matches) {
+		return nil, total, nil
+	}
+	end := len(matches)
+	if query.Limit > 0 && query.Offset+query.Limit < end {
+		end = query.Offset + query.Limit
+	}
+	return matches[query.Offset:end], total, nil
+}
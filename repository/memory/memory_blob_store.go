@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"task7/domain"
+)
+
+type MemoryBlobStore struct { // in-memory implementer, for the memory backend and tests
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+func NewMemoryBlobStore() *MemoryBlobStore {
+	return &MemoryBlobStore{blobs: make(map[string][]byte)}
+}
+
+func (m *MemoryBlobStore) Put(ctx context.Context, key string, content io.Reader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blobs[key] = data
+	return nil
+}
+
+func (m *MemoryBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, ok := m.blobs[key]
+	if !ok {
+		return nil, domain.NewNotFound("no blob stored under %s", key)
+	}
+	// stored slices are never written to again, so readers can share them
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *MemoryBlobStore) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.blobs, key)
+	return nil
+}
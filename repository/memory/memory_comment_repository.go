@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"task7/domain"
+)
+
+type MemoryCommentRepository struct { // in-memory implementer, comments in the order they were created
+	mu       sync.RWMutex
+	comments []domain.Comment
+	nextID   int
+}
+
+func NewMemoryCommentRepository() *MemoryCommentRepository {
+	return &MemoryCommentRepository{nextID: 1}
+}
+
+func (m *MemoryCommentRepository) CreateComment(ctx context.Context, comment *domain.Comment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	comment.ID = strconv.Itoa(m.nextID)
+	m.nextID++
+	m.comments = append(m.comments, comment.Clone())
+	return nil
+}
+
+// the position of the comment with this id, or -1; callers hold the lock
+func (m *MemoryCommentRepository) indexOf(id string) int {
+	return slices.IndexFunc(m.comments, func(c domain.Comment) bool { return c.ID == id })
+}
+
+func (m *MemoryCommentRepository) GetComment(ctx context.Context, id string) (domain.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Comment{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := m.indexOf(id)
+	if i < 0 {
+		return domain.Comment{}, domain.NewNotFound("no comment found with id %s", id)
+	}
+	return m.comments[i].Clone(), nil
+}
+
+func (m *MemoryCommentRepository) ListComments(ctx context.Context, query domain.CommentQuery) ([]domain.Comment, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	m.mu.RLock()
+	var matches []domain.Comment
+	for _, comment := range m.comments {
+		if comment.TaskID == query.TaskID {
+			matches = append(matches, comment.Clone())
+		}
+	}
+	m.mu.RUnlock()
+
+	// creation order is oldest first; a stable sort keeps it for equal timestamps
+	slices.SortStableFunc(matches, func(a, b domain.Comment) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	total := int64(len(matches))
+	if query.Offset >= len(matches) {
+		return nil, total, nil
+	}
+	end := len(matches)
+	if query.Limit > 0 && query.Offset+query.Limit < end {
+		end = query.Offset + query.Limit
+	}
+	return matches[query.Offset:end], total, nil
+}
+
+func (m *MemoryCommentRepository) UpdateComment(ctx context.Context, comment domain.Comment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexOf(comment.ID)
+	if i < 0 {
+		return domain.NewNotFound("no comment found with id %s", comment.ID)
+	}
+	updated := comment.Clone()
+	stored := &m.comments[i]
+	stored.Body, stored.Mentions, stored.EditedAt = updated.Body, updated.Mentions, updated.EditedAt
+	return nil
+}
+
+func (m *MemoryCommentRepository) DeleteComment(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexOf(id)
+	if i < 0 {
+		return domain.NewNotFound("no comment found with id %s", id)
+	}
+	m.comments = slices.Delete(m.comments, i, i+1)
+	return nil
+}
+
+func (m *MemoryCommentRepository) DeleteTaskComments(ctx context.Context, taskID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.comments = slices.DeleteFunc(m.comments, func(c domain.Comment) bool { return c.TaskID == taskID })
+	return nil
+}
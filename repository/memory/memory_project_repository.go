@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"task7/domain"
+)
+
+type MemoryProjectRepository struct { // in-memory implementer, projects in the order they were created
+	mu         sync.RWMutex
+	projects   []domain.Project
+	taskCounts map[string]int // by project id
+	nextID     int
+}
+
+func NewMemoryProjectRepository() *MemoryProjectRepository {
+	return &MemoryProjectRepository{taskCounts: map[string]int{}, nextID: 1}
+}
+
+func (m *MemoryProjectRepository) CreateProject(ctx context.Context, project *domain.Project) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	project.ID = strconv.Itoa(m.nextID)
+	project.Version = 1
+	m.nextID++
+	m.projects = append(m.projects, project.Clone())
+	return nil
+}
+
+// the position of the project with this id, or -1; callers hold the lock
+func (m *MemoryProjectRepository) indexOf(id string) int {
+	return slices.IndexFunc(m.projects, func(p domain.Project) bool { return p.ID == id })
+}
+
+func (m *MemoryProjectRepository) GetProject(ctx context.Context, id string) (domain.Project, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Project{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := m.indexOf(id)
+	if i < 0 {
+		return domain.Project{}, domain.NewNotFound("no project found with id %s", id)
+	}
+	return m.projects[i].Clone(), nil
+}
+
+func (m *MemoryProjectRepository) ListProjects(ctx context.Context, username string) ([]domain.Project, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var projects []domain.Project
+	for _, project := range m.projects {
+		if _, ok := project.RoleOf(username); username == "" || ok {
+			projects = append(projects, project.Clone())
+		}
+	}
+	return projects, nil
+}
+
+func (m *MemoryProjectRepository) UpdateProject(ctx context.Context, project *domain.Project) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexOf(project.ID)
+	if i < 0 {
+		return domain.NewNotFound("no project found with id %s", project.ID)
+	}
+	stored := &m.projects[i]
+	if stored.Version != project.Version {
+		return domain.ErrProjectVersionMismatch
+	}
+	updated := project.Clone()
+	stored.Name, stored.Description, stored.Members, stored.UpdatedAt = updated.Name, updated.Description, updated.Members, updated.UpdatedAt
+	stored.Version++
+	project.Version = stored.Version
+	return nil
+}
+
+func (m *MemoryProjectRepository) DeleteProject(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexOf(id)
+	if i < 0 {
+		return domain.NewNotFound("no project found with id %s", id)
+	}
+	if m.taskCounts[id] > 0 {
+		return domain.ErrProjectNotEmpty
+	}
+	m.projects = slices.Delete(m.projects, i, i+1)
+	delete(m.taskCounts, id)
+	return nil
+}
+
+func (m *MemoryProjectRepository) AdjustTaskCount(ctx context.Context, id string, delta int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.indexOf(id) < 0 {
+		return domain.NewNotFound("no project found with id %s", id)
+	}
+	m.taskCounts[id] = max(m.taskCounts[id]+delta, 0)
+	return nil
+}
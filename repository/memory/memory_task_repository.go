@@ -0,0 +1,314 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"task7/domain"
+	"time"
+)
+
+// in-memory implementation of Task interface, safe for concurrent use
+
+type MemoryTaskRepository struct {
+	mu     sync.RWMutex
+	tasks  map[int]domain.Task
+	lastID int
+}
+
+// constructor
+func NewMemoryTaskRepository() *MemoryTaskRepository {
+	return &MemoryTaskRepository{
+		tasks: make(map[int]domain.Task),
+	}
+}
+
+func (m *MemoryTaskRepository) GetAllTasks(ctx context.Context) ([]domain.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var tasks []domain.Task
+	for _, task := range m.tasks {
+		if !task.IsDeleted() {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+func (m *MemoryTaskRepository) QueryTasks(ctx context.Context, query domain.TaskQuery) ([]domain.Task, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	m.mu.RLock()
+	var matches []domain.Task
+	for _, task := range m.tasks {
+		if matchesQuery(task, query) {
+			matches = append(matches, task)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		cmp := compareTasks(matches[i], matches[j], query.SortBy)
+		if cmp == 0 {
+			return matches[i].ID < matches[j].ID
+		}
+		if query.SortDesc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	total := int64(len(matches))
+	if query.Offset >= len(matches) {
+		return nil, total, nil
+	}
+	end := len(matches)
+	if query.Limit > 0 && query.Offset+query.Limit < end {
+		end = query.Offset + query.Limit
+	}
+	return matches[query.Offset:end], total, nil
+}
+
+func matchesQuery(task domain.Task, query domain.TaskQuery) bool {
+	if task.IsDeleted() != query.Trashed {
+		return false
+	}
+	if query.Status != "" && task.Status != query.Status {
+		return false
+	}
+	if query.Priority != "" && task.Priority != query.Priority {
+		return false
+	}
+	if !task.HasLabels(query.Labels) {
+		return false
+	}
+	if query.ParentID != 0 && task.ParentID != query.ParentID {
+		return false
+	}
+	if query.ProjectID != "" && task.ProjectID != query.ProjectID {
+		return false
+	}
+	if len(query.IDs) > 0 && !slices.Contains(query.IDs, task.ID) {
+		return false
+	}
+	if len(query.WaitingOn) > 0 && !slices.ContainsFunc(task.BlockedBy, func(id int) bool { return slices.Contains(query.WaitingOn, id) }) {
+		return false
+	}
+	if !query.DueAfter.IsZero() && task.DueDate.Before(query.DueAfter) {
+		return false
+	}
+	if !query.DueBefore.IsZero() && task.DueDate.After(query.DueBefore) {
+		return false
+	}
+	if query.TitleContains != "" && !strings.Contains(strings.ToLower(task.Title), strings.ToLower(query.TitleContains)) {
+		return false
+	}
+	if query.VisibleTo != "" && task.CreatedBy != query.VisibleTo && task.AssignedTo != query.VisibleTo {
+		return false
+	}
+	return true
+}
+
+// orders a and b by one of domain.TaskSortFields
+func compareTasks(a, b domain.Task, field string) int {
+	switch field {
+	case "title":
+		return strings.Compare(a.Title, b.Title)
+	case "duedate":
+		return a.DueDate.Compare(b.DueDate)
+	case "status":
+		return strings.Compare(string(a.Status), string(b.Status))
+	default:
+		return a.ID - b.ID
+	}
+}
+
+func (m *MemoryTaskRepository) GetTaskById(ctx context.Context, id int) (domain.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Task{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	task, ok := m.tasks[id]
+	if !ok || task.IsDeleted() {
+		return domain.Task{}, domain.NewNotFound("no task found with id %d", id)
+	}
+	return task, nil
+}
+
+// assigns newTask.ID from an in-process sequence; any id or deletion time sent by the caller is ignored
+func (m *MemoryTaskRepository) CreateTask(ctx context.Context, newTask *domain.Task) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if newTask.Title == "" || newTask.Description == "" || newTask.Status == "" || newTask.DueDate.IsZero() {
+		return domain.NewValidation("missing required field(s) in newTask")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastID++
+	newTask.ID = m.lastID
+	newTask.Version = 1
+	newTask.DeletedAt = nil
+	if newTask.Priority == "" {
+		newTask.Priority = domain.DefaultPriority
+	}
+	stored := *newTask
+	stored.Labels = slices.Clone(newTask.Labels) // the caller keeps its slices
+	stored.Checklist = slices.Clone(newTask.Checklist)
+	stored.BlockedBy = slices.Clone(newTask.BlockedBy)
+	stored.Recurrence = newTask.Recurrence.Clone()
+	stored.Progress = nil
+	m.tasks[newTask.ID] = stored
+	return nil
+}
+
+func (m *MemoryTaskRepository) UpdateTask(ctx context.Context, id int, version int64, updatedTask *domain.Task) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if updatedTask.Title == "" && updatedTask.Description == "" && updatedTask.DueDate.IsZero() && updatedTask.Status == "" &&
+		updatedTask.AssignedTo == "" && updatedTask.Priority == "" && updatedTask.Labels == nil && updatedTask.Checklist == nil &&
+		updatedTask.BlockedBy == nil && updatedTask.Recurrence == nil {
+		updatedTask.Version = version
+		return nil
+	}
+
+	task, ok := m.tasks[id]
+	if !ok || task.IsDeleted() {
+		return domain.NewNotFound("no task found with id %d", id)
+	}
+	if task.Version != version {
+		return domain.ErrTaskVersionMismatch
+	}
+	task = task.WithUpdate(*updatedTask)
+	task.Labels = slices.Clone(task.Labels)
+	task.Checklist = slices.Clone(task.Checklist)
+	task.BlockedBy = slices.Clone(task.BlockedBy)
+	task.Recurrence = task.Recurrence.Clone()
+	task.Version++
+	m.tasks[id] = task
+	updatedTask.Version = task.Version
+	return nil
+}
+
+func (m *MemoryTaskRepository) DeleteTaskById(ctx context.Context, id int, version int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok || task.IsDeleted() {
+		return domain.NewNotFound("no task found with id %d", id)
+	}
+	if task.Version != version {
+		return domain.ErrTaskVersionMismatch
+	}
+	now := time.Now().UTC()
+	task.DeletedAt = &now
+	task.Version++
+	m.tasks[id] = task
+	return nil
+}
+
+func (m *MemoryTaskRepository) RestoreTask(ctx context.Context, id int) (domain.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Task{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok || !task.IsDeleted() {
+		return domain.Task{}, domain.NewNotFound("no deleted task found with id %d", id)
+	}
+	task.DeletedAt = nil
+	task.Version++
+	m.tasks[id] = task
+	return task, nil
+}
+
+func (m *MemoryTaskRepository) PurgeDeletedTasks(ctx context.Context, deletedBefore time.Time) ([]domain.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var purged []domain.Task
+	for id, task := range m.tasks {
+		if task.IsDeleted() && task.DeletedAt.Before(deletedBefore) {
+			delete(m.tasks, id)
+			purged = append(purged, task)
+		}
+	}
+	slices.SortFunc(purged, func(a, b domain.Task) int { return a.ID - b.ID })
+	return purged, nil
+}
+
+func (m *MemoryTaskRepository) CountLabels(ctx context.Context, visibleTo string) ([]domain.LabelCount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	counts := map[string]int64{}
+	for _, task := range m.tasks {
+		if task.IsDeleted() || (visibleTo != "" && task.CreatedBy != visibleTo && task.AssignedTo != visibleTo) {
+			continue
+		}
+		for _, label := range task.Labels {
+			counts[label]++
+		}
+	}
+	m.mu.RUnlock()
+
+	labels := make([]domain.LabelCount, 0, len(counts))
+	for label, count := range counts {
+		labels = append(labels, domain.LabelCount{Label: label, Count: count})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Count != labels[j].Count {
+			return labels[i].Count > labels[j].Count
+		}
+		return labels[i].Label < labels[j].Label
+	})
+	return labels, nil
+}
+
+func (m *MemoryTaskRepository) CountSubtasks(ctx context.Context, parentIDs []int) (map[int]domain.SubtaskCount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := map[int]domain.SubtaskCount{}
+	for _, task := range m.tasks {
+		if task.IsDeleted() || task.ParentID == 0 || !slices.Contains(parentIDs, task.ParentID) {
+			continue
+		}
+		count := counts[task.ParentID]
+		count.Total++
+		if task.Status.IsClosed() {
+			count.Closed++
+		}
+		counts[task.ParentID] = count
+	}
+	return counts, nil
+}
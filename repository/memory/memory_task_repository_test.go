@@ -0,0 +1,59 @@
+package memory_test
+
+import (
+	"context"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"task7/repository/memory"
+	"task7/repository/repotest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MemoryTaskRepositorySuite struct {
+	suite.Suite
+	taskRepo *memory.MemoryTaskRepository
+}
+
+func TestMemoryTaskRepositorySuite(t *testing.T) {
+	suite.Run(t, new(MemoryTaskRepositorySuite))
+}
+
+func TestMemoryTaskRepositoryConformance(t *testing.T) {
+	suite.Run(t, &repotest.TaskRepositorySuite{
+		NewRepository: func() interfaces.TaskRepository {
+			return memory.NewMemoryTaskRepository()
+		},
+	})
+}
+
+func (s *MemoryTaskRepositorySuite) SetupTest() {
+	s.taskRepo = memory.NewMemoryTaskRepository()
+}
+
+func (s *MemoryTaskRepositorySuite) newTask(title string) *domain.Task {
+	dueDate, err := time.Parse(time.RFC3339, "2025-07-30T00:00:00Z")
+	s.Require().NoError(err, "Failed to parse due date string")
+	return &domain.Task{Title: title, Description: "Desc", DueDate: dueDate, Status: domain.StatusTodo}
+}
+
+func (s *MemoryTaskRepositorySuite) TestGetAllTasks_OrderedByID() {
+	task1 := s.newTask("Task1")
+	task2 := s.newTask("Task2")
+	s.Require().NoError(s.taskRepo.CreateTask(context.Background(), task1))
+	s.Require().NoError(s.taskRepo.CreateTask(context.Background(), task2))
+
+	allTasks, err := s.taskRepo.GetAllTasks(context.Background())
+	s.Require().NoError(err, "Failed to get all tasks")
+	s.Equal([]domain.Task{*task1, *task2}, allTasks, "Tasks should be ordered by ID")
+}
+
+func (s *MemoryTaskRepositorySuite) TestCreateTask_SequentialIDs() {
+	for want := 1; want <= 3; want++ {
+		task := s.newTask("Sequential")
+		s.Require().NoError(s.taskRepo.CreateTask(context.Background(), task))
+		s.Equal(want, task.ID)
+	}
+}
@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"task7/domain"
+	"time"
+)
+
+type MemoryTokenRepository struct { // in-memory implementer, refresh tokens keyed by hash
+	mu      sync.Mutex
+	refresh map[string]domain.RefreshToken
+	revoked map[string]time.Time // jti -> access token expiry
+}
+
+func NewMemoryTokenRepository() *MemoryTokenRepository {
+	return &MemoryTokenRepository{
+		refresh: make(map[string]domain.RefreshToken),
+		revoked: make(map[string]time.Time),
+	}
+}
+
+func (m *MemoryTokenRepository) StoreRefreshToken(ctx context.Context, token domain.RefreshToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.refresh[token.TokenHash] = token
+	return nil
+}
+
+func (m *MemoryTokenRepository) ConsumeRefreshToken(ctx context.Context, tokenHash string) (domain.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.RefreshToken{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, ok := m.refresh[tokenHash]
+	if !ok {
+		return domain.RefreshToken{}, domain.ErrInvalidRefreshToken
+	}
+	delete(m.refresh, tokenHash)
+	if !time.Now().Before(token.ExpiresAt) {
+		return domain.RefreshToken{}, domain.ErrInvalidRefreshToken
+	}
+	return token, nil
+}
+
+// also drops revocations whose tokens have expired, so the map stays bounded
+func (m *MemoryTokenRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for id, exp := range m.revoked {
+		if !now.Before(exp) {
+			delete(m.revoked, id)
+		}
+	}
+	m.revoked[jti] = expiresAt
+	return nil
+}
+
+func (m *MemoryTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.revoked[jti]
+	return ok, nil
+}
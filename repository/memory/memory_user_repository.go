@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"task7/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type MemoryUserRepository struct { // in-memory implementer, keyed by username
+	mu         sync.RWMutex
+	users      map[string]domain.User
+	BcryptCost int
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:      make(map[string]domain.User),
+		BcryptCost: bcrypt.DefaultCost,
+	}
+}
+
+func (m *MemoryUserRepository) RegisterUser(ctx context.Context, newUser *domain.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if newUser.PasswordHash == "" {
+		return domain.NewValidation("password cannot be empty")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.users[newUser.Username]; exists {
+		return domain.NewConflict("user with username '%s' already exists", newUser.Username)
+	}
+
+	hashed_pw, err := bcrypt.GenerateFromPassword([]byte(newUser.PasswordHash), m.BcryptCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	newUser.PasswordHash = string(hashed_pw)
+
+	if len(m.users) == 0 {
+		newUser.Role = "admin"
+	} else {
+		newUser.Role = "regular"
+	}
+
+	newUser.ID = primitive.NewObjectID()
+	m.users[newUser.Username] = *newUser
+	return nil
+}
+
+func (m *MemoryUserRepository) LoginUser(ctx context.Context, existingUser *domain.User) (domain.User, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.User{}, err
+	}
+	m.mu.RLock()
+	user, ok := m.users[existingUser.Username]
+	m.mu.RUnlock()
+	if !ok {
+		return domain.User{}, domain.NewNotFound("user not found")
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(existingUser.PasswordHash))
+	if err != nil {
+		return domain.User{}, &domain.Error{Kind: domain.KindUnauthorized, Message: "incorrect password", Err: err}
+	}
+	return user, nil
+}
+
+func (m *MemoryUserRepository) PromoteUser(ctx context.Context, username string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[username]
+	if !ok {
+		return domain.NewNotFound("user not found")
+	}
+	user.Role = "admin"
+	m.users[username] = user
+	return nil
+}
+
+func (m *MemoryUserRepository) GetRole(ctx context.Context, username string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.users[username]
+	if !ok {
+		return "", domain.NewNotFound("user not found")
+	}
+	return user.Role, nil
+}
+
+func (m *MemoryUserRepository) FindUsernames(ctx context.Context, usernames []string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var found []string
+	for _, name := range usernames {
+		if _, ok := m.users[name]; ok {
+			found = append(found, name)
+		}
+	}
+	slices.Sort(found)
+	return slices.Compact(found), nil
+}
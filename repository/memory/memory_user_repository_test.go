@@ -0,0 +1,18 @@
+package memory_test
+
+import (
+	"task7/repository/interfaces"
+	"task7/repository/memory"
+	"task7/repository/repotest"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestMemoryUserRepositoryConformance(t *testing.T) {
+	suite.Run(t, &repotest.UserRepositorySuite{
+		NewRepository: func() interfaces.UserRepository {
+			return memory.NewMemoryUserRepository()
+		},
+	})
+}
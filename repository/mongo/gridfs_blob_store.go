@@ -0,0 +1,115 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"task7/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// how long a whole upload or download may take; blobs are far larger than the documents
+// OperationTimeout is sized for
+const DefaultTransferTimeout = 2 * time.Minute
+
+type GridFSBlobStore struct { // GridFS implementer, blobs stored as files whose _id is the key
+	Database        *mongo.Database
+	BucketName      string
+	TransferTimeout time.Duration
+}
+
+func NewGridFSBlobStore(db *mongo.Database, bucketName string) *GridFSBlobStore {
+	return &GridFSBlobStore{
+		Database:        db,
+		BucketName:      bucketName,
+		TransferTimeout: DefaultTransferTimeout,
+	}
+}
+
+// a bucket for one operation: deadlines and the copy buffer are bucket state, so a shared bucket
+// could not serve concurrent requests
+func (s *GridFSBlobStore) bucket(ctx context.Context) (*gridfs.Bucket, time.Time, error) {
+	bucket, err := gridfs.NewBucket(s.Database, options.GridFSBucket().SetName(s.BucketName))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	deadline := time.Now().Add(s.TransferTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	bucket.SetWriteDeadline(deadline)
+	bucket.SetReadDeadline(deadline)
+	return bucket, deadline, nil
+}
+
+// GridFS file ids are unique, so a replaced blob is deleted before the new one is written
+func (s *GridFSBlobStore) Put(ctx context.Context, key string, content io.Reader) error {
+	bucket, deadline, err := s.bucket(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.delete(ctx, bucket, key); err != nil {
+		return err
+	}
+	upload, err := bucket.OpenUploadStreamWithID(key, key)
+	if err != nil {
+		return err
+	}
+	upload.SetWriteDeadline(deadline)
+	if _, err := io.Copy(upload, contextReader{ctx: ctx, r: content}); err != nil {
+		upload.Abort()
+		return err
+	}
+	return upload.Close()
+}
+
+func (s *GridFSBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	bucket, deadline, err := s.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	download, err := bucket.OpenDownloadStream(key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, domain.NewNotFound("no blob stored under %s", key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	download.SetReadDeadline(deadline)
+	return download, nil
+}
+
+func (s *GridFSBlobStore) Delete(ctx context.Context, key string) error {
+	bucket, _, err := s.bucket(ctx)
+	if err != nil {
+		return err
+	}
+	return s.delete(ctx, bucket, key)
+}
+
+func (s *GridFSBlobStore) delete(ctx context.Context, bucket *gridfs.Bucket, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.TransferTimeout)
+	defer cancel()
+
+	if err := bucket.DeleteContext(ctx, key); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+		return err
+	}
+	return nil
+}
+
+// stops a copy once ctx is done; the GridFS streams only know about deadlines
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,101 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"task7/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoAttachmentRepository struct { // mongo implementer
+	AttachmentCollection *mongo.Collection
+	OperationTimeout     time.Duration
+}
+
+func NewMongoAttachmentRepository(attachmentCol *mongo.Collection) *MongoAttachmentRepository {
+	return &MongoAttachmentRepository{
+		AttachmentCollection: attachmentCol,
+		OperationTimeout:     DefaultOperationTimeout,
+	}
+}
+
+// ids are unique, and a task's attachments are always read oldest first
+func (m *MongoAttachmentRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	_, err := m.AttachmentCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "taskid", Value: 1}, {Key: "uploadedat", Value: 1}}},
+	})
+	return err
+}
+
+func (m *MongoAttachmentRepository) CreateAttachment(ctx context.Context, attachment domain.Attachment) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	_, err := m.AttachmentCollection.InsertOne(ctx, attachment)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.NewConflict("attachment %s already exists", attachment.ID)
+	}
+	return err
+}
+
+func (m *MongoAttachmentRepository) GetAttachment(ctx context.Context, id string) (domain.Attachment, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	var attachment domain.Attachment
+	err := m.AttachmentCollection.FindOne(ctx, bson.M{"id": id}).Decode(&attachment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return domain.Attachment{}, domain.NewNotFound("no attachment found with id %s", id)
+	}
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+	attachment.UploadedAt = attachment.UploadedAt.UTC()
+	return attachment, nil
+}
+
+func (m *MongoAttachmentRepository) ListAttachments(ctx context.Context, taskID int) ([]domain.Attachment, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	// the driver-assigned ObjectIDs grow with insertion, so they break timestamp ties oldest first
+	opts := options.Find().SetSort(bson.D{{Key: "uploadedat", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := m.AttachmentCollection.Find(ctx, bson.M{"taskid": taskID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var attachments []domain.Attachment
+	for cursor.Next(ctx) {
+		var attachment domain.Attachment
+		if err := cursor.Decode(&attachment); err != nil {
+			return nil, err
+		}
+		attachment.UploadedAt = attachment.UploadedAt.UTC()
+		attachments = append(attachments, attachment)
+	}
+	return attachments, cursor.Err()
+}
+
+func (m *MongoAttachmentRepository) DeleteAttachment(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	result, err := m.AttachmentCollection.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domain.NewNotFound("no attachment found with id %s", id)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package mongo
+
+import (
+	"context"
+	"task7/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoAuditRepository struct { // mongo implementer
+	AuditCollection  *mongo.Collection
+	OperationTimeout time.Duration
+}
+
+func NewMongoAuditRepository(auditCol *mongo.Collection) *MongoAuditRepository {
+	return &MongoAuditRepository{
+		AuditCollection:  auditCol,
+		OperationTimeout: DefaultOperationTimeout,
+	}
+}
+
+// an audit entry as stored; the ObjectID doubles as the entry id
+type auditDocument struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty"`
+	domain.AuditEntry `bson:",inline"`
+}
+
+// indexes for the filters GET /audit offers, all ending in the newest-first sort
+func (m *MongoAuditRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	_, err := m.AuditCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "targettype", Value: 1}, {Key: "targetid", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "actor", Value: 1}, {Key: "timestamp", Value: -1}}},
+	})
+	return err
+}
+
+func (m *MongoAuditRepository) Append(ctx context.Context, entry *domain.AuditEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	doc := auditDocument{ID: primitive.NewObjectID(), AuditEntry: *entry}
+	if _, err := m.AuditCollection.InsertOne(ctx, doc); err != nil {
+		return err
+	}
+	entry.ID = doc.ID.Hex()
+	return nil
+}
+
+func (m *MongoAuditRepository) QueryAudit(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEntry, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	filter := auditFilter(query)
+	total, err := m.AuditCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// ObjectIDs grow with insertion, so they break timestamp ties newest first too
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).SetSkip(int64(query.Offset))
+	if query.Limit > 0 {
+		opts.SetLimit(int64(query.Limit))
+	}
+	cursor, err := m.AuditCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []domain.AuditEntry
+	for cursor.Next(ctx) {
+		var doc auditDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, 0, err
+		}
+		doc.AuditEntry.ID = doc.ID.Hex()
+		doc.AuditEntry.Timestamp = doc.AuditEntry.Timestamp.UTC()
+		entries = append(entries, doc.AuditEntry)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func auditFilter(query domain.AuditQuery) bson.M {
+	filter := bson.M{}
+	if query.Actor != "" {
+		filter["actor"] = query.Actor
+	}
+	if query.Action != "" {
+		filter["action"] = query.Action
+	}
+	if query.TargetType != "" {
+		filter["targettype"] = query.TargetType
+	}
+	if query.TargetID != "" {
+		filter["targetid"] = query.TargetID
+	}
+	at := bson.M{}
+	if !query.Since.IsZero() {
+		at["$gte"] = query.Since
+	}
+	if !query.Until.IsZero() {
+		at["$lte"] = query.Until
+	}
+	if len(at) > 0 {
+		filter["timestamp"] = at
+	}
+	return filter
+}
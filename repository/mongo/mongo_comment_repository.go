@@ -0,0 +1,164 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"task7/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoCommentRepository struct { // mongo implementer
+	CommentCollection *mongo.Collection
+	OperationTimeout  time.Duration
+}
+
+func NewMongoCommentRepository(commentCol *mongo.Collection) *MongoCommentRepository {
+	return &MongoCommentRepository{
+		CommentCollection: commentCol,
+		OperationTimeout:  DefaultOperationTimeout,
+	}
+}
+
+// a comment as stored; the ObjectID doubles as the comment id
+type commentDocument struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	domain.Comment `bson:",inline"`
+}
+
+func (d commentDocument) comment() domain.Comment {
+	comment := d.Comment
+	comment.ID = d.ID.Hex()
+	comment.CreatedAt = comment.CreatedAt.UTC()
+	if comment.EditedAt != nil {
+		edited := comment.EditedAt.UTC()
+		comment.EditedAt = &edited
+	}
+	return comment
+}
+
+// a task's thread is always read oldest first
+func (m *MongoCommentRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	_, err := m.CommentCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "taskid", Value: 1}, {Key: "createdat", Value: 1}},
+	})
+	return err
+}
+
+func (m *MongoCommentRepository) CreateComment(ctx context.Context, comment *domain.Comment) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	doc := commentDocument{ID: primitive.NewObjectID(), Comment: *comment}
+	if _, err := m.CommentCollection.InsertOne(ctx, doc); err != nil {
+		return err
+	}
+	comment.ID = doc.ID.Hex()
+	return nil
+}
+
+func (m *MongoCommentRepository) GetComment(ctx context.Context, id string) (domain.Comment, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Comment{}, domain.NewNotFound("no comment found with id %s", id)
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	var doc commentDocument
+	err = m.CommentCollection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return domain.Comment{}, domain.NewNotFound("no comment found with id %s", id)
+	}
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	return doc.comment(), nil
+}
+
+func (m *MongoCommentRepository) ListComments(ctx context.Context, query domain.CommentQuery) ([]domain.Comment, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	filter := bson.M{"taskid": query.TaskID}
+	total, err := m.CommentCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// ObjectIDs grow with insertion, so they break timestamp ties oldest first too
+	opts := options.Find().SetSort(bson.D{{Key: "createdat", Value: 1}, {Key: "_id", Value: 1}}).SetSkip(int64(query.Offset))
+	if query.Limit > 0 {
+		opts.SetLimit(int64(query.Limit))
+	}
+	cursor, err := m.CommentCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var comments []domain.Comment
+	for cursor.Next(ctx) {
+		var doc commentDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, 0, err
+		}
+		comments = append(comments, doc.comment())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, 0, err
+	}
+	return comments, total, nil
+}
+
+func (m *MongoCommentRepository) UpdateComment(ctx context.Context, comment domain.Comment) error {
+	objectID, err := primitive.ObjectIDFromHex(comment.ID)
+	if err != nil {
+		return domain.NewNotFound("no comment found with id %s", comment.ID)
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	set := bson.M{"body": comment.Body, "mentions": comment.Mentions, "editedat": comment.EditedAt}
+	result, err := m.CommentCollection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.NewNotFound("no comment found with id %s", comment.ID)
+	}
+	return nil
+}
+
+func (m *MongoCommentRepository) DeleteComment(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.NewNotFound("no comment found with id %s", id)
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	result, err := m.CommentCollection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domain.NewNotFound("no comment found with id %s", id)
+	}
+	return nil
+}
+
+func (m *MongoCommentRepository) DeleteTaskComments(ctx context.Context, taskID int) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	_, err := m.CommentCollection.DeleteMany(ctx, bson.M{"taskid": taskID})
+	return err
+}
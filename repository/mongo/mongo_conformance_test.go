@@ -0,0 +1,134 @@
+package mongo_test
+
+import (
+	"context"
+	"task7/repository/interfaces"
+	"task7/repository/mongo"
+	"task7/repository/repotest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const conformanceDatabaseName = "task7_test_conformance_db"
+
+// connects to the local test MongoDB and drops the conformance database when the test ends
+func conformanceDatabase(t *testing.T) *mongodriver.Database {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongodriver.Connect(ctx, options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err, "Failed to connect to local MongoDB")
+	require.NoError(t, client.Ping(ctx, nil), "Failed to ping local MongoDB. Is it running?")
+
+	db := client.Database(conformanceDatabaseName)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		require.NoError(t, db.Drop(ctx), "Failed to drop conformance database")
+		require.NoError(t, client.Disconnect(ctx), "Failed to disconnect MongoDB client")
+	})
+	return db
+}
+
+func emptyCollection(t *testing.T, col *mongodriver.Collection) *mongodriver.Collection {
+	_, err := col.DeleteMany(context.Background(), bson.D{})
+	require.NoError(t, err, "Failed to clear "+col.Name()+" collection")
+	return col
+}
+
+func TestMongoTaskRepositoryConformance(t *testing.T) {
+	col := conformanceDatabase(t).Collection("tasks")
+	suite.Run(t, &repotest.TaskRepositorySuite{
+		NewRepository: func() interfaces.TaskRepository {
+			repo := mongo.NewMongoTaskRepository(emptyCollection(t, col))
+			emptyCollection(t, repo.CounterCollection)
+			return repo
+		},
+	})
+}
+
+func TestMongoUserRepositoryConformance(t *testing.T) {
+	col := conformanceDatabase(t).Collection("users")
+	suite.Run(t, &repotest.UserRepositorySuite{
+		NewRepository: func() interfaces.UserRepository {
+			return mongo.NewMongoUserRepository(emptyCollection(t, col))
+		},
+	})
+}
+
+func TestMongoTokenRepositoryConformance(t *testing.T) {
+	col := conformanceDatabase(t).Collection("refresh_tokens")
+	suite.Run(t, &repotest.TokenRepositorySuite{
+		NewRepository: func() interfaces.TokenRepository {
+			repo := mongo.NewMongoTokenRepository(emptyCollection(t, col))
+			emptyCollection(t, repo.RevokedCollection)
+			return repo
+		},
+	})
+}
+
+func TestMongoAuditRepositoryConformance(t *testing.T) {
+	col := conformanceDatabase(t).Collection("audit_log")
+	suite.Run(t, &repotest.AuditRepositorySuite{
+		NewRepository: func() interfaces.AuditRepository {
+			return mongo.NewMongoAuditRepository(emptyCollection(t, col))
+		},
+	})
+}
+
+func TestMongoCommentRepositoryConformance(t *testing.T) {
+	col := conformanceDatabase(t).Collection("comments")
+	suite.Run(t, &repotest.CommentRepositorySuite{
+		NewRepository: func() interfaces.CommentRepository {
+			return mongo.NewMongoCommentRepository(emptyCollection(t, col))
+		},
+	})
+}
+
+func TestMongoAttachmentRepositoryConformance(t *testing.T) {
+	col := conformanceDatabase(t).Collection("attachments")
+	suite.Run(t, &repotest.AttachmentRepositorySuite{
+		NewRepository: func() interfaces.AttachmentRepository {
+			repo := mongo.NewMongoAttachmentRepository(emptyCollection(t, col))
+			require.NoError(t, repo.EnsureIndexes(context.Background()))
+			return repo
+		},
+	})
+}
+
+func TestGridFSBlobStoreConformance(t *testing.T) {
+	db := conformanceDatabase(t)
+	suite.Run(t, &repotest.BlobStoreSuite{
+		NewStore: func() interfaces.BlobStore {
+			emptyCollection(t, db.Collection("blobs.files"))
+			emptyCollection(t, db.Collection("blobs.chunks"))
+			return mongo.NewGridFSBlobStore(db, "blobs")
+		},
+	})
+}
+
+func TestMongoHealthCheckerConformance(t *testing.T) {
+	client := conformanceDatabase(t).Client()
+	suite.Run(t, &repotest.HealthCheckerSuite{
+		NewChecker: func() interfaces.HealthChecker {
+			return mongo.NewMongoHealthChecker(client)
+		},
+	})
+}
+
+func TestMongoProjectRepositoryConformance(t *testing.T) {
+	col := conformanceDatabase(t).Collection("projects")
+	suite.Run(t, &repotest.ProjectRepositorySuite{
+		NewRepository: func() interfaces.ProjectRepository {
+			repo := mongo.NewMongoProjectRepository(emptyCollection(t, col))
+			require.NoError(t, repo.EnsureIndexes(context.Background()))
+			return repo
+		},
+	})
+}
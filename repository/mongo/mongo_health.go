@@ -0,0 +1,32 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+type MongoHealthChecker struct {
+	Client           *mongo.Client
+	OperationTimeout time.Duration
+}
+
+func NewMongoHealthChecker(client *mongo.Client) *MongoHealthChecker {
+	return &MongoHealthChecker{
+		Client:           client,
+		OperationTimeout: DefaultOperationTimeout,
+	}
+}
+
+func (m *MongoHealthChecker) Name() string {
+	return "mongo"
+}
+
+// round-trips to the primary, since that is where every write goes
+func (m *MongoHealthChecker) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+	return m.Client.Ping(ctx, readpref.Primary())
+}
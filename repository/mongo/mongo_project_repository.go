@@ -0,0 +1,194 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"task7/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoProjectRepository struct { // mongo implementer, members embedded in the project document
+	ProjectCollection *mongo.Collection
+	OperationTimeout  time.Duration
+}
+
+func NewMongoProjectRepository(projectCol *mongo.Collection) *MongoProjectRepository {
+	return &MongoProjectRepository{
+		ProjectCollection: projectCol,
+		OperationTimeout:  DefaultOperationTimeout,
+	}
+}
+
+// a project as stored; the ObjectID doubles as the project id
+type projectDocument struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	domain.Project `bson:",inline"`
+}
+
+func (d projectDocument) project() domain.Project {
+	project := d.Project
+	project.ID = d.ID.Hex()
+	project.CreatedAt = project.CreatedAt.UTC()
+	project.UpdatedAt = project.UpdatedAt.UTC()
+	return project
+}
+
+// members look up the projects they belong to on every listing
+func (m *MongoProjectRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	_, err := m.ProjectCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "members.username", Value: 1}},
+	})
+	return err
+}
+
+// gives projects stored before versioning version 1, so conditional writes can match them
+func (m *MongoProjectRepository) BackfillVersions(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	_, err := m.ProjectCollection.UpdateMany(ctx, bson.M{"version": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"version": 1}})
+	return err
+}
+
+func (m *MongoProjectRepository) CreateProject(ctx context.Context, project *domain.Project) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	doc := projectDocument{ID: primitive.NewObjectID(), Project: *project}
+	doc.Version = 1
+	if _, err := m.ProjectCollection.InsertOne(ctx, doc); err != nil {
+		return err
+	}
+	project.ID, project.Version = doc.ID.Hex(), doc.Version
+	return nil
+}
+
+func (m *MongoProjectRepository) GetProject(ctx context.Context, id string) (domain.Project, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Project{}, domain.NewNotFound("no project found with id %s", id)
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	var doc projectDocument
+	err = m.ProjectCollection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return domain.Project{}, domain.NewNotFound("no project found with id %s", id)
+	}
+	if err != nil {
+		return domain.Project{}, err
+	}
+	return doc.project(), nil
+}
+
+func (m *MongoProjectRepository) ListProjects(ctx context.Context, username string) ([]domain.Project, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	filter := bson.M{}
+	if username != "" {
+		filter["members.username"] = username
+	}
+	// ObjectIDs grow with insertion, so they break timestamp ties oldest first too
+	opts := options.Find().SetSort(bson.D{{Key: "createdat", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := m.ProjectCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var projects []domain.Project
+	for cursor.Next(ctx) {
+		var doc projectDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		projects = append(projects, doc.project())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+func (m *MongoProjectRepository) UpdateProject(ctx context.Context, project *domain.Project) error {
+	objectID, err := primitive.ObjectIDFromHex(project.ID)
+	if err != nil {
+		return domain.NewNotFound("no project found with id %s", project.ID)
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	set := bson.M{"name": project.Name, "description": project.Description, "members": project.Members, "updatedat": project.UpdatedAt}
+	result, err := m.ProjectCollection.UpdateOne(ctx, bson.M{"_id": objectID, "version": project.Version},
+		bson.M{"$set": set, "$inc": bson.M{"version": 1}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		n, err := m.ProjectCollection.CountDocuments(ctx, bson.M{"_id": objectID}, options.Count().SetLimit(1))
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return domain.NewNotFound("no project found with id %s", project.ID)
+		}
+		return domain.ErrProjectVersionMismatch
+	}
+	project.Version++
+	return nil
+}
+
+// the task count lives in the project document, as taskcount, next to the fields of domain.Project
+func (m *MongoProjectRepository) AdjustTaskCount(ctx context.Context, id string, delta int) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.NewNotFound("no project found with id %s", id)
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	count := bson.M{"$max": bson.A{bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$taskcount", 0}}, delta}}, 0}}
+	result, err := m.ProjectCollection.UpdateOne(ctx, bson.M{"_id": objectID}, mongo.Pipeline{{{Key: "$set", Value: bson.M{"taskcount": count}}}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.NewNotFound("no project found with id %s", id)
+	}
+	return nil
+}
+
+func (m *MongoProjectRepository) DeleteProject(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.NewNotFound("no project found with id %s", id)
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	result, err := m.ProjectCollection.DeleteOne(ctx, bson.M{"_id": objectID, "taskcount": bson.M{"$not": bson.M{"$gt": 0}}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount > 0 {
+		return nil
+	}
+	n, err := m.ProjectCollection.CountDocuments(ctx, bson.M{"_id": objectID}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.NewNotFound("no project found with id %s", id)
+	}
+	return domain.ErrProjectNotEmpty
+}
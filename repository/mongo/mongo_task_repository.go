@@ -3,42 +3,152 @@ package mongo
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"regexp"
 	"task7/domain"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// upper bound for a single repository call, on top of whatever deadline the caller's context carries
+const DefaultOperationTimeout = 5 * time.Second
+
+// task ids come from an atomically incremented document in this collection, keyed by the task collection name
+const countersCollection = "counters"
+
 // mongo db implementation of Task interface
 
 type MongoTaskRepository struct { // one type of implementation
-	TaskCollection *mongo.Collection
+	TaskCollection    *mongo.Collection
+	CounterCollection *mongo.Collection
+	OperationTimeout  time.Duration
+	Logger            *slog.Logger // undecodable documents are skipped and reported here
 }
 
 // constructor
 func NewMongoTaskRepository(taskCol *mongo.Collection) *MongoTaskRepository { // create object for that
 	return &MongoTaskRepository{
-		TaskCollection: taskCol,
+		TaskCollection:    taskCol,
+		CounterCollection: taskCol.Database().Collection(countersCollection),
+		OperationTimeout:  DefaultOperationTimeout,
+		Logger:            slog.Default(),
+	}
+}
+
+// creates the unique index on the task id so a duplicate can never be inserted, one on deletedat
+// for the trash listing and purge, ones for the priority and label filters, one on parentid
+// for subtask listings and counts, one on blockedby for walking dependencies downstream, and one
+// on projectid for project boards
+func (m *MongoTaskRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	_, err := m.TaskCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "deletedat", Value: 1}}},
+		{Keys: bson.D{{Key: "priority", Value: 1}}},
+		{Keys: bson.D{{Key: "labels", Value: 1}}}, // multikey: one entry per label
+		{Keys: bson.D{{Key: "parentid", Value: 1}}},
+		{Keys: bson.D{{Key: "blockedby", Value: 1}}}, // multikey
+		{Keys: bson.D{{Key: "projectid", Value: 1}}},
+	})
+	return err
+}
+
+// rewrites statuses stored before the task status state machine ("pending", "Done" ...) in canonical form
+func (m *MongoTaskRepository) NormalizeStatuses(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	stored, err := m.TaskCollection.Distinct(ctx, "status", bson.M{})
+	if err != nil {
+		return err
+	}
+	for _, value := range stored {
+		raw, ok := value.(string)
+		if !ok {
+			continue
+		}
+		status, err := domain.ParseTaskStatus(raw)
+		if err != nil || string(status) == raw {
+			continue // unknown values are left for an admin to sort out
+		}
+		_, err = m.TaskCollection.UpdateMany(ctx, bson.M{"status": raw}, bson.M{"$set": bson.M{"status": status}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// how often CreateTask draws a new id when the one it got is already taken
+const taskIDAttempts = 3
+
+// raises the task id sequence to at least the highest stored id, trashed tasks included, so ids handed
+// out from now on cannot clash with tasks stored before the sequence existed, when clients chose ids
+func (m *MongoTaskRepository) SeedIDCounter(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+	return m.raiseIDCounter(ctx)
+}
+
+func (m *MongoTaskRepository) raiseIDCounter(ctx context.Context) error {
+	var highest struct {
+		ID int `bson:"id"`
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "id", Value: -1}}).SetProjection(bson.M{"id": 1})
+	err := m.TaskCollection.FindOne(ctx, bson.M{}, opts).Decode(&highest)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find the highest task id: %w", err)
+	}
+	_, err = m.CounterCollection.UpdateOne(ctx, bson.M{"_id": m.TaskCollection.Name()}, bson.M{"$max": bson.M{"seq": highest.ID}}, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to raise the task id counter: %w", err)
 	}
+	return nil
+}
+
+// atomically increments and returns the task id sequence
+func (m *MongoTaskRepository) nextID(ctx context.Context) (int, error) {
+	filter := bson.M{"_id": m.TaskCollection.Name()}
+	update := bson.M{"$inc": bson.M{"seq": 1}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var counter struct {
+		Seq int `bson:"seq"`
+	}
+	err := m.CounterCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&counter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate task id: %w", err)
+	}
+	return counter.Seq, nil
 }
 
-func (m *MongoTaskRepository) GetAllTasks() ([]domain.Task, error) {
+func (m *MongoTaskRepository) GetAllTasks(ctx context.Context) ([]domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
 	var tasks []domain.Task
 
-	filter := bson.D{}
+	filter := bson.M{"deletedat": nil}
 
-	cursor, err := m.TaskCollection.Find(context.TODO(), filter)
+	cursor, err := m.TaskCollection.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
-	for cursor.Next(context.TODO()) {
+	for cursor.Next(ctx) {
 		var task domain.Task
 		err := cursor.Decode(&task)
 		if err != nil {
-			log.Println("Error decoding task:", err)
+			m.Logger.ErrorContext(ctx, "skipping undecodable task", slog.Any("error", err))
 			continue
 		}
 		tasks = append(tasks, task)
@@ -51,34 +161,153 @@ func (m *MongoTaskRepository) GetAllTasks() ([]domain.Task, error) {
 	return tasks, nil
 }
 
-func (m *MongoTaskRepository) GetTaskById(id int) (domain.Task, error) {
-	filter := bson.M{"id": id}
+func (m *MongoTaskRepository) QueryTasks(ctx context.Context, query domain.TaskQuery) ([]domain.Task, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	filter := taskFilter(query)
+	total, err := m.TaskCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	direction := 1
+	if query.SortDesc {
+		direction = -1
+	}
+	sortField, ok := domain.TaskSortFields[query.SortBy]
+	if !ok {
+		sortField = "id"
+	}
+	sort := bson.D{{Key: sortField, Value: direction}}
+	if sortField != "id" {
+		sort = append(sort, bson.E{Key: "id", Value: 1}) // stable order across pages
+	}
+	opts := options.Find().SetSort(sort).SetSkip(int64(query.Offset))
+	if query.Limit > 0 {
+		opts.SetLimit(int64(query.Limit))
+	}
+
+	cursor, err := m.TaskCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []domain.Task
+	for cursor.Next(ctx) {
+		var task domain.Task
+		if err := cursor.Decode(&task); err != nil {
+			m.Logger.ErrorContext(ctx, "skipping undecodable task", slog.Any("error", err))
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, 0, err
+	}
+	return tasks, total, nil
+}
+
+// a null deletedat also matches documents stored before soft delete, which have no such field
+func taskFilter(query domain.TaskQuery) bson.M {
+	filter := bson.M{"deletedat": nil}
+	if query.Trashed {
+		filter["deletedat"] = bson.M{"$ne": nil}
+	}
+	if query.Status != "" {
+		filter["status"] = query.Status
+	}
+	if query.Priority != "" {
+		filter["priority"] = query.Priority
+	}
+	if len(query.Labels) > 0 {
+		filter["labels"] = bson.M{"$all": query.Labels}
+	}
+	if query.ParentID != 0 {
+		filter["parentid"] = query.ParentID
+	}
+	if query.ProjectID != "" {
+		filter["projectid"] = query.ProjectID
+	}
+	if len(query.IDs) > 0 {
+		filter["id"] = bson.M{"$in": query.IDs}
+	}
+	if len(query.WaitingOn) > 0 {
+		filter["blockedby"] = bson.M{"$in": query.WaitingOn}
+	}
+	due := bson.M{}
+	if !query.DueAfter.IsZero() {
+		due["$gte"] = query.DueAfter
+	}
+	if !query.DueBefore.IsZero() {
+		due["$lte"] = query.DueBefore
+	}
+	if len(due) > 0 {
+		filter["duedate"] = due
+	}
+	if query.TitleContains != "" {
+		filter["title"] = bson.M{"$regex": regexp.QuoteMeta(query.TitleContains), "$options": "i"}
+	}
+	if query.VisibleTo != "" {
+		filter["$or"] = bson.A{bson.M{"createdby": query.VisibleTo}, bson.M{"assignedto": query.VisibleTo}}
+	}
+	return filter
+}
+
+func (m *MongoTaskRepository) GetTaskById(ctx context.Context, id int) (domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	filter := bson.M{"id": id, "deletedat": nil}
 
 	var task domain.Task
-	err := m.TaskCollection.FindOne(context.TODO(), filter).Decode(&task)
+	err := m.TaskCollection.FindOne(ctx, filter).Decode(&task)
+	if err == mongo.ErrNoDocuments {
+		return domain.Task{}, domain.NewNotFound("no task found with id %d", id)
+	}
 	if err != nil {
 		return domain.Task{}, err
 	}
 	return task, nil
 }
 
-func (m *MongoTaskRepository) CreateTask(newTask *domain.Task) error {
-	if newTask.ID == 0 || newTask.Title == "" || newTask.Description == "" || newTask.Status == "" || newTask.DueDate.IsZero() {
-		return fmt.Errorf("missing required field(s) in newTask")
+// assigns newTask.ID from the counter; any id or deletion time sent by the caller is ignored
+func (m *MongoTaskRepository) CreateTask(ctx context.Context, newTask *domain.Task) error {
+	if newTask.Title == "" || newTask.Description == "" || newTask.Status == "" || newTask.DueDate.IsZero() {
+		return domain.NewValidation("missing required field(s) in newTask")
 	}
-	filter := bson.M{"id": newTask.ID}
-	var tempTask domain.Task
-	err := m.TaskCollection.FindOne(context.TODO(), filter).Decode(&tempTask)
-	if err == nil {
-		return fmt.Errorf("id already exists")
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
 
+	newTask.Version = 1
+	newTask.DeletedAt = nil
+	if newTask.Priority == "" {
+		newTask.Priority = domain.DefaultPriority
+	}
+	for attempt := 1; ; attempt++ {
+		id, err := m.nextID(ctx)
+		if err != nil {
+			return err
+		}
+		newTask.ID = id
+		_, err = m.TaskCollection.InsertOne(ctx, newTask)
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+		if attempt == taskIDAttempts {
+			return domain.NewConflict("could not allocate a free task id, please retry")
+		}
+		// the counter is behind the stored ids; move it past them and draw again
+		if err := m.raiseIDCounter(ctx); err != nil {
+			return err
+		}
 	}
-	_, err = m.TaskCollection.InsertOne(context.TODO(), newTask)
-	return err
 }
 
-func (m *MongoTaskRepository) UpdateTask(id int, updatedTask *domain.Task) error {
-	filter := bson.M{"id": id}
+// a compare-and-set on the version: the filter only matches the version the caller read
+func (m *MongoTaskRepository) UpdateTask(ctx context.Context, id int, version int64, updatedTask *domain.Task) error {
+	filter := bson.M{"id": id, "version": version, "deletedat": nil}
 
 	updateFields := bson.M{}
 	if updatedTask.Title != "" {
@@ -93,21 +322,211 @@ func (m *MongoTaskRepository) UpdateTask(id int, updatedTask *domain.Task) error
 	if updatedTask.Status != "" {
 		updateFields["status"] = updatedTask.Status
 	}
+	if updatedTask.AssignedTo != "" {
+		updateFields["assignedto"] = updatedTask.AssignedTo
+	}
+	if updatedTask.Priority != "" {
+		updateFields["priority"] = updatedTask.Priority
+	}
+	if updatedTask.Labels != nil {
+		updateFields["labels"] = updatedTask.Labels
+	}
+	if updatedTask.Checklist != nil {
+		updateFields["checklist"] = updatedTask.Checklist
+	}
+	if updatedTask.BlockedBy != nil {
+		updateFields["blockedby"] = updatedTask.BlockedBy
+	}
+	if updatedTask.Recurrence != nil {
+		updateFields["recurrence"] = nil // a rule without a frequency clears it
+		if updatedTask.Recurrence.Frequency != "" {
+			updateFields["recurrence"] = updatedTask.Recurrence
+		}
+	}
 
 	if len(updateFields) == 0 {
+		updatedTask.Version = version
 		return nil
 	}
 
-	update := bson.M{"$set": updateFields}
-	res, err := m.TaskCollection.UpdateOne(context.TODO(), filter, update)
-	if res.MatchedCount == 0 || err != nil {
-		return fmt.Errorf("no task found with id %d", id)
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	update := bson.M{"$set": updateFields, "$inc": bson.M{"version": 1}}
+	res, err := m.TaskCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return m.missOrMismatch(ctx, id)
+	}
+	updatedTask.Version = version + 1
+	return nil
+}
+
+// a soft delete: the task only gets a deletedat stamp, guarded by the same version check as UpdateTask
+func (m *MongoTaskRepository) DeleteTaskById(ctx context.Context, id int, version int64) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	filter := bson.M{"id": id, "version": version, "deletedat": nil}
+	update := bson.M{"$set": bson.M{"deletedat": time.Now().UTC()}, "$inc": bson.M{"version": 1}}
+	res, err := m.TaskCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return m.missOrMismatch(ctx, id)
 	}
 	return nil
 }
 
-func (m *MongoTaskRepository) DeleteTaskById(id int) error {
-	filter := bson.M{"id": id}
-	_, err := m.TaskCollection.DeleteOne(context.TODO(), filter)
+func (m *MongoTaskRepository) RestoreTask(ctx context.Context, id int) (domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	filter := bson.M{"id": id, "deletedat": bson.M{"$ne": nil}}
+	update := bson.M{"$unset": bson.M{"deletedat": ""}, "$inc": bson.M{"version": 1}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var task domain.Task
+	err := m.TaskCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&task)
+	if err == mongo.ErrNoDocuments {
+		return domain.Task{}, domain.NewNotFound("no deleted task found with id %d", id)
+	}
+	if err != nil {
+		return domain.Task{}, err
+	}
+	return task, nil
+}
+
+func (m *MongoTaskRepository) PurgeDeletedTasks(ctx context.Context, deletedBefore time.Time) ([]domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	// the candidates are read first and deleted one by one, so a task restored in between is neither
+	// deleted nor reported
+	filter := bson.M{"deletedat": bson.M{"$ne": nil, "$lt": deletedBefore}}
+	cursor, err := m.TaskCollection.Find(ctx, filter, options.Find().SetSort(bson.M{"id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var found []domain.Task
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	var purged []domain.Task
+	for _, task := range found {
+		filter["id"] = task.ID
+		res, err := m.TaskCollection.DeleteOne(ctx, filter)
+		if err != nil {
+			return purged, err
+		}
+		if res.DeletedCount > 0 {
+			purged = append(purged, task)
+		}
+	}
+	return purged, nil
+}
+
+// explains why a versioned filter on a live task id matched nothing
+func (m *MongoTaskRepository) missOrMismatch(ctx context.Context, id int) error {
+	n, err := m.TaskCollection.CountDocuments(ctx, bson.M{"id": id, "deletedat": nil}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.NewNotFound("no task found with id %d", id)
+	}
+	return domain.ErrTaskVersionMismatch
+}
+
+// gives tasks stored before priorities the default one, so priority filters find them
+func (m *MongoTaskRepository) BackfillPriorities(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	_, err := m.TaskCollection.UpdateMany(ctx, bson.M{"priority": bson.M{"$in": bson.A{nil, ""}}}, bson.M{"$set": bson.M{"priority": domain.DefaultPriority}})
+	return err
+}
+
+// gives tasks stored before versioning version 1, so conditional writes can match them
+func (m *MongoTaskRepository) BackfillVersions(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	_, err := m.TaskCollection.UpdateMany(ctx, bson.M{"version": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"version": 1}})
 	return err
 }
+
+func (m *MongoTaskRepository) CountLabels(ctx context.Context, visibleTo string) ([]domain.LabelCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	match := taskFilter(domain.TaskQuery{VisibleTo: visibleTo})
+	cursor, err := m.TaskCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$labels"}},
+		{{Key: "$group", Value: bson.M{"_id": "$labels", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	labels := []domain.LabelCount{}
+	for cursor.Next(ctx) {
+		var row struct {
+			Label string `bson:"_id"`
+			Count int64  `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		labels = append(labels, domain.LabelCount{Label: row.Label, Count: row.Count})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func (m *MongoTaskRepository) CountSubtasks(ctx context.Context, parentIDs []int) (map[int]domain.SubtaskCount, error) {
+	counts := map[int]domain.SubtaskCount{}
+	if len(parentIDs) == 0 {
+		return counts, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	closed := bson.A{domain.StatusDone, domain.StatusArchived}
+	cursor, err := m.TaskCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"parentid": bson.M{"$in": parentIDs}, "deletedat": nil}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    "$parentid",
+			"total":  bson.M{"$sum": 1},
+			"closed": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$in": bson.A{"$status", closed}}, 1, 0}}},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var row struct {
+			ParentID int   `bson:"_id"`
+			Total    int64 `bson:"total"`
+			Closed   int64 `bson:"closed"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		counts[row.ParentID] = domain.SubtaskCount{Total: row.Total, Closed: row.Closed}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
@@ -43,10 +43,7 @@ func (s *TaskRepositorySuite) SetupSuite() {
 	s.taskCollection = client.Database(s.databaseName).Collection("tasks")
 	s.taskRepo = mongo.NewMongoTaskRepository(s.taskCollection)
 
-	_, err = s.taskCollection.Indexes().CreateOne(context.Background(), mongodriver.IndexModel{
-		Keys:    bson.D{{Key: "id", Value: 1}},
-		Options: options.Index().SetUnique(true),
-	})
+	err = s.taskRepo.EnsureIndexes(context.Background())
 	s.Require().NoError(err, "Failed to create unique index on task ID")
 }
 
@@ -68,6 +65,8 @@ func (s *TaskRepositorySuite) TearDownSuite() {
 func (s *TaskRepositorySuite) SetupTest() {
 	_, err := s.taskCollection.DeleteMany(context.Background(), bson.D{})
 	s.Require().NoError(err, "Failed to clear tasks collection")
+	_, err = s.taskRepo.CounterCollection.DeleteMany(context.Background(), bson.D{})
+	s.Require().NoError(err, "Failed to reset task id counter")
 }
 
 func (s *TaskRepositorySuite) TestCreateTask_Success() {
@@ -75,13 +74,12 @@ func (s *TaskRepositorySuite) TestCreateTask_Success() {
 	s.Require().NoError(err, "Failed to parse due date string")
 
 	task := &domain.Task{
-		ID:          1,
 		Title:       "Test Task",
 		Description: "Test Description",
 		DueDate:     dueDate.Truncate(time.Millisecond),
 		Status:      "pending",
 	}
-	err = s.taskRepo.CreateTask(task)
+	err = s.taskRepo.CreateTask(context.Background(), task)
 	s.Require().NoError(err, "Failed to create task")
 
 	var result domain.Task
@@ -100,13 +98,13 @@ func (s *TaskRepositorySuite) TestGetAllTasks() {
 	dueDate2, err := time.Parse(time.RFC3339, "2025-07-31T00:00:00Z")
 	s.Require().NoError(err, "Failed to parse due date for Task2")
 
-	task1 := &domain.Task{ID: 10, Title: "Task1", Description: "Desc1", DueDate: dueDate1.Truncate(time.Millisecond), Status: "pending"}
-	task2 := &domain.Task{ID: 11, Title: "Task2", Description: "Desc2", DueDate: dueDate2.Truncate(time.Millisecond), Status: "completed"}
+	task1 := &domain.Task{Title: "Task1", Description: "Desc1", DueDate: dueDate1.Truncate(time.Millisecond), Status: "pending"}
+	task2 := &domain.Task{Title: "Task2", Description: "Desc2", DueDate: dueDate2.Truncate(time.Millisecond), Status: "completed"}
 
-	s.Require().NoError(s.taskRepo.CreateTask(task1), "Failed to insert Task1")
-	s.Require().NoError(s.taskRepo.CreateTask(task2), "Failed to insert Task2")
+	s.Require().NoError(s.taskRepo.CreateTask(context.Background(), task1), "Failed to insert Task1")
+	s.Require().NoError(s.taskRepo.CreateTask(context.Background(), task2), "Failed to insert Task2")
 
-	allTasks, err := s.taskRepo.GetAllTasks()
+	allTasks, err := s.taskRepo.GetAllTasks(context.Background())
 	s.Require().NoError(err, "Failed to get all tasks")
 	s.Len(allTasks, 2)
 
@@ -119,15 +117,14 @@ func (s *TaskRepositorySuite) TestGetTaskById_Success() {
 	s.Require().NoError(err, "Failed to parse due date for FindMe")
 
 	task := &domain.Task{
-		ID:          101,
 		Title:       "FindMe",
 		Description: "Find this task",
 		DueDate:     dueDate.Truncate(time.Millisecond),
 		Status:      "pending",
 	}
-	s.Require().NoError(s.taskRepo.CreateTask(task), "Failed to insert task for GetTaskById")
+	s.Require().NoError(s.taskRepo.CreateTask(context.Background(), task), "Failed to insert task for GetTaskById")
 
-	found, err := s.taskRepo.GetTaskById(101)
+	found, err := s.taskRepo.GetTaskById(context.Background(), task.ID)
 	s.Require().NoError(err, "Failed to get task by ID")
 	s.Equal(task.ID, found.ID)
 	s.Equal(task.Title, found.Title)
@@ -137,14 +134,121 @@ func (s *TaskRepositorySuite) TestGetTaskById_Success() {
 }
 
 func (s *TaskRepositorySuite) TestGetTaskById_NotFound() {
-	_, err := s.taskRepo.GetTaskById(9999)
+	_, err := s.taskRepo.GetTaskById(context.Background(), 9999)
 	s.Error(err, "Expected error for non-existent task ID")
 	s.Contains(err.Error(), "mongo: no documents in result")
 }
 
+func (s *TaskRepositorySuite) TestCreateTask_AssignsIDFromCounter() {
+	dueDate, err := time.Parse(time.RFC3339, "2025-07-30T00:00:00Z")
+	s.Require().NoError(err)
+
+	for want := 1; want <= 3; want++ {
+		task := &domain.Task{ID: 42, Title: "Counted", Description: "Desc", DueDate: dueDate, Status: "pending"}
+		s.Require().NoError(s.taskRepo.CreateTask(context.Background(), task))
+		s.Equal(want, task.ID, "Client-supplied ID should be replaced by the counter value")
+	}
+
+	var counter bson.M
+	err = s.taskRepo.CounterCollection.FindOne(context.Background(), bson.M{"_id": s.taskCollection.Name()}).Decode(&counter)
+	s.Require().NoError(err, "Counter document should exist")
+	s.EqualValues(3, counter["seq"])
+}
+
+func (s *TaskRepositorySuite) TestNormalizeStatuses() {
+	ctx := context.Background()
+	_, err := s.taskCollection.InsertMany(ctx, []interface{}{
+		bson.M{"id": 1, "title": "Legacy pending", "status": "pending"},
+		bson.M{"id": 2, "title": "Legacy done", "status": "Done"},
+		bson.M{"id": 3, "title": "Already canonical", "status": "blocked"},
+		bson.M{"id": 4, "title": "Unknown", "status": "someday"},
+	})
+	s.Require().NoError(err, "Failed to seed legacy tasks")
+
+	s.Require().NoError(s.taskRepo.NormalizeStatuses(ctx))
+
+	want := map[int]domain.TaskStatus{1: domain.StatusTodo, 2: domain.StatusDone, 3: domain.StatusBlocked, 4: "someday"}
+	for id, status := range want {
+		task, err := s.taskRepo.GetTaskById(ctx, id)
+		s.Require().NoError(err)
+		s.Equal(status, task.Status, "task %d", id)
+	}
+}
+
+func (s *TaskRepositorySuite) TestSeedIDCounter_SkipsExistingIDs() {
+	ctx := context.Background()
+	deletedAt := time.Now()
+	_, err := s.taskCollection.InsertMany(ctx, []interface{}{
+		bson.M{"id": 1, "title": "Client chosen", "status": "todo", "version": 1},
+		bson.M{"id": 41, "title": "Client chosen", "status": "todo", "version": 1},
+		bson.M{"id": 42, "title": "Trashed", "status": "todo", "version": 1, "deletedat": deletedAt},
+	})
+	s.Require().NoError(err, "Failed to seed tasks stored before the counter")
+
+	s.Require().NoError(s.taskRepo.SeedIDCounter(ctx))
+	s.Require().NoError(s.taskRepo.SeedIDCounter(ctx), "Seeding twice must not move the counter again")
+
+	task := &domain.Task{Title: "New", Description: "After the upgrade", DueDate: time.Now(), Status: domain.StatusTodo}
+	s.Require().NoError(s.taskRepo.CreateTask(ctx, task))
+	s.Equal(43, task.ID, "New ids start above every stored one, trashed tasks included")
+}
+
+func (s *TaskRepositorySuite) TestCreateTask_CounterBehindStoredIDs() {
+	ctx := context.Background()
+	_, err := s.taskCollection.InsertMany(ctx, []interface{}{
+		bson.M{"id": 1, "title": "Client chosen", "status": "todo", "version": 1},
+		bson.M{"id": 7, "title": "Client chosen", "status": "todo", "version": 1},
+	})
+	s.Require().NoError(err, "Failed to seed tasks stored before the counter")
+
+	task := &domain.Task{Title: "New", Description: "Without a seeded counter", DueDate: time.Now(), Status: domain.StatusTodo}
+	s.Require().NoError(s.taskRepo.CreateTask(ctx, task), "A clash moves the counter on instead of failing")
+	s.Equal(8, task.ID)
+
+	found, err := s.taskRepo.GetTaskById(ctx, 1)
+	s.Require().NoError(err)
+	s.Equal("Client chosen", found.Title, "The stored task is not overwritten")
+}
+
+func (s *TaskRepositorySuite) TestBackfillVersions() {
+	ctx := context.Background()
+	_, err := s.taskCollection.InsertMany(ctx, []interface{}{
+		bson.M{"id": 1, "title": "Legacy", "status": "todo"},
+		bson.M{"id": 2, "title": "Versioned", "status": "todo", "version": 5},
+	})
+	s.Require().NoError(err, "Failed to seed tasks")
+
+	s.Require().NoError(s.taskRepo.BackfillVersions(ctx))
+
+	legacy, err := s.taskRepo.GetTaskById(ctx, 1)
+	s.Require().NoError(err)
+	s.EqualValues(1, legacy.Version)
+	versioned, err := s.taskRepo.GetTaskById(ctx, 2)
+	s.Require().NoError(err)
+	s.EqualValues(5, versioned.Version, "Existing versions are left alone")
+}
+
+func (s *TaskRepositorySuite) TestBackfillPriorities() {
+	ctx := context.Background()
+	_, err := s.taskCollection.InsertMany(ctx, []interface{}{
+		bson.M{"id": 1, "title": "Legacy", "status": "todo", "version": 1},
+		bson.M{"id": 2, "title": "Urgent", "status": "todo", "version": 1, "priority": "urgent"},
+	})
+	s.Require().NoError(err, "Failed to seed tasks")
+
+	s.Require().NoError(s.taskRepo.BackfillPriorities(ctx))
+
+	legacy, err := s.taskRepo.GetTaskById(ctx, 1)
+	s.Require().NoError(err)
+	s.Equal(domain.DefaultPriority, legacy.Priority)
+	urgent, err := s.taskRepo.GetTaskById(ctx, 2)
+	s.Require().NoError(err)
+	s.Equal(domain.PriorityUrgent, urgent.Priority, "Existing priorities are left alone")
+}
+
 func (s *TaskRepositorySuite) TestCreateTask_MissingFields() {
-	task := &domain.Task{ID: 0, Title: "", Description: "", Status: "", DueDate: time.Time{}}
-	err := s.taskRepo.CreateTask(task)
+	task := &domain.Task{Title: "", Description: "", Status: "", DueDate: time.Time{}}
+	err := s.taskRepo.CreateTask(context.Background(), task)
 	s.Error(err, "Expected error for missing required fields")
 	s.Contains(err.Error(), "missing required field(s) in newTask")
 }
@@ -153,14 +257,14 @@ func (s *TaskRepositorySuite) TestUpdateTask_NoFields() {
 	dueDate, err := time.Parse(time.RFC3339, "2025-07-30T00:00:00Z")
 	s.Require().NoError(err, "Failed to parse due date for NoUpdate")
 
-	task := &domain.Task{ID: 202, Title: "NoUpdate", Description: "Nothing", DueDate: dueDate.Truncate(time.Millisecond), Status: "pending"}
-	s.Require().NoError(s.taskRepo.CreateTask(task), "Failed to insert task for no update")
+	task := &domain.Task{Title: "NoUpdate", Description: "Nothing", DueDate: dueDate.Truncate(time.Millisecond), Status: "pending"}
+	s.Require().NoError(s.taskRepo.CreateTask(context.Background(), task), "Failed to insert task for no update")
 
 	emptyUpdate := &domain.Task{}
-	err = s.taskRepo.UpdateTask(202, emptyUpdate)
+	err = s.taskRepo.UpdateTask(context.Background(), task.ID, task.Version, emptyUpdate)
 	s.NoError(err, "No error expected when no fields to update")
 
-	fetchedTask, err := s.taskRepo.GetTaskById(202)
+	fetchedTask, err := s.taskRepo.GetTaskById(context.Background(), task.ID)
 	s.Require().NoError(err)
 	s.Equal(task.Title, fetchedTask.Title)
 	s.Equal(task.Description, fetchedTask.Description)
@@ -170,28 +274,27 @@ func (s *TaskRepositorySuite) TestUpdateTask_NoFields() {
 
 func (s *TaskRepositorySuite) TestUpdateTask_NotFound() {
 	update := &domain.Task{Title: "ShouldNotUpdate"}
-	err := s.taskRepo.UpdateTask(9999, update)
+	err := s.taskRepo.UpdateTask(context.Background(), 9999, 1, update)
 	s.Error(err, "Expected error for updating non-existent task")
 	s.Contains(err.Error(), "no task found with id 9999")
 }
 
 func (s *TaskRepositorySuite) TestDeleteTaskById_NotFound() {
-	err := s.taskRepo.DeleteTaskById(9999)
-	s.NoError(err, "Delete on non-existent ID should not error")
+	err := s.taskRepo.DeleteTaskById(context.Background(), 9999, 1)
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "Deleting a non-existent ID should say so")
 }
 
 func (s *TaskRepositorySuite) TestUpdateTask_Success() {
 	originalDueDate, err := time.Parse(time.RFC3339, "2025-07-30T00:00:00Z")
 	s.Require().NoError(err, "Failed to parse original due date")
-	taskID := 300
 	task := &domain.Task{
-		ID:          taskID,
 		Title:       "Old Title",
 		Description: "Old Description",
 		DueDate:     originalDueDate.Truncate(time.Millisecond),
 		Status:      "pending",
 	}
-	s.Require().NoError(s.taskRepo.CreateTask(task), "Failed to insert task for update test")
+	s.Require().NoError(s.taskRepo.CreateTask(context.Background(), task), "Failed to insert task for update test")
+	taskID := task.ID
 
 	updatedDueDate, err := time.Parse(time.RFC3339, "2025-08-01T00:00:00Z")
 	s.Require().NoError(err, "Failed to parse updated due date")
@@ -202,7 +305,7 @@ func (s *TaskRepositorySuite) TestUpdateTask_Success() {
 		DueDate:     updatedDueDate.Truncate(time.Millisecond),
 		Status:      "completed",
 	}
-	err = s.taskRepo.UpdateTask(taskID, update)
+	err = s.taskRepo.UpdateTask(context.Background(), taskID, task.Version, update)
 	s.Require().NoError(err, "Failed to update task")
 
 	var result domain.Task
@@ -217,19 +320,25 @@ func (s *TaskRepositorySuite) TestUpdateTask_Success() {
 func (s *TaskRepositorySuite) TestDeleteTaskById_Success() {
 	dueDate, err := time.Parse(time.RFC3339, "2025-07-30T00:00:00Z")
 	s.Require().NoError(err, "Failed to parse due date for DeleteMe")
-	taskID := 400
 	task := &domain.Task{
-		ID:          taskID,
 		Title:       "DeleteMe",
 		Description: "ToDelete",
 		DueDate:     dueDate.Truncate(time.Millisecond),
 		Status:      "pending",
 	}
-	s.Require().NoError(s.taskRepo.CreateTask(task), "Failed to insert task for deletion")
+	s.Require().NoError(s.taskRepo.CreateTask(context.Background(), task), "Failed to insert task for deletion")
+	taskID := task.ID
 
-	err = s.taskRepo.DeleteTaskById(taskID)
+	err = s.taskRepo.DeleteTaskById(context.Background(), taskID, task.Version)
 	s.Require().NoError(err, "Failed to delete task")
 
+	var stored domain.Task
+	err = s.taskCollection.FindOne(context.Background(), bson.M{"id": taskID}).Decode(&stored)
+	s.Require().NoError(err, "A deleted task stays stored until it is purged")
+	s.NotNil(stored.DeletedAt)
+
+	_, err = s.taskRepo.PurgeDeletedTasks(context.Background(), time.Now().Add(time.Minute))
+	s.Require().NoError(err)
 	err = s.taskCollection.FindOne(context.Background(), bson.M{"id": taskID}).Err()
-	s.Equal(mongodriver.ErrNoDocuments, err, "Task was not deleted or wrong error returned")
+	s.Equal(mongodriver.ErrNoDocuments, err, "Task was not purged or wrong error returned")
 }
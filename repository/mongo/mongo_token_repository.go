@@ -0,0 +1,97 @@
+package mongo
+
+import (
+	"context"
+	"task7/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// revoked access token ids live next to the refresh tokens, in this collection
+const revokedTokensCollection = "revoked_tokens"
+
+type MongoTokenRepository struct { // mongo implementer
+	RefreshCollection *mongo.Collection
+	RevokedCollection *mongo.Collection
+	OperationTimeout  time.Duration
+}
+
+func NewMongoTokenRepository(refreshCol *mongo.Collection) *MongoTokenRepository {
+	return &MongoTokenRepository{
+		RefreshCollection: refreshCol,
+		RevokedCollection: refreshCol.Database().Collection(revokedTokensCollection),
+		OperationTimeout:  DefaultOperationTimeout,
+	}
+}
+
+// unique index on the token hash, plus TTL indexes so mongo drops expired entries on its own
+func (m *MongoTokenRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	_, err := m.RefreshCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenhash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expiresat", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = m.RevokedCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresat", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (m *MongoTokenRepository) StoreRefreshToken(ctx context.Context, token domain.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	_, err := m.RefreshCollection.InsertOne(ctx, token)
+	return err
+}
+
+func (m *MongoTokenRepository) ConsumeRefreshToken(ctx context.Context, tokenHash string) (domain.RefreshToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	var token domain.RefreshToken
+	err := m.RefreshCollection.FindOneAndDelete(ctx, bson.M{"tokenhash": tokenHash}).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		return domain.RefreshToken{}, domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return domain.RefreshToken{}, err
+	}
+	// the TTL monitor only runs once a minute, so expiry is checked here too
+	if !time.Now().Before(token.ExpiresAt) {
+		return domain.RefreshToken{}, domain.ErrInvalidRefreshToken
+	}
+	return token, nil
+}
+
+func (m *MongoTokenRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	_, err := m.RevokedCollection.UpdateOne(ctx,
+		bson.M{"_id": jti},
+		bson.M{"$set": bson.M{"expiresat": expiresAt}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (m *MongoTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	n, err := m.RevokedCollection.CountDocuments(ctx, bson.M{"_id": jti}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -3,47 +3,56 @@ package mongo
 import (
 	"context"
 	"fmt"
+	"slices"
 	"task7/domain"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type MongoUserRepository struct { // mongo implementer
-	UserCollection *mongo.Collection
+	UserCollection   *mongo.Collection
+	OperationTimeout time.Duration
+	BcryptCost       int
 }
 
 func NewMongoUserRepository(userCol *mongo.Collection) *MongoUserRepository { // instance of mongo implementer
 	return &MongoUserRepository{
-		UserCollection: userCol,
+		UserCollection:   userCol,
+		OperationTimeout: DefaultOperationTimeout,
+		BcryptCost:       bcrypt.DefaultCost,
 	}
 }
 
-func (m *MongoUserRepository) RegisterUser(newUser *domain.User) error {
+func (m *MongoUserRepository) RegisterUser(ctx context.Context, newUser *domain.User) error {
 	if newUser.PasswordHash == "" {
-		return fmt.Errorf("password cannot be empty")
+		return domain.NewValidation("password cannot be empty")
 	}
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
 
 	filter := bson.M{"username": newUser.Username}
 	existingUser := &domain.User{}
-	err := m.UserCollection.FindOne(context.TODO(), filter).Decode(existingUser)
+	err := m.UserCollection.FindOne(ctx, filter).Decode(existingUser)
 
 	if err == nil {
-		return fmt.Errorf("user with username '%s' already exists", newUser.Username)
+		return domain.NewConflict("user with username '%s' already exists", newUser.Username)
 	}
 	if err != mongo.ErrNoDocuments {
 		return fmt.Errorf("database error checking for existing user: %w", err)
 	}
 
-	hashed_pw, err := bcrypt.GenerateFromPassword([]byte(newUser.PasswordHash), bcrypt.DefaultCost)
+	hashed_pw, err := bcrypt.GenerateFromPassword([]byte(newUser.PasswordHash), m.BcryptCost)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
 	}
 	newUser.PasswordHash = string(hashed_pw)
 
-	count, err := m.UserCollection.CountDocuments(context.TODO(), bson.D{})
+	count, err := m.UserCollection.CountDocuments(ctx, bson.D{})
 	if err != nil {
 		return fmt.Errorf("database error counting users: %w", err)
 	}
@@ -55,10 +64,10 @@ func (m *MongoUserRepository) RegisterUser(newUser *domain.User) error {
 	}
 
 	newUser.ID = primitive.NewObjectID()
-	_, err = m.UserCollection.InsertOne(context.TODO(), newUser)
+	_, err = m.UserCollection.InsertOne(ctx, newUser)
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
-			return fmt.Errorf("user with username '%s' already exists (duplicate key error)", newUser.Username)
+			return domain.NewConflict("user with username '%s' already exists", newUser.Username)
 		}
 		return fmt.Errorf("failed to insert user into database: %w", err)
 	}
@@ -66,29 +75,77 @@ func (m *MongoUserRepository) RegisterUser(newUser *domain.User) error {
 	return nil
 }
 
-func (m *MongoUserRepository) LoginUser(existingUser *domain.User) (domain.User, error) {
+func (m *MongoUserRepository) LoginUser(ctx context.Context, existingUser *domain.User) (domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
 	filter := bson.M{"username": existingUser.Username}
 	var user domain.User
-	err := m.UserCollection.FindOne(context.TODO(), filter).Decode(&user)
+	err := m.UserCollection.FindOne(ctx, filter).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return domain.User{}, domain.NewNotFound("user not found")
+	}
 	if err != nil {
 		return domain.User{}, err
 	}
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(existingUser.PasswordHash))
 	if err != nil {
-		return domain.User{}, err
+		return domain.User{}, &domain.Error{Kind: domain.KindUnauthorized, Message: "incorrect password", Err: err}
 	}
 	return user, nil
 }
 
-func (m *MongoUserRepository) PromoteUser(username string) error {
+func (m *MongoUserRepository) PromoteUser(ctx context.Context, username string) error {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
 	filter := bson.M{"username": username}
 	update := bson.M{"$set": bson.M{"role": "admin"}}
-	result, err := m.UserCollection.UpdateOne(context.TODO(), filter, update)
+	result, err := m.UserCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("user not found")
+		return domain.NewNotFound("user not found")
 	}
 	return nil
 }
+
+func (m *MongoUserRepository) GetRole(ctx context.Context, username string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	var user struct {
+		Role string `bson:"role"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"role": 1})
+	err := m.UserCollection.FindOne(ctx, bson.M{"username": username}, opts).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return "", domain.NewNotFound("user not found")
+	}
+	if err != nil {
+		return "", err
+	}
+	return user.Role, nil
+}
+
+func (m *MongoUserRepository) FindUsernames(ctx context.Context, usernames []string) ([]string, error) {
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.OperationTimeout)
+	defer cancel()
+
+	found, err := m.UserCollection.Distinct(ctx, "username", bson.M{"username": bson.M{"$in": usernames}})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(found))
+	for _, name := range found {
+		if s, ok := name.(string); ok {
+			names = append(names, s)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
@@ -77,7 +77,7 @@ func (s *MongoUserRepositorySuite) TestRegisterUser_FirstUserAsAdmin() {
 		PasswordHash: "password123",
 	}
 
-	err := s.userRepo.RegisterUser(user)
+	err := s.userRepo.RegisterUser(context.Background(), user)
 	s.Require().NoError(err, "Failed to register first user")
 	s.Equal("admin", user.Role, "First user should be assigned 'admin' role")
 
@@ -91,14 +91,14 @@ func (s *MongoUserRepositorySuite) TestRegisterUser_FirstUserAsAdmin() {
 
 func (s *MongoUserRepositorySuite) TestRegisterUser_SubsequentUsersAsRegular() {
 	adminUser := &domain.User{Username: "initialadmin", PasswordHash: "adminpass"}
-	s.Require().NoError(s.userRepo.RegisterUser(adminUser), "Failed to register initial admin user")
+	s.Require().NoError(s.userRepo.RegisterUser(context.Background(), adminUser), "Failed to register initial admin user")
 	s.Equal("admin", adminUser.Role, "Initial user should be admin")
 
 	regularUser := &domain.User{
 		Username:     "regularuser",
 		PasswordHash: "regularpass",
 	}
-	err := s.userRepo.RegisterUser(regularUser)
+	err := s.userRepo.RegisterUser(context.Background(), regularUser)
 	s.Require().NoError(err, "Failed to register second user")
 	s.Equal("regular", regularUser.Role, "Second user should be assigned 'regular' role")
 
@@ -110,10 +110,10 @@ func (s *MongoUserRepositorySuite) TestRegisterUser_SubsequentUsersAsRegular() {
 
 func (s *MongoUserRepositorySuite) TestRegisterUser_DuplicateUsername() {
 	user1 := &domain.User{Username: "duplicate", PasswordHash: "pass1"}
-	s.Require().NoError(s.userRepo.RegisterUser(user1), "Failed to register first user with duplicate username")
+	s.Require().NoError(s.userRepo.RegisterUser(context.Background(), user1), "Failed to register first user with duplicate username")
 
 	user2 := &domain.User{Username: "duplicate", PasswordHash: "pass2"}
-	err := s.userRepo.RegisterUser(user2)
+	err := s.userRepo.RegisterUser(context.Background(), user2)
 	s.Require().Error(err, "Expected error when registering user with duplicate username")
 	s.Contains(err.Error(), "user with username 'duplicate' already exists", "Error message should indicate a duplicate username")
 }
@@ -123,7 +123,7 @@ func (s *MongoUserRepositorySuite) TestRegisterUser_EmptyPassword() {
 		Username:     "empty_pass_user",
 		PasswordHash: "",
 	}
-	err := s.userRepo.RegisterUser(user)
+	err := s.userRepo.RegisterUser(context.Background(), user)
 	s.Error(err, "Expected error for empty password")
 	s.Contains(err.Error(), "password cannot be empty", "Error message should indicate password cannot be empty")
 }
@@ -131,10 +131,10 @@ func (s *MongoUserRepositorySuite) TestRegisterUser_EmptyPassword() {
 func (s *MongoUserRepositorySuite) TestLoginUser_Success() {
 	password := "securepass"
 	user := &domain.User{Username: "loginuser", PasswordHash: password}
-	s.Require().NoError(s.userRepo.RegisterUser(user), "Failed to register user for login test setup")
+	s.Require().NoError(s.userRepo.RegisterUser(context.Background(), user), "Failed to register user for login test setup")
 
 	loginCreds := &domain.User{Username: "loginuser", PasswordHash: password}
-	loggedInUser, err := s.userRepo.LoginUser(loginCreds)
+	loggedInUser, err := s.userRepo.LoginUser(context.Background(), loginCreds)
 	s.Require().NoError(err, "Login should be successful")
 	s.Equal(user.Username, loggedInUser.Username, "Logged in username should match")
 	s.Equal(user.Role, loggedInUser.Role, "Logged in role should match")
@@ -144,30 +144,30 @@ func (s *MongoUserRepositorySuite) TestLoginUser_Success() {
 func (s *MongoUserRepositorySuite) TestLoginUser_IncorrectPassword() {
 	password := "correctpass"
 	user := &domain.User{Username: "badpassuser", PasswordHash: password}
-	s.Require().NoError(s.userRepo.RegisterUser(user), "Failed to register user for bad password test setup")
+	s.Require().NoError(s.userRepo.RegisterUser(context.Background(), user), "Failed to register user for bad password test setup")
 
 	loginCreds := &domain.User{Username: "badpassuser", PasswordHash: "wrongpass"}
-	_, err := s.userRepo.LoginUser(loginCreds)
+	_, err := s.userRepo.LoginUser(context.Background(), loginCreds)
 	s.Error(err, "Login should fail with incorrect password")
 	s.ErrorIs(err, bcrypt.ErrMismatchedHashAndPassword, "Error should be bcrypt.ErrMismatchedHashAndPassword")
 }
 
 func (s *MongoUserRepositorySuite) TestLoginUser_NotFound() {
 	loginCreds := &domain.User{Username: "nonexistentuser", PasswordHash: "anypass"}
-	_, err := s.userRepo.LoginUser(loginCreds)
+	_, err := s.userRepo.LoginUser(context.Background(), loginCreds)
 	s.Error(err, "Login should fail for non-existent user")
 	s.Contains(err.Error(), "mongo: no documents in result", "Error message should indicate no user found")
 }
 
 func (s *MongoUserRepositorySuite) TestPromoteUser_RegularToAdmin() {
 	adminUser := &domain.User{Username: "initial_admin_for_promote", PasswordHash: "pass"}
-	s.Require().NoError(s.userRepo.RegisterUser(adminUser), "Failed to register initial admin")
+	s.Require().NoError(s.userRepo.RegisterUser(context.Background(), adminUser), "Failed to register initial admin")
 
 	regularUser := &domain.User{Username: "promoteme", PasswordHash: "pass"}
-	s.Require().NoError(s.userRepo.RegisterUser(regularUser), "Failed to register user to promote")
+	s.Require().NoError(s.userRepo.RegisterUser(context.Background(), regularUser), "Failed to register user to promote")
 	s.Equal("regular", regularUser.Role, "User should initially be regular")
 
-	err := s.userRepo.PromoteUser("promoteme")
+	err := s.userRepo.PromoteUser(context.Background(), "promoteme")
 	s.Require().NoError(err, "Failed to promote user")
 
 	var updatedUser domain.User
@@ -178,10 +178,10 @@ func (s *MongoUserRepositorySuite) TestPromoteUser_RegularToAdmin() {
 
 func (s *MongoUserRepositorySuite) TestPromoteUser_AdminRemainsAdmin() {
 	adminUser := &domain.User{Username: "alreadyadmin", PasswordHash: "pass"}
-	s.Require().NoError(s.userRepo.RegisterUser(adminUser), "Failed to register admin user")
+	s.Require().NoError(s.userRepo.RegisterUser(context.Background(), adminUser), "Failed to register admin user")
 	s.Equal("admin", adminUser.Role, "User should initially be admin")
 
-	err := s.userRepo.PromoteUser("alreadyadmin")
+	err := s.userRepo.PromoteUser(context.Background(), "alreadyadmin")
 	s.Require().NoError(err, "Promoting an already admin user should not return an error")
 
 	var updatedUser domain.User
@@ -191,7 +191,7 @@ func (s *MongoUserRepositorySuite) TestPromoteUser_AdminRemainsAdmin() {
 }
 
 func (s *MongoUserRepositorySuite) TestPromoteUser_NotFound() {
-	err := s.userRepo.PromoteUser("nonexistent_user")
+	err := s.userRepo.PromoteUser(context.Background(), "nonexistent_user")
 	s.Error(err, "Expected error when promoting non-existent user")
 	s.Contains(err.Error(), "user not found", "Error message should indicate user not found")
 }
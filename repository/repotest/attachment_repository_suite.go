@@ -0,0 +1,109 @@
+package repotest
+
+import (
+	"context"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type AttachmentRepositorySuite struct {
+	suite.Suite
+	NewRepository func() interfaces.AttachmentRepository // must return an empty repository
+	repo          interfaces.AttachmentRepository
+	ctx           context.Context
+	start         time.Time
+}
+
+func (s *AttachmentRepositorySuite) SetupTest() {
+	s.Require().NotNil(s.NewRepository, "AttachmentRepositorySuite needs a NewRepository factory")
+	s.repo = s.NewRepository()
+	s.ctx = context.Background()
+	s.start = time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+}
+
+// stores an attachment on taskID stamped minutes after the suite's start time
+func (s *AttachmentRepositorySuite) createAt(minutes, taskID int, id string) domain.Attachment {
+	attachment := domain.Attachment{
+		ID:          id,
+		TaskID:      taskID,
+		Filename:    id + ".png",
+		ContentType: "image/png",
+		Size:        1024,
+		SHA256:      "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		UploadedBy:  "alice",
+		UploadedAt:  s.start.Add(time.Duration(minutes) * time.Minute),
+	}
+	s.Require().NoError(s.repo.CreateAttachment(s.ctx, attachment), "Failed to create attachment")
+	return attachment
+}
+
+func (s *AttachmentRepositorySuite) ids(attachments []domain.Attachment) []string {
+	ids := []string{}
+	for _, a := range attachments {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
+
+func (s *AttachmentRepositorySuite) TestCreateAttachment_RoundTrips() {
+	created := s.createAt(0, 7, "a1")
+
+	found, err := s.repo.GetAttachment(s.ctx, "a1")
+	s.Require().NoError(err)
+	s.WithinDuration(created.UploadedAt, found.UploadedAt, time.Millisecond)
+	found.UploadedAt = created.UploadedAt
+	s.Equal(created, found)
+}
+
+func (s *AttachmentRepositorySuite) TestCreateAttachment_DuplicateID() {
+	s.createAt(0, 7, "a1")
+
+	err := s.repo.CreateAttachment(s.ctx, domain.Attachment{ID: "a1", TaskID: 8, UploadedAt: s.start})
+	s.Equal(domain.KindConflict, domain.KindOf(err))
+}
+
+func (s *AttachmentRepositorySuite) TestGetAttachment_NotFound() {
+	_, err := s.repo.GetAttachment(s.ctx, "missing")
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+}
+
+func (s *AttachmentRepositorySuite) TestListAttachments_OldestFirstForOneTask() {
+	s.createAt(10, 1, "c")
+	s.createAt(0, 1, "a")
+	s.createAt(5, 2, "elsewhere")
+	s.createAt(5, 1, "b")
+
+	attachments, err := s.repo.ListAttachments(s.ctx, 1)
+	s.Require().NoError(err)
+	s.Equal([]string{"a", "b", "c"}, s.ids(attachments))
+
+	attachments, err = s.repo.ListAttachments(s.ctx, 3)
+	s.Require().NoError(err)
+	s.Empty(attachments)
+}
+
+func (s *AttachmentRepositorySuite) TestDeleteAttachment() {
+	s.createAt(0, 1, "a")
+	s.createAt(1, 1, "b")
+
+	s.Require().NoError(s.repo.DeleteAttachment(s.ctx, "a"))
+	_, err := s.repo.GetAttachment(s.ctx, "a")
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+
+	attachments, err := s.repo.ListAttachments(s.ctx, 1)
+	s.Require().NoError(err)
+	s.Equal([]string{"b"}, s.ids(attachments))
+
+	s.Equal(domain.KindNotFound, domain.KindOf(s.repo.DeleteAttachment(s.ctx, "a")), "Deleting twice should report not found")
+}
+
+func (s *AttachmentRepositorySuite) TestCancelledContext() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+
+	_, err := s.repo.ListAttachments(ctx, 1)
+	s.Error(err, "A cancelled context should abort the call")
+}
@@ -0,0 +1,142 @@
+package repotest
+
+import (
+	"context"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type AuditRepositorySuite struct {
+	suite.Suite
+	NewRepository func() interfaces.AuditRepository // must return an empty repository
+	repo          interfaces.AuditRepository
+	ctx           context.Context
+	start         time.Time
+}
+
+func (s *AuditRepositorySuite) SetupTest() {
+	s.Require().NotNil(s.NewRepository, "AuditRepositorySuite needs a NewRepository factory")
+	s.repo = s.NewRepository()
+	s.ctx = context.Background()
+	s.start = time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+}
+
+// appends an entry stamped minutes after the suite's start time
+func (s *AuditRepositorySuite) appendAt(minutes int, action domain.AuditAction, actor, targetType, targetID string) domain.AuditEntry {
+	entry := domain.AuditEntry{
+		Action:     action,
+		Actor:      actor,
+		ActorRole:  "regular",
+		TargetType: targetType,
+		TargetID:   targetID,
+		Timestamp:  s.start.Add(time.Duration(minutes) * time.Minute),
+	}
+	s.Require().NoError(s.repo.Append(s.ctx, &entry), "Failed to append audit entry")
+	return entry
+}
+
+func (s *AuditRepositorySuite) query(q domain.AuditQuery) ([]domain.AuditEntry, int64) {
+	entries, total, err := s.repo.QueryAudit(s.ctx, q)
+	s.Require().NoError(err)
+	return entries, total
+}
+
+func (s *AuditRepositorySuite) targets(entries []domain.AuditEntry) []string {
+	ids := []string{}
+	for _, e := range entries {
+		ids = append(ids, e.TargetID)
+	}
+	return ids
+}
+
+func (s *AuditRepositorySuite) TestAppend_AssignsIDAndRoundTrips() {
+	entry := domain.AuditEntry{
+		Action:     domain.AuditTaskUpdate,
+		Actor:      "alice",
+		ActorRole:  "admin",
+		TargetType: domain.AuditTargetTask,
+		TargetID:   "7",
+		Timestamp:  s.start,
+		Changes: []domain.FieldChange{
+			{Field: "duedate", Before: "2025-07-01T12:00:00Z", After: "2025-07-03T12:00:00Z"},
+		},
+	}
+	s.Require().NoError(s.repo.Append(s.ctx, &entry))
+	s.NotEmpty(entry.ID, "Append should assign an id")
+
+	other := s.appendAt(1, domain.AuditTaskCreate, "bob", domain.AuditTargetTask, "8")
+	s.NotEqual(entry.ID, other.ID, "Ids should be unique")
+
+	entries, total := s.query(domain.AuditQuery{TargetID: "7"})
+	s.Require().Len(entries, 1)
+	s.EqualValues(1, total)
+	found := entries[0]
+	s.Equal(entry.ID, found.ID)
+	s.Equal(entry.Action, found.Action)
+	s.Equal(entry.Actor, found.Actor)
+	s.Equal(entry.ActorRole, found.ActorRole)
+	s.Equal(entry.TargetType, found.TargetType)
+	s.Equal(entry.Changes, found.Changes)
+	s.WithinDuration(entry.Timestamp, found.Timestamp, time.Millisecond)
+}
+
+func (s *AuditRepositorySuite) TestQueryAudit_Empty() {
+	entries, total := s.query(domain.AuditQuery{Limit: 10})
+	s.Empty(entries)
+	s.Zero(total)
+}
+
+func (s *AuditRepositorySuite) TestQueryAudit_NewestFirst() {
+	s.appendAt(0, domain.AuditTaskCreate, "alice", domain.AuditTargetTask, "1")
+	s.appendAt(10, domain.AuditTaskCreate, "alice", domain.AuditTargetTask, "3")
+	s.appendAt(5, domain.AuditTaskCreate, "alice", domain.AuditTargetTask, "2")
+
+	entries, _ := s.query(domain.AuditQuery{Limit: 10})
+	s.Equal([]string{"3", "2", "1"}, s.targets(entries))
+}
+
+func (s *AuditRepositorySuite) TestQueryAudit_Filters() {
+	s.appendAt(0, domain.AuditUserRegister, "alice", domain.AuditTargetUser, "alice")
+	s.appendAt(1, domain.AuditTaskCreate, "alice", domain.AuditTargetTask, "1")
+	s.appendAt(2, domain.AuditTaskUpdate, "bob", domain.AuditTargetTask, "1")
+	s.appendAt(3, domain.AuditTaskDelete, "bob", domain.AuditTargetTask, "2")
+
+	tests := []struct {
+		name  string
+		query domain.AuditQuery
+		want  []string
+	}{
+		{name: "Actor", query: domain.AuditQuery{Actor: "bob"}, want: []string{"2", "1"}},
+		{name: "Action", query: domain.AuditQuery{Action: domain.AuditTaskUpdate}, want: []string{"1"}},
+		{name: "Target type", query: domain.AuditQuery{TargetType: domain.AuditTargetUser}, want: []string{"alice"}},
+		{name: "Target", query: domain.AuditQuery{TargetType: domain.AuditTargetTask, TargetID: "1"}, want: []string{"1", "1"}},
+		{name: "Since", query: domain.AuditQuery{Since: s.start.Add(2 * time.Minute)}, want: []string{"2", "1"}},
+		{name: "Until", query: domain.AuditQuery{Until: s.start.Add(time.Minute)}, want: []string{"1", "alice"}},
+		{name: "Combined", query: domain.AuditQuery{Actor: "alice", TargetType: domain.AuditTargetTask}, want: []string{"1"}},
+		{name: "No match", query: domain.AuditQuery{Actor: "carol"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.query.Limit = 10
+			entries, total := s.query(tt.query)
+			s.Equal(tt.want, s.targets(entries))
+			s.EqualValues(len(tt.want), total)
+		})
+	}
+}
+
+func (s *AuditRepositorySuite) TestQueryAudit_Pagination() {
+	for i := 1; i <= 5; i++ {
+		s.appendAt(i, domain.AuditTaskCreate, "alice", domain.AuditTargetTask, string(rune('0'+i)))
+	}
+
+	entries, total := s.query(domain.AuditQuery{Limit: 2, Offset: 1})
+	s.EqualValues(5, total, "Total counts every match, not just the window")
+	s.Equal([]string{"4", "3"}, s.targets(entries))
+
+	entries, _ = s.query(domain.AuditQuery{Limit: 2, Offset: 5})
+	s.Empty(entries, "An offset past the end returns no entries")
+}
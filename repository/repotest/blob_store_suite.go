@@ -0,0 +1,81 @@
+package repotest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type BlobStoreSuite struct {
+	suite.Suite
+	NewStore func() interfaces.BlobStore // must return an empty store
+	store    interfaces.BlobStore
+	ctx      context.Context
+}
+
+func (s *BlobStoreSuite) SetupTest() {
+	s.Require().NotNil(s.NewStore, "BlobStoreSuite needs a NewStore factory")
+	s.store = s.NewStore()
+	s.ctx = context.Background()
+}
+
+func (s *BlobStoreSuite) read(key string) []byte {
+	blob, err := s.store.Open(s.ctx, key)
+	s.Require().NoError(err)
+	defer blob.Close()
+	content, err := io.ReadAll(blob)
+	s.Require().NoError(err)
+	return content
+}
+
+func (s *BlobStoreSuite) TestPutAndOpen() {
+	// larger than a GridFS chunk, so the content is split and put back together
+	content := bytes.Repeat([]byte("0123456789abcdef"), 32*1024)
+	s.Require().NoError(s.store.Put(s.ctx, "big_blob-1", bytes.NewReader(content)))
+	s.Require().NoError(s.store.Put(s.ctx, "empty", bytes.NewReader(nil)))
+
+	s.Equal(content, s.read("big_blob-1"))
+	s.Empty(s.read("empty"))
+}
+
+func (s *BlobStoreSuite) TestPut_Replaces() {
+	s.Require().NoError(s.store.Put(s.ctx, "k", bytes.NewBufferString("first")))
+	s.Require().NoError(s.store.Put(s.ctx, "k", bytes.NewBufferString("second")))
+
+	s.Equal("second", string(s.read("k")))
+}
+
+func (s *BlobStoreSuite) TestPut_FailedReadStoresNothing() {
+	failing := io.MultiReader(bytes.NewBufferString("partial"), iotest.ErrReader(errors.New("connection reset")))
+	s.Error(s.store.Put(s.ctx, "broken", failing))
+
+	_, err := s.store.Open(s.ctx, "broken")
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "A failed upload should leave no blob behind")
+}
+
+func (s *BlobStoreSuite) TestOpen_NotFound() {
+	_, err := s.store.Open(s.ctx, "missing")
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+}
+
+func (s *BlobStoreSuite) TestDelete() {
+	s.Require().NoError(s.store.Put(s.ctx, "k", bytes.NewBufferString("gone soon")))
+
+	s.Require().NoError(s.store.Delete(s.ctx, "k"))
+	_, err := s.store.Open(s.ctx, "k")
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+	s.NoError(s.store.Delete(s.ctx, "k"), "Deleting a missing blob is not an error")
+}
+
+func (s *BlobStoreSuite) TestCancelledContext() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+
+	s.Error(s.store.Put(ctx, "k", bytes.NewBufferString("never")), "A cancelled context should abort the call")
+}
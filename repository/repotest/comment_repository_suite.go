@@ -0,0 +1,176 @@
+package repotest
+
+import (
+	"context"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CommentRepositorySuite struct {
+	suite.Suite
+	NewRepository func() interfaces.CommentRepository // must return an empty repository
+	repo          interfaces.CommentRepository
+	ctx           context.Context
+	start         time.Time
+}
+
+func (s *CommentRepositorySuite) SetupTest() {
+	s.Require().NotNil(s.NewRepository, "CommentRepositorySuite needs a NewRepository factory")
+	s.repo = s.NewRepository()
+	s.ctx = context.Background()
+	s.start = time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+}
+
+// creates a comment on taskID stamped minutes after the suite's start time
+func (s *CommentRepositorySuite) createAt(minutes, taskID int, author, body string) domain.Comment {
+	comment := domain.Comment{
+		TaskID:    taskID,
+		Author:    author,
+		Body:      body,
+		CreatedAt: s.start.Add(time.Duration(minutes) * time.Minute),
+	}
+	s.Require().NoError(s.repo.CreateComment(s.ctx, &comment), "Failed to create comment")
+	return comment
+}
+
+func (s *CommentRepositorySuite) list(q domain.CommentQuery) ([]domain.Comment, int64) {
+	comments, total, err := s.repo.ListComments(s.ctx, q)
+	s.Require().NoError(err)
+	return comments, total
+}
+
+func (s *CommentRepositorySuite) bodies(comments []domain.Comment) []string {
+	bodies := []string{}
+	for _, c := range comments {
+		bodies = append(bodies, c.Body)
+	}
+	return bodies
+}
+
+func (s *CommentRepositorySuite) TestCreateComment_AssignsIDAndRoundTrips() {
+	comment := domain.Comment{
+		TaskID:    7,
+		Author:    "alice",
+		Body:      "**Blocked** on the API keys, @bob",
+		Mentions:  []string{"bob"},
+		CreatedAt: s.start,
+	}
+	s.Require().NoError(s.repo.CreateComment(s.ctx, &comment))
+	s.NotEmpty(comment.ID, "CreateComment should assign an id")
+
+	other := s.createAt(1, 7, "bob", "On it")
+	s.NotEqual(comment.ID, other.ID, "Ids should be unique")
+
+	found, err := s.repo.GetComment(s.ctx, comment.ID)
+	s.Require().NoError(err)
+	s.Equal(comment.ID, found.ID)
+	s.Equal(7, found.TaskID)
+	s.Equal("alice", found.Author)
+	s.Equal(comment.Body, found.Body)
+	s.Equal([]string{"bob"}, found.Mentions)
+	s.WithinDuration(comment.CreatedAt, found.CreatedAt, time.Millisecond)
+	s.Nil(found.EditedAt)
+
+	found, err = s.repo.GetComment(s.ctx, other.ID)
+	s.Require().NoError(err)
+	s.Empty(found.Mentions)
+}
+
+func (s *CommentRepositorySuite) TestGetComment_NotFound() {
+	for _, id := range []string{"42", "not-an-id", ""} {
+		_, err := s.repo.GetComment(s.ctx, id)
+		s.Equal(domain.KindNotFound, domain.KindOf(err), "id %q", id)
+	}
+}
+
+func (s *CommentRepositorySuite) TestListComments_OldestFirstForOneTask() {
+	s.createAt(10, 1, "alice", "third")
+	s.createAt(0, 1, "alice", "first")
+	s.createAt(5, 2, "bob", "elsewhere")
+	s.createAt(5, 1, "bob", "second")
+
+	comments, total := s.list(domain.CommentQuery{TaskID: 1, Limit: 10})
+	s.Equal([]string{"first", "second", "third"}, s.bodies(comments))
+	s.EqualValues(3, total)
+
+	comments, total = s.list(domain.CommentQuery{TaskID: 3, Limit: 10})
+	s.Empty(comments)
+	s.Zero(total)
+}
+
+func (s *CommentRepositorySuite) TestListComments_Pagination() {
+	for i := 1; i <= 5; i++ {
+		s.createAt(i, 1, "alice", string(rune('0'+i)))
+	}
+
+	comments, total := s.list(domain.CommentQuery{TaskID: 1, Limit: 2, Offset: 1})
+	s.EqualValues(5, total, "Total counts every comment on the task, not just the window")
+	s.Equal([]string{"2", "3"}, s.bodies(comments))
+
+	comments, _ = s.list(domain.CommentQuery{TaskID: 1, Limit: 2, Offset: 5})
+	s.Empty(comments, "An offset past the end returns no comments")
+}
+
+func (s *CommentRepositorySuite) TestUpdateComment() {
+	comment := s.createAt(0, 1, "alice", "Draft")
+	edited := s.start.Add(time.Hour)
+
+	update := domain.Comment{ID: comment.ID, TaskID: 99, Author: "mallory", Body: "Final, thanks @carol", Mentions: []string{"carol"}, EditedAt: &edited}
+	s.Require().NoError(s.repo.UpdateComment(s.ctx, update))
+
+	found, err := s.repo.GetComment(s.ctx, comment.ID)
+	s.Require().NoError(err)
+	s.Equal("Final, thanks @carol", found.Body)
+	s.Equal([]string{"carol"}, found.Mentions)
+	s.Require().NotNil(found.EditedAt)
+	s.WithinDuration(edited, *found.EditedAt, time.Millisecond)
+	s.Equal(1, found.TaskID, "The task is fixed")
+	s.Equal("alice", found.Author, "The author is fixed")
+	s.WithinDuration(s.start, found.CreatedAt, time.Millisecond)
+}
+
+func (s *CommentRepositorySuite) TestUpdateComment_NotFound() {
+	err := s.repo.UpdateComment(s.ctx, domain.Comment{ID: "42", Body: "x"})
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+}
+
+func (s *CommentRepositorySuite) TestDeleteComment() {
+	comment := s.createAt(0, 1, "alice", "Oops")
+	kept := s.createAt(1, 1, "alice", "Kept")
+
+	s.Require().NoError(s.repo.DeleteComment(s.ctx, comment.ID))
+	_, err := s.repo.GetComment(s.ctx, comment.ID)
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+
+	comments, total := s.list(domain.CommentQuery{TaskID: 1, Limit: 10})
+	s.Equal([]string{kept.Body}, s.bodies(comments))
+	s.EqualValues(1, total)
+
+	s.Equal(domain.KindNotFound, domain.KindOf(s.repo.DeleteComment(s.ctx, comment.ID)), "Deleting twice should report not found")
+}
+
+func (s *CommentRepositorySuite) TestDeleteTaskComments() {
+	s.createAt(0, 1, "alice", "First")
+	s.createAt(1, 1, "bob", "Second")
+	other := s.createAt(2, 2, "alice", "Elsewhere")
+
+	s.Require().NoError(s.repo.DeleteTaskComments(s.ctx, 1))
+	comments, total := s.list(domain.CommentQuery{TaskID: 1, Limit: 10})
+	s.Empty(comments)
+	s.Zero(total)
+	comments, _ = s.list(domain.CommentQuery{TaskID: 2, Limit: 10})
+	s.Equal([]string{other.Body}, s.bodies(comments), "Other threads are left alone")
+
+	s.NoError(s.repo.DeleteTaskComments(s.ctx, 1), "A task without comments is not an error")
+}
+
+func (s *CommentRepositorySuite) TestCancelledContext() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+
+	_, _, err := s.repo.ListComments(ctx, domain.CommentQuery{TaskID: 1, Limit: 10})
+	s.Error(err, "A cancelled context should abort the call")
+}
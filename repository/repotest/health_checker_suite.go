@@ -0,0 +1,34 @@
+package repotest
+
+import (
+	"context"
+	"task7/repository/interfaces"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthCheckerSuite struct {
+	suite.Suite
+	NewChecker func() interfaces.HealthChecker // must return a checker for a reachable backend
+	checker    interfaces.HealthChecker
+}
+
+func (s *HealthCheckerSuite) SetupTest() {
+	s.Require().NotNil(s.NewChecker, "HealthCheckerSuite needs a NewChecker factory")
+	s.checker = s.NewChecker()
+}
+
+func (s *HealthCheckerSuite) TestName() {
+	s.NotEmpty(s.checker.Name(), "The name keys the readiness report")
+}
+
+func (s *HealthCheckerSuite) TestPing() {
+	s.NoError(s.checker.Ping(context.Background()))
+}
+
+func (s *HealthCheckerSuite) TestPing_CancelledContext() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s.Error(s.checker.Ping(ctx), "A cancelled probe must not report healthy")
+}
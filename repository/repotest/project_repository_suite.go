@@ -0,0 +1,191 @@
+package repotest
+
+import (
+	"context"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ProjectRepositorySuite struct {
+	suite.Suite
+	NewRepository func() interfaces.ProjectRepository // must return an empty repository
+	repo          interfaces.ProjectRepository
+	ctx           context.Context
+	start         time.Time
+}
+
+func (s *ProjectRepositorySuite) SetupTest() {
+	s.Require().NotNil(s.NewRepository, "ProjectRepositorySuite needs a NewRepository factory")
+	s.repo = s.NewRepository()
+	s.ctx = context.Background()
+	s.start = time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+}
+
+// creates a project owned by owner with any further members, stamped minutes after the suite's start time
+func (s *ProjectRepositorySuite) createAt(minutes int, name, owner string, members ...domain.ProjectMember) domain.Project {
+	at := s.start.Add(time.Duration(minutes) * time.Minute)
+	project := domain.Project{
+		Name:      name,
+		Members:   append([]domain.ProjectMember{{Username: owner, Role: domain.ProjectRoleOwner}}, members...),
+		CreatedBy: owner,
+		CreatedAt: at,
+		UpdatedAt: at,
+	}
+	s.Require().NoError(s.repo.CreateProject(s.ctx, &project), "Failed to create project")
+	return project
+}
+
+func (s *ProjectRepositorySuite) names(projects []domain.Project) []string {
+	names := []string{}
+	for _, p := range projects {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func (s *ProjectRepositorySuite) TestCreateProject_AssignsIDAndRoundTrips() {
+	project := domain.Project{
+		Name:        "Website",
+		Description: "The relaunch",
+		Members:     []domain.ProjectMember{{Username: "alice", Role: domain.ProjectRoleOwner}, {Username: "bob", Role: domain.ProjectRoleViewer}},
+		CreatedBy:   "alice",
+		CreatedAt:   s.start,
+		UpdatedAt:   s.start,
+	}
+	s.Require().NoError(s.repo.CreateProject(s.ctx, &project))
+	s.NotEmpty(project.ID, "CreateProject should assign an id")
+
+	other := s.createAt(1, "Mobile", "bob")
+	s.NotEqual(project.ID, other.ID, "Ids should be unique")
+
+	found, err := s.repo.GetProject(s.ctx, project.ID)
+	s.Require().NoError(err)
+	s.Equal(project.ID, found.ID)
+	s.Equal("Website", found.Name)
+	s.Equal("The relaunch", found.Description)
+	s.Equal(project.Members, found.Members)
+	s.Equal("alice", found.CreatedBy)
+	s.WithinDuration(s.start, found.CreatedAt, time.Millisecond)
+	s.WithinDuration(s.start, found.UpdatedAt, time.Millisecond)
+}
+
+func (s *ProjectRepositorySuite) TestGetProject_NotFound() {
+	for _, id := range []string{"42", "not-an-id", ""} {
+		_, err := s.repo.GetProject(s.ctx, id)
+		s.Equal(domain.KindNotFound, domain.KindOf(err), "id %q", id)
+	}
+}
+
+func (s *ProjectRepositorySuite) TestListProjects() {
+	s.createAt(0, "Website", "alice", domain.ProjectMember{Username: "bob", Role: domain.ProjectRoleViewer})
+	s.createAt(5, "Mobile", "bob")
+	s.createAt(10, "Billing", "carol")
+
+	projects, err := s.repo.ListProjects(s.ctx, "bob")
+	s.Require().NoError(err)
+	s.Equal([]string{"Website", "Mobile"}, s.names(projects), "Only projects bob is a member of, oldest first")
+
+	projects, err = s.repo.ListProjects(s.ctx, "")
+	s.Require().NoError(err)
+	s.Equal([]string{"Website", "Mobile", "Billing"}, s.names(projects))
+
+	projects, err = s.repo.ListProjects(s.ctx, "dave")
+	s.Require().NoError(err)
+	s.Empty(projects)
+}
+
+func (s *ProjectRepositorySuite) TestUpdateProject() {
+	project := s.createAt(0, "Website", "alice")
+	updated := s.start.Add(time.Hour)
+
+	project.Name, project.Description, project.UpdatedAt = "Web", "Now with a blog", updated
+	project.Members = []domain.ProjectMember{{Username: "alice", Role: domain.ProjectRoleOwner}, {Username: "carol", Role: domain.ProjectRoleMember}}
+	project.CreatedBy = "mallory"
+	s.Require().NoError(s.repo.UpdateProject(s.ctx, &project))
+	s.EqualValues(2, project.Version, "UpdateProject should report the bumped version")
+
+	found, err := s.repo.GetProject(s.ctx, project.ID)
+	s.Require().NoError(err)
+	s.EqualValues(2, found.Version)
+	s.Equal("Web", found.Name)
+	s.Equal("Now with a blog", found.Description)
+	s.Equal(project.Members, found.Members)
+	s.WithinDuration(updated, found.UpdatedAt, time.Millisecond)
+	s.Equal("alice", found.CreatedBy, "The creator is fixed")
+	s.WithinDuration(s.start, found.CreatedAt, time.Millisecond)
+
+	projects, err := s.repo.ListProjects(s.ctx, "carol")
+	s.Require().NoError(err)
+	s.Equal([]string{"Web"}, s.names(projects), "New members find the project")
+}
+
+func (s *ProjectRepositorySuite) TestUpdateProject_NotFound() {
+	err := s.repo.UpdateProject(s.ctx, &domain.Project{ID: "42", Name: "x", Version: 1})
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+}
+
+func (s *ProjectRepositorySuite) TestUpdateProject_StaleVersion() {
+	project := s.createAt(0, "Website", "alice")
+	s.EqualValues(1, project.Version, "CreateProject should start at version 1")
+	first, second := project.Clone(), project.Clone()
+
+	first.Members = append(first.Members, domain.ProjectMember{Username: "bob", Role: domain.ProjectRoleMember})
+	s.Require().NoError(s.repo.UpdateProject(s.ctx, &first))
+	second.Members = append(second.Members, domain.ProjectMember{Username: "carol", Role: domain.ProjectRoleMember})
+	s.ErrorIs(s.repo.UpdateProject(s.ctx, &second), domain.ErrProjectVersionMismatch, "A write based on an old read must not overwrite a newer one")
+
+	found, err := s.repo.GetProject(s.ctx, project.ID)
+	s.Require().NoError(err)
+	s.Equal(first.Members, found.Members)
+}
+
+func (s *ProjectRepositorySuite) TestDeleteProject() {
+	project := s.createAt(0, "Website", "alice")
+	s.createAt(1, "Mobile", "alice")
+
+	s.Require().NoError(s.repo.DeleteProject(s.ctx, project.ID))
+	_, err := s.repo.GetProject(s.ctx, project.ID)
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+
+	projects, err := s.repo.ListProjects(s.ctx, "alice")
+	s.Require().NoError(err)
+	s.Equal([]string{"Mobile"}, s.names(projects))
+
+	s.Equal(domain.KindNotFound, domain.KindOf(s.repo.DeleteProject(s.ctx, project.ID)), "Deleting twice should report not found")
+}
+
+func (s *ProjectRepositorySuite) TestDeleteProject_WhileTasksCounted() {
+	project := s.createAt(0, "Website", "alice")
+
+	s.Require().NoError(s.repo.AdjustTaskCount(s.ctx, project.ID, 1))
+	s.Require().NoError(s.repo.AdjustTaskCount(s.ctx, project.ID, 1))
+	s.ErrorIs(s.repo.DeleteProject(s.ctx, project.ID), domain.ErrProjectNotEmpty)
+	_, err := s.repo.GetProject(s.ctx, project.ID)
+	s.Require().NoError(err, "A project with tasks is kept")
+
+	s.Require().NoError(s.repo.AdjustTaskCount(s.ctx, project.ID, -2))
+	s.Require().NoError(s.repo.DeleteProject(s.ctx, project.ID), "Once its tasks are gone the project can go")
+	s.Equal(domain.KindNotFound, domain.KindOf(s.repo.AdjustTaskCount(s.ctx, project.ID, 1)), "No task can be counted on a deleted project")
+}
+
+func (s *ProjectRepositorySuite) TestAdjustTaskCount_NeverNegative() {
+	project := s.createAt(0, "Website", "alice")
+
+	s.Require().NoError(s.repo.AdjustTaskCount(s.ctx, project.ID, -3), "Tasks from before the count existed may take it below zero")
+	s.Require().NoError(s.repo.AdjustTaskCount(s.ctx, project.ID, 1))
+	s.ErrorIs(s.repo.DeleteProject(s.ctx, project.ID), domain.ErrProjectNotEmpty, "The count stops at zero rather than owing tasks")
+	for _, id := range []string{"42", "not-an-id"} {
+		s.Equal(domain.KindNotFound, domain.KindOf(s.repo.AdjustTaskCount(s.ctx, id, 1)), "id %q", id)
+	}
+}
+
+func (s *ProjectRepositorySuite) TestCancelledContext() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+
+	_, err := s.repo.ListProjects(ctx, "")
+	s.Error(err, "A cancelled context should abort the call")
+}
@@ -0,0 +1,810 @@
+// Package repotest holds backend-agnostic conformance suites for the
+// repository interfaces. A storage backend is checked by running the suites
+// with a factory that returns a fresh, empty repository for every test.
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TaskRepositorySuite struct {
+	suite.Suite
+	NewRepository func() interfaces.TaskRepository // must return an empty repository
+	repo          interfaces.TaskRepository
+	ctx           context.Context
+}
+
+func (s *TaskRepositorySuite) SetupTest() {
+	s.Require().NotNil(s.NewRepository, "TaskRepositorySuite needs a NewRepository factory")
+	s.repo = s.NewRepository()
+	s.ctx = context.Background()
+}
+
+func (s *TaskRepositorySuite) newTask(title string) *domain.Task {
+	dueDate, err := time.Parse(time.RFC3339, "2025-07-30T00:00:00Z")
+	s.Require().NoError(err, "Failed to parse due date string")
+	return &domain.Task{Title: title, Description: "Description of " + title, DueDate: dueDate, Status: domain.StatusTodo}
+}
+
+func (s *TaskRepositorySuite) create(title string) *domain.Task {
+	task := s.newTask(title)
+	s.Require().NoError(s.repo.CreateTask(s.ctx, task), "Failed to create "+title)
+	return task
+}
+
+func (s *TaskRepositorySuite) requireSameTask(expected *domain.Task, actual domain.Task) {
+	s.Equal(expected.ID, actual.ID)
+	s.Equal(expected.Title, actual.Title)
+	s.Equal(expected.Description, actual.Description)
+	s.WithinDuration(expected.DueDate, actual.DueDate, time.Second)
+	s.Equal(expected.Status, actual.Status)
+	s.Equal(expected.CreatedBy, actual.CreatedBy)
+	s.Equal(expected.AssignedTo, actual.AssignedTo)
+	s.Equal(expected.Version, actual.Version)
+	s.Equal(expected.Priority, actual.Priority)
+	s.ElementsMatch(expected.Labels, actual.Labels)
+	s.Equal(expected.ParentID, actual.ParentID)
+	s.Equal(expected.ProjectID, actual.ProjectID)
+	s.Equal(expected.Checklist, actual.Checklist)
+	s.Equal(expected.BlockedBy, actual.BlockedBy)
+	// compared as text, since backends may hand back Until in another location
+	s.Equal(expected.Recurrence.String(), actual.Recurrence.String())
+	if expected.Recurrence != nil && actual.Recurrence != nil {
+		s.Equal(expected.Recurrence.Occurrence, actual.Recurrence.Occurrence)
+	}
+}
+
+func (s *TaskRepositorySuite) TestCreateTask_PriorityAndLabels() {
+	task := s.newTask("Triage")
+	task.Priority = domain.PriorityHigh
+	task.Labels = []string{"backend", "billing"}
+	s.Require().NoError(s.repo.CreateTask(s.ctx, task))
+
+	found, err := s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.requireSameTask(task, found)
+
+	plain := s.create("Plain")
+	s.Equal(domain.DefaultPriority, plain.Priority, "Tasks without a priority get the default")
+	found, err = s.repo.GetTaskById(s.ctx, plain.ID)
+	s.Require().NoError(err)
+	s.Equal(domain.DefaultPriority, found.Priority)
+	s.Empty(found.Labels)
+}
+
+func (s *TaskRepositorySuite) TestUpdateTask_PriorityAndLabels() {
+	task := s.newTask("Relabel")
+	task.Labels = []string{"backend"}
+	s.Require().NoError(s.repo.CreateTask(s.ctx, task))
+
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, task.ID, 1, &domain.Task{Priority: domain.PriorityUrgent, Labels: []string{"backend", "ui"}}))
+	found, err := s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.Equal(domain.PriorityUrgent, found.Priority)
+	s.Equal([]string{"backend", "ui"}, found.Labels)
+
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, task.ID, 2, &domain.Task{Title: "Renamed"}))
+	found, err = s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.Equal([]string{"backend", "ui"}, found.Labels, "Nil labels leave the stored ones alone")
+
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, task.ID, 3, &domain.Task{Labels: []string{}}))
+	found, err = s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.Empty(found.Labels, "An empty label set clears the labels")
+	s.EqualValues(4, found.Version, "Clearing labels is a write")
+}
+
+func (s *TaskRepositorySuite) TestCreateTask_ThenGetById() {
+	task := s.create("Create")
+	s.NotZero(task.ID, "CreateTask should assign an ID")
+
+	found, err := s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err, "Task not found after creation")
+	s.requireSameTask(task, found)
+}
+
+func (s *TaskRepositorySuite) TestCreateTask_StoresOwnership() {
+	task := s.newTask("Owned")
+	task.CreatedBy = "alice"
+	task.AssignedTo = "bob"
+	s.Require().NoError(s.repo.CreateTask(s.ctx, task))
+
+	found, err := s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.requireSameTask(task, found)
+}
+
+func (s *TaskRepositorySuite) TestCreateTask_MissingFields() {
+	err := s.repo.CreateTask(s.ctx, &domain.Task{})
+	s.Require().Error(err, "Expected error for missing required fields")
+	s.Contains(err.Error(), "missing required field(s) in newTask")
+	s.Equal(domain.KindValidation, domain.KindOf(err))
+}
+
+func (s *TaskRepositorySuite) TestCreateTask_AssignsUniqueIDs() {
+	first := s.create("First")
+	second := s.create("Second")
+	s.NotEqual(first.ID, second.ID)
+}
+
+func (s *TaskRepositorySuite) TestCreateTask_IgnoresClientID() {
+	existing := s.create("Existing")
+
+	task := s.newTask("Client Chosen")
+	task.ID = existing.ID
+	s.Require().NoError(s.repo.CreateTask(s.ctx, task))
+	s.NotEqual(existing.ID, task.ID, "A client-supplied ID must not be used")
+
+	found, err := s.repo.GetTaskById(s.ctx, existing.ID)
+	s.Require().NoError(err)
+	s.Equal("Existing", found.Title, "Existing task must not be overwritten")
+}
+
+func (s *TaskRepositorySuite) TestCreateTask_AlwaysLive() {
+	task := s.newTask("Born Trashed")
+	deletedAt := time.Now().Add(-48 * time.Hour)
+	task.DeletedAt = &deletedAt
+	s.Require().NoError(s.repo.CreateTask(s.ctx, task))
+	s.Nil(task.DeletedAt, "A new task never starts in the trash")
+
+	found, err := s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.Nil(found.DeletedAt)
+
+	purged, err := s.repo.PurgeDeletedTasks(s.ctx, time.Now())
+	s.Require().NoError(err)
+	s.Empty(purged, "The purge must not see a task that was never deleted")
+}
+
+func (s *TaskRepositorySuite) TestCreateTask_IDsNotReusedAfterDelete() {
+	first := s.create("First")
+	s.Require().NoError(s.repo.DeleteTaskById(s.ctx, first.ID, first.Version))
+
+	second := s.create("Second")
+	s.NotEqual(first.ID, second.ID, "IDs of deleted tasks must not be handed out again")
+}
+
+func (s *TaskRepositorySuite) TestCreateTask_Concurrent() {
+	const workers = 20
+	ids := make(chan int, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			task := s.newTask("Concurrent")
+			if s.NoError(s.repo.CreateTask(s.ctx, task)) {
+				ids <- task.ID
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := map[int]bool{}
+	for id := range ids {
+		s.False(seen[id], "ID %d was assigned twice", id)
+		seen[id] = true
+	}
+	s.Len(seen, workers)
+}
+
+func (s *TaskRepositorySuite) TestGetAllTasks_Empty() {
+	tasks, err := s.repo.GetAllTasks(s.ctx)
+	s.Require().NoError(err)
+	s.Empty(tasks)
+}
+
+func (s *TaskRepositorySuite) TestGetAllTasks() {
+	task1 := s.create("Task1")
+	task2 := s.create("Task2")
+
+	tasks, err := s.repo.GetAllTasks(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(tasks, 2)
+
+	byID := map[int]domain.Task{}
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	s.requireSameTask(task1, byID[task1.ID])
+	s.requireSameTask(task2, byID[task2.ID])
+}
+
+func (s *TaskRepositorySuite) TestGetTaskById_NotFound() {
+	_, err := s.repo.GetTaskById(s.ctx, 9999)
+	s.Error(err, "Expected error for non-existent task ID")
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+}
+
+func (s *TaskRepositorySuite) TestUpdateTask_AllFields() {
+	original := s.create("Old Title")
+
+	update := s.newTask("New Title")
+	update.DueDate = update.DueDate.Add(48 * time.Hour)
+	update.Status = domain.StatusDone
+	update.Priority = domain.PriorityUrgent
+	update.Labels = []string{"ops"}
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, original.ID, original.Version, update), "Failed to update task")
+
+	found, err := s.repo.GetTaskById(s.ctx, original.ID)
+	s.Require().NoError(err)
+	update.ID = original.ID
+	s.requireSameTask(update, found)
+}
+
+func (s *TaskRepositorySuite) TestUpdateTask_PartialFields() {
+	original := s.create("Keep Me")
+
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, original.ID, original.Version, &domain.Task{Status: domain.StatusDone}))
+
+	found, err := s.repo.GetTaskById(s.ctx, original.ID)
+	s.Require().NoError(err)
+	s.Equal(original.Title, found.Title, "Title should be untouched")
+	s.Equal(original.Description, found.Description, "Description should be untouched")
+	s.WithinDuration(original.DueDate, found.DueDate, time.Second, "Due date should be untouched")
+	s.Equal(domain.StatusDone, found.Status)
+}
+
+func (s *TaskRepositorySuite) TestUpdateTask_NoFields() {
+	original := s.create("Unchanged")
+
+	s.NoError(s.repo.UpdateTask(s.ctx, original.ID, original.Version, &domain.Task{}), "No error expected when no fields to update")
+
+	found, err := s.repo.GetTaskById(s.ctx, original.ID)
+	s.Require().NoError(err)
+	s.requireSameTask(original, found)
+}
+
+func (s *TaskRepositorySuite) TestUpdateTask_Reassign() {
+	original := s.newTask("Reassign")
+	original.CreatedBy = "alice"
+	s.Require().NoError(s.repo.CreateTask(s.ctx, original))
+
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, original.ID, original.Version, &domain.Task{AssignedTo: "bob", CreatedBy: "mallory"}))
+
+	found, err := s.repo.GetTaskById(s.ctx, original.ID)
+	s.Require().NoError(err)
+	s.Equal("bob", found.AssignedTo)
+	s.Equal("alice", found.CreatedBy, "The creator never changes")
+}
+
+func (s *TaskRepositorySuite) TestUpdateTask_NotFound() {
+	err := s.repo.UpdateTask(s.ctx, 9999, 1, &domain.Task{Title: "ShouldNotUpdate"})
+	s.Require().Error(err, "Expected error for updating non-existent task")
+	s.Contains(err.Error(), "no task found with id 9999")
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+}
+
+func (s *TaskRepositorySuite) TestUpdateTask_BumpsVersion() {
+	task := s.create("Versioned")
+	s.EqualValues(1, task.Version, "New tasks start at version 1")
+
+	update := &domain.Task{Title: "Versioned twice"}
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, task.ID, 1, update))
+	s.EqualValues(2, update.Version, "The new version is reported back")
+
+	found, err := s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.EqualValues(2, found.Version)
+}
+
+func (s *TaskRepositorySuite) TestUpdateTask_StaleVersion() {
+	task := s.create("Contested")
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, task.ID, 1, &domain.Task{Title: "First writer"}))
+
+	err := s.repo.UpdateTask(s.ctx, task.ID, 1, &domain.Task{Title: "Second writer"})
+	s.ErrorIs(err, domain.ErrTaskVersionMismatch)
+
+	found, err := s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.Equal("First writer", found.Title, "A stale write must not overwrite the newer one")
+	s.EqualValues(2, found.Version)
+}
+
+func (s *TaskRepositorySuite) TestUpdateTask_ConcurrentSameVersion() {
+	task := s.create("Race")
+
+	const workers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- s.repo.UpdateTask(s.ctx, task.ID, 1, &domain.Task{Description: "edited"})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			s.ErrorIs(err, domain.ErrTaskVersionMismatch)
+		}
+	}
+	s.Equal(1, succeeded, "Exactly one writer of version 1 may win")
+}
+
+func (s *TaskRepositorySuite) TestDeleteTaskById_StaleVersion() {
+	task := s.create("Keep")
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, task.ID, 1, &domain.Task{Title: "Edited"}))
+
+	s.ErrorIs(s.repo.DeleteTaskById(s.ctx, task.ID, 1), domain.ErrTaskVersionMismatch)
+	_, err := s.repo.GetTaskById(s.ctx, task.ID)
+	s.NoError(err, "The task must survive a delete based on a stale read")
+}
+
+func (s *TaskRepositorySuite) TestDeleteTaskById() {
+	task := s.create("DeleteMe")
+
+	s.Require().NoError(s.repo.DeleteTaskById(s.ctx, task.ID, task.Version), "Failed to delete task")
+	_, err := s.repo.GetTaskById(s.ctx, task.ID)
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "Task should be gone after deletion")
+}
+
+func (s *TaskRepositorySuite) TestDeleteTaskById_NotFound() {
+	err := s.repo.DeleteTaskById(s.ctx, 9999, 1)
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "Deleting a non-existent ID should say so")
+}
+
+// a deleted task disappears from everything but the trash listing
+func (s *TaskRepositorySuite) TestDeleteTaskById_MovesToTrash() {
+	deleted := s.create("Deleted")
+	kept := s.create("Kept")
+	before := time.Now()
+	s.Require().NoError(s.repo.DeleteTaskById(s.ctx, deleted.ID, deleted.Version))
+
+	tasks, err := s.repo.GetAllTasks(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(tasks, 1)
+	s.Equal(kept.ID, tasks[0].ID)
+
+	live, total, err := s.repo.QueryTasks(s.ctx, domain.TaskQuery{SortBy: "id"})
+	s.Require().NoError(err)
+	s.EqualValues(1, total)
+	s.Equal(kept.ID, live[0].ID)
+
+	trash, total, err := s.repo.QueryTasks(s.ctx, domain.TaskQuery{SortBy: "id", Trashed: true})
+	s.Require().NoError(err)
+	s.EqualValues(1, total)
+	s.Require().Len(trash, 1)
+	s.Equal(deleted.ID, trash[0].ID)
+	s.Equal("Deleted", trash[0].Title)
+	s.EqualValues(2, trash[0].Version, "Deleting is a write and bumps the version")
+	s.Require().NotNil(trash[0].DeletedAt)
+	s.WithinDuration(before, *trash[0].DeletedAt, time.Minute)
+
+	err = s.repo.UpdateTask(s.ctx, deleted.ID, 2, &domain.Task{Title: "Zombie"})
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "Deleted tasks cannot be updated")
+	err = s.repo.DeleteTaskById(s.ctx, deleted.ID, 2)
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "Deleted tasks cannot be deleted again")
+}
+
+func (s *TaskRepositorySuite) TestRestoreTask() {
+	task := s.create("Oops")
+	s.Require().NoError(s.repo.DeleteTaskById(s.ctx, task.ID, task.Version))
+
+	restored, err := s.repo.RestoreTask(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.Nil(restored.DeletedAt)
+	s.EqualValues(3, restored.Version)
+	s.Equal("Oops", restored.Title)
+
+	found, err := s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err, "A restored task is live again")
+	s.Equal(restored.Version, found.Version)
+	s.Nil(found.DeletedAt)
+
+	trash, _, err := s.repo.QueryTasks(s.ctx, domain.TaskQuery{SortBy: "id", Trashed: true})
+	s.Require().NoError(err)
+	s.Empty(trash)
+}
+
+func (s *TaskRepositorySuite) TestRestoreTask_NotInTrash() {
+	live := s.create("Live")
+
+	_, err := s.repo.RestoreTask(s.ctx, live.ID)
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "Only deleted tasks can be restored")
+	_, err = s.repo.RestoreTask(s.ctx, 9999)
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+}
+
+func (s *TaskRepositorySuite) TestPurgeDeletedTasks() {
+	first := s.create("First")
+	second := s.create("Second")
+	live := s.create("Live")
+	s.Require().NoError(s.repo.DeleteTaskById(s.ctx, first.ID, first.Version))
+	s.Require().NoError(s.repo.DeleteTaskById(s.ctx, second.ID, second.Version))
+
+	purged, err := s.repo.PurgeDeletedTasks(s.ctx, time.Now().Add(-time.Hour))
+	s.Require().NoError(err)
+	s.Empty(purged, "Tasks deleted after the cutoff are kept")
+
+	purged, err = s.repo.PurgeDeletedTasks(s.ctx, time.Now().Add(time.Hour))
+	s.Require().NoError(err)
+	s.Require().Len(purged, 2)
+	s.Equal([]int{first.ID, second.ID}, []int{purged[0].ID, purged[1].ID})
+	s.Equal("Second", purged[1].Title, "The purged tasks are returned as they were stored")
+
+	trash, _, err := s.repo.QueryTasks(s.ctx, domain.TaskQuery{SortBy: "id", Trashed: true})
+	s.Require().NoError(err)
+	s.Empty(trash)
+	_, err = s.repo.RestoreTask(s.ctx, first.ID)
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "Purged tasks are gone for good")
+	_, err = s.repo.GetTaskById(s.ctx, live.ID)
+	s.NoError(err, "Live tasks are never purged")
+}
+
+func (s *TaskRepositorySuite) TestCancelledContext() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+
+	_, err := s.repo.GetAllTasks(ctx)
+	s.Error(err, "A cancelled context should abort the call")
+}
+
+// seeds five tasks due on consecutive days from 2025-07-01, alternating status
+func (s *TaskRepositorySuite) seedQueryTasks() []*domain.Task {
+	start, err := time.Parse(time.RFC3339, "2025-07-01T00:00:00Z")
+	s.Require().NoError(err)
+
+	titles := []string{"Write report", "Review PR", "write tests", "Deploy", "Report bug"}
+	var tasks []*domain.Task
+	for i, title := range titles {
+		task := s.newTask(title)
+		task.DueDate = start.AddDate(0, 0, i)
+		if i%2 == 0 {
+			task.Status = domain.StatusTodo
+		} else {
+			task.Status = domain.StatusDone
+		}
+		s.Require().NoError(s.repo.CreateTask(s.ctx, task))
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+func (s *TaskRepositorySuite) queryTitles(query domain.TaskQuery) ([]string, int64) {
+	tasks, total, err := s.repo.QueryTasks(s.ctx, query)
+	s.Require().NoError(err)
+	titles := []string{}
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+	}
+	return titles, total
+}
+
+func (s *TaskRepositorySuite) TestQueryTasks_NoFilters() {
+	s.seedQueryTasks()
+
+	titles, total := s.queryTitles(domain.TaskQuery{SortBy: "id"})
+	s.EqualValues(5, total)
+	s.Equal([]string{"Write report", "Review PR", "write tests", "Deploy", "Report bug"}, titles)
+}
+
+func (s *TaskRepositorySuite) TestQueryTasks_ByStatus() {
+	s.seedQueryTasks()
+
+	titles, total := s.queryTitles(domain.TaskQuery{Status: domain.StatusDone, SortBy: "id"})
+	s.EqualValues(2, total)
+	s.Equal([]string{"Review PR", "Deploy"}, titles)
+}
+
+func (s *TaskRepositorySuite) TestQueryTasks_ByDueDateRange() {
+	tasks := s.seedQueryTasks()
+
+	titles, total := s.queryTitles(domain.TaskQuery{DueAfter: tasks[1].DueDate, DueBefore: tasks[3].DueDate, SortBy: "id"})
+	s.EqualValues(3, total, "Both range bounds are inclusive")
+	s.Equal([]string{"Review PR", "write tests", "Deploy"}, titles)
+}
+
+func (s *TaskRepositorySuite) TestQueryTasks_ByTitleSubstring() {
+	s.seedQueryTasks()
+
+	titles, total := s.queryTitles(domain.TaskQuery{TitleContains: "REPORT", SortBy: "id"})
+	s.EqualValues(2, total, "Title matching is case-insensitive")
+	s.Equal([]string{"Write report", "Report bug"}, titles)
+
+	titles, total = s.queryTitles(domain.TaskQuery{TitleContains: "r%t", SortBy: "id"})
+	s.EqualValues(0, total, "Wildcard characters are matched literally")
+	s.Empty(titles)
+}
+
+func (s *TaskRepositorySuite) TestQueryTasks_VisibleTo() {
+	tasks := s.seedQueryTasks()
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, tasks[3].ID, tasks[3].Version, &domain.Task{AssignedTo: "alice"}))
+	for owner, title := range map[string]string{"alice": "Alice's own", "bob": "Bob's own"} {
+		owned := s.newTask(title)
+		owned.CreatedBy = owner
+		s.Require().NoError(s.repo.CreateTask(s.ctx, owned))
+	}
+
+	titles, total := s.queryTitles(domain.TaskQuery{VisibleTo: "alice", SortBy: "id"})
+	s.EqualValues(2, total, "Created and assigned tasks are both visible")
+	s.Equal([]string{"Deploy", "Alice's own"}, titles)
+}
+
+func (s *TaskRepositorySuite) TestQueryTasks_Sorting() {
+	s.seedQueryTasks()
+
+	titles, _ := s.queryTitles(domain.TaskQuery{SortBy: "duedate", SortDesc: true})
+	s.Equal([]string{"Report bug", "Deploy", "write tests", "Review PR", "Write report"}, titles)
+
+	titles, _ = s.queryTitles(domain.TaskQuery{SortBy: "status"})
+	s.Equal([]string{"Review PR", "Deploy", "Write report", "write tests", "Report bug"}, titles, "Ties are broken by ascending ID")
+}
+
+func (s *TaskRepositorySuite) TestQueryTasks_Pagination() {
+	s.seedQueryTasks()
+
+	first, total := s.queryTitles(domain.TaskQuery{SortBy: "id", Limit: 2})
+	s.EqualValues(5, total, "Total counts every match, not just the page")
+	s.Equal([]string{"Write report", "Review PR"}, first)
+
+	last, total := s.queryTitles(domain.TaskQuery{SortBy: "id", Limit: 2, Offset: 4})
+	s.EqualValues(5, total)
+	s.Equal([]string{"Report bug"}, last)
+
+	beyond, total := s.queryTitles(domain.TaskQuery{SortBy: "id", Limit: 2, Offset: 10})
+	s.EqualValues(5, total)
+	s.Empty(beyond)
+}
+
+func (s *TaskRepositorySuite) TestQueryTasks_ByPriorityAndLabels() {
+	tasks := s.seedQueryTasks()
+	edits := []domain.Task{
+		{Priority: domain.PriorityHigh, Labels: []string{"backend", "billing"}},
+		{Labels: []string{"backend"}},
+		{Priority: domain.PriorityHigh, Labels: []string{"ui"}},
+	}
+	for i, edit := range edits {
+		s.Require().NoError(s.repo.UpdateTask(s.ctx, tasks[i].ID, tasks[i].Version, &edit))
+	}
+
+	titles, total := s.queryTitles(domain.TaskQuery{Priority: domain.PriorityHigh, SortBy: "id"})
+	s.EqualValues(2, total)
+	s.Equal([]string{"Write report", "write tests"}, titles)
+
+	titles, _ = s.queryTitles(domain.TaskQuery{Labels: []string{"backend"}, SortBy: "id"})
+	s.Equal([]string{"Write report", "Review PR"}, titles)
+
+	titles, _ = s.queryTitles(domain.TaskQuery{Labels: []string{"backend", "billing"}, SortBy: "id"})
+	s.Equal([]string{"Write report"}, titles, "A task must carry every requested label")
+
+	titles, _ = s.queryTitles(domain.TaskQuery{Priority: domain.PriorityHigh, Labels: []string{"backend"}, SortBy: "id"})
+	s.Equal([]string{"Write report"}, titles)
+
+	titles, _ = s.queryTitles(domain.TaskQuery{Labels: []string{"back"}, SortBy: "id"})
+	s.Empty(titles, "Labels match whole, not by prefix")
+}
+
+func (s *TaskRepositorySuite) TestCountLabels() {
+	labelled := func(owner string, labels ...string) *domain.Task {
+		task := s.newTask("Labelled")
+		task.CreatedBy = owner
+		task.Labels = labels
+		s.Require().NoError(s.repo.CreateTask(s.ctx, task))
+		return task
+	}
+	labelled("alice", "backend", "ui")
+	labelled("alice", "backend")
+	labelled("bob", "billing", "ui")
+	deleted := labelled("bob", "backend", "legacy")
+	s.Require().NoError(s.repo.DeleteTaskById(s.ctx, deleted.ID, deleted.Version))
+	s.create("Unlabelled")
+
+	counts, err := s.repo.CountLabels(s.ctx, "")
+	s.Require().NoError(err)
+	s.Equal([]domain.LabelCount{
+		{Label: "backend", Count: 2},
+		{Label: "ui", Count: 2},
+		{Label: "billing", Count: 1},
+	}, counts, "Most used first, ties by name, deleted tasks left out")
+
+	counts, err = s.repo.CountLabels(s.ctx, "bob")
+	s.Require().NoError(err)
+	s.Equal([]domain.LabelCount{{Label: "billing", Count: 1}, {Label: "ui", Count: 1}}, counts)
+
+	counts, err = s.repo.CountLabels(s.ctx, "carol")
+	s.Require().NoError(err)
+	s.Empty(counts)
+}
+
+func (s *TaskRepositorySuite) TestCreateTask_SubtaskWithChecklist() {
+	parent := s.create("Release")
+	task := s.newTask("Changelog")
+	task.ParentID = parent.ID
+	task.Checklist = []domain.ChecklistItem{{ID: 1, Text: "Collect PRs", Done: true}, {ID: 2, Text: "Write notes"}}
+	s.Require().NoError(s.repo.CreateTask(s.ctx, task))
+
+	found, err := s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.requireSameTask(task, found)
+
+	found, err = s.repo.GetTaskById(s.ctx, parent.ID)
+	s.Require().NoError(err)
+	s.Zero(found.ParentID)
+	s.Empty(found.Checklist)
+}
+
+func (s *TaskRepositorySuite) TestUpdateTask_Checklist() {
+	task := s.newTask("Checklist")
+	task.Checklist = []domain.ChecklistItem{{ID: 1, Text: "One"}}
+	s.Require().NoError(s.repo.CreateTask(s.ctx, task))
+
+	checklist := []domain.ChecklistItem{{ID: 2, Text: "Two"}, {ID: 1, Text: "One", Done: true}}
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, task.ID, 1, &domain.Task{Checklist: checklist}))
+	found, err := s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.Equal(checklist, found.Checklist, "Items keep their order")
+
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, task.ID, 2, &domain.Task{Title: "Renamed"}))
+	found, err = s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.Equal(checklist, found.Checklist, "A nil checklist leaves the stored one alone")
+
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, task.ID, 3, &domain.Task{Checklist: []domain.ChecklistItem{}}))
+	found, err = s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.Empty(found.Checklist, "An empty checklist clears it")
+	s.EqualValues(4, found.Version)
+}
+
+// creates a subtask of parent with the given status
+func (s *TaskRepositorySuite) createSubtask(parent *domain.Task, title string, status domain.TaskStatus) *domain.Task {
+	task := s.newTask(title)
+	task.ParentID = parent.ID
+	task.Status = status
+	s.Require().NoError(s.repo.CreateTask(s.ctx, task))
+	return task
+}
+
+func (s *TaskRepositorySuite) TestQueryTasks_ByParent() {
+	parent := s.create("Parent")
+	other := s.create("Other parent")
+	s.createSubtask(parent, "First", domain.StatusTodo)
+	s.createSubtask(other, "Elsewhere", domain.StatusTodo)
+	s.createSubtask(parent, "Second", domain.StatusDone)
+	deleted := s.createSubtask(parent, "Deleted", domain.StatusTodo)
+	s.Require().NoError(s.repo.DeleteTaskById(s.ctx, deleted.ID, deleted.Version))
+
+	titles, total := s.queryTitles(domain.TaskQuery{ParentID: parent.ID, SortBy: "id"})
+	s.EqualValues(2, total)
+	s.Equal([]string{"First", "Second"}, titles)
+
+	titles, _ = s.queryTitles(domain.TaskQuery{ParentID: parent.ID, Status: domain.StatusDone, SortBy: "id"})
+	s.Equal([]string{"Second"}, titles)
+}
+
+func (s *TaskRepositorySuite) TestQueryTasks_ByProject() {
+	for i, project := range []string{"p1", "", "p2", "p1"} {
+		task := s.newTask(fmt.Sprintf("Task %d", i))
+		task.ProjectID = project
+		s.Require().NoError(s.repo.CreateTask(s.ctx, task))
+	}
+
+	titles, total := s.queryTitles(domain.TaskQuery{ProjectID: "p1", SortBy: "id"})
+	s.EqualValues(2, total)
+	s.Equal([]string{"Task 0", "Task 3"}, titles)
+
+	tasks, _, err := s.repo.QueryTasks(s.ctx, domain.TaskQuery{ProjectID: "p2"})
+	s.Require().NoError(err)
+	s.Require().Len(tasks, 1)
+	s.Equal("p2", tasks[0].ProjectID)
+
+	_, total = s.queryTitles(domain.TaskQuery{})
+	s.EqualValues(4, total, "Without a project every board is listed, and tasks on none")
+}
+
+func (s *TaskRepositorySuite) TestCountSubtasks() {
+	parent := s.create("Parent")
+	other := s.create("Other parent")
+	lonely := s.create("No children")
+	s.createSubtask(parent, "Open", domain.StatusInProgress)
+	s.createSubtask(parent, "Done", domain.StatusDone)
+	s.createSubtask(parent, "Archived", domain.StatusArchived)
+	s.createSubtask(other, "Blocked", domain.StatusBlocked)
+	deleted := s.createSubtask(other, "Deleted", domain.StatusTodo)
+	s.Require().NoError(s.repo.DeleteTaskById(s.ctx, deleted.ID, deleted.Version))
+
+	counts, err := s.repo.CountSubtasks(s.ctx, []int{parent.ID, other.ID, lonely.ID})
+	s.Require().NoError(err)
+	s.Equal(map[int]domain.SubtaskCount{
+		parent.ID: {Total: 3, Closed: 2},
+		other.ID:  {Total: 1, Closed: 0},
+	}, counts, "Deleted subtasks are left out, as are parents without any")
+
+	counts, err = s.repo.CountSubtasks(s.ctx, nil)
+	s.Require().NoError(err)
+	s.Empty(counts)
+}
+
+func (s *TaskRepositorySuite) TestUpdateTask_BlockedBy() {
+	first := s.create("First")
+	second := s.create("Second")
+	task := s.newTask("Blocked")
+	task.BlockedBy = []int{first.ID}
+	s.Require().NoError(s.repo.CreateTask(s.ctx, task))
+
+	found, err := s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.requireSameTask(task, found)
+
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, task.ID, 1, &domain.Task{BlockedBy: []int{first.ID, second.ID}}))
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, task.ID, 2, &domain.Task{Title: "Renamed"}))
+	found, err = s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.Equal([]int{first.ID, second.ID}, found.BlockedBy, "Nil blockers leave the stored ones alone")
+
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, task.ID, 3, &domain.Task{BlockedBy: []int{}}))
+	found, err = s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.Empty(found.BlockedBy, "An empty list clears the blockers")
+	s.EqualValues(4, found.Version)
+}
+
+func (s *TaskRepositorySuite) TestQueryTasks_ByIDsAndWaitingOn() {
+	first := s.create("First")
+	second := s.create("Second")
+	third := s.newTask("Third")
+	third.BlockedBy = []int{first.ID}
+	s.Require().NoError(s.repo.CreateTask(s.ctx, third))
+	fourth := s.newTask("Fourth")
+	fourth.BlockedBy = []int{first.ID, second.ID}
+	s.Require().NoError(s.repo.CreateTask(s.ctx, fourth))
+	deleted := s.newTask("Deleted")
+	deleted.BlockedBy = []int{second.ID}
+	s.Require().NoError(s.repo.CreateTask(s.ctx, deleted))
+	s.Require().NoError(s.repo.DeleteTaskById(s.ctx, deleted.ID, deleted.Version))
+
+	titles, total := s.queryTitles(domain.TaskQuery{IDs: []int{second.ID, fourth.ID, deleted.ID, 999}, SortBy: "id"})
+	s.EqualValues(2, total)
+	s.Equal([]string{"Second", "Fourth"}, titles)
+
+	titles, _ = s.queryTitles(domain.TaskQuery{WaitingOn: []int{first.ID}, SortBy: "id"})
+	s.Equal([]string{"Third", "Fourth"}, titles)
+
+	titles, _ = s.queryTitles(domain.TaskQuery{WaitingOn: []int{second.ID, third.ID}, SortBy: "id"})
+	s.Equal([]string{"Fourth"}, titles, "Deleted tasks are not waiting on anything")
+
+	titles, _ = s.queryTitles(domain.TaskQuery{IDs: []int{third.ID, fourth.ID}, WaitingOn: []int{second.ID}})
+	s.Equal([]string{"Fourth"}, titles)
+}
+
+func (s *TaskRepositorySuite) TestUpdateTask_Recurrence() {
+	until := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	task := s.newTask("Weekly ops review")
+	task.Recurrence = &domain.Recurrence{Frequency: domain.FrequencyWeekly, Interval: 2, ByWeekday: []string{"MO", "TH"}, Until: &until, Occurrence: 1}
+	s.Require().NoError(s.repo.CreateTask(s.ctx, task))
+
+	found, err := s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.requireSameTask(task, found)
+
+	monthly := &domain.Recurrence{Frequency: domain.FrequencyMonthly, Interval: 1, Count: 6, Occurrence: 3}
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, task.ID, 1, &domain.Task{Recurrence: monthly}))
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, task.ID, 2, &domain.Task{Title: "Monthly ops review"}))
+	found, err = s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.Require().NotNil(found.Recurrence, "A nil rule leaves the stored one alone")
+	s.Equal(monthly.String(), found.Recurrence.String())
+	s.Equal(3, found.Recurrence.Occurrence)
+
+	s.Require().NoError(s.repo.UpdateTask(s.ctx, task.ID, 3, &domain.Task{Recurrence: &domain.Recurrence{}}))
+	found, err = s.repo.GetTaskById(s.ctx, task.ID)
+	s.Require().NoError(err)
+	s.Nil(found.Recurrence, "A rule without a frequency clears it")
+	s.EqualValues(4, found.Version)
+}
@@ -0,0 +1,113 @@
+package repotest
+
+import (
+	"context"
+	"sync"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TokenRepositorySuite struct {
+	suite.Suite
+	NewRepository func() interfaces.TokenRepository // must return an empty repository
+	repo          interfaces.TokenRepository
+	ctx           context.Context
+}
+
+func (s *TokenRepositorySuite) SetupTest() {
+	s.Require().NotNil(s.NewRepository, "TokenRepositorySuite needs a NewRepository factory")
+	s.repo = s.NewRepository()
+	s.ctx = context.Background()
+}
+
+func (s *TokenRepositorySuite) store(hash string, ttl time.Duration) domain.RefreshToken {
+	token := domain.RefreshToken{TokenHash: hash, Username: "alice", ExpiresAt: time.Now().Add(ttl).UTC()}
+	s.Require().NoError(s.repo.StoreRefreshToken(s.ctx, token), "Failed to store refresh token")
+	return token
+}
+
+func (s *TokenRepositorySuite) TestConsumeRefreshToken() {
+	stored := s.store("hash-1", time.Hour)
+
+	found, err := s.repo.ConsumeRefreshToken(s.ctx, "hash-1")
+	s.Require().NoError(err)
+	s.Equal(stored.TokenHash, found.TokenHash)
+	s.Equal(stored.Username, found.Username)
+	s.WithinDuration(stored.ExpiresAt, found.ExpiresAt, time.Second)
+}
+
+func (s *TokenRepositorySuite) TestConsumeRefreshToken_OnlyOnce() {
+	s.store("hash-1", time.Hour)
+
+	_, err := s.repo.ConsumeRefreshToken(s.ctx, "hash-1")
+	s.Require().NoError(err)
+	_, err = s.repo.ConsumeRefreshToken(s.ctx, "hash-1")
+	s.ErrorIs(err, domain.ErrInvalidRefreshToken, "A refresh token must not work twice")
+}
+
+func (s *TokenRepositorySuite) TestConsumeRefreshToken_Concurrent() {
+	s.store("hash-1", time.Hour)
+
+	const workers = 10
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	successes := 0
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.repo.ConsumeRefreshToken(s.ctx, "hash-1"); err == nil {
+				mu.Lock()
+				successes++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	s.Equal(1, successes, "Exactly one concurrent refresh may win")
+}
+
+func (s *TokenRepositorySuite) TestConsumeRefreshToken_Unknown() {
+	_, err := s.repo.ConsumeRefreshToken(s.ctx, "nope")
+	s.ErrorIs(err, domain.ErrInvalidRefreshToken)
+}
+
+func (s *TokenRepositorySuite) TestConsumeRefreshToken_Expired() {
+	s.store("hash-1", -time.Minute)
+
+	_, err := s.repo.ConsumeRefreshToken(s.ctx, "hash-1")
+	s.ErrorIs(err, domain.ErrInvalidRefreshToken)
+}
+
+func (s *TokenRepositorySuite) TestRevokeAccessToken() {
+	revoked, err := s.repo.IsAccessTokenRevoked(s.ctx, "jti-1")
+	s.Require().NoError(err)
+	s.False(revoked)
+
+	s.Require().NoError(s.repo.RevokeAccessToken(s.ctx, "jti-1", time.Now().Add(time.Hour)))
+
+	revoked, err = s.repo.IsAccessTokenRevoked(s.ctx, "jti-1")
+	s.Require().NoError(err)
+	s.True(revoked)
+
+	revoked, err = s.repo.IsAccessTokenRevoked(s.ctx, "jti-2")
+	s.Require().NoError(err)
+	s.False(revoked, "Revoking one token must not affect others")
+}
+
+func (s *TokenRepositorySuite) TestRevokeAccessToken_Twice() {
+	expiresAt := time.Now().Add(time.Hour)
+	s.Require().NoError(s.repo.RevokeAccessToken(s.ctx, "jti-1", expiresAt))
+	s.NoError(s.repo.RevokeAccessToken(s.ctx, "jti-1", expiresAt), "Revoking an already revoked token is not an error")
+}
+
+func (s *TokenRepositorySuite) TestCancelledContext() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+
+	_, err := s.repo.IsAccessTokenRevoked(ctx, "jti-1")
+	s.Error(err, "A cancelled context should abort the call")
+}
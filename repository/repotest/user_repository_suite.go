@@ -0,0 +1,148 @@
+package repotest
+
+import (
+	"context"
+	"task7/domain"
+	"task7/repository/interfaces"
+
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type UserRepositorySuite struct {
+	suite.Suite
+	NewRepository func() interfaces.UserRepository // must return an empty repository
+	repo          interfaces.UserRepository
+	ctx           context.Context
+}
+
+func (s *UserRepositorySuite) SetupTest() {
+	s.Require().NotNil(s.NewRepository, "UserRepositorySuite needs a NewRepository factory")
+	s.repo = s.NewRepository()
+	s.ctx = context.Background()
+}
+
+func (s *UserRepositorySuite) register(username, password string) *domain.User {
+	user := &domain.User{Username: username, PasswordHash: password}
+	s.Require().NoError(s.repo.RegisterUser(s.ctx, user), "Failed to register "+username)
+	return user
+}
+
+func (s *UserRepositorySuite) TestRegisterUser_FirstUserAsAdmin() {
+	user := s.register("adminuser", "password123")
+	s.Equal("admin", user.Role, "First user should be assigned 'admin' role")
+	s.False(user.ID.IsZero(), "User ID should be assigned on registration")
+}
+
+func (s *UserRepositorySuite) TestRegisterUser_SubsequentUsersAsRegular() {
+	s.register("initialadmin", "adminpass")
+	user := s.register("regularuser", "regularpass")
+	s.Equal("regular", user.Role, "Second user should be assigned 'regular' role")
+}
+
+func (s *UserRepositorySuite) TestRegisterUser_HashesPassword() {
+	user := s.register("hashme", "plaintext")
+	s.NotEqual("plaintext", user.PasswordHash, "Password must not be stored in plain text")
+	s.NoError(bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("plaintext")))
+}
+
+func (s *UserRepositorySuite) TestRegisterUser_DuplicateUsername() {
+	s.register("duplicate", "pass1")
+
+	err := s.repo.RegisterUser(s.ctx, &domain.User{Username: "duplicate", PasswordHash: "pass2"})
+	s.Require().Error(err, "Expected error when registering user with duplicate username")
+	s.Contains(err.Error(), "user with username 'duplicate' already exists")
+	s.Equal(domain.KindConflict, domain.KindOf(err))
+}
+
+func (s *UserRepositorySuite) TestRegisterUser_EmptyPassword() {
+	err := s.repo.RegisterUser(s.ctx, &domain.User{Username: "empty_pass_user"})
+	s.Require().Error(err, "Expected error for empty password")
+	s.Contains(err.Error(), "password cannot be empty")
+	s.Equal(domain.KindValidation, domain.KindOf(err))
+}
+
+func (s *UserRepositorySuite) TestLoginUser_Success() {
+	user := s.register("loginuser", "securepass")
+
+	loggedIn, err := s.repo.LoginUser(s.ctx, &domain.User{Username: "loginuser", PasswordHash: "securepass"})
+	s.Require().NoError(err, "Login should be successful")
+	s.Equal(user.ID, loggedIn.ID)
+	s.Equal(user.Username, loggedIn.Username)
+	s.Equal(user.Role, loggedIn.Role)
+}
+
+func (s *UserRepositorySuite) TestLoginUser_IncorrectPassword() {
+	s.register("badpassuser", "correctpass")
+
+	_, err := s.repo.LoginUser(s.ctx, &domain.User{Username: "badpassuser", PasswordHash: "wrongpass"})
+	s.ErrorIs(err, bcrypt.ErrMismatchedHashAndPassword)
+	s.Equal(domain.KindUnauthorized, domain.KindOf(err))
+}
+
+func (s *UserRepositorySuite) TestLoginUser_NotFound() {
+	_, err := s.repo.LoginUser(s.ctx, &domain.User{Username: "nonexistentuser", PasswordHash: "anypass"})
+	s.Error(err, "Login should fail for non-existent user")
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+}
+
+func (s *UserRepositorySuite) TestPromoteUser_RegularToAdmin() {
+	s.register("initial_admin", "pass")
+	s.register("promoteme", "pass")
+
+	s.Require().NoError(s.repo.PromoteUser(s.ctx, "promoteme"), "Failed to promote user")
+
+	promoted, err := s.repo.LoginUser(s.ctx, &domain.User{Username: "promoteme", PasswordHash: "pass"})
+	s.Require().NoError(err)
+	s.Equal("admin", promoted.Role, "User role should be updated to 'admin'")
+}
+
+func (s *UserRepositorySuite) TestPromoteUser_AdminRemainsAdmin() {
+	s.register("alreadyadmin", "pass")
+	s.NoError(s.repo.PromoteUser(s.ctx, "alreadyadmin"), "Promoting an admin should not return an error")
+}
+
+func (s *UserRepositorySuite) TestPromoteUser_NotFound() {
+	err := s.repo.PromoteUser(s.ctx, "nonexistent_user")
+	s.Require().Error(err, "Expected error when promoting non-existent user")
+	s.Contains(err.Error(), "user not found")
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+}
+
+func (s *UserRepositorySuite) TestGetRole() {
+	s.register("initial_admin", "pass")
+	s.register("bob", "pass")
+
+	role, err := s.repo.GetRole(s.ctx, "bob")
+	s.Require().NoError(err)
+	s.Equal("regular", role)
+
+	s.Require().NoError(s.repo.PromoteUser(s.ctx, "bob"))
+	role, err = s.repo.GetRole(s.ctx, "bob")
+	s.Require().NoError(err)
+	s.Equal("admin", role, "The role is read as it is now")
+
+	_, err = s.repo.GetRole(s.ctx, "nobody")
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+}
+
+func (s *UserRepositorySuite) TestFindUsernames() {
+	s.register("carol", "pass")
+	s.register("alice", "pass")
+
+	found, err := s.repo.FindUsernames(s.ctx, []string{"dave", "carol", "alice", "Alice"})
+	s.Require().NoError(err)
+	s.Equal([]string{"alice", "carol"}, found, "Only registered names, matched exactly and sorted")
+
+	found, err = s.repo.FindUsernames(s.ctx, nil)
+	s.Require().NoError(err)
+	s.Empty(found)
+}
+
+func (s *UserRepositorySuite) TestCancelledContext() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+
+	err := s.repo.PromoteUser(ctx, "anyone")
+	s.Error(err, "A cancelled context should abort the call")
+}
@@ -0,0 +1,317 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// a migration moves the schema from version-1 to version; never edit one that has shipped, append a new one
+type migration struct {
+	version    int
+	statements []string
+}
+
+var migrations = []migration{
+	{
+		version: 1,
+		statements: []string{
+			`CREATE TABLE tasks (
+				id          INTEGER PRIMARY KEY,
+				title       TEXT    NOT NULL,
+				description TEXT    NOT NULL,
+				due_date    INTEGER NOT NULL,
+				status      TEXT    NOT NULL
+			)`,
+			`CREATE TABLE users (
+				id            TEXT PRIMARY KEY,
+				username      TEXT NOT NULL,
+				password_hash TEXT NOT NULL,
+				role          TEXT NOT NULL
+			)`,
+			`CREATE UNIQUE INDEX idx_users_username ON users (username)`,
+		},
+	},
+	{
+		// AUTOINCREMENT stops sqlite from reusing the id of the most recently deleted task
+		version: 2,
+		statements: []string{
+			`CREATE TABLE tasks_v2 (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				title       TEXT    NOT NULL,
+				description TEXT    NOT NULL,
+				due_date    INTEGER NOT NULL,
+				status      TEXT    NOT NULL
+			)`,
+			`INSERT INTO tasks_v2 (id, title, description, due_date, status)
+				SELECT id, title, description, due_date, status FROM tasks`,
+			`DROP TABLE tasks`,
+			`ALTER TABLE tasks_v2 RENAME TO tasks`,
+		},
+	},
+	{
+		// task ownership; rows from before this version belong to nobody and are visible to admins only
+		version: 3,
+		statements: []string{
+			`ALTER TABLE tasks ADD COLUMN created_by  TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE tasks ADD COLUMN assigned_to TEXT NOT NULL DEFAULT ''`,
+			`CREATE INDEX idx_tasks_created_by  ON tasks (created_by)`,
+			`CREATE INDEX idx_tasks_assigned_to ON tasks (assigned_to)`,
+		},
+	},
+	{
+		// refresh tokens are kept by hash only; revoked_tokens holds logged-out access token ids
+		version: 4,
+		statements: []string{
+			`CREATE TABLE refresh_tokens (
+				token_hash TEXT    PRIMARY KEY,
+				username   TEXT    NOT NULL,
+				role       TEXT    NOT NULL,
+				expires_at INTEGER NOT NULL
+			)`,
+			`CREATE TABLE revoked_tokens (
+				jti        TEXT    PRIMARY KEY,
+				expires_at INTEGER NOT NULL
+			)`,
+		},
+	},
+	{
+		// free-form statuses written before the task status state machine, mapped to canonical ones
+		version: 5,
+		statements: []string{
+			`UPDATE tasks SET status = 'todo'        WHERE lower(trim(status)) IN ('todo', 'pending', 'open')`,
+			`UPDATE tasks SET status = 'in_progress' WHERE lower(trim(status)) IN ('in_progress', 'in progress', 'in-progress', 'started')`,
+			`UPDATE tasks SET status = 'blocked'     WHERE lower(trim(status)) = 'blocked'`,
+			`UPDATE tasks SET status = 'done'        WHERE lower(trim(status)) IN ('done', 'completed', 'complete', 'closed')`,
+			`UPDATE tasks SET status = 'archived'    WHERE lower(trim(status)) = 'archived'`,
+		},
+	},
+	{
+		// append-only audit trail; changes holds the JSON-encoded before/after diff of an update
+		version: 6,
+		statements: []string{
+			`CREATE TABLE audit_log (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				action      TEXT    NOT NULL,
+				actor       TEXT    NOT NULL,
+				actor_role  TEXT    NOT NULL,
+				target_type TEXT    NOT NULL,
+				target_id   TEXT    NOT NULL,
+				timestamp   INTEGER NOT NULL,
+				changes     TEXT    NOT NULL DEFAULT '[]'
+			)`,
+			`CREATE INDEX idx_audit_log_timestamp ON audit_log (timestamp)`,
+			`CREATE INDEX idx_audit_log_target    ON audit_log (target_type, target_id)`,
+			`CREATE INDEX idx_audit_log_actor     ON audit_log (actor)`,
+		},
+	},
+	{
+		// optimistic concurrency; existing rows start at version 1 like new ones
+		version: 7,
+		statements: []string{
+			`ALTER TABLE tasks ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+		},
+	},
+	{
+		// soft delete: unix nanoseconds, NULL while the task is live
+		version: 8,
+		statements: []string{
+			`ALTER TABLE tasks ADD COLUMN deleted_at INTEGER`,
+			`CREATE INDEX idx_tasks_deleted_at ON tasks (deleted_at)`,
+		},
+	},
+	{
+		// labels are a JSON array of normalised label strings, queried with json_each
+		version: 9,
+		statements: []string{
+			`ALTER TABLE tasks ADD COLUMN priority TEXT NOT NULL DEFAULT 'medium'`,
+			`ALTER TABLE tasks ADD COLUMN labels TEXT NOT NULL DEFAULT '[]'`,
+			`CREATE INDEX idx_tasks_priority ON tasks (priority)`,
+		},
+	},
+	{
+		// subtasks and checklists; 0 means no parent, and the checklist is a JSON array of items
+		version: 10,
+		statements: []string{
+			`ALTER TABLE tasks ADD COLUMN parent_id INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE tasks ADD COLUMN checklist TEXT NOT NULL DEFAULT '[]'`,
+			`CREATE INDEX idx_tasks_parent_id ON tasks (parent_id)`,
+		},
+	},
+	{
+		// dependencies: a JSON array of the ids of the tasks that block this one
+		version: 11,
+		statements: []string{
+			`ALTER TABLE tasks ADD COLUMN blocked_by TEXT NOT NULL DEFAULT '[]'`,
+		},
+	},
+	{
+		// recurring tasks: the rule as a JSON object, or empty for a one-off task
+		version: 12,
+		statements: []string{
+			`ALTER TABLE tasks ADD COLUMN recurrence TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		// task comments; mentions is a JSON array of usernames and edited_at is NULL until the first edit
+		version: 13,
+		statements: []string{
+			`CREATE TABLE comments (
+				id         INTEGER PRIMARY KEY AUTOINCREMENT,
+				task_id    INTEGER NOT NULL,
+				author     TEXT    NOT NULL,
+				body       TEXT    NOT NULL,
+				mentions   TEXT    NOT NULL DEFAULT '[]',
+				created_at INTEGER NOT NULL,
+				edited_at  INTEGER
+			)`,
+			`CREATE INDEX idx_comments_task ON comments (task_id, created_at)`,
+		},
+	},
+	{
+		// attachment metadata; the content lives in the configured blob store under the same id
+		version: 14,
+		statements: []string{
+			`CREATE TABLE attachments (
+				id           TEXT    PRIMARY KEY,
+				task_id      INTEGER NOT NULL,
+				filename     TEXT    NOT NULL,
+				content_type TEXT    NOT NULL,
+				size         INTEGER NOT NULL,
+				sha256       TEXT    NOT NULL,
+				uploaded_by  TEXT    NOT NULL,
+				uploaded_at  INTEGER NOT NULL
+			)`,
+			`CREATE INDEX idx_attachments_task ON attachments (task_id, uploaded_at)`,
+		},
+	},
+	{
+		// projects and their members; tasks from before this version are on no project's board
+		version: 15,
+		statements: []string{
+			`CREATE TABLE projects (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				name        TEXT    NOT NULL,
+				description TEXT    NOT NULL,
+				created_by  TEXT    NOT NULL,
+				created_at  INTEGER NOT NULL,
+				updated_at  INTEGER NOT NULL
+			)`,
+			`CREATE TABLE project_members (
+				project_id INTEGER NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+				username   TEXT    NOT NULL,
+				role       TEXT    NOT NULL,
+				PRIMARY KEY (project_id, username)
+			)`,
+			`CREATE INDEX idx_project_members_username ON project_members (username)`,
+			`ALTER TABLE tasks ADD COLUMN project_id TEXT NOT NULL DEFAULT ''`,
+			`CREATE INDEX idx_tasks_project_id ON tasks (project_id)`,
+		},
+	},
+	{
+		// optimistic concurrency for projects, as for tasks in version 7; existing rows start at version 1
+		version: 16,
+		statements: []string{
+			`ALTER TABLE projects ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+		},
+	},
+	{
+		// the tasks counted on each project, which keep it from being deleted; counted afresh here
+		version: 17,
+		statements: []string{
+			`ALTER TABLE projects ADD COLUMN task_count INTEGER NOT NULL DEFAULT 0`,
+			`UPDATE projects SET task_count = (SELECT COUNT(*) FROM tasks WHERE tasks.project_id = CAST(projects.id AS TEXT))`,
+		},
+	},
+	{
+		// refreshes sign the user's current role, so refresh tokens no longer keep the one from login
+		version: 18,
+		statements: []string{
+			`ALTER TABLE refresh_tokens DROP COLUMN role`,
+		},
+	},
+}
+
+// opens (creating if needed) the sqlite file at path and brings its schema up to date
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", withForeignKeys(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	// sqlite allows a single writer; one connection also keeps ":memory:" databases shared
+	db.SetMaxOpenConns(1)
+
+	if err := Migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// path with foreign keys switched on in its query, so the driver enables them on every connection it
+// opens rather than only the first; the cascades from projects to their members rely on them
+func withForeignKeys(path string) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "_pragma=foreign_keys(1)"
+}
+
+// applies every migration newer than the recorded schema version, each in its own transaction
+func Migrate(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(db, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returns the highest applied migration version, 0 for a fresh database
+func SchemaVersion(db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start migration %d: %w", m.version, err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range m.statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("migration %d failed: %w", m.version, err)
+		}
+	}
+	_, err = tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, m.version, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.version, err)
+	}
+	return tx.Commit()
+}
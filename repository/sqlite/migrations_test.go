@@ -0,0 +1,78 @@
+package sqlite_test
+
+import (
+	"path/filepath"
+	"task7/repository/sqlite"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrationsSuite struct {
+	suite.Suite
+	path string
+}
+
+func TestMigrationsSuite(t *testing.T) {
+	suite.Run(t, new(MigrationsSuite))
+}
+
+func (s *MigrationsSuite) SetupTest() {
+	s.path = filepath.Join(s.T().TempDir(), "migrations.db")
+}
+
+func (s *MigrationsSuite) TestOpen_AppliesAllMigrations() {
+	db, err := sqlite.Open(s.path)
+	s.Require().NoError(err)
+	defer db.Close()
+
+	version, err := sqlite.SchemaVersion(db)
+	s.Require().NoError(err)
+	s.Equal(18, version)
+
+	for _, table := range []string{"tasks", "users", "refresh_tokens", "revoked_tokens", "audit_log", "comments", "attachments", "projects", "project_members"} {
+		var name string
+		err := db.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&name)
+		s.NoError(err, "Expected table "+table+" to exist")
+	}
+}
+
+func (s *MigrationsSuite) TestMigrate_IsIdempotent() {
+	db, err := sqlite.Open(s.path)
+	s.Require().NoError(err)
+	s.Require().NoError(sqlite.Migrate(db), "Re-running migrations should be a no-op")
+
+	var applied int
+	s.Require().NoError(db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied))
+	s.Equal(18, applied, "Each migration should be recorded once")
+	s.Require().NoError(db.Close())
+
+	reopened, err := sqlite.Open(s.path)
+	require.NoError(s.T(), err, "Reopening a migrated database should succeed")
+	defer reopened.Close()
+}
+
+func (s *MigrationsSuite) TestOpen_ForeignKeysOnEveryConnection() {
+	db, err := sqlite.Open(s.path)
+	s.Require().NoError(err)
+	defer db.Close()
+	db.SetMaxIdleConns(0) // every query below runs on a fresh connection
+
+	for range 2 {
+		var enabled int
+		s.Require().NoError(db.QueryRow(`PRAGMA foreign_keys`).Scan(&enabled))
+		s.Equal(1, enabled)
+	}
+}
+
+func (s *MigrationsSuite) TestUsernameUniqueIndex() {
+	db, err := sqlite.Open(s.path)
+	s.Require().NoError(err)
+	defer db.Close()
+
+	_, err = db.Exec(`INSERT INTO users (id, username, password_hash, role) VALUES ('a', 'same', 'x', 'admin')`)
+	s.Require().NoError(err)
+	_, err = db.Exec(`INSERT INTO users (id, username, password_hash, role) VALUES ('b', 'same', 'x', 'regular')`)
+	s.Error(err, "Duplicate usernames must be rejected by the schema")
+}
@@ -0,0 +1,95 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"task7/domain"
+	"time"
+)
+
+type SQLiteAttachmentRepository struct { // sqlite implementer
+	DB               *sql.DB
+	OperationTimeout time.Duration
+}
+
+func NewSQLiteAttachmentRepository(db *sql.DB) *SQLiteAttachmentRepository {
+	return &SQLiteAttachmentRepository{
+		DB:               db,
+		OperationTimeout: DefaultOperationTimeout,
+	}
+}
+
+const attachmentColumns = `id, task_id, filename, content_type, size, sha256, uploaded_by, uploaded_at`
+
+func scanAttachment(row rowScanner) (domain.Attachment, error) {
+	var attachment domain.Attachment
+	var uploadedAt int64
+	err := row.Scan(&attachment.ID, &attachment.TaskID, &attachment.Filename, &attachment.ContentType,
+		&attachment.Size, &attachment.SHA256, &attachment.UploadedBy, &uploadedAt)
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+	attachment.UploadedAt = time.Unix(0, uploadedAt).UTC()
+	return attachment, nil
+}
+
+func (r *SQLiteAttachmentRepository) CreateAttachment(ctx context.Context, attachment domain.Attachment) error {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, `INSERT INTO attachments (`+attachmentColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		attachment.ID, attachment.TaskID, attachment.Filename, attachment.ContentType,
+		attachment.Size, attachment.SHA256, attachment.UploadedBy, attachment.UploadedAt.UnixNano())
+	if isConstraintViolation(err) {
+		return domain.NewConflict("attachment %s already exists", attachment.ID)
+	}
+	return err
+}
+
+func (r *SQLiteAttachmentRepository) GetAttachment(ctx context.Context, id string) (domain.Attachment, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	attachment, err := scanAttachment(r.DB.QueryRowContext(ctx, `SELECT `+attachmentColumns+` FROM attachments WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Attachment{}, domain.NewNotFound("no attachment found with id %s", id)
+	}
+	return attachment, err
+}
+
+func (r *SQLiteAttachmentRepository) ListAttachments(ctx context.Context, taskID int) ([]domain.Attachment, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	// rowid follows insertion, so it breaks timestamp ties oldest first
+	rows, err := r.DB.QueryContext(ctx, `SELECT `+attachmentColumns+` FROM attachments WHERE task_id = ? ORDER BY uploaded_at, rowid`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []domain.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, rows.Err()
+}
+
+func (r *SQLiteAttachmentRepository) DeleteAttachment(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	res, err := r.DB.ExecContext(ctx, `DELETE FROM attachments WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return domain.NewNotFound("no attachment found with id %s", id)
+	}
+	return nil
+}
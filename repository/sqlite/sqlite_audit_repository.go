@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"task7/domain"
+	"time"
+)
+
+type SQLiteAuditRepository struct { // sqlite implementer
+	DB               *sql.DB
+	OperationTimeout time.Duration
+}
+
+func NewSQLiteAuditRepository(db *sql.DB) *SQLiteAuditRepository {
+	return &SQLiteAuditRepository{
+		DB:               db,
+		OperationTimeout: DefaultOperationTimeout,
+	}
+}
+
+func (r *SQLiteAuditRepository) Append(ctx context.Context, entry *domain.AuditEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	changes := entry.Changes
+	if changes == nil {
+		changes = []domain.FieldChange{}
+	}
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	res, err := r.DB.ExecContext(ctx,
+		`INSERT INTO audit_log (action, actor, actor_role, target_type, target_id, timestamp, changes) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entry.Action, entry.Actor, entry.ActorRole, entry.TargetType, entry.TargetID, entry.Timestamp.UnixNano(), string(encoded))
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	entry.ID = strconv.FormatInt(id, 10)
+	return nil
+}
+
+func (r *SQLiteAuditRepository) QueryAudit(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEntry, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	where, args := auditWhere(query)
+
+	var total int64
+	if err := r.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_log`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = -1 // sqlite: no limit
+	}
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT id, action, actor, actor_role, target_type, target_id, timestamp, changes FROM audit_log`+where+
+			` ORDER BY timestamp DESC, id DESC LIMIT ? OFFSET ?`,
+		append(args, limit, query.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []domain.AuditEntry
+	for rows.Next() {
+		var entry domain.AuditEntry
+		var id, timestamp int64
+		var changes string
+		if err := rows.Scan(&id, &entry.Action, &entry.Actor, &entry.ActorRole, &entry.TargetType, &entry.TargetID, &timestamp, &changes); err != nil {
+			return nil, 0, err
+		}
+		if err := json.Unmarshal([]byte(changes), &entry.Changes); err != nil {
+			return nil, 0, err
+		}
+		if len(entry.Changes) == 0 {
+			entry.Changes = nil
+		}
+		entry.ID = strconv.FormatInt(id, 10)
+		entry.Timestamp = time.Unix(0, timestamp).UTC()
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func auditWhere(query domain.AuditQuery) (string, []any) {
+	var conds []string
+	var args []any
+	for _, eq := range []struct{ column, value string }{
+		{"actor", query.Actor},
+		{"action", string(query.Action)},
+		{"target_type", query.TargetType},
+		{"target_id", query.TargetID},
+	} {
+		if eq.value != "" {
+			conds = append(conds, eq.column+" = ?")
+			args = append(args, eq.value)
+		}
+	}
+	if !query.Since.IsZero() {
+		conds = append(conds, "timestamp >= ?")
+		args = append(args, query.Since.UnixNano())
+	}
+	if !query.Until.IsZero() {
+		conds = append(conds, "timestamp <= ?")
+		args = append(args, query.Until.UnixNano())
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
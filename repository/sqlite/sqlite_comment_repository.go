@@ -0,0 +1,191 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"task7/domain"
+	"time"
+)
+
+type SQLiteCommentRepository struct { // sqlite implementer
+	DB               *sql.DB
+	OperationTimeout time.Duration
+}
+
+func NewSQLiteCommentRepository(db *sql.DB) *SQLiteCommentRepository {
+	return &SQLiteCommentRepository{
+		DB:               db,
+		OperationTimeout: DefaultOperationTimeout,
+	}
+}
+
+const commentColumns = `id, task_id, author, body, mentions, created_at, edited_at`
+
+func scanComment(row rowScanner) (domain.Comment, error) {
+	var comment domain.Comment
+	var id, createdAt int64
+	var editedAt sql.NullInt64
+	var mentions string
+	if err := row.Scan(&id, &comment.TaskID, &comment.Author, &comment.Body, &mentions, &createdAt, &editedAt); err != nil {
+		return domain.Comment{}, err
+	}
+	if err := json.Unmarshal([]byte(mentions), &comment.Mentions); err != nil {
+		return domain.Comment{}, err
+	}
+	if len(comment.Mentions) == 0 {
+		comment.Mentions = nil
+	}
+	comment.ID = strconv.FormatInt(id, 10)
+	comment.CreatedAt = time.Unix(0, createdAt).UTC()
+	if editedAt.Valid {
+		edited := time.Unix(0, editedAt.Int64).UTC()
+		comment.EditedAt = &edited
+	}
+	return comment, nil
+}
+
+func encodeMentions(mentions []string) (string, error) {
+	if mentions == nil {
+		mentions = []string{}
+	}
+	encoded, err := json.Marshal(mentions)
+	return string(encoded), err
+}
+
+// the row id behind a comment id; false for one this repository could not have issued
+func commentRowID(id string) (int64, bool) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	return n, err == nil && n > 0
+}
+
+func (r *SQLiteCommentRepository) CreateComment(ctx context.Context, comment *domain.Comment) error {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	mentions, err := encodeMentions(comment.Mentions)
+	if err != nil {
+		return err
+	}
+	var editedAt any
+	if comment.EditedAt != nil {
+		editedAt = comment.EditedAt.UnixNano()
+	}
+	res, err := r.DB.ExecContext(ctx,
+		`INSERT INTO comments (task_id, author, body, mentions, created_at, edited_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		comment.TaskID, comment.Author, comment.Body, mentions, comment.CreatedAt.UnixNano(), editedAt)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	comment.ID = strconv.FormatInt(id, 10)
+	return nil
+}
+
+func (r *SQLiteCommentRepository) GetComment(ctx context.Context, id string) (domain.Comment, error) {
+	rowID, ok := commentRowID(id)
+	if !ok {
+		return domain.Comment{}, domain.NewNotFound("no comment found with id %s", id)
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	comment, err := scanComment(r.DB.QueryRowContext(ctx, `SELECT `+commentColumns+` FROM comments WHERE id = ?`, rowID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Comment{}, domain.NewNotFound("no comment found with id %s", id)
+	}
+	return comment, err
+}
+
+func (r *SQLiteCommentRepository) ListComments(ctx context.Context, query domain.CommentQuery) ([]domain.Comment, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	var total int64
+	if err := r.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM comments WHERE task_id = ?`, query.TaskID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = -1 // sqlite: no limit
+	}
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+commentColumns+` FROM comments WHERE task_id = ? ORDER BY created_at, id LIMIT ? OFFSET ?`,
+		query.TaskID, limit, query.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var comments []domain.Comment
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		comments = append(comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return comments, total, nil
+}
+
+func (r *SQLiteCommentRepository) UpdateComment(ctx context.Context, comment domain.Comment) error {
+	rowID, ok := commentRowID(comment.ID)
+	if !ok {
+		return domain.NewNotFound("no comment found with id %s", comment.ID)
+	}
+	mentions, err := encodeMentions(comment.Mentions)
+	if err != nil {
+		return err
+	}
+	var editedAt any
+	if comment.EditedAt != nil {
+		editedAt = comment.EditedAt.UnixNano()
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	res, err := r.DB.ExecContext(ctx, `UPDATE comments SET body = ?, mentions = ?, edited_at = ? WHERE id = ?`,
+		comment.Body, mentions, editedAt, rowID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return domain.NewNotFound("no comment found with id %s", comment.ID)
+	}
+	return nil
+}
+
+func (r *SQLiteCommentRepository) DeleteComment(ctx context.Context, id string) error {
+	rowID, ok := commentRowID(id)
+	if !ok {
+		return domain.NewNotFound("no comment found with id %s", id)
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	res, err := r.DB.ExecContext(ctx, `DELETE FROM comments WHERE id = ?`, rowID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return domain.NewNotFound("no comment found with id %s", id)
+	}
+	return nil
+}
+
+func (r *SQLiteCommentRepository) DeleteTaskComments(ctx context.Context, taskID int) error {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, `DELETE FROM comments WHERE task_id = ?`, taskID)
+	return err
+}
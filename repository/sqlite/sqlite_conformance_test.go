@@ -0,0 +1,93 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"task7/repository/interfaces"
+	"task7/repository/repotest"
+	"task7/repository/sqlite"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// opens a fresh, migrated database file that is removed when the test ends
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err, "Failed to open sqlite test database")
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSQLiteTaskRepositoryConformance(t *testing.T) {
+	suite.Run(t, &repotest.TaskRepositorySuite{
+		NewRepository: func() interfaces.TaskRepository {
+			return sqlite.NewSQLiteTaskRepository(openTestDB(t))
+		},
+	})
+}
+
+func TestSQLiteUserRepositoryConformance(t *testing.T) {
+	suite.Run(t, &repotest.UserRepositorySuite{
+		NewRepository: func() interfaces.UserRepository {
+			return sqlite.NewSQLiteUserRepository(openTestDB(t))
+		},
+	})
+}
+
+func TestSQLiteTokenRepositoryConformance(t *testing.T) {
+	suite.Run(t, &repotest.TokenRepositorySuite{
+		NewRepository: func() interfaces.TokenRepository {
+			return sqlite.NewSQLiteTokenRepository(openTestDB(t))
+		},
+	})
+}
+
+func TestSQLiteAuditRepositoryConformance(t *testing.T) {
+	suite.Run(t, &repotest.AuditRepositorySuite{
+		NewRepository: func() interfaces.AuditRepository {
+			return sqlite.NewSQLiteAuditRepository(openTestDB(t))
+		},
+	})
+}
+
+func TestSQLiteCommentRepositoryConformance(t *testing.T) {
+	suite.Run(t, &repotest.CommentRepositorySuite{
+		NewRepository: func() interfaces.CommentRepository {
+			return sqlite.NewSQLiteCommentRepository(openTestDB(t))
+		},
+	})
+}
+
+func TestSQLiteAttachmentRepositoryConformance(t *testing.T) {
+	suite.Run(t, &repotest.AttachmentRepositorySuite{
+		NewRepository: func() interfaces.AttachmentRepository {
+			return sqlite.NewSQLiteAttachmentRepository(openTestDB(t))
+		},
+	})
+}
+
+func TestSQLiteHealthCheckerConformance(t *testing.T) {
+	suite.Run(t, &repotest.HealthCheckerSuite{
+		NewChecker: func() interfaces.HealthChecker {
+			return sqlite.NewSQLiteHealthChecker(openTestDB(t))
+		},
+	})
+}
+
+func TestSQLiteHealthChecker_ClosedDatabase(t *testing.T) {
+	db := openTestDB(t)
+	require.NoError(t, db.Close())
+
+	require.Error(t, sqlite.NewSQLiteHealthChecker(db).Ping(context.Background()))
+}
+
+func TestSQLiteProjectRepositoryConformance(t *testing.T) {
+	suite.Run(t, &repotest.ProjectRepositorySuite{
+		NewRepository: func() interfaces.ProjectRepository {
+			return sqlite.NewSQLiteProjectRepository(openTestDB(t))
+		},
+	})
+}
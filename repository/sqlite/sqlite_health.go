@@ -0,0 +1,31 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type SQLiteHealthChecker struct {
+	DB               *sql.DB
+	OperationTimeout time.Duration
+}
+
+func NewSQLiteHealthChecker(db *sql.DB) *SQLiteHealthChecker {
+	return &SQLiteHealthChecker{
+		DB:               db,
+		OperationTimeout: DefaultOperationTimeout,
+	}
+}
+
+func (r *SQLiteHealthChecker) Name() string {
+	return "sqlite"
+}
+
+// runs a trivial query; PingContext alone would not notice an unreadable database file
+func (r *SQLiteHealthChecker) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+	var one int
+	return r.DB.QueryRowContext(ctx, `SELECT 1`).Scan(&one)
+}
@@ -0,0 +1,279 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"task7/domain"
+	"time"
+)
+
+type SQLiteProjectRepository struct { // sqlite implementer, members kept in their own table
+	DB               *sql.DB
+	OperationTimeout time.Duration
+}
+
+func NewSQLiteProjectRepository(db *sql.DB) *SQLiteProjectRepository {
+	return &SQLiteProjectRepository{
+		DB:               db,
+		OperationTimeout: DefaultOperationTimeout,
+	}
+}
+
+const projectColumns = `id, name, description, created_by, created_at, updated_at, version`
+
+func scanProject(row rowScanner) (domain.Project, int64, error) {
+	var project domain.Project
+	var id, createdAt, updatedAt int64
+	if err := row.Scan(&id, &project.Name, &project.Description, &project.CreatedBy, &createdAt, &updatedAt, &project.Version); err != nil {
+		return domain.Project{}, 0, err
+	}
+	project.ID = strconv.FormatInt(id, 10)
+	project.CreatedAt = time.Unix(0, createdAt).UTC()
+	project.UpdatedAt = time.Unix(0, updatedAt).UTC()
+	return project, id, nil
+}
+
+// the row id behind a project id; false for one this repository could not have issued
+func projectRowID(id string) (int64, bool) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	return n, err == nil && n > 0
+}
+
+// anything that runs queries: the database or a transaction on it
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// the members of the projects with these row ids, sorted by username
+func loadProjectMembers(ctx context.Context, q queryer, rowIDs []int64) (map[int64][]domain.ProjectMember, error) {
+	members := map[int64][]domain.ProjectMember{}
+	if len(rowIDs) == 0 {
+		return members, nil
+	}
+	args := make([]any, len(rowIDs))
+	for i, id := range rowIDs {
+		args[i] = id
+	}
+	rows, err := q.QueryContext(ctx,
+		`SELECT project_id, username, role FROM project_members WHERE project_id IN (`+placeholders(len(rowIDs))+`) ORDER BY project_id, username`,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var projectID int64
+		var member domain.ProjectMember
+		if err := rows.Scan(&projectID, &member.Username, &member.Role); err != nil {
+			return nil, err
+		}
+		members[projectID] = append(members[projectID], member)
+	}
+	return members, rows.Err()
+}
+
+// replaces the member rows of a project with members
+func writeProjectMembers(ctx context.Context, q queryer, rowID int64, members []domain.ProjectMember) error {
+	if _, err := q.ExecContext(ctx, `DELETE FROM project_members WHERE project_id = ?`, rowID); err != nil {
+		return err
+	}
+	for _, member := range members {
+		if _, err := q.ExecContext(ctx, `INSERT INTO project_members (project_id, username, role) VALUES (?, ?, ?)`,
+			rowID, member.Username, member.Role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *SQLiteProjectRepository) CreateProject(ctx context.Context, project *domain.Project) error {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("database error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO projects (name, description, created_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		project.Name, project.Description, project.CreatedBy, project.CreatedAt.UnixNano(), project.UpdatedAt.UnixNano())
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if err := writeProjectMembers(ctx, tx, id, project.Members); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	project.ID, project.Version = strconv.FormatInt(id, 10), 1
+	return nil
+}
+
+func (r *SQLiteProjectRepository) GetProject(ctx context.Context, id string) (domain.Project, error) {
+	rowID, ok := projectRowID(id)
+	if !ok {
+		return domain.Project{}, domain.NewNotFound("no project found with id %s", id)
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	project, _, err := scanProject(r.DB.QueryRowContext(ctx, `SELECT `+projectColumns+` FROM projects WHERE id = ?`, rowID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Project{}, domain.NewNotFound("no project found with id %s", id)
+	}
+	if err != nil {
+		return domain.Project{}, err
+	}
+	members, err := loadProjectMembers(ctx, r.DB, []int64{rowID})
+	if err != nil {
+		return domain.Project{}, err
+	}
+	project.Members = members[rowID]
+	return project, nil
+}
+
+func (r *SQLiteProjectRepository) ListProjects(ctx context.Context, username string) ([]domain.Project, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	query, args := `SELECT `+projectColumns+` FROM projects`, []any{}
+	if username != "" {
+		query += ` WHERE id IN (SELECT project_id FROM project_members WHERE username = ?)`
+		args = append(args, username)
+	}
+	rows, err := r.DB.QueryContext(ctx, query+` ORDER BY created_at, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	var projects []domain.Project
+	var rowIDs []int64
+	for rows.Next() {
+		project, rowID, err := scanProject(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		projects = append(projects, project)
+		rowIDs = append(rowIDs, rowID)
+	}
+	// the members are read once the projects are, as the database has a single connection
+	if err := errors.Join(rows.Err(), rows.Close()); err != nil {
+		return nil, err
+	}
+
+	members, err := loadProjectMembers(ctx, r.DB, rowIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i, rowID := range rowIDs {
+		projects[i].Members = members[rowID]
+	}
+	return projects, nil
+}
+
+func (r *SQLiteProjectRepository) UpdateProject(ctx context.Context, project *domain.Project) error {
+	rowID, ok := projectRowID(project.ID)
+	if !ok {
+		return domain.NewNotFound("no project found with id %s", project.ID)
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("database error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE projects SET name = ?, description = ?, updated_at = ?, version = version + 1 WHERE id = ? AND version = ?`,
+		project.Name, project.Description, project.UpdatedAt.UnixNano(), rowID, project.Version)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		var exists int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM projects WHERE id = ?`, rowID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return domain.NewNotFound("no project found with id %s", project.ID)
+		}
+		return domain.ErrProjectVersionMismatch
+	}
+	if err := writeProjectMembers(ctx, tx, rowID, project.Members); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	project.Version++
+	return nil
+}
+
+func (r *SQLiteProjectRepository) AdjustTaskCount(ctx context.Context, id string, delta int) error {
+	rowID, ok := projectRowID(id)
+	if !ok {
+		return domain.NewNotFound("no project found with id %s", id)
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	res, err := r.DB.ExecContext(ctx, `UPDATE projects SET task_count = MAX(task_count + ?, 0) WHERE id = ?`, delta, rowID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.NewNotFound("no project found with id %s", id)
+	}
+	return nil
+}
+
+// the member rows go with the project, through the foreign key's cascade
+func (r *SQLiteProjectRepository) DeleteProject(ctx context.Context, id string) error {
+	rowID, ok := projectRowID(id)
+	if !ok {
+		return domain.NewNotFound("no project found with id %s", id)
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	res, err := r.DB.ExecContext(ctx, `DELETE FROM projects WHERE id = ? AND task_count = 0`, rowID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	var exists int
+	if err := r.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM projects WHERE id = ?`, rowID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return domain.NewNotFound("no project found with id %s", id)
+	}
+	return domain.ErrProjectNotEmpty
+}
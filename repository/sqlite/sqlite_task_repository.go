@@ -0,0 +1,529 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"task7/domain"
+	"time"
+
+	sqlitedriver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// upper bound for a single repository call, on top of whatever deadline the caller's context carries
+const DefaultOperationTimeout = 5 * time.Second
+
+// sqlite implementation of Task interface
+
+type SQLiteTaskRepository struct {
+	DB               *sql.DB
+	OperationTimeout time.Duration
+}
+
+// constructor, expects a database prepared by Open
+func NewSQLiteTaskRepository(db *sql.DB) *SQLiteTaskRepository {
+	return &SQLiteTaskRepository{
+		DB:               db,
+		OperationTimeout: DefaultOperationTimeout,
+	}
+}
+
+const taskColumns = `id, title, description, due_date, status, created_by, assigned_to, version, deleted_at, priority, labels, parent_id, checklist, blocked_by, recurrence, project_id`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(row rowScanner) (domain.Task, error) {
+	var task domain.Task
+	var dueDate int64
+	var deletedAt sql.NullInt64
+	var labels, checklist, blockedBy, recurrence string
+	if err := row.Scan(&task.ID, &task.Title, &task.Description, &dueDate, &task.Status, &task.CreatedBy, &task.AssignedTo, &task.Version, &deletedAt, &task.Priority, &labels, &task.ParentID, &checklist, &blockedBy, &recurrence, &task.ProjectID); err != nil {
+		return domain.Task{}, err
+	}
+	if err := json.Unmarshal([]byte(labels), &task.Labels); err != nil {
+		return domain.Task{}, fmt.Errorf("decoding labels of task %d: %w", task.ID, err)
+	}
+	if len(task.Labels) == 0 {
+		task.Labels = nil
+	}
+	if err := json.Unmarshal([]byte(checklist), &task.Checklist); err != nil {
+		return domain.Task{}, fmt.Errorf("decoding checklist of task %d: %w", task.ID, err)
+	}
+	if len(task.Checklist) == 0 {
+		task.Checklist = nil
+	}
+	if err := json.Unmarshal([]byte(blockedBy), &task.BlockedBy); err != nil {
+		return domain.Task{}, fmt.Errorf("decoding blockers of task %d: %w", task.ID, err)
+	}
+	if len(task.BlockedBy) == 0 {
+		task.BlockedBy = nil
+	}
+	if recurrence != "" {
+		if err := json.Unmarshal([]byte(recurrence), &task.Recurrence); err != nil {
+			return domain.Task{}, fmt.Errorf("decoding recurrence of task %d: %w", task.ID, err)
+		}
+	}
+	task.DueDate = time.Unix(0, dueDate).UTC()
+	if deletedAt.Valid {
+		at := time.Unix(0, deletedAt.Int64).UTC()
+		task.DeletedAt = &at
+	}
+	return task, nil
+}
+
+func (r *SQLiteTaskRepository) GetAllTasks(ctx context.Context) ([]domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, `SELECT `+taskColumns+` FROM tasks WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []domain.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// sqlite column behind each of domain.TaskSortFields
+var sortColumns = map[string]string{
+	"id":      "id",
+	"title":   "title",
+	"duedate": "due_date",
+	"status":  "status",
+}
+
+func (r *SQLiteTaskRepository) QueryTasks(ctx context.Context, query domain.TaskQuery) ([]domain.Task, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	where, args := taskWhere(query)
+
+	var total int64
+	if err := r.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM tasks`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := sortColumns[query.SortBy]
+	if !ok {
+		column = "id"
+	}
+	direction := "ASC"
+	if query.SortDesc {
+		direction = "DESC"
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = -1 // sqlite: no limit
+	}
+	stmt := `SELECT ` + taskColumns + ` FROM tasks` + where +
+		` ORDER BY ` + column + ` ` + direction + `, id ASC LIMIT ? OFFSET ?`
+
+	rows, err := r.DB.QueryContext(ctx, stmt, append(args, limit, query.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var tasks []domain.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return tasks, total, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func taskWhere(query domain.TaskQuery) (string, []any) {
+	conds := []string{"deleted_at IS NULL"}
+	if query.Trashed {
+		conds[0] = "deleted_at IS NOT NULL"
+	}
+	var args []any
+	if query.Status != "" {
+		conds = append(conds, "status = ?")
+		args = append(args, query.Status)
+	}
+	if query.Priority != "" {
+		conds = append(conds, "priority = ?")
+		args = append(args, query.Priority)
+	}
+	for _, label := range query.Labels {
+		conds = append(conds, "EXISTS (SELECT 1 FROM json_each(tasks.labels) WHERE value = ?)")
+		args = append(args, label)
+	}
+	if query.ParentID != 0 {
+		conds = append(conds, "parent_id = ?")
+		args = append(args, query.ParentID)
+	}
+	if query.ProjectID != "" {
+		conds = append(conds, "project_id = ?")
+		args = append(args, query.ProjectID)
+	}
+	if len(query.IDs) > 0 {
+		conds = append(conds, "id IN ("+placeholders(len(query.IDs))+")")
+		for _, id := range query.IDs {
+			args = append(args, id)
+		}
+	}
+	if len(query.WaitingOn) > 0 {
+		conds = append(conds, "EXISTS (SELECT 1 FROM json_each(tasks.blocked_by) WHERE value IN ("+placeholders(len(query.WaitingOn))+"))")
+		for _, id := range query.WaitingOn {
+			args = append(args, id)
+		}
+	}
+	if !query.DueAfter.IsZero() {
+		conds = append(conds, "due_date >= ?")
+		args = append(args, query.DueAfter.UnixNano())
+	}
+	if !query.DueBefore.IsZero() {
+		conds = append(conds, "due_date <= ?")
+		args = append(args, query.DueBefore.UnixNano())
+	}
+	if query.TitleContains != "" {
+		conds = append(conds, `title LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+likeEscaper.Replace(query.TitleContains)+"%")
+	}
+	if query.VisibleTo != "" {
+		conds = append(conds, "(created_by = ? OR assigned_to = ?)")
+		args = append(args, query.VisibleTo, query.VisibleTo)
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// n comma-separated bind parameters, for an IN list
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func (r *SQLiteTaskRepository) GetTaskById(ctx context.Context, id int) (domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	task, err := scanTask(r.DB.QueryRowContext(ctx, `SELECT `+taskColumns+` FROM tasks WHERE id = ? AND deleted_at IS NULL`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Task{}, domain.NewNotFound("no task found with id %d", id)
+	}
+	if err != nil {
+		return domain.Task{}, err
+	}
+	return task, nil
+}
+
+// lets sqlite assign newTask.ID; any id sent by the caller is ignored
+func (r *SQLiteTaskRepository) CreateTask(ctx context.Context, newTask *domain.Task) error {
+	if newTask.Title == "" || newTask.Description == "" || newTask.Status == "" || newTask.DueDate.IsZero() {
+		return domain.NewValidation("missing required field(s) in newTask")
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	priority := newTask.Priority
+	if priority == "" {
+		priority = domain.DefaultPriority
+	}
+	labels, err := encodeLabels(newTask.Labels)
+	if err != nil {
+		return err
+	}
+	checklist, err := encodeChecklist(newTask.Checklist)
+	if err != nil {
+		return err
+	}
+	blockedBy, err := encodeTaskIDs(newTask.BlockedBy)
+	if err != nil {
+		return err
+	}
+	recurrence, err := encodeRecurrence(newTask.Recurrence)
+	if err != nil {
+		return err
+	}
+	res, err := r.DB.ExecContext(ctx, `INSERT INTO tasks (title, description, due_date, status, created_by, assigned_to, priority, labels, parent_id, checklist, blocked_by, recurrence, project_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		newTask.Title, newTask.Description, newTask.DueDate.UnixNano(), newTask.Status, newTask.CreatedBy, newTask.AssignedTo, priority, labels, newTask.ParentID, checklist, blockedBy, recurrence, newTask.ProjectID)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	newTask.ID = int(id)
+	newTask.Priority = priority
+	newTask.Version = 1     // the column default
+	newTask.DeletedAt = nil // never inserted, so never in the trash
+	return nil
+}
+
+// a compare-and-set on the version: the WHERE clause only matches the version the caller read
+func (r *SQLiteTaskRepository) UpdateTask(ctx context.Context, id int, version int64, updatedTask *domain.Task) error {
+	var sets []string
+	var args []any
+	if updatedTask.Title != "" {
+		sets = append(sets, "title = ?")
+		args = append(args, updatedTask.Title)
+	}
+	if updatedTask.Description != "" {
+		sets = append(sets, "description = ?")
+		args = append(args, updatedTask.Description)
+	}
+	if !updatedTask.DueDate.IsZero() {
+		sets = append(sets, "due_date = ?")
+		args = append(args, updatedTask.DueDate.UnixNano())
+	}
+	if updatedTask.Status != "" {
+		sets = append(sets, "status = ?")
+		args = append(args, updatedTask.Status)
+	}
+	if updatedTask.AssignedTo != "" {
+		sets = append(sets, "assigned_to = ?")
+		args = append(args, updatedTask.AssignedTo)
+	}
+	if updatedTask.Priority != "" {
+		sets = append(sets, "priority = ?")
+		args = append(args, updatedTask.Priority)
+	}
+	if updatedTask.Labels != nil {
+		labels, err := encodeLabels(updatedTask.Labels)
+		if err != nil {
+			return err
+		}
+		sets = append(sets, "labels = ?")
+		args = append(args, labels)
+	}
+	if updatedTask.Checklist != nil {
+		checklist, err := encodeChecklist(updatedTask.Checklist)
+		if err != nil {
+			return err
+		}
+		sets = append(sets, "checklist = ?")
+		args = append(args, checklist)
+	}
+	if updatedTask.BlockedBy != nil {
+		blockedBy, err := encodeTaskIDs(updatedTask.BlockedBy)
+		if err != nil {
+			return err
+		}
+		sets = append(sets, "blocked_by = ?")
+		args = append(args, blockedBy)
+	}
+	if updatedTask.Recurrence != nil {
+		recurrence, err := encodeRecurrence(updatedTask.Recurrence)
+		if err != nil {
+			return err
+		}
+		sets = append(sets, "recurrence = ?")
+		args = append(args, recurrence)
+	}
+
+	if len(sets) == 0 {
+		updatedTask.Version = version
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	sets = append(sets, "version = version + 1")
+	args = append(args, id, version)
+	res, err := r.DB.ExecContext(ctx, `UPDATE tasks SET `+strings.Join(sets, ", ")+` WHERE id = ? AND version = ? AND deleted_at IS NULL`, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return r.missOrMismatch(ctx, id)
+	}
+	updatedTask.Version = version + 1
+	return nil
+}
+
+// a soft delete: the row only gets a deleted_at stamp, guarded by the same version check as UpdateTask
+func (r *SQLiteTaskRepository) DeleteTaskById(ctx context.Context, id int, version int64) error {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	res, err := r.DB.ExecContext(ctx, `UPDATE tasks SET deleted_at = ?, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL`,
+		time.Now().UnixNano(), id, version)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return r.missOrMismatch(ctx, id)
+	}
+	return nil
+}
+
+func (r *SQLiteTaskRepository) RestoreTask(ctx context.Context, id int) (domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	row := r.DB.QueryRowContext(ctx, `UPDATE tasks SET deleted_at = NULL, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL RETURNING `+taskColumns, id)
+	task, err := scanTask(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Task{}, domain.NewNotFound("no deleted task found with id %d", id)
+	}
+	if err != nil {
+		return domain.Task{}, err
+	}
+	return task, nil
+}
+
+func (r *SQLiteTaskRepository) PurgeDeletedTasks(ctx context.Context, deletedBefore time.Time) ([]domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, `DELETE FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < ? RETURNING `+taskColumns, deletedBefore.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var purged []domain.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		purged = append(purged, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// RETURNING gives no order
+	slices.SortFunc(purged, func(a, b domain.Task) int { return a.ID - b.ID })
+	return purged, nil
+}
+
+// explains why a versioned statement on a live task id touched no row
+func (r *SQLiteTaskRepository) missOrMismatch(ctx context.Context, id int) error {
+	var n int
+	if err := r.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM tasks WHERE id = ? AND deleted_at IS NULL`, id).Scan(&n); err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.NewNotFound("no task found with id %d", id)
+	}
+	return domain.ErrTaskVersionMismatch
+}
+
+func encodeLabels(labels []string) (string, error) {
+	if labels == nil {
+		labels = []string{}
+	}
+	encoded, err := json.Marshal(labels)
+	return string(encoded), err
+}
+
+func encodeTaskIDs(ids []int) (string, error) {
+	if ids == nil {
+		ids = []int{}
+	}
+	encoded, err := json.Marshal(ids)
+	return string(encoded), err
+}
+
+// a rule without a frequency, like no rule at all, is stored as the empty string
+func encodeRecurrence(recurrence *domain.Recurrence) (string, error) {
+	if recurrence == nil || recurrence.Frequency == "" {
+		return "", nil
+	}
+	encoded, err := json.Marshal(recurrence)
+	return string(encoded), err
+}
+
+func encodeChecklist(checklist []domain.ChecklistItem) (string, error) {
+	if checklist == nil {
+		checklist = []domain.ChecklistItem{}
+	}
+	encoded, err := json.Marshal(checklist)
+	return string(encoded), err
+}
+
+func (r *SQLiteTaskRepository) CountLabels(ctx context.Context, visibleTo string) ([]domain.LabelCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	where, args := taskWhere(domain.TaskQuery{VisibleTo: visibleTo})
+	rows, err := r.DB.QueryContext(ctx, `SELECT label.value, COUNT(*) FROM tasks, json_each(tasks.labels) AS label`+where+
+		` GROUP BY label.value ORDER BY COUNT(*) DESC, label.value`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := []domain.LabelCount{}
+	for rows.Next() {
+		var lc domain.LabelCount
+		if err := rows.Scan(&lc.Label, &lc.Count); err != nil {
+			return nil, err
+		}
+		labels = append(labels, lc)
+	}
+	return labels, rows.Err()
+}
+
+func (r *SQLiteTaskRepository) CountSubtasks(ctx context.Context, parentIDs []int) (map[int]domain.SubtaskCount, error) {
+	counts := map[int]domain.SubtaskCount{}
+	if len(parentIDs) == 0 {
+		return counts, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	args := []any{domain.StatusDone, domain.StatusArchived}
+	for _, id := range parentIDs {
+		args = append(args, id)
+	}
+	rows, err := r.DB.QueryContext(ctx, `SELECT parent_id, COUNT(*), SUM(status IN (?, ?)) FROM tasks
+		WHERE deleted_at IS NULL AND parent_id IN (`+placeholders(len(parentIDs))+`) GROUP BY parent_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var parentID int
+		var count domain.SubtaskCount
+		if err := rows.Scan(&parentID, &count.Total, &count.Closed); err != nil {
+			return nil, err
+		}
+		counts[parentID] = count
+	}
+	return counts, rows.Err()
+}
+
+// reports whether err is a PRIMARY KEY / UNIQUE / NOT NULL constraint failure
+func isConstraintViolation(err error) bool {
+	var sqliteErr *sqlitedriver.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code()&0xff == sqlite3.SQLITE_CONSTRAINT
+}
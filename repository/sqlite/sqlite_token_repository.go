@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"task7/domain"
+	"time"
+)
+
+type SQLiteTokenRepository struct { // sqlite implementer
+	DB               *sql.DB
+	OperationTimeout time.Duration
+}
+
+func NewSQLiteTokenRepository(db *sql.DB) *SQLiteTokenRepository {
+	return &SQLiteTokenRepository{
+		DB:               db,
+		OperationTimeout: DefaultOperationTimeout,
+	}
+}
+
+func (r *SQLiteTokenRepository) StoreRefreshToken(ctx context.Context, token domain.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, `INSERT INTO refresh_tokens (token_hash, username, expires_at) VALUES (?, ?, ?)`,
+		token.TokenHash, token.Username, token.ExpiresAt.UnixNano())
+	return err
+}
+
+func (r *SQLiteTokenRepository) ConsumeRefreshToken(ctx context.Context, tokenHash string) (domain.RefreshToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	token := domain.RefreshToken{TokenHash: tokenHash}
+	var expiresAt int64
+	err := r.DB.QueryRowContext(ctx, `DELETE FROM refresh_tokens WHERE token_hash = ? RETURNING username, expires_at`, tokenHash).
+		Scan(&token.Username, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.RefreshToken{}, domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return domain.RefreshToken{}, err
+	}
+	token.ExpiresAt = time.Unix(0, expiresAt).UTC()
+	if !time.Now().Before(token.ExpiresAt) {
+		return domain.RefreshToken{}, domain.ErrInvalidRefreshToken
+	}
+	return token, nil
+}
+
+// also purges revocations whose tokens have expired
+func (r *SQLiteTokenRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	if _, err := r.DB.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= ?`, time.Now().UnixNano()); err != nil {
+		return err
+	}
+	_, err := r.DB.ExecContext(ctx, `INSERT OR REPLACE INTO revoked_tokens (jti, expires_at) VALUES (?, ?)`, jti, expiresAt.UnixNano())
+	return err
+}
+
+func (r *SQLiteTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	var n int
+	err := r.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM revoked_tokens WHERE jti = ?`, jti).Scan(&n)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"task7/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type SQLiteUserRepository struct { // sqlite implementer
+	DB               *sql.DB
+	OperationTimeout time.Duration
+	BcryptCost       int
+}
+
+func NewSQLiteUserRepository(db *sql.DB) *SQLiteUserRepository {
+	return &SQLiteUserRepository{
+		DB:               db,
+		OperationTimeout: DefaultOperationTimeout,
+		BcryptCost:       bcrypt.DefaultCost,
+	}
+}
+
+func (r *SQLiteUserRepository) RegisterUser(ctx context.Context, newUser *domain.User) error {
+	if newUser.PasswordHash == "" {
+		return domain.NewValidation("password cannot be empty")
+	}
+
+	hashed_pw, err := bcrypt.GenerateFromPassword([]byte(newUser.PasswordHash), r.BcryptCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("database error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		return fmt.Errorf("database error counting users: %w", err)
+	}
+
+	role := "regular"
+	if count == 0 {
+		role = "admin"
+	}
+
+	id := primitive.NewObjectID()
+	_, err = tx.ExecContext(ctx, `INSERT INTO users (id, username, password_hash, role) VALUES (?, ?, ?, ?)`,
+		id.Hex(), newUser.Username, string(hashed_pw), role)
+	if isConstraintViolation(err) {
+		return domain.NewConflict("user with username '%s' already exists", newUser.Username)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert user into database: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to insert user into database: %w", err)
+	}
+
+	newUser.ID = id
+	newUser.PasswordHash = string(hashed_pw)
+	newUser.Role = role
+	return nil
+}
+
+func (r *SQLiteUserRepository) LoginUser(ctx context.Context, existingUser *domain.User) (domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	var user domain.User
+	var id string
+	err := r.DB.QueryRowContext(ctx, `SELECT id, username, password_hash, role FROM users WHERE username = ?`, existingUser.Username).
+		Scan(&id, &user.Username, &user.PasswordHash, &user.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.User{}, domain.NewNotFound("user not found")
+	}
+	if err != nil {
+		return domain.User{}, err
+	}
+	user.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("corrupt user id %q: %w", id, err)
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(existingUser.PasswordHash))
+	if err != nil {
+		return domain.User{}, &domain.Error{Kind: domain.KindUnauthorized, Message: "incorrect password", Err: err}
+	}
+	return user, nil
+}
+
+func (r *SQLiteUserRepository) PromoteUser(ctx context.Context, username string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	res, err := r.DB.ExecContext(ctx, `UPDATE users SET role = 'admin' WHERE username = ?`, username)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return domain.NewNotFound("user not found")
+	}
+	return nil
+}
+
+func (r *SQLiteUserRepository) GetRole(ctx context.Context, username string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	var role string
+	err := r.DB.QueryRowContext(ctx, `SELECT role FROM users WHERE username = ?`, username).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", domain.NewNotFound("user not found")
+	}
+	if err != nil {
+		return "", err
+	}
+	return role, nil
+}
+
+func (r *SQLiteUserRepository) FindUsernames(ctx context.Context, usernames []string) ([]string, error) {
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.OperationTimeout)
+	defer cancel()
+
+	args := make([]any, len(usernames))
+	for i, name := range usernames {
+		args[i] = name
+	}
+	rows, err := r.DB.QueryContext(ctx, `SELECT username FROM users WHERE username IN (`+placeholders(len(usernames))+`) ORDER BY username`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		found = append(found, name)
+	}
+	return found, rows.Err()
+}
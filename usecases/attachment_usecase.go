@@ -0,0 +1,240 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+)
+
+// files attached to tasks. Reading them follows the rules for reading the task, adding and
+// removing them the rules for changing it.
+type AttachmentService interface {
+	// stores content as a new attachment on task taskID; its type is detected from the content and
+	// must be allowed by the policy, as must its size
+	Upload(ctx context.Context, taskID int, filename string, content io.Reader) (domain.Attachment, error)
+	// the attachments on task taskID, oldest first
+	ListAttachments(ctx context.Context, taskID int) ([]domain.Attachment, error)
+	// the attachment and its content, which the caller must close
+	OpenAttachment(ctx context.Context, taskID int, id string) (domain.Attachment, io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, taskID int, id string) error
+}
+
+type attachmentService struct {
+	attachmentRepo interfaces.AttachmentRepository
+	blobs          interfaces.BlobStore
+	taskRepo       interfaces.TaskRepository
+	projectRepo    interfaces.ProjectRepository
+	policy         domain.AttachmentPolicy
+	logger         *slog.Logger
+}
+
+func NewAttachmentService(ar interfaces.AttachmentRepository, blobs interfaces.BlobStore, tr interfaces.TaskRepository, pr interfaces.ProjectRepository, policy domain.AttachmentPolicy, logger *slog.Logger) AttachmentService {
+	return &attachmentService{
+		attachmentRepo: ar,
+		blobs:          blobs,
+		taskRepo:       tr,
+		projectRepo:    pr,
+		policy:         policy,
+		logger:         logger,
+	}
+}
+
+// http.DetectContentType looks at no more than this many bytes
+const sniffLength = 512
+
+var errEmptyAttachment = fmt.Errorf("%w: the file is empty", domain.ErrInvalidAttachment)
+
+// the caller, once they may change task id; as for task updates, tasks they cannot see are forbidden
+func (s *attachmentService) changeableTask(ctx context.Context, id int) (domain.Actor, error) {
+	actor, err := currentActor(ctx)
+	if err != nil {
+		return domain.Actor{}, err
+	}
+	task, err := s.taskRepo.GetTaskById(ctx, id)
+	if err != nil {
+		return domain.Actor{}, err
+	}
+	ok, err := newTaskAccess(ctx, actor, s.projectRepo).canChange(task)
+	if err != nil {
+		return domain.Actor{}, err
+	}
+	if !ok {
+		s.logger.WarnContext(ctx, "task access denied", slog.Int("task_id", id))
+		return domain.Actor{}, errNotYourTask
+	}
+	return actor, nil
+}
+
+// fails unless the caller can see task id; tasks they cannot see are reported as missing
+func (s *attachmentService) visibleTask(ctx context.Context, id int) error {
+	actor, err := currentActor(ctx)
+	if err != nil {
+		return err
+	}
+	task, err := s.taskRepo.GetTaskById(ctx, id)
+	if err != nil {
+		return err
+	}
+	ok, err := newTaskAccess(ctx, actor, s.projectRepo).canRead(task)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.NewNotFound("no task found with id %d", id)
+	}
+	return nil
+}
+
+// attachment id of task taskID; one that belongs to another task is reported as missing
+func (s *attachmentService) load(ctx context.Context, taskID int, id string) (domain.Attachment, error) {
+	attachment, err := s.attachmentRepo.GetAttachment(ctx, id)
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+	if attachment.TaskID != taskID {
+		return domain.Attachment{}, domain.NewNotFound("no attachment found with id %s", id)
+	}
+	return attachment, nil
+}
+
+// a random id that is also a safe blob key
+func newAttachmentID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// counts and hashes what passes through it
+type measuringReader struct {
+	r    io.Reader
+	n    int64
+	hash hash.Hash
+}
+
+func (m *measuringReader) Read(p []byte) (int, error) {
+	n, err := m.r.Read(p)
+	m.n += int64(n)
+	m.hash.Write(p[:n])
+	return n, err
+}
+
+func (s *attachmentService) Upload(ctx context.Context, taskID int, filename string, content io.Reader) (domain.Attachment, error) {
+	actor, err := s.changeableTask(ctx, taskID)
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+
+	buffered := bufio.NewReaderSize(content, sniffLength)
+	head, err := buffered.Peek(sniffLength)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return domain.Attachment{}, fmt.Errorf("reading upload: %w", err)
+	}
+	if len(head) == 0 {
+		return domain.Attachment{}, errEmptyAttachment
+	}
+	contentType := http.DetectContentType(head)
+	if !s.policy.Allows(contentType) {
+		return domain.Attachment{}, fmt.Errorf("%w: %s", domain.ErrAttachmentType, contentType)
+	}
+
+	id, err := newAttachmentID()
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+	// one byte past the limit is enough to tell that the file is too large
+	measured := &measuringReader{r: io.LimitReader(buffered, s.policy.MaxSize+1), hash: sha256.New()}
+	if err := s.blobs.Put(ctx, id, measured); err != nil {
+		return domain.Attachment{}, err
+	}
+	if measured.n > s.policy.MaxSize {
+		s.discardBlob(ctx, id)
+		return domain.Attachment{}, fmt.Errorf("%w: the limit is %d bytes", domain.ErrAttachmentTooLarge, s.policy.MaxSize)
+	}
+
+	attachment := domain.Attachment{
+		ID:          id,
+		TaskID:      taskID,
+		Filename:    domain.CleanFilename(filename),
+		ContentType: contentType,
+		Size:        measured.n,
+		SHA256:      hex.EncodeToString(measured.hash.Sum(nil)),
+		UploadedBy:  actor.Username,
+		UploadedAt:  time.Now().UTC(),
+	}
+	if err := s.attachmentRepo.CreateAttachment(ctx, attachment); err != nil {
+		s.discardBlob(ctx, id)
+		return domain.Attachment{}, err
+	}
+	s.logger.InfoContext(ctx, "attachment uploaded", slog.Int("task_id", taskID), slog.String("attachment_id", id),
+		slog.String("content_type", contentType), slog.Int64("size", attachment.Size))
+	return attachment, nil
+}
+
+// removes content that no attachment refers to; a failure only leaves an unreachable blob, so it is logged
+func (s *attachmentService) discardBlob(ctx context.Context, id string) {
+	if err := s.blobs.Delete(ctx, id); err != nil {
+		s.logger.ErrorContext(ctx, "orphaned attachment content", slog.String("attachment_id", id), slog.Any("error", err))
+	}
+}
+
+func (s *attachmentService) ListAttachments(ctx context.Context, taskID int) ([]domain.Attachment, error) {
+	if err := s.visibleTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+	attachments, err := s.attachmentRepo.ListAttachments(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if attachments == nil {
+		attachments = []domain.Attachment{}
+	}
+	return attachments, nil
+}
+
+func (s *attachmentService) OpenAttachment(ctx context.Context, taskID int, id string) (domain.Attachment, io.ReadCloser, error) {
+	if err := s.visibleTask(ctx, taskID); err != nil {
+		return domain.Attachment{}, nil, err
+	}
+	attachment, err := s.load(ctx, taskID, id)
+	if err != nil {
+		return domain.Attachment{}, nil, err
+	}
+	content, err := s.blobs.Open(ctx, id)
+	if domain.KindOf(err) == domain.KindNotFound {
+		// the metadata outlived its content; that is our fault, not the client's
+		return domain.Attachment{}, nil, domain.NewInternal(err, "attachment %s has no content", id)
+	}
+	if err != nil {
+		return domain.Attachment{}, nil, err
+	}
+	return attachment, content, nil
+}
+
+// the metadata goes first, so the attachment disappears even if its content cannot be removed
+func (s *attachmentService) DeleteAttachment(ctx context.Context, taskID int, id string) error {
+	if _, err := s.changeableTask(ctx, taskID); err != nil {
+		return err
+	}
+	if _, err := s.load(ctx, taskID, id); err != nil {
+		return err
+	}
+	if err := s.attachmentRepo.DeleteAttachment(ctx, id); err != nil {
+		return err
+	}
+	s.discardBlob(ctx, id)
+	s.logger.InfoContext(ctx, "attachment deleted", slog.Int("task_id", taskID), slog.String("attachment_id", id))
+	return nil
+}
@@ -0,0 +1,312 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"task7/domain"
+	services "task7/usecases"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockAttachmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockAttachmentRepository) CreateAttachment(ctx context.Context, attachment domain.Attachment) error {
+	args := m.Called(ctx, attachment)
+	return args.Error(0)
+}
+
+func (m *MockAttachmentRepository) GetAttachment(ctx context.Context, id string) (domain.Attachment, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) ListAttachments(ctx context.Context, taskID int) ([]domain.Attachment, error) {
+	args := m.Called(ctx, taskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) DeleteAttachment(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// Put reads the whole content, like a real store, and keeps the last one in stored
+type MockBlobStore struct {
+	mock.Mock
+	stored []byte
+}
+
+func (m *MockBlobStore) Put(ctx context.Context, key string, content io.Reader) error {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	m.stored = data
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+func (m *MockBlobStore) Delete(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+type AttachmentServiceSuite struct {
+	suite.Suite
+	mockAttachments   *MockAttachmentRepository
+	mockBlobs         *MockBlobStore
+	mockTasks         *MockTaskRepository
+	logs              *bytes.Buffer
+	attachmentService services.AttachmentService
+	attachment        domain.Attachment
+}
+
+func (s *AttachmentServiceSuite) SetupTest() {
+	s.mockAttachments = new(MockAttachmentRepository)
+	s.mockBlobs = new(MockBlobStore)
+	s.mockTasks = new(MockTaskRepository)
+	s.logs = new(bytes.Buffer)
+	policy := domain.AttachmentPolicy{MaxSize: 1024, AllowedTypes: []string{"image/*", "text/plain"}}
+	s.attachmentService = services.NewAttachmentService(s.mockAttachments, s.mockBlobs, s.mockTasks, newBoardProjects(), policy, slog.New(slog.NewJSONHandler(s.logs, nil)))
+	s.mockTasks.On("GetTaskById", mock.Anything, 4).Return(domain.Task{ID: 4, Title: "Launch", CreatedBy: "alice", AssignedTo: "bob"}, nil).Maybe()
+	s.mockTasks.On("GetTaskById", mock.Anything, 5).Return(domain.Task{ID: 5, Title: "Other", CreatedBy: "alice"}, nil).Maybe()
+	s.attachment = domain.Attachment{ID: "a1", TaskID: 4, Filename: "notes.txt", ContentType: "text/plain; charset=utf-8", Size: 5, UploadedBy: "bob"}
+	s.mockAttachments.On("GetAttachment", mock.Anything, "a1").Return(s.attachment, nil).Maybe()
+}
+
+func TestAttachmentServiceSuite(t *testing.T) {
+	suite.Run(t, new(AttachmentServiceSuite))
+}
+
+func (s *AttachmentServiceSuite) as(username, role string) context.Context {
+	return domain.WithActor(context.Background(), domain.Actor{Username: username, Role: role})
+}
+
+func (s *AttachmentServiceSuite) TestUpload() {
+	ctx := s.as("bob", "regular")
+	content := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0}, 600)...)
+	sum := sha256.Sum256(content)
+
+	var key string
+	s.mockBlobs.On("Put", ctx, mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		key = args.String(1)
+	}).Return(nil).Once()
+	s.mockAttachments.On("CreateAttachment", ctx, mock.MatchedBy(func(a domain.Attachment) bool {
+		return a.ID == key && !a.UploadedAt.IsZero()
+	})).Return(nil).Once()
+
+	attachment, err := s.attachmentService.Upload(ctx, 4, "../../shots/screen.png", bytes.NewReader(content))
+	s.Require().NoError(err)
+	s.NotEmpty(attachment.ID)
+	s.Equal(4, attachment.TaskID)
+	s.Equal("screen.png", attachment.Filename, "Only the base name is kept")
+	s.Equal("image/png", attachment.ContentType)
+	s.Equal(int64(len(content)), attachment.Size)
+	s.Equal(hex.EncodeToString(sum[:]), attachment.SHA256)
+	s.Equal("bob", attachment.UploadedBy)
+	s.Equal(content, s.mockBlobs.stored, "The sniffed bytes are stored too")
+	s.mockAttachments.AssertExpectations(s.T())
+	s.mockBlobs.AssertExpectations(s.T())
+}
+
+func (s *AttachmentServiceSuite) TestUpload_TypeComesFromContent() {
+	ctx := s.as("alice", "regular")
+	s.mockBlobs.On("Put", ctx, mock.Anything).Return(nil).Once()
+	s.mockAttachments.On("CreateAttachment", ctx, mock.Anything).Return(nil).Once()
+
+	attachment, err := s.attachmentService.Upload(ctx, 4, "photo.png", strings.NewReader("plain words"))
+	s.Require().NoError(err)
+	s.Equal("text/plain; charset=utf-8", attachment.ContentType, "The file name does not decide the type")
+}
+
+func (s *AttachmentServiceSuite) TestUpload_Rejected() {
+	s.mockTasks.On("GetTaskById", mock.Anything, 404).Return(domain.Task{}, domain.NewNotFound("no task found with id 404"))
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		task    int
+		content string
+		want    error
+		kind    domain.ErrorKind
+	}{
+		{name: "Unauthenticated", ctx: context.Background(), task: 4, content: "hi", want: domain.ErrUnauthenticated},
+		{name: "Missing task", ctx: s.as("alice", "regular"), task: 404, content: "hi", kind: domain.KindNotFound},
+		{name: "Task hidden from caller", ctx: s.as("carol", "regular"), task: 4, content: "hi", kind: domain.KindForbidden},
+		{name: "Empty file", ctx: s.as("alice", "regular"), task: 4, content: "", want: domain.ErrInvalidAttachment},
+		{name: "Type not allowed", ctx: s.as("alice", "regular"), task: 4, content: "%PDF-1.7\n", want: domain.ErrAttachmentType},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			_, err := s.attachmentService.Upload(tt.ctx, tt.task, "file", strings.NewReader(tt.content))
+			s.Require().Error(err)
+			if tt.want != nil {
+				s.ErrorIs(err, tt.want)
+			} else {
+				s.Equal(tt.kind, domain.KindOf(err))
+			}
+		})
+	}
+	s.mockBlobs.AssertNotCalled(s.T(), "Put", mock.Anything, mock.Anything)
+	s.mockAttachments.AssertNotCalled(s.T(), "CreateAttachment", mock.Anything, mock.Anything)
+}
+
+func (s *AttachmentServiceSuite) TestUpload_TooLargeDiscardsContent() {
+	ctx := s.as("alice", "regular")
+	var key string
+	s.mockBlobs.On("Put", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		key = args.String(1)
+	}).Return(nil).Once()
+	s.mockBlobs.On("Delete", ctx, mock.Anything).Return(nil).Once()
+
+	_, err := s.attachmentService.Upload(ctx, 4, "big.txt", strings.NewReader(strings.Repeat("a", 4096)))
+	s.ErrorIs(err, domain.ErrAttachmentTooLarge)
+	s.Equal(domain.KindTooLarge, domain.KindOf(err))
+	s.Len(s.mockBlobs.stored, 1025, "Reading stops just past the limit")
+	s.mockBlobs.AssertCalled(s.T(), "Delete", ctx, key)
+	s.mockAttachments.AssertNotCalled(s.T(), "CreateAttachment", mock.Anything, mock.Anything)
+}
+
+func (s *AttachmentServiceSuite) TestUpload_ExactlyAtLimit() {
+	ctx := s.as("alice", "regular")
+	s.mockBlobs.On("Put", ctx, mock.Anything).Return(nil).Once()
+	s.mockAttachments.On("CreateAttachment", ctx, mock.Anything).Return(nil).Once()
+
+	attachment, err := s.attachmentService.Upload(ctx, 4, "full.txt", strings.NewReader(strings.Repeat("a", 1024)))
+	s.Require().NoError(err)
+	s.Equal(int64(1024), attachment.Size)
+	s.mockBlobs.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+}
+
+func (s *AttachmentServiceSuite) TestUpload_MetadataFailureDiscardsContent() {
+	ctx := s.as("alice", "regular")
+	failure := errors.New("database unavailable")
+	s.mockBlobs.On("Put", ctx, mock.Anything).Return(nil).Once()
+	s.mockAttachments.On("CreateAttachment", ctx, mock.Anything).Return(failure).Once()
+	s.mockBlobs.On("Delete", ctx, mock.Anything).Return(nil).Once()
+
+	_, err := s.attachmentService.Upload(ctx, 4, "notes.txt", strings.NewReader("hello"))
+	s.ErrorIs(err, failure)
+	s.mockBlobs.AssertExpectations(s.T())
+}
+
+func (s *AttachmentServiceSuite) TestUpload_StoreFailure() {
+	ctx := s.as("alice", "regular")
+	failure := errors.New("disk full")
+	s.mockBlobs.On("Put", ctx, mock.Anything).Return(failure).Once()
+
+	_, err := s.attachmentService.Upload(ctx, 4, "notes.txt", strings.NewReader("hello"))
+	s.ErrorIs(err, failure)
+	s.mockAttachments.AssertNotCalled(s.T(), "CreateAttachment", mock.Anything, mock.Anything)
+}
+
+func (s *AttachmentServiceSuite) TestListAttachments() {
+	ctx := s.as("bob", "regular")
+	s.mockAttachments.On("ListAttachments", ctx, 4).Return(nil, nil).Once()
+
+	attachments, err := s.attachmentService.ListAttachments(ctx, 4)
+	s.Require().NoError(err)
+	s.NotNil(attachments, "An empty list is not null")
+	s.Empty(attachments)
+
+	_, err = s.attachmentService.ListAttachments(s.as("carol", "regular"), 4)
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "Hidden tasks are reported as missing")
+}
+
+func (s *AttachmentServiceSuite) TestAttachments_ProjectRoles() {
+	s.mockTasks.On("GetTaskById", mock.Anything, 9).Return(domain.Task{ID: 9, CreatedBy: "alice", ProjectID: "p1"}, nil)
+	viewer := s.as("vera", "regular")
+	s.mockAttachments.On("ListAttachments", viewer, 9).Return([]domain.Attachment{{ID: "a1", TaskID: 9}}, nil).Once()
+
+	attachments, err := s.attachmentService.ListAttachments(viewer, 9)
+	s.Require().NoError(err, "Viewers see the files on the board's tasks")
+	s.Len(attachments, 1)
+	_, err = s.attachmentService.Upload(viewer, 9, "notes.txt", strings.NewReader("hello"))
+	s.ErrorIs(err, domain.ErrForbidden, "but only members add to them")
+	s.mockBlobs.AssertNotCalled(s.T(), "Put", mock.Anything, mock.Anything)
+}
+
+func (s *AttachmentServiceSuite) TestOpenAttachment() {
+	ctx := s.as("carol", "admin")
+	s.mockBlobs.On("Open", ctx, "a1").Return(io.NopCloser(strings.NewReader("hello")), nil).Once()
+
+	attachment, content, err := s.attachmentService.OpenAttachment(ctx, 4, "a1")
+	s.Require().NoError(err)
+	defer content.Close()
+	s.Equal(s.attachment, attachment)
+	data, err := io.ReadAll(content)
+	s.Require().NoError(err)
+	s.Equal("hello", string(data))
+}
+
+func (s *AttachmentServiceSuite) TestOpenAttachment_Errors() {
+	s.mockAttachments.On("GetAttachment", mock.Anything, "gone").Return(domain.Attachment{}, domain.NewNotFound("no attachment found with id gone"))
+	s.mockBlobs.On("Open", mock.Anything, "a1").Return(nil, domain.NewNotFound("no blob a1")).Once()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		task int
+		id   string
+		kind domain.ErrorKind
+	}{
+		{name: "Task hidden from caller", ctx: s.as("carol", "regular"), task: 4, id: "a1", kind: domain.KindNotFound},
+		{name: "Missing attachment", ctx: s.as("bob", "regular"), task: 4, id: "gone", kind: domain.KindNotFound},
+		{name: "Attachment of another task", ctx: s.as("alice", "regular"), task: 5, id: "a1", kind: domain.KindNotFound},
+		{name: "Content missing", ctx: s.as("bob", "regular"), task: 4, id: "a1", kind: domain.KindInternal},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			_, content, err := s.attachmentService.OpenAttachment(tt.ctx, tt.task, tt.id)
+			s.Require().Error(err)
+			s.Nil(content)
+			s.Equal(tt.kind, domain.KindOf(err))
+		})
+	}
+}
+
+func (s *AttachmentServiceSuite) TestDeleteAttachment() {
+	ctx := s.as("alice", "regular")
+	s.mockAttachments.On("DeleteAttachment", ctx, "a1").Return(nil).Once()
+	s.mockBlobs.On("Delete", ctx, "a1").Return(errors.New("disk gone")).Once()
+
+	s.NoError(s.attachmentService.DeleteAttachment(ctx, 4, "a1"), "Content that cannot be removed does not fail the delete")
+	s.Contains(s.logs.String(), "orphaned attachment content")
+	s.mockAttachments.AssertExpectations(s.T())
+	s.mockBlobs.AssertExpectations(s.T())
+}
+
+func (s *AttachmentServiceSuite) TestDeleteAttachment_Errors() {
+	err := s.attachmentService.DeleteAttachment(s.as("carol", "regular"), 4, "a1")
+	s.Equal(domain.KindForbidden, domain.KindOf(err))
+
+	err = s.attachmentService.DeleteAttachment(s.as("alice", "regular"), 5, "a1")
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+
+	s.mockAttachments.AssertNotCalled(s.T(), "DeleteAttachment", mock.Anything, mock.Anything)
+	s.mockBlobs.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+)
+
+type AuditService interface {
+	QueryAudit(ctx context.Context, query domain.AuditQuery) (domain.AuditPage, error)
+}
+
+type auditService struct {
+	auditRepo interfaces.AuditRepository
+}
+
+func NewAuditService(repo interfaces.AuditRepository) AuditService {
+	return &auditService{
+		auditRepo: repo,
+	}
+}
+
+// access is restricted to admins by the router
+func (s *auditService) QueryAudit(ctx context.Context, query domain.AuditQuery) (domain.AuditPage, error) {
+	if err := query.Normalize(); err != nil {
+		return domain.AuditPage{}, err
+	}
+	entries, total, err := s.auditRepo.QueryAudit(ctx, query)
+	if err != nil {
+		return domain.AuditPage{}, err
+	}
+	return domain.NewAuditPage(query, entries, total), nil
+}
+
+// how long recordAudit waits for the audit store
+const auditAppendTimeout = 5 * time.Second
+
+// appends entry to the audit trail once its mutation has succeeded. A failed append is logged
+// rather than returned: the change itself is already stored and must not be reported as failed.
+// The append outlives the request's context, so a client that hangs up right after the change
+// cannot keep it out of the trail.
+func recordAudit(ctx context.Context, repo interfaces.AuditRepository, logger *slog.Logger, entry domain.AuditEntry) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditAppendTimeout)
+	defer cancel()
+	if err := repo.Append(ctx, &entry); err != nil {
+		logger.ErrorContext(ctx, "audit entry lost",
+			slog.String("action", string(entry.Action)),
+			slog.String("target_type", entry.TargetType),
+			slog.String("target_id", entry.TargetID),
+			slog.Any("error", err))
+	}
+}
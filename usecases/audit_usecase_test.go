@@ -0,0 +1,91 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"task7/domain"
+	services "task7/usecases"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) Append(ctx context.Context, entry *domain.AuditEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepository) QueryAudit(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEntry, int64, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]domain.AuditEntry), args.Get(1).(int64), args.Error(2)
+}
+
+// a mock that accepts every append, for suites that only inspect what was recorded
+func newRecordingAuditRepository() *MockAuditRepository {
+	m := new(MockAuditRepository)
+	m.On("Append", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return m
+}
+
+// the entries appended so far, in order
+func (m *MockAuditRepository) appended() []domain.AuditEntry {
+	var entries []domain.AuditEntry
+	for _, call := range m.Calls {
+		if call.Method == "Append" {
+			entries = append(entries, *call.Arguments.Get(1).(*domain.AuditEntry))
+		}
+	}
+	return entries
+}
+
+type AuditServiceSuite struct {
+	suite.Suite
+	mockRepo     *MockAuditRepository
+	ctx          context.Context
+	auditService services.AuditService
+}
+
+func (s *AuditServiceSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mockRepo = new(MockAuditRepository)
+	s.auditService = services.NewAuditService(s.mockRepo)
+}
+
+func TestAuditServiceSuite(t *testing.T) {
+	suite.Run(t, new(AuditServiceSuite))
+}
+
+func (s *AuditServiceSuite) TestQueryAudit_AppliesDefaultsAndBuildsPage() {
+	expectedQuery := domain.AuditQuery{TargetType: "task", TargetID: "7", Limit: domain.DefaultAuditPageSize}
+	entries := []domain.AuditEntry{{ID: "2", Action: domain.AuditTaskUpdate}, {ID: "1", Action: domain.AuditTaskCreate}}
+	s.mockRepo.On("QueryAudit", s.ctx, expectedQuery).Return(entries, int64(2), nil).Once()
+
+	page, err := s.auditService.QueryAudit(s.ctx, domain.AuditQuery{TargetType: "task", TargetID: "7"})
+	s.Require().NoError(err)
+	s.Equal(entries, page.Entries)
+	s.EqualValues(2, page.Total)
+	s.Nil(page.NextOffset)
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *AuditServiceSuite) TestQueryAudit_InvalidQuery() {
+	_, err := s.auditService.QueryAudit(s.ctx, domain.AuditQuery{Action: "task.explode"})
+	s.ErrorIs(err, domain.ErrInvalidAuditQuery)
+	s.mockRepo.AssertNotCalled(s.T(), "QueryAudit", mock.Anything, mock.Anything)
+}
+
+func (s *AuditServiceSuite) TestQueryAudit_RepositoryError() {
+	repoError := errors.New("database error")
+	s.mockRepo.On("QueryAudit", s.ctx, mock.Anything).Return(nil, int64(0), repoError).Once()
+
+	_, err := s.auditService.QueryAudit(s.ctx, domain.AuditQuery{})
+	s.Equal(repoError, err)
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+)
+
+// discussion threads on tasks: anyone who can see a task can read and add to its thread
+type CommentService interface {
+	// one window of the thread on query.TaskID, oldest first
+	ListComments(ctx context.Context, query domain.CommentQuery) (domain.CommentPage, error)
+	// body is markdown; @mentions of registered users are recorded on the comment
+	AddComment(ctx context.Context, taskID int, body string) (domain.Comment, error)
+	// replaces the body and its mentions; only the author or an admin may edit or delete a comment
+	EditComment(ctx context.Context, taskID int, commentID string, body string) (domain.Comment, error)
+	DeleteComment(ctx context.Context, taskID int, commentID string) error
+}
+
+type commentService struct {
+	commentRepo interfaces.CommentRepository
+	taskRepo    interfaces.TaskRepository
+	projectRepo interfaces.ProjectRepository
+	userRepo    interfaces.UserRepository
+	logger      *slog.Logger
+}
+
+func NewCommentService(cr interfaces.CommentRepository, tr interfaces.TaskRepository, pr interfaces.ProjectRepository, ur interfaces.UserRepository, logger *slog.Logger) CommentService {
+	return &commentService{
+		commentRepo: cr,
+		taskRepo:    tr,
+		projectRepo: pr,
+		userRepo:    ur,
+		logger:      logger,
+	}
+}
+
+var errNotYourComment = fmt.Errorf("%w: you can only change comments you wrote", domain.ErrForbidden)
+
+// the caller, once task id is known to be visible to them; tasks they cannot see are reported as
+// missing, as for GET /tasks/:id
+func (s *commentService) visibleTask(ctx context.Context, id int) (domain.Actor, error) {
+	actor, err := currentActor(ctx)
+	if err != nil {
+		return domain.Actor{}, err
+	}
+	task, err := s.taskRepo.GetTaskById(ctx, id)
+	if err != nil {
+		return domain.Actor{}, err
+	}
+	ok, err := newTaskAccess(ctx, actor, s.projectRepo).canRead(task)
+	if err != nil {
+		return domain.Actor{}, err
+	}
+	if !ok {
+		return domain.Actor{}, domain.NewNotFound("no task found with id %d", id)
+	}
+	return actor, nil
+}
+
+// loads comment commentID on task taskID for a change by the caller, failing unless they may make it
+func (s *commentService) loadForChange(ctx context.Context, taskID int, commentID string) (domain.Actor, domain.Comment, error) {
+	actor, err := s.visibleTask(ctx, taskID)
+	if err != nil {
+		return domain.Actor{}, domain.Comment{}, err
+	}
+	comment, err := s.commentRepo.GetComment(ctx, commentID)
+	if err != nil {
+		return domain.Actor{}, domain.Comment{}, err
+	}
+	if comment.TaskID != taskID {
+		return domain.Actor{}, domain.Comment{}, domain.NewNotFound("no comment found with id %s", commentID)
+	}
+	if !comment.EditableBy(actor) {
+		s.logger.WarnContext(ctx, "comment access denied", slog.Int("task_id", taskID), slog.String("comment_id", commentID))
+		return domain.Actor{}, domain.Comment{}, errNotYourComment
+	}
+	return actor, comment, nil
+}
+
+// the registered users @mentioned in body; names nobody is registered under are left as plain text
+func (s *commentService) resolveMentions(ctx context.Context, body string) ([]string, error) {
+	candidates := domain.ParseMentions(body)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	mentions, err := s.userRepo.FindUsernames(ctx, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("resolving mentions: %w", err)
+	}
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+	return mentions, nil
+}
+
+func (s *commentService) ListComments(ctx context.Context, query domain.CommentQuery) (domain.CommentPage, error) {
+	if err := query.Normalize(); err != nil {
+		return domain.CommentPage{}, err
+	}
+	if _, err := s.visibleTask(ctx, query.TaskID); err != nil {
+		return domain.CommentPage{}, err
+	}
+	comments, total, err := s.commentRepo.ListComments(ctx, query)
+	if err != nil {
+		return domain.CommentPage{}, err
+	}
+	return domain.NewCommentPage(query, comments, total), nil
+}
+
+func (s *commentService) AddComment(ctx context.Context, taskID int, body string) (domain.Comment, error) {
+	if err := domain.ValidateCommentBody(body); err != nil {
+		return domain.Comment{}, err
+	}
+	actor, err := s.visibleTask(ctx, taskID)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	mentions, err := s.resolveMentions(ctx, body)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	comment := domain.Comment{
+		TaskID:    taskID,
+		Author:    actor.Username,
+		Body:      body,
+		Mentions:  mentions,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.commentRepo.CreateComment(ctx, &comment); err != nil {
+		return domain.Comment{}, err
+	}
+	s.logger.InfoContext(ctx, "comment added", slog.Int("task_id", taskID), slog.String("comment_id", comment.ID), slog.Any("mentions", mentions))
+	return comment, nil
+}
+
+func (s *commentService) EditComment(ctx context.Context, taskID int, commentID string, body string) (domain.Comment, error) {
+	if err := domain.ValidateCommentBody(body); err != nil {
+		return domain.Comment{}, err
+	}
+	actor, comment, err := s.loadForChange(ctx, taskID, commentID)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	mentions, err := s.resolveMentions(ctx, body)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	edited := time.Now().UTC()
+	comment.Body, comment.Mentions, comment.EditedAt = body, mentions, &edited
+	if err := s.commentRepo.UpdateComment(ctx, comment); err != nil {
+		return domain.Comment{}, err
+	}
+	s.logger.InfoContext(ctx, "comment edited", slog.Int("task_id", taskID), slog.String("comment_id", commentID),
+		slog.String("author", comment.Author), slog.Bool("by_author", actor.Username == comment.Author))
+	return comment, nil
+}
+
+func (s *commentService) DeleteComment(ctx context.Context, taskID int, commentID string) error {
+	actor, comment, err := s.loadForChange(ctx, taskID, commentID)
+	if err != nil {
+		return err
+	}
+	if err := s.commentRepo.DeleteComment(ctx, commentID); err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "comment deleted", slog.Int("task_id", taskID), slog.String("comment_id", commentID),
+		slog.String("author", comment.Author), slog.Bool("by_author", actor.Username == comment.Author))
+	return nil
+}
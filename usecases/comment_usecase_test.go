@@ -0,0 +1,218 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"task7/domain"
+	services "task7/usecases"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockCommentRepository struct {
+	mock.Mock
+}
+
+func (m *MockCommentRepository) CreateComment(ctx context.Context, comment *domain.Comment) error {
+	args := m.Called(ctx, comment)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) GetComment(ctx context.Context, id string) (domain.Comment, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) ListComments(ctx context.Context, query domain.CommentQuery) ([]domain.Comment, int64, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]domain.Comment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockCommentRepository) UpdateComment(ctx context.Context, comment domain.Comment) error {
+	args := m.Called(ctx, comment)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) DeleteComment(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) DeleteTaskComments(ctx context.Context, taskID int) error {
+	args := m.Called(ctx, taskID)
+	return args.Error(0)
+}
+
+type CommentServiceSuite struct {
+	suite.Suite
+	mockComments   *MockCommentRepository
+	mockTasks      *MockTaskRepository
+	mockUsers      *MockUserRepository
+	logs           *bytes.Buffer
+	commentService services.CommentService
+	task           domain.Task
+}
+
+func (s *CommentServiceSuite) SetupTest() {
+	s.mockComments = new(MockCommentRepository)
+	s.mockTasks = new(MockTaskRepository)
+	s.mockUsers = new(MockUserRepository)
+	s.logs = new(bytes.Buffer)
+	s.commentService = services.NewCommentService(s.mockComments, s.mockTasks, newBoardProjects(), s.mockUsers, slog.New(slog.NewJSONHandler(s.logs, nil)))
+	s.task = domain.Task{ID: 4, Title: "Launch", CreatedBy: "alice", AssignedTo: "bob"}
+	s.mockTasks.On("GetTaskById", mock.Anything, 4).Return(s.task, nil).Maybe()
+}
+
+func TestCommentServiceSuite(t *testing.T) {
+	suite.Run(t, new(CommentServiceSuite))
+}
+
+func (s *CommentServiceSuite) as(username, role string) context.Context {
+	return domain.WithActor(context.Background(), domain.Actor{Username: username, Role: role})
+}
+
+func (s *CommentServiceSuite) TestAddComment_ResolvesMentions() {
+	ctx := s.as("bob", "regular")
+	body := "Waiting on **legal**, cc @alice @ghost and `@notme`"
+
+	s.mockUsers.On("FindUsernames", ctx, []string{"alice", "ghost"}).Return([]string{"alice"}, nil).Once()
+	s.mockComments.On("CreateComment", ctx, mock.MatchedBy(func(c *domain.Comment) bool {
+		return c.TaskID == 4 && c.Author == "bob" && c.Body == body && !c.CreatedAt.IsZero()
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.Comment).ID = "c1"
+	}).Return(nil).Once()
+
+	comment, err := s.commentService.AddComment(ctx, 4, body)
+	s.Require().NoError(err)
+	s.Equal("c1", comment.ID)
+	s.Equal([]string{"alice"}, comment.Mentions, "Only registered users are mentioned")
+	s.Nil(comment.EditedAt)
+	s.mockComments.AssertExpectations(s.T())
+	s.mockUsers.AssertExpectations(s.T())
+}
+
+func (s *CommentServiceSuite) TestAddComment_NoMentionsSkipsLookup() {
+	ctx := s.as("alice", "regular")
+	s.mockComments.On("CreateComment", ctx, mock.Anything).Return(nil).Once()
+
+	comment, err := s.commentService.AddComment(ctx, 4, "Looks good")
+	s.Require().NoError(err)
+	s.Nil(comment.Mentions)
+	s.mockUsers.AssertNotCalled(s.T(), "FindUsernames", mock.Anything, mock.Anything)
+}
+
+func (s *CommentServiceSuite) TestAddComment_Errors() {
+	s.mockTasks.On("GetTaskById", mock.Anything, 404).Return(domain.Task{}, domain.NewNotFound("no task found with id 404"))
+	lookupFailure := errors.New("users unavailable")
+	s.mockUsers.On("FindUsernames", mock.Anything, []string{"bob"}).Return(nil, lookupFailure)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		task int
+		body string
+		want error
+		kind domain.ErrorKind
+	}{
+		{name: "Blank body", ctx: s.as("alice", "regular"), task: 4, body: "  ", want: domain.ErrInvalidComment},
+		{name: "Unauthenticated", ctx: context.Background(), task: 4, body: "hi", want: domain.ErrUnauthenticated},
+		{name: "Missing task", ctx: s.as("alice", "regular"), task: 404, body: "hi", kind: domain.KindNotFound},
+		{name: "Task hidden from caller", ctx: s.as("carol", "regular"), task: 4, body: "hi", kind: domain.KindNotFound},
+		{name: "Mention lookup fails", ctx: s.as("alice", "regular"), task: 4, body: "@bob?", want: lookupFailure},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			_, err := s.commentService.AddComment(tt.ctx, tt.task, tt.body)
+			s.Require().Error(err)
+			if tt.want != nil {
+				s.ErrorIs(err, tt.want)
+			} else {
+				s.Equal(tt.kind, domain.KindOf(err))
+			}
+		})
+	}
+	s.mockComments.AssertNotCalled(s.T(), "CreateComment", mock.Anything, mock.Anything)
+}
+
+func (s *CommentServiceSuite) TestListComments() {
+	ctx := s.as("alice", "regular")
+	comments := []domain.Comment{{ID: "c1", TaskID: 4, Author: "bob", Body: "first"}}
+	s.mockComments.On("ListComments", ctx, domain.CommentQuery{TaskID: 4, Limit: 1}).Return(comments, int64(3), nil).Once()
+
+	page, err := s.commentService.ListComments(ctx, domain.CommentQuery{TaskID: 4, Limit: 1})
+	s.Require().NoError(err)
+	s.Equal(comments, page.Comments)
+	s.EqualValues(3, page.Total)
+	s.Require().NotNil(page.NextOffset)
+	s.Equal(1, *page.NextOffset)
+
+	_, err = s.commentService.ListComments(s.as("carol", "regular"), domain.CommentQuery{TaskID: 4})
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "A task the caller cannot see has no visible thread")
+	_, err = s.commentService.ListComments(ctx, domain.CommentQuery{TaskID: 4, Limit: -1})
+	s.ErrorIs(err, domain.ErrInvalidCommentQuery)
+}
+
+func (s *CommentServiceSuite) TestListComments_ProjectViewer() {
+	ctx := s.as("vera", "regular")
+	s.mockTasks.On("GetTaskById", ctx, 9).Return(domain.Task{ID: 9, CreatedBy: "alice", ProjectID: "p1"}, nil)
+	s.mockComments.On("ListComments", ctx, domain.CommentQuery{TaskID: 9, Limit: domain.DefaultCommentPageSize}).Return([]domain.Comment{}, int64(0), nil).Once()
+
+	_, err := s.commentService.ListComments(ctx, domain.CommentQuery{TaskID: 9})
+	s.Require().NoError(err, "The board's members read the threads on its tasks")
+	s.mockComments.AssertExpectations(s.T())
+}
+
+func (s *CommentServiceSuite) TestEditComment_ByAuthor() {
+	ctx := s.as("bob", "regular")
+	stored := domain.Comment{ID: "c1", TaskID: 4, Author: "bob", Body: "Draft", Mentions: []string{"alice"}}
+	s.mockComments.On("GetComment", ctx, "c1").Return(stored, nil).Once()
+	s.mockComments.On("UpdateComment", ctx, mock.MatchedBy(func(c domain.Comment) bool {
+		return c.ID == "c1" && c.Body == "Done" && c.Mentions == nil && c.EditedAt != nil && c.Author == "bob"
+	})).Return(nil).Once()
+
+	comment, err := s.commentService.EditComment(ctx, 4, "c1", "Done")
+	s.Require().NoError(err)
+	s.Equal("Done", comment.Body)
+	s.Nil(comment.Mentions, "Mentions follow the new body")
+	s.NotNil(comment.EditedAt)
+	s.mockComments.AssertExpectations(s.T())
+}
+
+func (s *CommentServiceSuite) TestEditComment_Permissions() {
+	stored := domain.Comment{ID: "c1", TaskID: 4, Author: "bob", Body: "Draft"}
+	s.mockComments.On("GetComment", mock.Anything, "c1").Return(stored, nil)
+	s.mockComments.On("UpdateComment", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := s.commentService.EditComment(s.as("alice", "regular"), 4, "c1", "Rewritten")
+	s.ErrorIs(err, domain.ErrForbidden, "The task's creator is not the comment's author")
+	s.mockComments.AssertNotCalled(s.T(), "UpdateComment", mock.Anything, mock.Anything)
+	s.Contains(s.logs.String(), "comment access denied")
+
+	_, err = s.commentService.EditComment(s.as("root", "admin"), 4, "c1", "Moderated")
+	s.NoError(err, "Admins may edit any comment")
+}
+
+func (s *CommentServiceSuite) TestEditComment_WrongTask() {
+	s.mockTasks.On("GetTaskById", mock.Anything, 5).Return(domain.Task{ID: 5, CreatedBy: "bob"}, nil)
+	s.mockComments.On("GetComment", mock.Anything, "c1").Return(domain.Comment{ID: "c1", TaskID: 4, Author: "bob"}, nil)
+
+	_, err := s.commentService.EditComment(s.as("bob", "regular"), 5, "c1", "Moved?")
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "A comment is only reachable through its own task")
+}
+
+func (s *CommentServiceSuite) TestDeleteComment() {
+	s.mockComments.On("GetComment", mock.Anything, "c1").Return(domain.Comment{ID: "c1", TaskID: 4, Author: "bob"}, nil)
+	s.mockComments.On("DeleteComment", mock.Anything, "c1").Return(nil).Once()
+
+	s.ErrorIs(s.commentService.DeleteComment(s.as("alice", "regular"), 4, "c1"), domain.ErrForbidden)
+	s.NoError(s.commentService.DeleteComment(s.as("bob", "regular"), 4, "c1"))
+	s.mockComments.AssertExpectations(s.T())
+	s.Contains(s.logs.String(), "comment deleted")
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+)
+
+// a slow dependency should fail the probe rather than hang it
+const DefaultHealthCheckTimeout = 2 * time.Second
+
+type HealthService interface {
+	CheckReadiness(ctx context.Context) domain.HealthReport
+}
+
+type healthService struct {
+	checkers []interfaces.HealthChecker
+	timeout  time.Duration
+}
+
+func NewHealthService(timeout time.Duration, checkers ...interfaces.HealthChecker) HealthService {
+	return &healthService{
+		checkers: checkers,
+		timeout:  timeout,
+	}
+}
+
+// pings every dependency in parallel, so the probe takes as long as the slowest one
+func (s *healthService) CheckReadiness(ctx context.Context) domain.HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	report := domain.HealthReport{Status: domain.HealthOK, Checks: make(map[string]domain.DependencyHealth, len(s.checkers))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, checker := range s.checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := checker.Ping(ctx)
+			result := domain.DependencyHealth{Status: domain.HealthOK, LatencyMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = domain.HealthUnavailable
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[checker.Name()] = result
+			if err != nil {
+				report.Status = domain.HealthUnavailable
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
@@ -0,0 +1,82 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"task7/domain"
+	services "task7/usecases"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// a checker that fails with err, or blocks until the probe's context ends when hang is set
+type fakeHealthChecker struct {
+	name string
+	err  error
+	hang bool
+}
+
+func (f fakeHealthChecker) Name() string { return f.name }
+
+func (f fakeHealthChecker) Ping(ctx context.Context) error {
+	if f.hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return f.err
+}
+
+type HealthServiceSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (s *HealthServiceSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+func TestHealthServiceSuite(t *testing.T) {
+	suite.Run(t, new(HealthServiceSuite))
+}
+
+func (s *HealthServiceSuite) TestCheckReadiness_AllHealthy() {
+	hs := services.NewHealthService(time.Second, fakeHealthChecker{name: "mongo"}, fakeHealthChecker{name: "cache"})
+
+	report := hs.CheckReadiness(s.ctx)
+
+	s.True(report.Healthy())
+	s.Len(report.Checks, 2)
+	s.Equal(domain.HealthOK, report.Checks["mongo"].Status)
+	s.Empty(report.Checks["mongo"].Error)
+}
+
+func (s *HealthServiceSuite) TestCheckReadiness_OneFailing() {
+	hs := services.NewHealthService(time.Second, fakeHealthChecker{name: "mongo", err: errors.New("server selection timeout")}, fakeHealthChecker{name: "cache"})
+
+	report := hs.CheckReadiness(s.ctx)
+
+	s.False(report.Healthy())
+	s.Equal(domain.HealthUnavailable, report.Checks["mongo"].Status)
+	s.Equal("server selection timeout", report.Checks["mongo"].Error)
+	s.Equal(domain.HealthOK, report.Checks["cache"].Status, "a healthy dependency is still reported as such")
+}
+
+func (s *HealthServiceSuite) TestCheckReadiness_SlowDependencyTimesOut() {
+	hs := services.NewHealthService(20*time.Millisecond, fakeHealthChecker{name: "mongo", hang: true})
+
+	start := time.Now()
+	report := hs.CheckReadiness(s.ctx)
+
+	s.Less(time.Since(start), time.Second, "the probe must not wait on a hung dependency")
+	s.False(report.Healthy())
+	s.Contains(report.Checks["mongo"].Error, "deadline exceeded")
+}
+
+func (s *HealthServiceSuite) TestCheckReadiness_NoDependencies() {
+	report := services.NewHealthService(time.Second).CheckReadiness(s.ctx)
+
+	s.True(report.Healthy())
+	s.Empty(report.Checks)
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+)
+
+// projects group tasks on a board; what a caller may do with one depends on their role in it, and
+// admins act as owners of every project
+type ProjectService interface {
+	// the projects the caller is a member of, oldest first; admins see every project
+	ListProjects(ctx context.Context) ([]domain.Project, error)
+	// the caller becomes the project's first owner
+	CreateProject(ctx context.Context, project *domain.Project) error
+	GetProject(ctx context.Context, id string) (domain.Project, error)
+	// changes the name and description that are not nil; owners only
+	UpdateProject(ctx context.Context, id string, name, description *string) (domain.Project, error)
+	// only an empty project can be deleted, so no task is left pointing at a missing board; owners only
+	DeleteProject(ctx context.Context, id string) error
+	// adds a registered user to the project or changes their role; owners only
+	SetMember(ctx context.Context, id, username string, role domain.ProjectRole) (domain.Project, error)
+	RemoveMember(ctx context.Context, id, username string) error
+	// the caller's role in project id; projects they are not a member of are reported as missing
+	RoleIn(ctx context.Context, id string) (domain.ProjectRole, error)
+}
+
+type projectService struct {
+	projectRepo interfaces.ProjectRepository
+	taskRepo    interfaces.TaskRepository
+	userRepo    interfaces.UserRepository
+	logger      *slog.Logger
+}
+
+func NewProjectService(pr interfaces.ProjectRepository, tr interfaces.TaskRepository, ur interfaces.UserRepository, logger *slog.Logger) ProjectService {
+	return &projectService{
+		projectRepo: pr,
+		taskRepo:    tr,
+		userRepo:    ur,
+		logger:      logger,
+	}
+}
+
+var errNotProjectOwner = fmt.Errorf("%w: only the project's owners can change it or its members", domain.ErrForbidden)
+
+// loads project id for the caller, failing unless their role in it is at least min; projects they are
+// not a member of are reported as missing, so ids cannot be probed
+func (s *projectService) load(ctx context.Context, id string, min domain.ProjectRole) (domain.Actor, domain.Project, error) {
+	actor, err := currentActor(ctx)
+	if err != nil {
+		return domain.Actor{}, domain.Project{}, err
+	}
+	project, err := s.projectRepo.GetProject(ctx, id)
+	if err != nil {
+		return domain.Actor{}, domain.Project{}, err
+	}
+	role, ok := project.RoleFor(actor)
+	if !ok {
+		return domain.Actor{}, domain.Project{}, domain.NewNotFound("no project found with id %s", id)
+	}
+	if !role.AtLeast(min) {
+		s.logger.WarnContext(ctx, "project access denied", slog.String("project_id", id), slog.String("role", string(role)))
+		return domain.Actor{}, domain.Project{}, errNotProjectOwner
+	}
+	return actor, project, nil
+}
+
+func (s *projectService) ListProjects(ctx context.Context) ([]domain.Project, error) {
+	actor, err := currentActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	member := actor.Username
+	if actor.IsAdmin() {
+		member = ""
+	}
+	projects, err := s.projectRepo.ListProjects(ctx, member)
+	if err != nil {
+		return nil, err
+	}
+	if projects == nil {
+		projects = []domain.Project{}
+	}
+	return projects, nil
+}
+
+func (s *projectService) CreateProject(ctx context.Context, project *domain.Project) error {
+	actor, err := currentActor(ctx)
+	if err != nil {
+		return err
+	}
+	if err := project.Normalize(); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	project.Members = []domain.ProjectMember{{Username: actor.Username, Role: domain.ProjectRoleOwner}}
+	project.CreatedBy = actor.Username
+	project.CreatedAt, project.UpdatedAt = now, now
+	if err := s.projectRepo.CreateProject(ctx, project); err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "project created", slog.String("project_id", project.ID))
+	return nil
+}
+
+func (s *projectService) GetProject(ctx context.Context, id string) (domain.Project, error) {
+	_, project, err := s.load(ctx, id, domain.ProjectRoleViewer)
+	return project, err
+}
+
+func (s *projectService) UpdateProject(ctx context.Context, id string, name, description *string) (domain.Project, error) {
+	return s.editProject(ctx, id, func(project *domain.Project) error {
+		if name != nil {
+			project.Name = *name
+		}
+		if description != nil {
+			project.Description = *description
+		}
+		return project.Normalize()
+	})
+}
+
+// loads project id for an owner, applies edit and stores the result. A write that lost a race with
+// another change to the project is retried on a fresh read, so concurrent edits, such as two owners
+// adding different members, both land.
+func (s *projectService) editProject(ctx context.Context, id string, edit func(project *domain.Project) error) (domain.Project, error) {
+	for attempt := 1; ; attempt++ {
+		_, project, err := s.load(ctx, id, domain.ProjectRoleOwner)
+		if err != nil {
+			return domain.Project{}, err
+		}
+		if err := edit(&project); err != nil {
+			return domain.Project{}, err
+		}
+		project.UpdatedAt = time.Now().UTC()
+		err = s.projectRepo.UpdateProject(ctx, &project)
+		if errors.Is(err, domain.ErrProjectVersionMismatch) && attempt < taskEditAttempts {
+			continue
+		}
+		if err != nil {
+			return domain.Project{}, err
+		}
+		return project, nil
+	}
+}
+
+func (s *projectService) DeleteProject(ctx context.Context, id string) error {
+	if _, _, err := s.load(ctx, id, domain.ProjectRoleOwner); err != nil {
+		return err
+	}
+	// tasks in the trash count too: restoring one must not bring back a task on a missing board. The
+	// repository refuses as well while tasks are counted on the project, which also covers a task
+	// being added while this runs.
+	for _, trashed := range []bool{false, true} {
+		_, total, err := s.taskRepo.QueryTasks(ctx, domain.TaskQuery{ProjectID: id, Trashed: trashed, Limit: 1})
+		if err != nil {
+			return err
+		}
+		if total > 0 {
+			return domain.ErrProjectNotEmpty
+		}
+	}
+	if err := s.projectRepo.DeleteProject(ctx, id); err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "project deleted", slog.String("project_id", id))
+	return nil
+}
+
+func (s *projectService) SetMember(ctx context.Context, id, username string, role domain.ProjectRole) (domain.Project, error) {
+	if _, _, err := s.load(ctx, id, domain.ProjectRoleOwner); err != nil {
+		return domain.Project{}, err
+	}
+	role, err := domain.ParseProjectRole(string(role))
+	if err != nil {
+		return domain.Project{}, err
+	}
+	found, err := s.userRepo.FindUsernames(ctx, []string{username})
+	if err != nil {
+		return domain.Project{}, err
+	}
+	if len(found) == 0 {
+		return domain.Project{}, domain.NewNotFound("no user found with username %s", username)
+	}
+	project, err := s.editProject(ctx, id, func(project *domain.Project) error {
+		return project.SetMember(username, role)
+	})
+	if err != nil {
+		return domain.Project{}, err
+	}
+	s.logger.InfoContext(ctx, "project member set", slog.String("project_id", id), slog.String("member", username), slog.String("role", string(role)))
+	return project, nil
+}
+
+func (s *projectService) RemoveMember(ctx context.Context, id, username string) error {
+	_, err := s.editProject(ctx, id, func(project *domain.Project) error {
+		removed, err := project.RemoveMember(username)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return domain.NewNotFound("%s is not a member of project %s", username, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "project member removed", slog.String("project_id", id), slog.String("member", username))
+	return nil
+}
+
+func (s *projectService) RoleIn(ctx context.Context, id string) (domain.ProjectRole, error) {
+	actor, project, err := s.load(ctx, id, domain.ProjectRoleViewer)
+	if err != nil {
+		return "", err
+	}
+	role, _ := project.RoleFor(actor)
+	return role, nil
+}
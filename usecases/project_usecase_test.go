@@ -0,0 +1,289 @@
+package services_test
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"task7/domain"
+	services "task7/usecases"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockProjectRepository struct {
+	mock.Mock
+}
+
+func (m *MockProjectRepository) CreateProject(ctx context.Context, project *domain.Project) error {
+	args := m.Called(ctx, project)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) GetProject(ctx context.Context, id string) (domain.Project, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) ListProjects(ctx context.Context, username string) ([]domain.Project, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) UpdateProject(ctx context.Context, project *domain.Project) error {
+	args := m.Called(ctx, project)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) AdjustTaskCount(ctx context.Context, id string, delta int) error {
+	args := m.Called(ctx, id, delta)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) DeleteProject(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+type ProjectServiceSuite struct {
+	suite.Suite
+	mockProjects   *MockProjectRepository
+	mockTasks      *MockTaskRepository
+	mockUsers      *MockUserRepository
+	projectService services.ProjectService
+	project        domain.Project
+}
+
+func (s *ProjectServiceSuite) SetupTest() {
+	s.mockProjects = new(MockProjectRepository)
+	s.mockTasks = new(MockTaskRepository)
+	s.mockUsers = new(MockUserRepository)
+	s.projectService = services.NewProjectService(s.mockProjects, s.mockTasks, s.mockUsers, slog.New(slog.DiscardHandler))
+	s.project = domain.Project{ID: "p1", Name: "Website", CreatedBy: "alice", Members: []domain.ProjectMember{
+		{Username: "alice", Role: domain.ProjectRoleOwner},
+		{Username: "bob", Role: domain.ProjectRoleMember},
+		{Username: "vera", Role: domain.ProjectRoleViewer},
+	}, Version: 1}
+	s.mockProjects.On("GetProject", mock.Anything, "p1").Return(s.project.Clone(), nil).Maybe()
+	s.mockProjects.On("GetProject", mock.Anything, mock.Anything).Return(domain.Project{}, domain.NewNotFound("no project found")).Maybe()
+}
+
+func TestProjectServiceSuite(t *testing.T) {
+	suite.Run(t, new(ProjectServiceSuite))
+}
+
+func (s *ProjectServiceSuite) as(username, role string) context.Context {
+	return domain.WithActor(context.Background(), domain.Actor{Username: username, Role: role})
+}
+
+func (s *ProjectServiceSuite) TestListProjects() {
+	s.mockProjects.On("ListProjects", mock.Anything, "bob").Return(nil, nil).Once()
+	s.mockProjects.On("ListProjects", mock.Anything, "").Return([]domain.Project{s.project}, nil).Once()
+
+	projects, err := s.projectService.ListProjects(s.as("bob", "regular"))
+	s.Require().NoError(err)
+	s.Equal([]domain.Project{}, projects, "No projects should list as empty, not null")
+
+	projects, err = s.projectService.ListProjects(s.as("root", "admin"))
+	s.Require().NoError(err)
+	s.Len(projects, 1, "Admins see every project")
+	s.mockProjects.AssertExpectations(s.T())
+}
+
+func (s *ProjectServiceSuite) TestCreateProject_CreatorOwnsIt() {
+	ctx := s.as("carol", "regular")
+	s.mockProjects.On("CreateProject", ctx, mock.MatchedBy(func(p *domain.Project) bool {
+		return p.Name == "Mobile" && p.CreatedBy == "carol" && !p.CreatedAt.IsZero() && p.CreatedAt.Equal(p.UpdatedAt)
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.Project).ID = "p2"
+	}).Return(nil).Once()
+
+	project := domain.Project{Name: " Mobile ", Members: []domain.ProjectMember{{Username: "mallory", Role: domain.ProjectRoleOwner}}}
+	s.Require().NoError(s.projectService.CreateProject(ctx, &project))
+	s.Equal("p2", project.ID)
+	s.Equal([]domain.ProjectMember{{Username: "carol", Role: domain.ProjectRoleOwner}}, project.Members, "Members given by the client are ignored")
+	s.mockProjects.AssertExpectations(s.T())
+}
+
+func (s *ProjectServiceSuite) TestCreateProject_Invalid() {
+	s.ErrorIs(s.projectService.CreateProject(s.as("carol", "regular"), &domain.Project{Name: "  "}), domain.ErrInvalidProject)
+	s.ErrorIs(s.projectService.CreateProject(context.Background(), &domain.Project{Name: "Mobile"}), domain.ErrUnauthenticated)
+	s.mockProjects.AssertNotCalled(s.T(), "CreateProject", mock.Anything, mock.Anything)
+}
+
+func (s *ProjectServiceSuite) TestGetProject_Access() {
+	_, err := s.projectService.GetProject(s.as("vera", "regular"), "p1")
+	s.NoError(err, "Viewers can see the project")
+
+	_, err = s.projectService.GetProject(s.as("root", "admin"), "p1")
+	s.NoError(err, "Admins can see every project")
+
+	_, err = s.projectService.GetProject(s.as("carol", "regular"), "p1")
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "Non-members cannot tell the project exists")
+
+	_, err = s.projectService.GetProject(s.as("alice", "regular"), "p9")
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+}
+
+func (s *ProjectServiceSuite) TestRoleIn() {
+	role, err := s.projectService.RoleIn(s.as("bob", "regular"), "p1")
+	s.Require().NoError(err)
+	s.Equal(domain.ProjectRoleMember, role)
+
+	role, err = s.projectService.RoleIn(s.as("root", "admin"), "p1")
+	s.Require().NoError(err)
+	s.Equal(domain.ProjectRoleOwner, role)
+
+	_, err = s.projectService.RoleIn(s.as("carol", "regular"), "p1")
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+}
+
+func (s *ProjectServiceSuite) TestUpdateProject() {
+	ctx := s.as("alice", "regular")
+	s.mockProjects.On("UpdateProject", ctx, mock.MatchedBy(func(p *domain.Project) bool {
+		return p.ID == "p1" && p.Name == "Website" && p.Description == "Now with a blog" && !p.UpdatedAt.IsZero()
+	})).Return(nil).Once()
+
+	description := " Now with a blog "
+	project, err := s.projectService.UpdateProject(ctx, "p1", nil, &description)
+	s.Require().NoError(err)
+	s.Equal("Website", project.Name, "A name that is not given is left alone")
+	s.Equal("Now with a blog", project.Description)
+	s.mockProjects.AssertExpectations(s.T())
+}
+
+func (s *ProjectServiceSuite) TestUpdateProject_Rejected() {
+	name := "Web"
+	_, err := s.projectService.UpdateProject(s.as("bob", "regular"), "p1", &name, nil)
+	s.ErrorIs(err, domain.ErrForbidden, "Members cannot change the project")
+
+	blank := " "
+	_, err = s.projectService.UpdateProject(s.as("alice", "regular"), "p1", &blank, nil)
+	s.ErrorIs(err, domain.ErrInvalidProject)
+
+	long := strings.Repeat("d", domain.MaxProjectDescriptionLength+1)
+	_, err = s.projectService.UpdateProject(s.as("alice", "regular"), "p1", nil, &long)
+	s.ErrorIs(err, domain.ErrInvalidProject)
+	s.mockProjects.AssertNotCalled(s.T(), "UpdateProject", mock.Anything, mock.Anything)
+}
+
+func (s *ProjectServiceSuite) TestDeleteProject() {
+	ctx := s.as("alice", "regular")
+	s.mockTasks.On("QueryTasks", ctx, domain.TaskQuery{ProjectID: "p1", Limit: 1}).Return([]domain.Task{}, int64(0), nil).Once()
+	s.mockTasks.On("QueryTasks", ctx, domain.TaskQuery{ProjectID: "p1", Trashed: true, Limit: 1}).Return([]domain.Task{}, int64(0), nil).Once()
+	s.mockProjects.On("DeleteProject", ctx, "p1").Return(nil).Once()
+
+	s.Require().NoError(s.projectService.DeleteProject(ctx, "p1"))
+	s.mockProjects.AssertExpectations(s.T())
+	s.mockTasks.AssertExpectations(s.T())
+}
+
+func (s *ProjectServiceSuite) TestDeleteProject_WithTrashedTasks() {
+	ctx := s.as("alice", "regular")
+	s.mockTasks.On("QueryTasks", ctx, domain.TaskQuery{ProjectID: "p1", Limit: 1}).Return([]domain.Task{}, int64(0), nil).Once()
+	s.mockTasks.On("QueryTasks", ctx, domain.TaskQuery{ProjectID: "p1", Trashed: true, Limit: 1}).Return([]domain.Task{{ID: 3}}, int64(1), nil).Once()
+
+	s.ErrorIs(s.projectService.DeleteProject(ctx, "p1"), domain.ErrProjectNotEmpty)
+	s.mockProjects.AssertNotCalled(s.T(), "DeleteProject", mock.Anything, mock.Anything)
+}
+
+func (s *ProjectServiceSuite) TestDeleteProject_TaskAddedMeanwhile() {
+	ctx := s.as("alice", "regular")
+	s.mockTasks.On("QueryTasks", ctx, mock.Anything).Return([]domain.Task{}, int64(0), nil).Twice()
+	s.mockProjects.On("DeleteProject", ctx, "p1").Return(domain.ErrProjectNotEmpty).Once()
+
+	s.ErrorIs(s.projectService.DeleteProject(ctx, "p1"), domain.ErrProjectNotEmpty, "The repository has the last word on whether the project is empty")
+	s.mockProjects.AssertExpectations(s.T())
+}
+
+func (s *ProjectServiceSuite) TestDeleteProject_OwnersOnly() {
+	s.ErrorIs(s.projectService.DeleteProject(s.as("bob", "regular"), "p1"), domain.ErrForbidden)
+	s.mockTasks.AssertNotCalled(s.T(), "QueryTasks", mock.Anything, mock.Anything)
+}
+
+func (s *ProjectServiceSuite) TestSetMember() {
+	ctx := s.as("alice", "regular")
+	s.mockUsers.On("FindUsernames", ctx, []string{"carol"}).Return([]string{"carol"}, nil).Once()
+	s.mockProjects.On("UpdateProject", ctx, mock.MatchedBy(func(p *domain.Project) bool {
+		role, ok := p.RoleOf("carol")
+		return ok && role == domain.ProjectRoleViewer
+	})).Return(nil).Once()
+
+	project, err := s.projectService.SetMember(ctx, "p1", "carol", "Viewer")
+	s.Require().NoError(err)
+	s.Len(project.Members, 4)
+	s.mockProjects.AssertExpectations(s.T())
+}
+
+func (s *ProjectServiceSuite) TestSetMember_RetriesAfterConcurrentChange() {
+	projects := new(MockProjectRepository)
+	service := services.NewProjectService(projects, s.mockTasks, s.mockUsers, slog.New(slog.DiscardHandler))
+	ctx := s.as("alice", "regular")
+	changed := s.project.Clone()
+	changed.Version = 2
+	s.Require().NoError(changed.SetMember("dave", domain.ProjectRoleMember))
+	projects.On("GetProject", ctx, "p1").Return(s.project.Clone(), nil).Twice()
+	projects.On("GetProject", ctx, "p1").Return(changed, nil).Once()
+	s.mockUsers.On("FindUsernames", ctx, []string{"carol"}).Return([]string{"carol"}, nil).Once()
+	projects.On("UpdateProject", ctx, mock.MatchedBy(func(p *domain.Project) bool { return p.Version == 1 })).
+		Return(domain.ErrProjectVersionMismatch).Once()
+	projects.On("UpdateProject", ctx, mock.MatchedBy(func(p *domain.Project) bool { return p.Version == 2 })).
+		Run(func(args mock.Arguments) { args.Get(1).(*domain.Project).Version = 3 }).Return(nil).Once()
+
+	project, err := service.SetMember(ctx, "p1", "carol", domain.ProjectRoleViewer)
+	s.Require().NoError(err)
+	_, kept := project.RoleOf("dave")
+	s.True(kept, "The member added in between is not overwritten")
+	_, added := project.RoleOf("carol")
+	s.True(added)
+	s.EqualValues(3, project.Version)
+	projects.AssertExpectations(s.T())
+}
+
+func (s *ProjectServiceSuite) TestSetMember_Rejected() {
+	s.mockUsers.On("FindUsernames", mock.Anything, []string{"ghost"}).Return([]string{}, nil)
+	s.mockUsers.On("FindUsernames", mock.Anything, []string{"alice"}).Return([]string{"alice"}, nil)
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		username string
+		role     domain.ProjectRole
+		want     error
+		kind     domain.ErrorKind
+	}{
+		{name: "Not an owner", ctx: s.as("bob", "regular"), username: "carol", role: domain.ProjectRoleMember, want: domain.ErrForbidden},
+		{name: "Unknown role", ctx: s.as("alice", "regular"), username: "carol", role: "admin", want: domain.ErrInvalidProjectRole},
+		{name: "Unknown user", ctx: s.as("alice", "regular"), username: "ghost", role: domain.ProjectRoleMember, kind: domain.KindNotFound},
+		{name: "Demoting the last owner", ctx: s.as("alice", "regular"), username: "alice", role: domain.ProjectRoleMember, want: domain.ErrLastProjectOwner},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			_, err := s.projectService.SetMember(tt.ctx, "p1", tt.username, tt.role)
+			s.Require().Error(err)
+			if tt.want != nil {
+				s.ErrorIs(err, tt.want)
+			} else {
+				s.Equal(tt.kind, domain.KindOf(err))
+			}
+		})
+	}
+	s.mockProjects.AssertNotCalled(s.T(), "UpdateProject", mock.Anything, mock.Anything)
+}
+
+func (s *ProjectServiceSuite) TestRemoveMember() {
+	ctx := s.as("root", "admin")
+	s.mockProjects.On("UpdateProject", ctx, mock.MatchedBy(func(p *domain.Project) bool {
+		_, ok := p.RoleOf("bob")
+		return !ok && len(p.Members) == 2
+	})).Return(nil).Once()
+
+	s.Require().NoError(s.projectService.RemoveMember(ctx, "p1", "bob"))
+	s.Equal(domain.KindNotFound, domain.KindOf(s.projectService.RemoveMember(ctx, "p1", "carol")), "Removing a non-member")
+	s.ErrorIs(s.projectService.RemoveMember(ctx, "p1", "alice"), domain.ErrLastProjectOwner)
+	s.mockProjects.AssertExpectations(s.T())
+}
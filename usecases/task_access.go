@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"task7/domain"
+	"task7/repository/interfaces"
+)
+
+// decides what one caller may do with tasks: a task's creator, its assignee and admins may read and change
+// it, and so may the members of its project as their role allows. Project roles are looked up once per check.
+type taskAccess struct {
+	ctx      context.Context
+	actor    domain.Actor
+	projects interfaces.ProjectRepository
+	roles    map[string]domain.ProjectRole
+}
+
+func newTaskAccess(ctx context.Context, actor domain.Actor, projects interfaces.ProjectRepository) *taskAccess {
+	return &taskAccess{ctx: ctx, actor: actor, projects: projects, roles: map[string]domain.ProjectRole{}}
+}
+
+// the caller's role in project id, or "" if they have none or the project does not exist. A role the
+// project middleware established for the request is taken as it is.
+func (a *taskAccess) role(id string) (domain.ProjectRole, error) {
+	if id == "" {
+		return "", nil
+	}
+	if role, ok := domain.ProjectRoleFromContext(a.ctx, id); ok {
+		return role, nil
+	}
+	if role, ok := a.roles[id]; ok {
+		return role, nil
+	}
+	project, err := a.projects.GetProject(a.ctx, id)
+	if domain.KindOf(err) == domain.KindNotFound {
+		a.roles[id] = ""
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	role, _ := project.RoleFor(a.actor)
+	a.roles[id] = role
+	return role, nil
+}
+
+func (a *taskAccess) canRead(task domain.Task) (bool, error) {
+	if task.VisibleTo(a.actor) {
+		return true, nil
+	}
+	role, err := a.role(task.ProjectID)
+	return task.ReadableBy(a.actor, role), err
+}
+
+func (a *taskAccess) canChange(task domain.Task) (bool, error) {
+	if task.VisibleTo(a.actor) {
+		return true, nil
+	}
+	role, err := a.role(task.ProjectID)
+	return task.ChangeableBy(a.actor, role), err
+}
+
+// fails unless the caller may add tasks to project id: with no role there the project is reported as
+// missing, as it is to non-members, and viewers are forbidden
+func (a *taskAccess) requireMember(id string) error {
+	role, err := a.role(id)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return domain.NewNotFound("no project found with id %s", id)
+	}
+	if !role.AtLeast(domain.ProjectRoleMember) {
+		return errNeedsProjectMember
+	}
+	return nil
+}
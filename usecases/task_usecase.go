@@ -1,44 +1,929 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
 	"task7/domain"
 	"task7/repository/interfaces"
 )
 
 type TaskService interface {
-	GetAllTasks() ([]domain.Task, error)
-	GetTaskById(id int) (domain.Task, error)
-	CreateTask(newTask *domain.Task) error
-	UpdateTask(id int, updatedTask *domain.Task ) error
-	DeleteTaskById(id int) error
+	// a query with a ProjectID lists that project's board, every task on it, to anyone with a role in the
+	// project. Single tasks on a board can be read by its viewers and changed by its members.
+	QueryTasks(ctx context.Context, query domain.TaskQuery) (domain.TaskPage, error)
+	GetTaskById(ctx context.Context, id int) (domain.Task, error)
+	// a task with a ProjectID goes on that project's board, which needs at least the member role in it;
+	// subtasks go on their parent's board, with the same role needed
+	CreateTask(ctx context.Context, newTask *domain.Task) error
+	// version is the one the caller read; a stale version fails with domain.ErrTaskVersionMismatch.
+	// Marking a recurring task done creates the task for its next occurrence.
+	UpdateTask(ctx context.Context, id int, version int64, updatedTask *domain.Task) error
+	// moves the task to the trash, where admins can list and restore it until it is purged. A task with
+	// open subtasks is only deleted with cascade, which takes all of its subtasks along.
+	DeleteTaskById(ctx context.Context, id int, version int64, cascade bool) error
+	QueryTrash(ctx context.Context, query domain.TaskQuery) (domain.TaskPage, error)
+	RestoreTask(ctx context.Context, id int) (domain.Task, error)
+	// adds and removes labels relative to the stored set; needs no version since edits of different labels do not clash
+	EditLabels(ctx context.Context, id int, add, remove []string) (domain.Task, error)
+	// the labels on tasks the caller created or is assigned (every task for admins), with how many tasks
+	// carry each; like an unscoped QueryTasks, project board tasks reached only through a role are left out
+	ListLabels(ctx context.Context) ([]domain.LabelCount, error)
+	// the direct subtasks of task id the caller can see, filtered and paged like QueryTasks
+	ListSubtasks(ctx context.Context, id int, query domain.TaskQuery) (domain.TaskPage, error)
+	// checklist edits work like EditLabels: they apply to the stored checklist and need no version
+	AddChecklistItem(ctx context.Context, id int, text string) (domain.Task, error)
+	SetChecklistItemDone(ctx context.Context, id, itemID int, done bool) (domain.Task, error)
+	RemoveChecklistItem(ctx context.Context, id, itemID int) (domain.Task, error)
+	ReorderChecklist(ctx context.Context, id int, itemIDs []int) (domain.Task, error)
+	// marks task id as blocked by the given tasks, which the caller must be able to see; an edge that
+	// would close a loop fails with domain.ErrDependencyCycle
+	AddDependencies(ctx context.Context, id int, blockerIDs []int) (domain.Task, error)
+	RemoveDependencies(ctx context.Context, id int, blockerIDs []int) (domain.Task, error)
+	// the tasks task id waits on and the tasks waiting on it, directly or not
+	DependencyGraph(ctx context.Context, id int) (domain.DependencyGraph, error)
+	// up to limit occurrences of task id's series, starting with the task itself; 0 means the default
+	PreviewOccurrences(ctx context.Context, id int, limit int) ([]domain.Occurrence, error)
 }
 
 type taskService struct {
-	taskRepo interfaces.TaskRepository
+	taskRepo    interfaces.TaskRepository
+	projectRepo interfaces.ProjectRepository
+	auditRepo   interfaces.AuditRepository
+	logger      *slog.Logger
 }
 
-func NewTaskService(tr interfaces.TaskRepository) TaskService {
+func NewTaskService(tr interfaces.TaskRepository, pr interfaces.ProjectRepository, audit interfaces.AuditRepository, logger *slog.Logger) TaskService {
 	return &taskService{
-		taskRepo: tr,
+		taskRepo:    tr,
+		projectRepo: pr,
+		auditRepo:   audit,
+		logger:      logger,
 	}
 }
 
-func (s *taskService) GetAllTasks() ([]domain.Task, error) {
-	return s.taskRepo.GetAllTasks()
+var (
+	errNotYourTask = fmt.Errorf("%w: you can only change tasks you created or are assigned, or ones on a project where you are at least a member", domain.ErrForbidden)
+	errTrashAdmin  = fmt.Errorf("%w: only admins can see or restore deleted tasks", domain.ErrForbidden)
+)
+
+var errNeedsProjectMember = fmt.Errorf("%w: adding tasks to a project needs at least the member role in it", domain.ErrForbidden)
+
+var errRecurrenceNeedsDueDate = fmt.Errorf("%w: a recurring task needs a due date to start from", domain.ErrInvalidRecurrence)
+
+// returns the caller, or ErrUnauthenticated if the context carries no authenticated user
+func currentActor(ctx context.Context) (domain.Actor, error) {
+	actor, ok := domain.ActorFromContext(ctx)
+	if !ok || actor.Username == "" {
+		return domain.Actor{}, domain.ErrUnauthenticated
+	}
+	return actor, nil
+}
+
+// loads task id for a change by the caller, failing unless they may make it
+func (s *taskService) loadForChange(ctx context.Context, id int) (domain.Actor, domain.Task, error) {
+	actor, err := currentActor(ctx)
+	if err != nil {
+		return domain.Actor{}, domain.Task{}, err
+	}
+	task, err := s.taskRepo.GetTaskById(ctx, id)
+	if err != nil {
+		return domain.Actor{}, domain.Task{}, err
+	}
+	ok, err := newTaskAccess(ctx, actor, s.projectRepo).canChange(task)
+	if err != nil {
+		return domain.Actor{}, domain.Task{}, err
+	}
+	if !ok {
+		s.logger.WarnContext(ctx, "task access denied", slog.Int("task_id", id))
+		return domain.Actor{}, domain.Task{}, errNotYourTask
+	}
+	return actor, task, nil
+}
+
+// loads task id for reading by the caller; tasks they cannot see are reported as missing, so ids cannot be probed
+func (s *taskService) loadVisible(ctx context.Context, id int) (domain.Actor, domain.Task, error) {
+	actor, err := currentActor(ctx)
+	if err != nil {
+		return domain.Actor{}, domain.Task{}, err
+	}
+	task, err := s.taskRepo.GetTaskById(ctx, id)
+	if err != nil {
+		return domain.Actor{}, domain.Task{}, err
+	}
+	ok, err := newTaskAccess(ctx, actor, s.projectRepo).canRead(task)
+	if err != nil {
+		return domain.Actor{}, domain.Task{}, err
+	}
+	if !ok {
+		return domain.Actor{}, domain.Task{}, domain.NewNotFound("no task found with id %d", id)
+	}
+	return actor, task, nil
+}
+
+// fills in Progress on each task, counting their subtasks in one repository call
+func (s *taskService) attachProgress(ctx context.Context, tasks []domain.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]int, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	counts, err := s.taskRepo.CountSubtasks(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		progress := tasks[i].ComputeProgress(counts[tasks[i].ID])
+		tasks[i].Progress = &progress
+	}
+	return nil
+}
+
+func (s *taskService) withProgress(ctx context.Context, task domain.Task) (domain.Task, error) {
+	tasks := []domain.Task{task}
+	if err := s.attachProgress(ctx, tasks); err != nil {
+		return domain.Task{}, err
+	}
+	return tasks[0], nil
+}
+
+func (s *taskService) QueryTasks(ctx context.Context, query domain.TaskQuery) (domain.TaskPage, error) {
+	actor, err := currentActor(ctx)
+	if err != nil {
+		return domain.TaskPage{}, err
+	}
+	if err := query.Normalize(); err != nil {
+		return domain.TaskPage{}, err
+	}
+	if query.ProjectID != "" {
+		// the board shows every task on it to anyone with a role in the project
+		role, err := newTaskAccess(ctx, actor, s.projectRepo).role(query.ProjectID)
+		if err != nil {
+			return domain.TaskPage{}, err
+		}
+		if role == "" {
+			return domain.TaskPage{}, domain.NewNotFound("no project found with id %s", query.ProjectID)
+		}
+	} else if !actor.IsAdmin() {
+		query.VisibleTo = actor.Username
+	}
+	query.Trashed = false // the trash has its own, admin-only listing
+	tasks, total, err := s.taskRepo.QueryTasks(ctx, query)
+	if err != nil {
+		return domain.TaskPage{}, err
+	}
+	if err := s.attachProgress(ctx, tasks); err != nil {
+		return domain.TaskPage{}, err
+	}
+	return domain.NewTaskPage(query, tasks, total), nil
+}
+
+func (s *taskService) GetTaskById(ctx context.Context, id int) (domain.Task, error) {
+	_, task, err := s.loadVisible(ctx, id)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	return s.withProgress(ctx, task)
+}
+
+func (s *taskService) ListSubtasks(ctx context.Context, id int, query domain.TaskQuery) (domain.TaskPage, error) {
+	actor, parent, err := s.loadVisible(ctx, id)
+	if err != nil {
+		return domain.TaskPage{}, err
+	}
+	if err := query.Normalize(); err != nil {
+		return domain.TaskPage{}, err
+	}
+	// subtasks share their parent's project, so a role in it shows all of them
+	role, err := newTaskAccess(ctx, actor, s.projectRepo).role(parent.ProjectID)
+	if err != nil {
+		return domain.TaskPage{}, err
+	}
+	if !actor.IsAdmin() && role == "" {
+		query.VisibleTo = actor.Username
+	}
+	query.ParentID = id
+	query.Trashed = false
+	tasks, total, err := s.taskRepo.QueryTasks(ctx, query)
+	if err != nil {
+		return domain.TaskPage{}, err
+	}
+	if err := s.attachProgress(ctx, tasks); err != nil {
+		return domain.TaskPage{}, err
+	}
+	return domain.NewTaskPage(query, tasks, total), nil
+}
+
+// the caller becomes the task's creator, whatever the request body said. A subtask's parent and
+// the task's blockers must be live tasks the caller can see.
+func (s *taskService) CreateTask(ctx context.Context, newTask *domain.Task) error {
+	actor, err := currentActor(ctx)
+	if err != nil {
+		return err
+	}
+	if newTask.Status == "" {
+		newTask.Status = domain.StatusTodo
+	}
+	status, err := domain.ParseTaskStatus(string(newTask.Status))
+	if err != nil {
+		return err
+	}
+	newTask.Status = status
+	if newTask.Priority == "" {
+		newTask.Priority = domain.DefaultPriority
+	}
+	if newTask.Priority, err = domain.ParseTaskPriority(string(newTask.Priority)); err != nil {
+		return err
+	}
+	if newTask.Labels, err = domain.NormalizeLabels(newTask.Labels); err != nil {
+		return err
+	}
+	if newTask.Checklist, err = domain.NewChecklist(newTask.Checklist); err != nil {
+		return err
+	}
+	access := newTaskAccess(ctx, actor, s.projectRepo)
+	if newTask.ParentID != 0 {
+		parent, err := s.taskRepo.GetTaskById(ctx, newTask.ParentID)
+		if domain.KindOf(err) == domain.KindNotFound {
+			return fmt.Errorf("%w: there is no task %d to add a subtask to", domain.ErrInvalidParent, newTask.ParentID)
+		}
+		if err != nil {
+			return err
+		}
+		readable, err := access.canRead(parent)
+		if err != nil {
+			return err
+		}
+		if !readable {
+			return fmt.Errorf("%w: there is no task %d to add a subtask to", domain.ErrInvalidParent, newTask.ParentID)
+		}
+		if newTask.ProjectID != "" && newTask.ProjectID != parent.ProjectID {
+			return fmt.Errorf("%w: a subtask goes on the same project as task %d", domain.ErrInvalidParent, newTask.ParentID)
+		}
+		// a subtask joins its parent's board, which takes the same role as adding to it directly
+		if parent.ProjectID != "" {
+			role, err := access.role(parent.ProjectID)
+			if err != nil {
+				return err
+			}
+			if !role.AtLeast(domain.ProjectRoleMember) {
+				return errNeedsProjectMember
+			}
+		}
+		newTask.ProjectID = parent.ProjectID
+	} else if newTask.ProjectID != "" {
+		if err := access.requireMember(newTask.ProjectID); err != nil {
+			return err
+		}
+	}
+	if newTask.Recurrence != nil && newTask.Recurrence.Frequency == "" {
+		newTask.Recurrence = nil
+	}
+	if newTask.Recurrence != nil {
+		if err := newTask.Recurrence.Normalize(); err != nil {
+			return err
+		}
+		if newTask.DueDate.IsZero() {
+			return errRecurrenceNeedsDueDate
+		}
+		newTask.Recurrence.Occurrence = 1
+	}
+	// a task nothing can wait on yet cannot close a loop, so only the blockers themselves are checked
+	if newTask.BlockedBy, err = (domain.Task{}).EditBlockers(newTask.BlockedBy, nil); err != nil {
+		return err
+	}
+	if len(newTask.BlockedBy) > 0 {
+		blockers, err := s.queryAll(ctx, domain.TaskQuery{IDs: newTask.BlockedBy})
+		if err != nil {
+			return err
+		}
+		if err := requireBlockers(access, newTask.BlockedBy, tasksByID(blockers)); err != nil {
+			return err
+		}
+	}
+	newTask.CreatedBy = actor.Username
+	newTask.Progress = nil
+	newTask.DeletedAt = nil // tasks reach the trash only through DeleteTaskById
+	if err := s.storeTask(ctx, newTask); err != nil {
+		return err
+	}
+	progress := newTask.ComputeProgress(domain.SubtaskCount{}) // too new to have subtasks
+	newTask.Progress = &progress
+	s.logger.InfoContext(ctx, "task created", slog.Int("task_id", newTask.ID), slog.String("status", string(newTask.Status)))
+	recordAudit(ctx, s.auditRepo, s.logger, domain.NewTaskAuditEntry(domain.AuditTaskCreate, actor, newTask.ID))
+	return nil
+}
+
+// stores a new task, counting it on its project first. The count keeps the project from being deleted
+// from then on, and fails if it already has been, so no task is left on a missing board.
+func (s *taskService) storeTask(ctx context.Context, newTask *domain.Task) error {
+	if newTask.ProjectID == "" {
+		return s.taskRepo.CreateTask(ctx, newTask)
+	}
+	if err := s.projectRepo.AdjustTaskCount(ctx, newTask.ProjectID, 1); err != nil {
+		return err
+	}
+	if err := s.taskRepo.CreateTask(ctx, newTask); err != nil {
+		// a count left too high only keeps the project from being deleted
+		if uncountErr := s.projectRepo.AdjustTaskCount(context.WithoutCancel(ctx), newTask.ProjectID, -1); uncountErr != nil {
+			s.logger.ErrorContext(ctx, "could not uncount a task that was not created", slog.String("project_id", newTask.ProjectID), slog.Any("error", uncountErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// a status change must be a legal transition from the task's current status, and a task only becomes
+// done once its blockers are closed. A recurring task that becomes done hands its rule on to a new task
+// for the next occurrence, so finishing it again after reopening cannot start a second one; the rule only
+// leaves the finished task once the new one is stored. The audit entry records every field the update changed.
+func (s *taskService) UpdateTask(ctx context.Context, id int, version int64, updatedTask *domain.Task) error {
+	if updatedTask.Status != "" {
+		next, err := domain.ParseTaskStatus(string(updatedTask.Status))
+		if err != nil {
+			return err
+		}
+		updatedTask.Status = next
+	}
+	if err := canonicalizeUpdate(updatedTask); err != nil {
+		return err
+	}
+	actor, current, err := s.loadForChange(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current.Version != version {
+		return domain.ErrTaskVersionMismatch
+	}
+	// a replaced rule keeps the task's place in its series
+	if rule := updatedTask.Recurrence; rule != nil && rule.Frequency != "" {
+		rule.Occurrence = 1
+		if current.Recurrence != nil {
+			rule.Occurrence = max(current.Recurrence.Occurrence, 1)
+		}
+	}
+	merged := current.WithUpdate(*updatedTask)
+	if merged.Recurrence != nil && merged.DueDate.IsZero() {
+		return errRecurrenceNeedsDueDate
+	}
+	var successor domain.Task
+	repeats := false
+	if updatedTask.Status != "" {
+		// a stored status we cannot read predates the state machine and may move anywhere
+		if from, err := domain.ParseTaskStatus(string(current.Status)); err == nil {
+			if err := from.TransitionTo(updatedTask.Status); err != nil {
+				return err
+			}
+		}
+		if updatedTask.Status == domain.StatusDone && current.Status != domain.StatusDone {
+			if err := s.requireBlockersClosed(ctx, current); err != nil {
+				return err
+			}
+			successor, repeats = merged.NextOccurrence()
+			if merged.Recurrence != nil && !repeats {
+				updatedTask.Recurrence = &domain.Recurrence{} // the series is over
+			}
+		}
+	}
+	// the repository repeats the version check atomically, in case of a write since the load above
+	if err := s.taskRepo.UpdateTask(ctx, id, version, updatedTask); err != nil {
+		return err
+	}
+	var rolloverErr error
+	if repeats {
+		// the task is closed now; the request going away must not leave its series without a next task
+		rolloverErr = s.startNextOccurrence(context.WithoutCancel(ctx), id, updatedTask, &successor)
+	}
+
+	attrs := []any{slog.Int("task_id", id)}
+	if updatedTask.Status != "" {
+		attrs = append(attrs, slog.String("from", string(current.Status)), slog.String("to", string(updatedTask.Status)))
+	}
+	s.logger.InfoContext(ctx, "task updated", attrs...)
+	entry := domain.NewTaskAuditEntry(domain.AuditTaskUpdate, actor, id)
+	entry.Changes = domain.DiffTasks(current, current.WithUpdate(*updatedTask))
+	recordAudit(ctx, s.auditRepo, s.logger, entry)
+
+	if repeats && rolloverErr == nil {
+		recordAudit(ctx, s.auditRepo, s.logger, domain.NewTaskAuditEntry(domain.AuditTaskCreate, actor, successor.ID))
+	}
+	return rolloverErr
+}
+
+// stores successor, the next occurrence of task id that update has just closed, and then takes the rule
+// off the closed task. Should the create fail the rule stays, so reopening and finishing the task retries;
+// once the successor exists, update carries the cleared rule and the task's new version.
+func (s *taskService) startNextOccurrence(ctx context.Context, id int, update *domain.Task, successor *domain.Task) error {
+	if err := s.storeTask(ctx, successor); err != nil {
+		return fmt.Errorf("task %d is done, but its next occurrence could not be created; reopen and finish it to try again: %w", id, err)
+	}
+	s.logger.InfoContext(ctx, "next occurrence created", slog.Int("task_id", successor.ID), slog.Int("previous_id", id), slog.Int("occurrence", successor.Recurrence.Occurrence))
+	cleared := &domain.Task{Recurrence: &domain.Recurrence{}}
+	if err := s.taskRepo.UpdateTask(ctx, id, update.Version, cleared); err != nil {
+		// the series carries on either way; only finishing this task a second time would fork it
+		s.logger.ErrorContext(ctx, "could not take the rule off a finished recurring task", slog.Int("task_id", id), slog.Any("error", err))
+		return nil
+	}
+	update.Recurrence, update.Version = cleared.Recurrence, cleared.Version
+	return nil
+}
+
+// normalises the priority, labels and recurrence of an update; an empty label set stays non-nil so it still
+// clears the labels, as does a rule without a frequency for the recurrence. The parent and project are fixed at creation, and the checklist and blockers have their own operations, which
+// keep item ids stable and check for cycles, so an update leaves them alone.
+func canonicalizeUpdate(update *domain.Task) error {
+	update.ParentID = 0
+	update.ProjectID = ""
+	update.Checklist = nil
+	update.BlockedBy = nil
+	var err error
+	if update.Priority != "" {
+		if update.Priority, err = domain.ParseTaskPriority(string(update.Priority)); err != nil {
+			return err
+		}
+	}
+	if update.Labels != nil {
+		labels, err := domain.NormalizeLabels(update.Labels)
+		if err != nil {
+			return err
+		}
+		if labels == nil {
+			labels = []string{}
+		}
+		update.Labels = labels
+	}
+	if update.Recurrence != nil && update.Recurrence.Frequency != "" {
+		return update.Recurrence.Normalize()
+	}
+	return nil
+}
+
+// the version check covers only the task itself; subtasks deleted with it are taken as they are. They go
+// to the trash before the task, deepest first, so a failure part way leaves the task and everything still
+// below it live, and the delete can simply be repeated.
+func (s *taskService) DeleteTaskById(ctx context.Context, id int, version int64, cascade bool) error {
+	actor, current, err := s.loadForChange(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current.Version != version {
+		return domain.ErrTaskVersionMismatch
+	}
+	var subtasks []domain.Task
+	if cascade {
+		if subtasks, err = s.liveDescendants(ctx, id); err != nil {
+			return err
+		}
+	} else {
+		counts, err := s.taskRepo.CountSubtasks(ctx, []int{id})
+		if err != nil {
+			return err
+		}
+		if open := counts[id].Open(); open > 0 {
+			return fmt.Errorf("%w: task %d has %d open subtask(s); close them first or delete with cascade=true", domain.ErrTaskHasOpenSubtasks, id, open)
+		}
+		// closed subtasks go to the trash with their parent rather than being left live under it
+		if counts[id].Total > 0 {
+			if subtasks, err = s.liveDescendants(ctx, id); err != nil {
+				return err
+			}
+		}
+	}
+	access := newTaskAccess(ctx, actor, s.projectRepo)
+	open := 0
+	for _, subtask := range subtasks {
+		if !subtask.Status.IsClosed() {
+			open++
+		}
+		changeable, err := access.canChange(subtask)
+		if err != nil {
+			return err
+		}
+		if !changeable {
+			s.logger.WarnContext(ctx, "task access denied", slog.Int("task_id", subtask.ID))
+			return fmt.Errorf("%w: subtask %d belongs to someone else", domain.ErrForbidden, subtask.ID)
+		}
+	}
+	if !cascade && open > 0 {
+		return fmt.Errorf("%w: task %d has %d open subtask(s) further down; close them first or delete with cascade=true", domain.ErrTaskHasOpenSubtasks, id, open)
+	}
+
+	for i := len(subtasks) - 1; i >= 0; i-- {
+		if err := s.deleteSubtask(ctx, actor, subtasks[i]); err != nil {
+			return err
+		}
+	}
+	if err := s.taskRepo.DeleteTaskById(ctx, id, version); err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "task deleted", slog.Int("task_id", id), slog.Int("subtasks", len(subtasks)))
+	recordAudit(ctx, s.auditRepo, s.logger, domain.NewTaskAuditEntry(domain.AuditTaskDelete, actor, id))
+	return nil
+}
+
+// every task matching query's filters, read a page at a time in id order
+func (s *taskService) queryAll(ctx context.Context, query domain.TaskQuery) ([]domain.Task, error) {
+	query.SortBy, query.SortDesc, query.Limit, query.Offset = "id", false, domain.MaxTaskPageSize, 0
+	var all []domain.Task
+	for {
+		tasks, total, err := s.taskRepo.QueryTasks(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, tasks...)
+		query.Offset += len(tasks)
+		if len(tasks) == 0 || int64(query.Offset) >= total {
+			return all, nil
+		}
+	}
+}
+
+// every live task below task id, breadth first
+func (s *taskService) liveDescendants(ctx context.Context, id int) ([]domain.Task, error) {
+	var found []domain.Task
+	for parents := []int{id}; len(parents) > 0; parents = parents[1:] {
+		tasks, err := s.queryAll(ctx, domain.TaskQuery{ParentID: parents[0]})
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range tasks {
+			found = append(found, task)
+			parents = append(parents, task.ID)
+		}
+	}
+	return found, nil
+}
+
+// moves a subtask to the trash during a cascade, reloading it if it changed since it was listed
+func (s *taskService) deleteSubtask(ctx context.Context, actor domain.Actor, subtask domain.Task) error {
+	for attempt := 1; ; attempt++ {
+		err := s.taskRepo.DeleteTaskById(ctx, subtask.ID, subtask.Version)
+		if errors.Is(err, domain.ErrTaskVersionMismatch) && attempt < taskEditAttempts {
+			if subtask, err = s.taskRepo.GetTaskById(ctx, subtask.ID); err == nil {
+				continue
+			}
+		}
+		if domain.KindOf(err) == domain.KindNotFound {
+			return nil // deleted by someone else in the meantime
+		}
+		if err != nil {
+			return err
+		}
+		recordAudit(ctx, s.auditRepo, s.logger, domain.NewTaskAuditEntry(domain.AuditTaskDelete, actor, subtask.ID))
+		return nil
+	}
+}
+
+// lists deleted tasks with the same filters and paging as QueryTasks
+func (s *taskService) QueryTrash(ctx context.Context, query domain.TaskQuery) (domain.TaskPage, error) {
+	actor, err := currentActor(ctx)
+	if err != nil {
+		return domain.TaskPage{}, err
+	}
+	if !actor.IsAdmin() {
+		return domain.TaskPage{}, errTrashAdmin
+	}
+	if err := query.Normalize(); err != nil {
+		return domain.TaskPage{}, err
+	}
+	query.Trashed = true
+	tasks, total, err := s.taskRepo.QueryTasks(ctx, query)
+	if err != nil {
+		return domain.TaskPage{}, err
+	}
+	return domain.NewTaskPage(query, tasks, total), nil
+}
+
+func (s *taskService) RestoreTask(ctx context.Context, id int) (domain.Task, error) {
+	actor, err := currentActor(ctx)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	if !actor.IsAdmin() {
+		return domain.Task{}, errTrashAdmin
+	}
+	task, err := s.taskRepo.RestoreTask(ctx, id)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	s.logger.InfoContext(ctx, "task restored", slog.Int("task_id", id))
+	recordAudit(ctx, s.auditRepo, s.logger, domain.NewTaskAuditEntry(domain.AuditTaskRestore, actor, id))
+	return s.withProgress(ctx, task)
+}
+
+// how often an edit relative to the stored task is reloaded and reapplied when another write got in first
+const taskEditAttempts = 3
+
+// applies edit to the stored task and writes the result with a version check, reapplying the edit to a
+// fresh copy when another write got in first. edit returns the update to write, or nil if there is
+// nothing to change.
+func (s *taskService) editTask(ctx context.Context, id int, message string, edit func(current domain.Task) (*domain.Task, error)) (domain.Task, error) {
+	for attempt := 1; ; attempt++ {
+		actor, current, err := s.loadForChange(ctx, id)
+		if err != nil {
+			return domain.Task{}, err
+		}
+		update, err := edit(current)
+		if err != nil {
+			return domain.Task{}, err
+		}
+		if update == nil {
+			return s.withProgress(ctx, current)
+		}
+		err = s.taskRepo.UpdateTask(ctx, id, current.Version, update)
+		if errors.Is(err, domain.ErrTaskVersionMismatch) && attempt < taskEditAttempts {
+			continue
+		}
+		if err != nil {
+			return domain.Task{}, err
+		}
+
+		updated := current.WithUpdate(*update)
+		updated.Version = update.Version
+		s.logger.InfoContext(ctx, message, slog.Int("task_id", id))
+		entry := domain.NewTaskAuditEntry(domain.AuditTaskUpdate, actor, id)
+		entry.Changes = domain.DiffTasks(current, updated)
+		recordAudit(ctx, s.auditRepo, s.logger, entry)
+		return s.withProgress(ctx, updated)
+	}
 }
 
-func (s *taskService) GetTaskById(id int) (domain.Task, error) {
-	return s.taskRepo.GetTaskById(id)
+func (s *taskService) EditLabels(ctx context.Context, id int, add, remove []string) (domain.Task, error) {
+	add, err := domain.NormalizeLabels(add)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	if remove, err = domain.NormalizeLabels(remove); err != nil {
+		return domain.Task{}, err
+	}
+	return s.editTask(ctx, id, "task labels edited", func(current domain.Task) (*domain.Task, error) {
+		labels, err := current.EditLabels(add, remove)
+		if err != nil || slices.Equal(labels, current.Labels) {
+			return nil, err
+		}
+		if labels == nil {
+			labels = []string{}
+		}
+		return &domain.Task{Labels: labels}, nil
+	})
 }
 
-func (s *taskService) CreateTask(newTask *domain.Task) error {
-	return s.taskRepo.CreateTask(newTask)
+// the update that gives current the checklist edited by change, or nil when change alters nothing
+func checklistEdit(change func(current domain.Task) ([]domain.ChecklistItem, error)) func(domain.Task) (*domain.Task, error) {
+	return func(current domain.Task) (*domain.Task, error) {
+		checklist, err := change(current)
+		if err != nil || slices.Equal(checklist, current.Checklist) {
+			return nil, err
+		}
+		if checklist == nil {
+			checklist = []domain.ChecklistItem{}
+		}
+		return &domain.Task{Checklist: checklist}, nil
+	}
 }
 
-func (s *taskService) UpdateTask(id int, updatedTask *domain.Task) error {
-	return s.taskRepo.UpdateTask(id,updatedTask)
+func (s *taskService) AddChecklistItem(ctx context.Context, id int, text string) (domain.Task, error) {
+	return s.editTask(ctx, id, "checklist item added", checklistEdit(func(current domain.Task) ([]domain.ChecklistItem, error) {
+		return current.AddChecklistItem(text)
+	}))
 }
 
-func (s *taskService) DeleteTaskById(id int) error {
-	return s.taskRepo.DeleteTaskById(id)
+func (s *taskService) SetChecklistItemDone(ctx context.Context, id, itemID int, done bool) (domain.Task, error) {
+	return s.editTask(ctx, id, "checklist item updated", checklistEdit(func(current domain.Task) ([]domain.ChecklistItem, error) {
+		return current.SetChecklistItemDone(itemID, done)
+	}))
+}
+
+func (s *taskService) RemoveChecklistItem(ctx context.Context, id, itemID int) (domain.Task, error) {
+	return s.editTask(ctx, id, "checklist item removed", checklistEdit(func(current domain.Task) ([]domain.ChecklistItem, error) {
+		return current.RemoveChecklistItem(itemID)
+	}))
+}
+
+func (s *taskService) ReorderChecklist(ctx context.Context, id int, itemIDs []int) (domain.Task, error) {
+	return s.editTask(ctx, id, "checklist reordered", checklistEdit(func(current domain.Task) ([]domain.ChecklistItem, error) {
+		return current.ReorderChecklist(itemIDs)
+	}))
+}
+
+func (s *taskService) ListLabels(ctx context.Context) ([]domain.LabelCount, error) {
+	actor, err := currentActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	visibleTo := ""
+	if !actor.IsAdmin() {
+		visibleTo = actor.Username
+	}
+	return s.taskRepo.CountLabels(ctx, visibleTo)
+}
+
+func tasksByID(tasks []domain.Task) map[int]domain.Task {
+	byID := make(map[int]domain.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	return byID
+}
+
+// fails unless every id in blockerIDs is one of found and readable by the caller
+func requireBlockers(access *taskAccess, blockerIDs []int, found map[int]domain.Task) error {
+	for _, blockerID := range blockerIDs {
+		blocker, ok := found[blockerID]
+		if ok {
+			readable, err := access.canRead(blocker)
+			if err != nil {
+				return err
+			}
+			ok = readable
+		}
+		if !ok {
+			return fmt.Errorf("%w: there is no task %d to depend on", domain.ErrInvalidDependency, blockerID)
+		}
+	}
+	return nil
+}
+
+// fails with ErrTaskBlocked while any live blocker of task is still open; deleted blockers no longer hold it up
+func (s *taskService) requireBlockersClosed(ctx context.Context, task domain.Task) error {
+	if len(task.BlockedBy) == 0 {
+		return nil
+	}
+	blockers, err := s.queryAll(ctx, domain.TaskQuery{IDs: task.BlockedBy})
+	if err != nil {
+		return err
+	}
+	var open []int
+	for _, blocker := range blockers {
+		if !blocker.Status.IsClosed() {
+			open = append(open, blocker.ID)
+		}
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("%w: task %d is waiting on open task(s) %v", domain.ErrTaskBlocked, task.ID, open)
+	}
+	return nil
+}
+
+// the live tasks reachable from ids, keyed by id. Upstream that is the tasks in ids and everything they
+// wait on; downstream it is everything waiting on a task in ids. Each step is one batched query.
+func (s *taskService) walkDependencies(ctx context.Context, ids []int, upstream bool) (map[int]domain.Task, error) {
+	found := map[int]domain.Task{}
+	visited := map[int]bool{}
+	for frontier := ids; len(frontier) > 0; {
+		for _, id := range frontier {
+			visited[id] = true
+		}
+		query := domain.TaskQuery{WaitingOn: frontier}
+		if upstream {
+			query = domain.TaskQuery{IDs: frontier}
+		}
+		tasks, err := s.queryAll(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		var next []int
+		for _, task := range tasks {
+			found[task.ID] = task
+			if upstream {
+				next = append(next, task.BlockedBy...)
+			} else {
+				next = append(next, task.ID)
+			}
+		}
+		frontier = nil
+		for _, id := range next {
+			if !visited[id] && !slices.Contains(frontier, id) {
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	return found, nil
+}
+
+// The blockers must exist and be visible to the caller, and task id must not already be upstream of any
+// of them. The cycle check reads the graph before the write, so two concurrent edits could still close a
+// loop between them; the graph walks tolerate that, since they never visit a task twice.
+func (s *taskService) AddDependencies(ctx context.Context, id int, blockerIDs []int) (domain.Task, error) {
+	actor, err := currentActor(ctx)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	add, err := domain.NormalizeTaskIDs(blockerIDs)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	return s.editTask(ctx, id, "task dependencies added", func(current domain.Task) (*domain.Task, error) {
+		blockers, err := current.EditBlockers(add, nil)
+		if err != nil || slices.Equal(blockers, current.BlockedBy) {
+			return nil, err
+		}
+		added := slices.DeleteFunc(slices.Clone(add), func(blockerID int) bool {
+			return slices.Contains(current.BlockedBy, blockerID)
+		})
+		upstream, err := s.walkDependencies(ctx, added, true)
+		if err != nil {
+			return nil, err
+		}
+		if err := requireBlockers(newTaskAccess(ctx, actor, s.projectRepo), added, upstream); err != nil {
+			return nil, err
+		}
+		if _, ok := upstream[id]; ok {
+			return nil, fmt.Errorf("%w: those tasks already wait on task %d, directly or not", domain.ErrDependencyCycle, id)
+		}
+		return &domain.Task{BlockedBy: blockers}, nil
+	})
+}
+
+func (s *taskService) RemoveDependencies(ctx context.Context, id int, blockerIDs []int) (domain.Task, error) {
+	remove, err := domain.NormalizeTaskIDs(blockerIDs)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	return s.editTask(ctx, id, "task dependencies removed", func(current domain.Task) (*domain.Task, error) {
+		blockers, err := current.EditBlockers(nil, remove)
+		if err != nil || slices.Equal(blockers, current.BlockedBy) {
+			return nil, err
+		}
+		if blockers == nil {
+			blockers = []int{}
+		}
+		return &domain.Task{BlockedBy: blockers}, nil
+	})
+}
+
+// tasks the caller cannot see stay in the graph, so its shape is complete, but show only their ids
+func (s *taskService) DependencyGraph(ctx context.Context, id int) (domain.DependencyGraph, error) {
+	actor, task, err := s.loadVisible(ctx, id)
+	if err != nil {
+		return domain.DependencyGraph{}, err
+	}
+	upstream, err := s.walkDependencies(ctx, task.BlockedBy, true)
+	if err != nil {
+		return domain.DependencyGraph{}, err
+	}
+	downstream, err := s.walkDependencies(ctx, []int{id}, false)
+	if err != nil {
+		return domain.DependencyGraph{}, err
+	}
+	delete(upstream, id)
+	delete(downstream, id)
+
+	all := map[int]domain.Task{id: task}
+	maps.Copy(all, upstream)
+	maps.Copy(all, downstream)
+	access := newTaskAccess(ctx, actor, s.projectRepo)
+	upstreamNodes, err := dependencyNodes(access, upstream)
+	if err != nil {
+		return domain.DependencyGraph{}, err
+	}
+	downstreamNodes, err := dependencyNodes(access, downstream)
+	if err != nil {
+		return domain.DependencyGraph{}, err
+	}
+	graph := domain.DependencyGraph{
+		Task:       domain.NewDependencyNode(task, true),
+		Upstream:   upstreamNodes,
+		Downstream: downstreamNodes,
+		Edges:      []domain.DependencyEdge{},
+	}
+	for _, blockedID := range slices.Sorted(maps.Keys(all)) {
+		for _, blockerID := range all[blockedID].BlockedBy {
+			if _, ok := all[blockerID]; ok {
+				graph.Edges = append(graph.Edges, domain.DependencyEdge{Blocker: blockerID, Blocked: blockedID})
+			}
+		}
+	}
+	return graph, nil
+}
+
+func dependencyNodes(access *taskAccess, tasks map[int]domain.Task) ([]domain.DependencyNode, error) {
+	nodes := make([]domain.DependencyNode, 0, len(tasks))
+	for _, id := range slices.Sorted(maps.Keys(tasks)) {
+		readable, err := access.canRead(tasks[id])
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, domain.NewDependencyNode(tasks[id], readable))
+	}
+	return nodes, nil
+}
+
+func (s *taskService) PreviewOccurrences(ctx context.Context, id int, limit int) ([]domain.Occurrence, error) {
+	if limit == 0 {
+		limit = domain.DefaultOccurrencePreview
+	}
+	if limit < 1 || limit > domain.MaxOccurrencePreview {
+		return nil, domain.NewBadRequest("limit must be between 1 and %d", domain.MaxOccurrencePreview)
+	}
+	_, task, err := s.loadVisible(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return task.UpcomingOccurrences(limit), nil
 }
@@ -1,7 +1,10 @@
 package services_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"log/slog"
 	"testing"
 	"time"
 
@@ -17,98 +20,150 @@ type MockTaskRepository struct {
 	mock.Mock
 }
 
-func (m *MockTaskRepository) GetAllTasks() ([]domain.Task, error) {
-	args := m.Called()
+func (m *MockTaskRepository) GetAllTasks(ctx context.Context) ([]domain.Task, error) {
+	args := m.Called(ctx)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepository) GetTaskById(id int) (domain.Task, error) {
-	args := m.Called(id)
+func (m *MockTaskRepository) QueryTasks(ctx context.Context, query domain.TaskQuery) ([]domain.Task, int64, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]domain.Task), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockTaskRepository) GetTaskById(ctx context.Context, id int) (domain.Task, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return domain.Task{}, args.Error(1)
 	}
 	return args.Get(0).(domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepository) CreateTask(newTask *domain.Task) error {
-	args := m.Called(newTask)
+func (m *MockTaskRepository) CreateTask(ctx context.Context, newTask *domain.Task) error {
+	args := m.Called(ctx, newTask)
 	return args.Error(0)
 }
 
-func (m *MockTaskRepository) UpdateTask(id int, updatedTask *domain.Task) error {
-	args := m.Called(id, updatedTask)
+func (m *MockTaskRepository) UpdateTask(ctx context.Context, id int, version int64, updatedTask *domain.Task) error {
+	args := m.Called(ctx, id, version, updatedTask)
 	return args.Error(0)
 }
 
-func (m *MockTaskRepository) DeleteTaskById(id int) error {
-	args := m.Called(id)
+func (m *MockTaskRepository) DeleteTaskById(ctx context.Context, id int, version int64) error {
+	args := m.Called(ctx, id, version)
 	return args.Error(0)
 }
 
+func (m *MockTaskRepository) RestoreTask(ctx context.Context, id int) (domain.Task, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) PurgeDeletedTasks(ctx context.Context, deletedBefore time.Time) ([]domain.Task, error) {
+	args := m.Called(ctx, deletedBefore)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) CountLabels(ctx context.Context, visibleTo string) ([]domain.LabelCount, error) {
+	args := m.Called(ctx, visibleTo)
+	return args.Get(0).([]domain.LabelCount), args.Error(1)
+}
+
+func (m *MockTaskRepository) CountSubtasks(ctx context.Context, parentIDs []int) (map[int]domain.SubtaskCount, error) {
+	args := m.Called(ctx, parentIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int]domain.SubtaskCount), args.Error(1)
+}
+
+// a project repository holding the board p1, owned by alice, with mia as a member and vera as a viewer;
+// any other project is missing
+func newBoardProjects() *MockProjectRepository {
+	projects := new(MockProjectRepository)
+	board := domain.Project{ID: "p1", Name: "Board", CreatedBy: "alice", Members: []domain.ProjectMember{
+		{Username: "alice", Role: domain.ProjectRoleOwner},
+		{Username: "mia", Role: domain.ProjectRoleMember},
+		{Username: "vera", Role: domain.ProjectRoleViewer},
+	}}
+	projects.On("GetProject", mock.Anything, "p1").Return(board, nil).Maybe()
+	projects.On("GetProject", mock.Anything, mock.Anything).Return(domain.Project{}, domain.NewNotFound("no project found")).Maybe()
+	projects.On("AdjustTaskCount", mock.Anything, "p1", mock.Anything).Return(nil).Maybe()
+	return projects
+}
+
 type TaskServiceSuite struct {
 	suite.Suite
-	mockRepo    *MockTaskRepository
-	taskService services.TaskService
+	mockRepo     *MockTaskRepository
+	noSubtasks   *mock.Call // the default CountSubtasks answer; Unset it to expect specific calls
+	mockProjects *MockProjectRepository
+	mockAudit    *MockAuditRepository
+	ctx          context.Context
+	logs         *bytes.Buffer
+	taskService  services.TaskService
 }
 
 func (s *TaskServiceSuite) SetupTest() {
+	s.ctx = domain.WithActor(context.Background(), domain.Actor{Username: "root", Role: "admin"})
 	s.mockRepo = new(MockTaskRepository)
-	s.taskService = services.NewTaskService(s.mockRepo)
+	s.noSubtasks = s.mockRepo.On("CountSubtasks", mock.Anything, mock.Anything).Return(map[int]domain.SubtaskCount{}, nil).Maybe()
+	s.mockProjects = newBoardProjects()
+	s.mockAudit = newRecordingAuditRepository()
+	s.logs = new(bytes.Buffer)
+	s.taskService = services.NewTaskService(s.mockRepo, s.mockProjects, s.mockAudit, slog.New(slog.NewJSONHandler(s.logs, nil)))
 }
 
 func TestTaskServiceSuite(t *testing.T) {
 	suite.Run(t, new(TaskServiceSuite))
 }
 
-func (s *TaskServiceSuite) TestGetAllTasks_SuccessWithTasks() {
-	expectedTasks := []domain.Task{
-		{ID: 1, Title: "Task 1", Description: "Desc 1", Status: "pending"},
-		{ID: 2, Title: "Task 2", Description: "Desc 2", Status: "completed"},
-	}
+func (s *TaskServiceSuite) TestQueryTasks_AppliesDefaultsAndBuildsPage() {
+	expectedQuery := domain.TaskQuery{Status: domain.StatusTodo, SortBy: "id", Limit: domain.DefaultTaskPageSize}
+	tasks := []domain.Task{{ID: 1, Title: "Task 1", Status: "pending"}}
 
-	s.mockRepo.On("GetAllTasks").Return(expectedTasks, nil).Once()
+	s.mockRepo.On("QueryTasks", s.ctx, expectedQuery).Return(tasks, int64(51), nil).Once()
 
-	tasks, err := s.taskService.GetAllTasks()
-	s.NoError(err, "GetAllTasks should not return an error on success")
-	assert.Len(s.T(), tasks, 2, "Should return 2 tasks")
-	assert.Equal(s.T(), expectedTasks, tasks, "Returned tasks should match expected tasks")
+	page, err := s.taskService.QueryTasks(s.ctx, domain.TaskQuery{Status: "pending"})
+	s.NoError(err)
+	s.Equal(tasks, page.Tasks)
+	s.EqualValues(51, page.Total)
+	s.Equal(domain.DefaultTaskPageSize, page.Limit)
+	s.Require().NotNil(page.NextOffset, "More matches remain, so there should be a next page")
+	s.Equal(1, *page.NextOffset)
 	s.mockRepo.AssertExpectations(s.T())
 }
 
-func (s *TaskServiceSuite) TestGetAllTasks_SuccessNoTasks() {
-	expectedTasks := []domain.Task{}
-
-	s.mockRepo.On("GetAllTasks").Return(expectedTasks, nil).Once()
-
-	tasks, err := s.taskService.GetAllTasks()
-	s.NoError(err, "GetAllTasks should not return an error on success with no tasks")
-	assert.Len(s.T(), tasks, 0, "Should return 0 tasks")
-	assert.Equal(s.T(), expectedTasks, tasks, "Returned tasks should be empty slice")
-	s.mockRepo.AssertExpectations(s.T())
+func (s *TaskServiceSuite) TestQueryTasks_InvalidQuery() {
+	_, err := s.taskService.QueryTasks(s.ctx, domain.TaskQuery{SortBy: "nope"})
+	s.ErrorIs(err, domain.ErrInvalidTaskQuery)
+	s.mockRepo.AssertNotCalled(s.T(), "QueryTasks", mock.Anything, mock.Anything)
 }
 
-func (s *TaskServiceSuite) TestGetAllTasks_RepositoryError() {
-	repoError := errors.New("database error fetching tasks")
+func (s *TaskServiceSuite) TestQueryTasks_RepositoryError() {
+	repoError := errors.New("database error querying tasks")
+	s.mockRepo.On("QueryTasks", s.ctx, mock.Anything).Return(nil, int64(0), repoError).Once()
 
-	s.mockRepo.On("GetAllTasks").Return(nil, repoError).Once()
-
-	tasks, err := s.taskService.GetAllTasks()
-	s.Error(err, "GetAllTasks should return an error when repository fails")
-	s.Equal(repoError, err, "Error returned should be the repository error")
-	s.Nil(tasks, "Tasks should be nil on error")
+	_, err := s.taskService.QueryTasks(s.ctx, domain.TaskQuery{})
+	s.Equal(repoError, err)
 	s.mockRepo.AssertExpectations(s.T())
 }
 
 func (s *TaskServiceSuite) TestGetTaskById_Success() {
 	expectedTask := domain.Task{ID: 1, Title: "Test Task", Description: "Description", Status: "pending"}
 
-	s.mockRepo.On("GetTaskById", 1).Return(expectedTask, nil).Once()
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(expectedTask, nil).Once()
 
-	task, err := s.taskService.GetTaskById(1)
+	task, err := s.taskService.GetTaskById(s.ctx, 1)
 	s.NoError(err, "GetTaskById should not return an error on success")
+	expectedTask.Progress = &domain.TaskProgress{}
 	assert.Equal(s.T(), expectedTask, task, "Returned task should match expected task")
 	s.mockRepo.AssertExpectations(s.T())
 }
@@ -116,9 +171,9 @@ func (s *TaskServiceSuite) TestGetTaskById_Success() {
 func (s *TaskServiceSuite) TestGetTaskById_NotFound() {
 	repoError := errors.New("task not found")
 
-	s.mockRepo.On("GetTaskById", 999).Return(domain.Task{}, repoError).Once()
+	s.mockRepo.On("GetTaskById", s.ctx, 999).Return(domain.Task{}, repoError).Once()
 
-	task, err := s.taskService.GetTaskById(999)
+	task, err := s.taskService.GetTaskById(s.ctx, 999)
 	s.Error(err, "GetTaskById should return an error when task is not found")
 	s.Equal(repoError, err, "Error returned should indicate task not found")
 	s.Equal(domain.Task{}, task, "Task should be empty on error")
@@ -128,62 +183,1092 @@ func (s *TaskServiceSuite) TestGetTaskById_NotFound() {
 func (s *TaskServiceSuite) TestCreateTask_Success() {
 	newTask := &domain.Task{Title: "New Task", Description: "To be created", DueDate: time.Now(), Status: "pending"}
 
-	s.mockRepo.On("CreateTask", newTask).Return(nil).Once()
+	s.mockRepo.On("CreateTask", s.ctx, newTask).Return(nil).Once()
 
-	err := s.taskService.CreateTask(newTask)
+	err := s.taskService.CreateTask(s.ctx, newTask)
 	s.NoError(err, "CreateTask should not return an error on success")
+	s.Equal("root", newTask.CreatedBy, "The caller should be recorded as the creator")
+	s.Contains(s.logs.String(), `"msg":"task created"`)
 	s.mockRepo.AssertExpectations(s.T())
+
+	audited := s.mockAudit.appended()
+	s.Require().Len(audited, 1)
+	s.Equal(domain.AuditTaskCreate, audited[0].Action)
+	s.Equal("root", audited[0].Actor)
+	s.Equal("admin", audited[0].ActorRole)
+	s.Equal(domain.AuditTargetTask, audited[0].TargetType)
+	s.False(audited[0].Timestamp.IsZero())
 }
 
 func (s *TaskServiceSuite) TestCreateTask_RepositoryError() {
 	newTask := &domain.Task{Title: "New Task", Description: "To be created", DueDate: time.Now(), Status: "pending"}
 	repoError := errors.New("database creation failed")
 
-	s.mockRepo.On("CreateTask", newTask).Return(repoError).Once()
+	s.mockRepo.On("CreateTask", s.ctx, newTask).Return(repoError).Once()
 
-	err := s.taskService.CreateTask(newTask)
+	err := s.taskService.CreateTask(s.ctx, newTask)
 	s.Error(err, "CreateTask should return an error when repository fails")
 	s.Equal(repoError, err, "Error returned should be the repository error")
+	s.NotContains(s.logs.String(), "task created", "Only tasks that were stored are logged as created")
+	s.Empty(s.mockAudit.appended(), "Failed changes are not audited")
 	s.mockRepo.AssertExpectations(s.T())
 }
 
 func (s *TaskServiceSuite) TestUpdateTask_Success() {
-	updatedTask := &domain.Task{ID: 1, Title: "Updated Task", Status: "completed"}
+	updatedTask := &domain.Task{ID: 1, Title: "Updated Task", Status: "In Progress"}
 
-	s.mockRepo.On("UpdateTask", 1, updatedTask).Return(nil).Once()
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 1, Status: domain.StatusTodo}, nil).Once()
+	s.mockRepo.On("UpdateTask", s.ctx, 1, int64(1), updatedTask).Return(nil).Once()
 
-	err := s.taskService.UpdateTask(1, updatedTask)
+	err := s.taskService.UpdateTask(s.ctx, 1, 1, updatedTask)
 	s.NoError(err, "UpdateTask should not return an error on success")
+	s.Equal(domain.StatusInProgress, updatedTask.Status, "The status should be stored in canonical form")
 	s.mockRepo.AssertExpectations(s.T())
 }
 
+func (s *TaskServiceSuite) TestUpdateTask_AuditsFieldChanges() {
+	due := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	update := &domain.Task{DueDate: due.Add(24 * time.Hour)}
+	s.mockRepo.On("GetTaskById", s.ctx, 4).Return(domain.Task{ID: 4, Version: 1, Title: "Report", DueDate: due, Status: domain.StatusTodo}, nil).Once()
+	s.mockRepo.On("UpdateTask", s.ctx, 4, int64(1), update).Return(nil).Once()
+
+	s.Require().NoError(s.taskService.UpdateTask(s.ctx, 4, 1, update))
+
+	audited := s.mockAudit.appended()
+	s.Require().Len(audited, 1)
+	s.Equal(domain.AuditTaskUpdate, audited[0].Action)
+	s.Equal("4", audited[0].TargetID)
+	s.Equal([]domain.FieldChange{{Field: "duedate", Before: "2025-07-01T12:00:00Z", After: "2025-07-02T12:00:00Z"}}, audited[0].Changes)
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_AuditFailureDoesNotFailUpdate() {
+	audit := new(MockAuditRepository)
+	audit.On("Append", mock.Anything, mock.Anything).Return(errors.New("audit store down")).Once()
+	taskService := services.NewTaskService(s.mockRepo, s.mockProjects, audit, slog.New(slog.NewJSONHandler(s.logs, nil)))
+	update := &domain.Task{Title: "Renamed"}
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 1, Title: "Old"}, nil).Once()
+	s.mockRepo.On("UpdateTask", s.ctx, 1, int64(1), update).Return(nil).Once()
+
+	s.NoError(taskService.UpdateTask(s.ctx, 1, 1, update))
+	s.Contains(s.logs.String(), `"msg":"audit entry lost"`)
+	audit.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_AuditOutlivesCancelledRequest() {
+	audit := new(MockAuditRepository)
+	audit.On("Append", mock.MatchedBy(func(ctx context.Context) bool {
+		_, hasDeadline := ctx.Deadline()
+		return ctx.Err() == nil && hasDeadline
+	}), mock.Anything).Return(nil).Once()
+	taskService := services.NewTaskService(s.mockRepo, s.mockProjects, audit, slog.New(slog.NewJSONHandler(s.logs, nil)))
+	ctx, cancel := context.WithCancel(s.ctx)
+	update := &domain.Task{Title: "Renamed"}
+	s.mockRepo.On("GetTaskById", ctx, 1).Return(domain.Task{ID: 1, Version: 1, Title: "Old"}, nil).Once()
+	s.mockRepo.On("UpdateTask", ctx, 1, int64(1), update).Run(func(mock.Arguments) { cancel() }).Return(nil).Once()
+
+	s.NoError(taskService.UpdateTask(ctx, 1, 1, update))
+	audit.AssertExpectations(s.T())
+	s.NotContains(s.logs.String(), "audit entry lost", "The client hanging up after the write does not drop its audit entry")
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_StaleVersion() {
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 3, Status: domain.StatusTodo}, nil).Once()
+
+	err := s.taskService.UpdateTask(s.ctx, 1, 2, &domain.Task{Title: "Renamed"})
+	s.ErrorIs(err, domain.ErrTaskVersionMismatch)
+	s.mockRepo.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	s.Empty(s.mockAudit.appended(), "Rejected changes are not audited")
+}
+
 func (s *TaskServiceSuite) TestUpdateTask_NotFound() {
-	updatedTask := &domain.Task{ID: 999, Title: "Non-existent", Status: "pending"}
+	updatedTask := &domain.Task{ID: 999, Title: "Non-existent"}
 	repoError := errors.New("task not found for update")
 
-	s.mockRepo.On("UpdateTask", 999, updatedTask).Return(repoError).Once()
+	s.mockRepo.On("GetTaskById", s.ctx, 999).Return(domain.Task{}, repoError).Once()
 
-	err := s.taskService.UpdateTask(999, updatedTask)
+	err := s.taskService.UpdateTask(s.ctx, 999, 1, updatedTask)
 	s.Error(err, "UpdateTask should return an error when task is not found")
 	s.Equal(repoError, err, "Error returned should indicate task not found")
+	s.mockRepo.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	s.Empty(s.mockAudit.appended())
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_StatusOfMissingTask() {
+	repoError := errors.New("no task found with id 999")
+	s.mockRepo.On("GetTaskById", s.ctx, 999).Return(domain.Task{}, repoError).Once()
+
+	err := s.taskService.UpdateTask(s.ctx, 999, 1, &domain.Task{Status: domain.StatusDone})
+	s.Equal(repoError, err)
+	s.mockRepo.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_IllegalTransition() {
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 1, Status: domain.StatusArchived}, nil).Once()
+
+	err := s.taskService.UpdateTask(s.ctx, 1, 1, &domain.Task{Status: domain.StatusInProgress})
+	s.ErrorIs(err, domain.ErrInvalidStatusTransition)
+	s.mockRepo.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_UnknownStatus() {
+	err := s.taskService.UpdateTask(s.ctx, 1, 1, &domain.Task{Status: "finished-ish"})
+	s.ErrorIs(err, domain.ErrInvalidStatus)
+	s.mockRepo.AssertNotCalled(s.T(), "GetTaskById", mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_LegacyStatusMayMoveAnywhere() {
+	update := &domain.Task{Status: domain.StatusBlocked}
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 1, Status: "waiting on vendor"}, nil).Once()
+	s.mockRepo.On("UpdateTask", s.ctx, 1, int64(1), update).Return(nil).Once()
+
+	s.NoError(s.taskService.UpdateTask(s.ctx, 1, 1, update))
 	s.mockRepo.AssertExpectations(s.T())
 }
 
+func (s *TaskServiceSuite) TestCreateTask_DefaultsAndNormalizesStatus() {
+	noStatus := &domain.Task{Title: "New"}
+	s.mockRepo.On("CreateTask", s.ctx, noStatus).Return(nil).Once()
+	s.NoError(s.taskService.CreateTask(s.ctx, noStatus))
+	s.Equal(domain.StatusTodo, noStatus.Status)
+
+	aliased := &domain.Task{Title: "Old style", Status: "Completed"}
+	s.mockRepo.On("CreateTask", s.ctx, aliased).Return(nil).Once()
+	s.NoError(s.taskService.CreateTask(s.ctx, aliased))
+	s.Equal(domain.StatusDone, aliased.Status)
+
+	s.ErrorIs(s.taskService.CreateTask(s.ctx, &domain.Task{Title: "Bad", Status: "someday"}), domain.ErrInvalidStatus)
+}
+
 func (s *TaskServiceSuite) TestDeleteTaskById_Success() {
-	s.mockRepo.On("DeleteTaskById", 1).Return(nil).Once()
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 1}, nil).Once()
+	s.mockRepo.On("DeleteTaskById", s.ctx, 1, int64(1)).Return(nil).Once()
 
-	err := s.taskService.DeleteTaskById(1)
+	err := s.taskService.DeleteTaskById(s.ctx, 1, 1, false)
 	s.NoError(err, "DeleteTaskById should not return an error on success")
 	s.mockRepo.AssertExpectations(s.T())
+
+	audited := s.mockAudit.appended()
+	s.Require().Len(audited, 1)
+	s.Equal(domain.AuditTaskDelete, audited[0].Action)
+	s.Equal("1", audited[0].TargetID)
 }
 
 func (s *TaskServiceSuite) TestDeleteTaskById_NotFound() {
 	repoError := errors.New("task not found for deletion")
 
-	s.mockRepo.On("DeleteTaskById", 999).Return(repoError).Once()
+	s.mockRepo.On("GetTaskById", s.ctx, 999).Return(domain.Task{ID: 999, Version: 1}, nil).Once()
+	s.mockRepo.On("DeleteTaskById", s.ctx, 999, int64(1)).Return(repoError).Once()
 
-	err := s.taskService.DeleteTaskById(999)
+	err := s.taskService.DeleteTaskById(s.ctx, 999, 1, false)
 	s.Error(err, "DeleteTaskById should return an error when task is not found")
 	s.Equal(repoError, err, "Error returned should indicate task not found")
 	s.mockRepo.AssertExpectations(s.T())
 }
+
+func (s *TaskServiceSuite) TestDeleteTaskById_StaleVersion() {
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 2}, nil).Twice()
+	s.mockRepo.On("DeleteTaskById", s.ctx, 1, int64(2)).Return(domain.ErrTaskVersionMismatch).Once()
+
+	s.ErrorIs(s.taskService.DeleteTaskById(s.ctx, 1, 1, false), domain.ErrTaskVersionMismatch)
+	s.mockRepo.AssertNotCalled(s.T(), "DeleteTaskById", mock.Anything, mock.Anything, mock.Anything)
+
+	s.ErrorIs(s.taskService.DeleteTaskById(s.ctx, 1, 2, false), domain.ErrTaskVersionMismatch, "A write racing the load is caught by the repository")
+	s.Empty(s.mockAudit.appended())
+}
+
+func (s *TaskServiceSuite) asUser(username string) context.Context {
+	return domain.WithActor(context.Background(), domain.Actor{Username: username, Role: "regular"})
+}
+
+func (s *TaskServiceSuite) TestNoActor_Unauthenticated() {
+	ctx := context.Background()
+
+	_, err := s.taskService.QueryTasks(ctx, domain.TaskQuery{})
+	s.ErrorIs(err, domain.ErrUnauthenticated)
+	s.ErrorIs(s.taskService.CreateTask(ctx, &domain.Task{Title: "x"}), domain.ErrUnauthenticated)
+	s.ErrorIs(s.taskService.DeleteTaskById(ctx, 1, 1, false), domain.ErrUnauthenticated)
+	s.mockRepo.AssertNotCalled(s.T(), "QueryTasks", mock.Anything, mock.Anything)
+	s.mockRepo.AssertNotCalled(s.T(), "CreateTask", mock.Anything, mock.Anything)
+	s.mockRepo.AssertNotCalled(s.T(), "DeleteTaskById", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestCreateTask_OverridesClientCreator() {
+	ctx := s.asUser("alice")
+	newTask := &domain.Task{Title: "Mine", CreatedBy: "mallory", AssignedTo: "bob"}
+
+	s.mockRepo.On("CreateTask", ctx, newTask).Return(nil).Once()
+
+	s.NoError(s.taskService.CreateTask(ctx, newTask))
+	s.Equal("alice", newTask.CreatedBy)
+	s.Equal("bob", newTask.AssignedTo, "The assignee is taken from the request")
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestCreateTask_IgnoresClientDeletionTime() {
+	deletedAt := time.Now().Add(-time.Hour)
+	newTask := &domain.Task{Title: "Fresh", DeletedAt: &deletedAt}
+	s.mockRepo.On("CreateTask", s.ctx, mock.MatchedBy(func(t *domain.Task) bool { return t.DeletedAt == nil })).Return(nil).Once()
+
+	s.NoError(s.taskService.CreateTask(s.ctx, newTask))
+	s.Nil(newTask.DeletedAt, "A task created through the API is never in the trash")
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestQueryTasks_RegularUserSeesOwnTasks() {
+	ctx := s.asUser("alice")
+	expectedQuery := domain.TaskQuery{SortBy: "id", Limit: domain.DefaultTaskPageSize, VisibleTo: "alice"}
+
+	s.mockRepo.On("QueryTasks", ctx, expectedQuery).Return([]domain.Task{}, int64(0), nil).Once()
+
+	_, err := s.taskService.QueryTasks(ctx, domain.TaskQuery{VisibleTo: "bob"})
+	s.NoError(err)
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestGetTaskById_OtherUsersTask() {
+	ctx := s.asUser("alice")
+	s.mockRepo.On("GetTaskById", ctx, 3).Return(domain.Task{ID: 3, Version: 1, CreatedBy: "bob"}, nil).Once()
+
+	_, err := s.taskService.GetTaskById(ctx, 3)
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "Invisible tasks are reported as missing")
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_AssigneeAllowed() {
+	ctx := s.asUser("alice")
+	update := &domain.Task{Status: "completed"}
+	s.mockRepo.On("GetTaskById", ctx, 2).Return(domain.Task{ID: 2, Version: 1, CreatedBy: "bob", AssignedTo: "alice", Status: domain.StatusInProgress}, nil).Once()
+	s.mockRepo.On("UpdateTask", ctx, 2, int64(1), update).Return(nil).Once()
+
+	s.NoError(s.taskService.UpdateTask(ctx, 2, 1, update))
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_OtherUsersTask() {
+	ctx := s.asUser("alice")
+	s.mockRepo.On("GetTaskById", ctx, 3).Return(domain.Task{ID: 3, Version: 1, CreatedBy: "bob"}, nil).Once()
+
+	err := s.taskService.UpdateTask(ctx, 3, 1, &domain.Task{Status: "completed"})
+	s.ErrorIs(err, domain.ErrForbidden)
+	s.mockRepo.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestDeleteTaskById_Owner() {
+	ctx := s.asUser("alice")
+	s.mockRepo.On("GetTaskById", ctx, 1).Return(domain.Task{ID: 1, Version: 1, CreatedBy: "alice"}, nil).Once()
+	s.mockRepo.On("DeleteTaskById", ctx, 1, int64(1)).Return(nil).Once()
+
+	s.NoError(s.taskService.DeleteTaskById(ctx, 1, 1, false))
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestDeleteTaskById_OtherUsersTask() {
+	ctx := s.asUser("alice")
+	s.mockRepo.On("GetTaskById", ctx, 3).Return(domain.Task{ID: 3, Version: 1, CreatedBy: "bob"}, nil).Once()
+
+	s.ErrorIs(s.taskService.DeleteTaskById(ctx, 3, 1, false), domain.ErrForbidden)
+	s.mockRepo.AssertNotCalled(s.T(), "DeleteTaskById", mock.Anything, mock.Anything, mock.Anything)
+	s.Contains(s.logs.String(), `"msg":"task access denied","task_id":3`)
+}
+
+func (s *TaskServiceSuite) TestQueryTasks_NeverListsTrash() {
+	expectedQuery := domain.TaskQuery{SortBy: "id", Limit: domain.DefaultTaskPageSize}
+	s.mockRepo.On("QueryTasks", s.ctx, expectedQuery).Return([]domain.Task{}, int64(0), nil).Once()
+
+	_, err := s.taskService.QueryTasks(s.ctx, domain.TaskQuery{Trashed: true})
+	s.NoError(err)
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestQueryTrash_Admin() {
+	deletedAt := time.Now()
+	trash := []domain.Task{{ID: 4, Title: "Gone", DeletedAt: &deletedAt}}
+	expectedQuery := domain.TaskQuery{SortBy: "id", Limit: domain.DefaultTaskPageSize, Trashed: true}
+	s.mockRepo.On("QueryTasks", s.ctx, expectedQuery).Return(trash, int64(1), nil).Once()
+
+	page, err := s.taskService.QueryTrash(s.ctx, domain.TaskQuery{})
+	s.Require().NoError(err)
+	s.Equal(trash, page.Tasks)
+	s.EqualValues(1, page.Total)
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestTrash_RegularUserForbidden() {
+	ctx := s.asUser("alice")
+
+	_, err := s.taskService.QueryTrash(ctx, domain.TaskQuery{})
+	s.ErrorIs(err, domain.ErrForbidden)
+	_, err = s.taskService.RestoreTask(ctx, 1)
+	s.ErrorIs(err, domain.ErrForbidden)
+	s.mockRepo.AssertNotCalled(s.T(), "QueryTasks", mock.Anything, mock.Anything)
+	s.mockRepo.AssertNotCalled(s.T(), "RestoreTask", mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestRestoreTask_Success() {
+	restored := domain.Task{ID: 4, Title: "Back", Version: 3}
+	s.mockRepo.On("RestoreTask", s.ctx, 4).Return(restored, nil).Once()
+
+	task, err := s.taskService.RestoreTask(s.ctx, 4)
+	s.Require().NoError(err)
+	restored.Progress = &domain.TaskProgress{}
+	s.Equal(restored, task)
+
+	audited := s.mockAudit.appended()
+	s.Require().Len(audited, 1)
+	s.Equal(domain.AuditTaskRestore, audited[0].Action)
+	s.Equal("4", audited[0].TargetID)
+	s.Equal("root", audited[0].Actor)
+}
+
+func (s *TaskServiceSuite) TestRestoreTask_NotInTrash() {
+	notFound := domain.NewNotFound("no deleted task found with id 4")
+	s.mockRepo.On("RestoreTask", s.ctx, 4).Return(domain.Task{}, notFound).Once()
+
+	_, err := s.taskService.RestoreTask(s.ctx, 4)
+	s.Equal(notFound, err)
+	s.Empty(s.mockAudit.appended())
+}
+
+func (s *TaskServiceSuite) TestCreateTask_PriorityAndLabels() {
+	plain := &domain.Task{Title: "Plain"}
+	s.mockRepo.On("CreateTask", s.ctx, plain).Return(nil).Once()
+	s.NoError(s.taskService.CreateTask(s.ctx, plain))
+	s.Equal(domain.DefaultPriority, plain.Priority)
+	s.Nil(plain.Labels)
+
+	triaged := &domain.Task{Title: "Triaged", Priority: "URGENT", Labels: []string{"UI", "backend", "ui"}}
+	s.mockRepo.On("CreateTask", s.ctx, triaged).Return(nil).Once()
+	s.NoError(s.taskService.CreateTask(s.ctx, triaged))
+	s.Equal(domain.PriorityUrgent, triaged.Priority)
+	s.Equal([]string{"backend", "ui"}, triaged.Labels)
+
+	s.ErrorIs(s.taskService.CreateTask(s.ctx, &domain.Task{Title: "Bad", Priority: "p0"}), domain.ErrInvalidPriority)
+	s.ErrorIs(s.taskService.CreateTask(s.ctx, &domain.Task{Title: "Bad", Labels: []string{"a,b"}}), domain.ErrInvalidLabel)
+	s.mockRepo.AssertNumberOfCalls(s.T(), "CreateTask", 2)
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_PriorityAndLabels() {
+	update := &domain.Task{Priority: "High", Labels: []string{}}
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 1, Priority: domain.PriorityLow, Labels: []string{"ui"}}, nil).Once()
+	s.mockRepo.On("UpdateTask", s.ctx, 1, int64(1), update).Return(nil).Once()
+
+	s.Require().NoError(s.taskService.UpdateTask(s.ctx, 1, 1, update))
+	s.Equal(domain.PriorityHigh, update.Priority)
+	s.NotNil(update.Labels, "An empty label set must still reach the repository to clear the labels")
+	s.Empty(update.Labels)
+
+	audited := s.mockAudit.appended()
+	s.Require().Len(audited, 1)
+	s.Equal([]domain.FieldChange{
+		{Field: "priority", Before: "low", After: "high"},
+		{Field: "labels", Before: "ui", After: ""},
+	}, audited[0].Changes)
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_InvalidPriority() {
+	err := s.taskService.UpdateTask(s.ctx, 1, 1, &domain.Task{Priority: "p0"})
+	s.ErrorIs(err, domain.ErrInvalidPriority)
+	s.mockRepo.AssertNotCalled(s.T(), "GetTaskById", mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestEditLabels_Success() {
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 4, Labels: []string{"backend", "ui"}}, nil).Once()
+	s.mockRepo.On("UpdateTask", s.ctx, 1, int64(4), &domain.Task{Labels: []string{"backend", "billing"}}).Run(func(args mock.Arguments) {
+		args.Get(3).(*domain.Task).Version = 5
+	}).Return(nil).Once()
+
+	task, err := s.taskService.EditLabels(s.ctx, 1, []string{"Billing"}, []string{"UI"})
+	s.Require().NoError(err)
+	s.Equal([]string{"backend", "billing"}, task.Labels)
+	s.EqualValues(5, task.Version)
+
+	audited := s.mockAudit.appended()
+	s.Require().Len(audited, 1)
+	s.Equal([]domain.FieldChange{{Field: "labels", Before: "backend,ui", After: "backend,billing"}}, audited[0].Changes)
+}
+
+func (s *TaskServiceSuite) TestEditLabels_RetriesAfterConcurrentWrite() {
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 1}, nil).Once()
+	s.mockRepo.On("UpdateTask", s.ctx, 1, int64(1), mock.Anything).Return(domain.ErrTaskVersionMismatch).Once()
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 2, Labels: []string{"ui"}}, nil).Once()
+	s.mockRepo.On("UpdateTask", s.ctx, 1, int64(2), &domain.Task{Labels: []string{"backend", "ui"}}).Return(nil).Once()
+
+	task, err := s.taskService.EditLabels(s.ctx, 1, []string{"backend"}, nil)
+	s.Require().NoError(err)
+	s.Equal([]string{"backend", "ui"}, task.Labels, "The edit is reapplied to the newer labels")
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestEditLabels_GivesUpAfterRepeatedConflicts() {
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 1}, nil)
+	s.mockRepo.On("UpdateTask", s.ctx, 1, int64(1), mock.Anything).Return(domain.ErrTaskVersionMismatch)
+
+	_, err := s.taskService.EditLabels(s.ctx, 1, []string{"backend"}, nil)
+	s.ErrorIs(err, domain.ErrTaskVersionMismatch)
+	s.mockRepo.AssertNumberOfCalls(s.T(), "UpdateTask", 3)
+	s.Empty(s.mockAudit.appended())
+}
+
+func (s *TaskServiceSuite) TestEditLabels_NothingToChange() {
+	current := domain.Task{ID: 1, Version: 2, Labels: []string{"ui"}}
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(current, nil).Once()
+
+	task, err := s.taskService.EditLabels(s.ctx, 1, []string{"ui"}, []string{"backend"})
+	s.Require().NoError(err)
+	current.Progress = &domain.TaskProgress{}
+	s.Equal(current, task)
+	s.mockRepo.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	s.Empty(s.mockAudit.appended())
+}
+
+func (s *TaskServiceSuite) TestEditLabels_OtherUsersTask() {
+	ctx := s.asUser("alice")
+	s.mockRepo.On("GetTaskById", ctx, 3).Return(domain.Task{ID: 3, CreatedBy: "bob"}, nil).Once()
+
+	_, err := s.taskService.EditLabels(ctx, 3, []string{"mine"}, nil)
+	s.ErrorIs(err, domain.ErrForbidden)
+	s.mockRepo.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestListLabels_ScopedToVisibleTasks() {
+	counts := []domain.LabelCount{{Label: "backend", Count: 2}}
+	s.mockRepo.On("CountLabels", s.ctx, "").Return(counts, nil).Once()
+	all, err := s.taskService.ListLabels(s.ctx)
+	s.Require().NoError(err)
+	s.Equal(counts, all)
+
+	ctx := s.asUser("alice")
+	s.mockRepo.On("CountLabels", ctx, "alice").Return([]domain.LabelCount{}, nil).Once()
+	_, err = s.taskService.ListLabels(ctx)
+	s.NoError(err)
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestQueryTasks_AttachesProgress() {
+	tasks := []domain.Task{
+		{ID: 1, Checklist: []domain.ChecklistItem{{ID: 1, Done: true}}},
+		{ID: 2, Status: domain.StatusDone},
+	}
+	s.mockRepo.On("QueryTasks", s.ctx, mock.Anything).Return(tasks, int64(2), nil).Once()
+	s.noSubtasks.Unset()
+	s.mockRepo.On("CountSubtasks", s.ctx, []int{1, 2}).Return(map[int]domain.SubtaskCount{1: {Total: 3, Closed: 1}}, nil).Once()
+
+	page, err := s.taskService.QueryTasks(s.ctx, domain.TaskQuery{})
+	s.Require().NoError(err)
+	s.Equal(&domain.TaskProgress{ChecklistDone: 1, ChecklistTotal: 1, SubtasksDone: 1, SubtasksTotal: 3, Percent: 50}, page.Tasks[0].Progress)
+	s.Equal(&domain.TaskProgress{Percent: 100}, page.Tasks[1].Progress)
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestCreateTask_SubtaskWithChecklist() {
+	ctx := s.asUser("alice")
+	s.mockRepo.On("GetTaskById", ctx, 1).Return(domain.Task{ID: 1, CreatedBy: "alice"}, nil).Once()
+	newTask := &domain.Task{Title: "Child", ParentID: 1, Checklist: []domain.ChecklistItem{{ID: 7, Text: " Draft ", Done: true}, {Text: "Ship"}}}
+	s.mockRepo.On("CreateTask", ctx, newTask).Return(nil).Once()
+
+	s.Require().NoError(s.taskService.CreateTask(ctx, newTask))
+	s.Equal([]domain.ChecklistItem{{ID: 1, Text: "Draft", Done: true}, {ID: 2, Text: "Ship"}}, newTask.Checklist)
+	s.Equal(&domain.TaskProgress{ChecklistDone: 1, ChecklistTotal: 2, Percent: 50}, newTask.Progress)
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestCreateTask_InvalidParent() {
+	ctx := s.asUser("alice")
+	s.mockRepo.On("GetTaskById", ctx, 404).Return(domain.Task{}, domain.NewNotFound("no task found with id 404")).Once()
+	s.mockRepo.On("GetTaskById", ctx, 3).Return(domain.Task{ID: 3, CreatedBy: "bob"}, nil).Once()
+
+	s.ErrorIs(s.taskService.CreateTask(ctx, &domain.Task{Title: "Orphan", ParentID: 404}), domain.ErrInvalidParent)
+	s.ErrorIs(s.taskService.CreateTask(ctx, &domain.Task{Title: "Stowaway", ParentID: 3}), domain.ErrInvalidParent, "Someone else's task looks missing")
+	s.ErrorIs(s.taskService.CreateTask(ctx, &domain.Task{Title: "Bad", Checklist: []domain.ChecklistItem{{Text: " "}}}), domain.ErrInvalidChecklist)
+	s.mockRepo.AssertNotCalled(s.T(), "CreateTask", mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestQueryTasks_ProjectBoard() {
+	ctx := domain.WithProjectRole(s.asUser("vera"), "p1", domain.ProjectRoleViewer)
+	expectedQuery := domain.TaskQuery{SortBy: "id", Limit: domain.DefaultTaskPageSize, ProjectID: "p1"}
+	s.mockRepo.On("QueryTasks", ctx, expectedQuery).Return([]domain.Task{}, int64(0), nil).Once()
+
+	_, err := s.taskService.QueryTasks(ctx, domain.TaskQuery{ProjectID: "p1"})
+	s.Require().NoError(err, "Any role in the project shows the whole board")
+
+	_, err = s.taskService.QueryTasks(ctx, domain.TaskQuery{ProjectID: "p2"})
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "A role in one project says nothing about another")
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestCreateTask_InProject() {
+	ctx := domain.WithProjectRole(s.asUser("bob"), "p1", domain.ProjectRoleMember)
+	newTask := &domain.Task{Title: "Launch", ProjectID: "p1"}
+	s.mockRepo.On("CreateTask", ctx, newTask).Return(nil).Once()
+
+	s.Require().NoError(s.taskService.CreateTask(ctx, newTask))
+	s.Equal("p1", newTask.ProjectID)
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestCreateTask_InProjectCountsTheTask() {
+	projects := new(MockProjectRepository)
+	taskService := services.NewTaskService(s.mockRepo, projects, s.mockAudit, slog.New(slog.NewJSONHandler(s.logs, nil)))
+	ctx := domain.WithProjectRole(s.asUser("bob"), "p1", domain.ProjectRoleMember)
+	projects.On("AdjustTaskCount", ctx, "p1", 1).Return(nil).Once()
+	s.mockRepo.On("CreateTask", ctx, mock.Anything).Return(errors.New("disk full")).Once()
+	projects.On("AdjustTaskCount", mock.Anything, "p1", -1).Return(nil).Once()
+
+	s.Error(taskService.CreateTask(ctx, &domain.Task{Title: "Launch", ProjectID: "p1"}))
+	projects.AssertExpectations(s.T())
+
+	gone := domain.WithProjectRole(s.asUser("bob"), "p2", domain.ProjectRoleMember)
+	projects.On("AdjustTaskCount", gone, "p2", 1).Return(domain.NewNotFound("no project found with id p2")).Once()
+	err := taskService.CreateTask(gone, &domain.Task{Title: "Launch", ProjectID: "p2"})
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "A project deleted after the role check takes no new tasks")
+	s.mockRepo.AssertNumberOfCalls(s.T(), "CreateTask", 1)
+}
+
+func (s *TaskServiceSuite) TestCreateTask_InProjectRejected() {
+	viewer := domain.WithProjectRole(s.asUser("vera"), "p1", domain.ProjectRoleViewer)
+	s.ErrorIs(s.taskService.CreateTask(viewer, &domain.Task{Title: "Launch", ProjectID: "p1"}), domain.ErrForbidden)
+
+	err := s.taskService.CreateTask(s.asUser("bob"), &domain.Task{Title: "Launch", ProjectID: "p1"})
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "Without a role the project looks missing")
+	s.mockRepo.AssertNotCalled(s.T(), "CreateTask", mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestCreateTask_SubtaskFollowsParentProject() {
+	ctx := s.asUser("alice")
+	s.mockRepo.On("GetTaskById", ctx, 1).Return(domain.Task{ID: 1, CreatedBy: "alice", ProjectID: "p1"}, nil)
+	s.mockRepo.On("CreateTask", ctx, mock.Anything).Return(nil).Once()
+
+	child := &domain.Task{Title: "Child", ParentID: 1}
+	s.Require().NoError(s.taskService.CreateTask(ctx, child))
+	s.Equal("p1", child.ProjectID, "A subtask goes on its parent's board")
+
+	s.ErrorIs(s.taskService.CreateTask(ctx, &domain.Task{Title: "Elsewhere", ParentID: 1, ProjectID: "p2"}), domain.ErrInvalidParent)
+	s.mockRepo.AssertNumberOfCalls(s.T(), "CreateTask", 1)
+}
+
+func (s *TaskServiceSuite) TestCreateTask_SubtaskNeedsProjectMember() {
+	ctx := s.asUser("vera")
+	s.mockRepo.On("GetTaskById", ctx, 1).Return(domain.Task{ID: 1, CreatedBy: "vera", ProjectID: "p1"}, nil).Once()
+
+	s.ErrorIs(s.taskService.CreateTask(ctx, &domain.Task{Title: "Child", ParentID: 1}), domain.ErrForbidden,
+		"A viewer cannot add to the board, not even under a task of their own")
+	s.mockRepo.AssertNotCalled(s.T(), "CreateTask", mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestGetTaskById_ProjectMembersSeeBoardTasks() {
+	board := domain.Task{ID: 9, Version: 1, CreatedBy: "alice", ProjectID: "p1"}
+	for _, username := range []string{"vera", "mia"} {
+		ctx := s.asUser(username)
+		s.mockRepo.On("GetTaskById", ctx, 9).Return(board, nil).Once()
+
+		task, err := s.taskService.GetTaskById(ctx, 9)
+		s.Require().NoError(err, username)
+		s.Equal(9, task.ID)
+	}
+
+	ctx := s.asUser("carol")
+	s.mockRepo.On("GetTaskById", ctx, 9).Return(board, nil).Once()
+	_, err := s.taskService.GetTaskById(ctx, 9)
+	s.Equal(domain.KindNotFound, domain.KindOf(err), "Outsiders still cannot see the board's tasks")
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_ProjectRoles() {
+	board := domain.Task{ID: 9, Version: 1, CreatedBy: "alice", ProjectID: "p1", Status: domain.StatusTodo}
+	viewer := s.asUser("vera")
+	s.mockRepo.On("GetTaskById", viewer, 9).Return(board, nil).Once()
+	s.ErrorIs(s.taskService.UpdateTask(viewer, 9, 1, &domain.Task{Title: "Renamed"}), domain.ErrForbidden, "Viewers only read the board")
+
+	member := s.asUser("mia")
+	update := &domain.Task{Title: "Renamed"}
+	s.mockRepo.On("GetTaskById", member, 9).Return(board, nil).Once()
+	s.mockRepo.On("UpdateTask", member, 9, int64(1), update).Return(nil).Once()
+	s.Require().NoError(s.taskService.UpdateTask(member, 9, 1, update), "Members work on every task of the board")
+	s.mockRepo.AssertNumberOfCalls(s.T(), "UpdateTask", 1)
+}
+
+func (s *TaskServiceSuite) TestListSubtasks_ProjectViewer() {
+	ctx := s.asUser("vera")
+	s.mockRepo.On("GetTaskById", ctx, 9).Return(domain.Task{ID: 9, CreatedBy: "alice", ProjectID: "p1"}, nil).Once()
+	expectedQuery := domain.TaskQuery{ParentID: 9, SortBy: "id", Limit: domain.DefaultTaskPageSize}
+	s.mockRepo.On("QueryTasks", ctx, expectedQuery).Return([]domain.Task{{ID: 10, ParentID: 9, ProjectID: "p1"}}, int64(1), nil).Once()
+
+	page, err := s.taskService.ListSubtasks(ctx, 9, domain.TaskQuery{})
+	s.Require().NoError(err)
+	s.Len(page.Tasks, 1, "A role in the project shows every subtask, not only the caller's own")
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestDependencyGraph_ProjectViewer() {
+	ctx := s.asUser("vera")
+	s.mockRepo.On("GetTaskById", ctx, 9).Return(domain.Task{ID: 9, Title: "Launch", CreatedBy: "alice", ProjectID: "p1", BlockedBy: []int{8}}, nil).Once()
+	s.expectLookup(ctx, domain.TaskQuery{IDs: []int{8}}, domain.Task{ID: 8, Title: "Build", CreatedBy: "mia", ProjectID: "p1"})
+	s.expectLookup(ctx, domain.TaskQuery{WaitingOn: []int{9}})
+
+	graph, err := s.taskService.DependencyGraph(ctx, 9)
+	s.Require().NoError(err)
+	s.Equal([]domain.DependencyNode{{ID: 8, Title: "Build"}}, graph.Upstream, "Board tasks are not hidden from the board's members")
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_LeavesParentAndChecklistAlone() {
+	update := &domain.Task{Title: "Renamed", ParentID: 9, Checklist: []domain.ChecklistItem{{ID: 1, Text: "Sneaky"}}}
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 1}, nil).Once()
+	s.mockRepo.On("UpdateTask", s.ctx, 1, int64(1), &domain.Task{Title: "Renamed"}).Return(nil).Once()
+
+	s.Require().NoError(s.taskService.UpdateTask(s.ctx, 1, 1, update))
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestDeleteTaskById_OpenSubtasks() {
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 1}, nil).Once()
+	s.noSubtasks.Unset()
+	s.mockRepo.On("CountSubtasks", s.ctx, []int{1}).Return(map[int]domain.SubtaskCount{1: {Total: 3, Closed: 1}}, nil).Once()
+
+	err := s.taskService.DeleteTaskById(s.ctx, 1, 1, false)
+	s.ErrorIs(err, domain.ErrTaskHasOpenSubtasks)
+	s.Contains(err.Error(), "2 open subtask(s)")
+	s.mockRepo.AssertNotCalled(s.T(), "DeleteTaskById", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestDeleteTaskById_ClosedSubtasksGoToTheTrashToo() {
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 1}, nil).Once()
+	s.noSubtasks.Unset()
+	s.mockRepo.On("CountSubtasks", s.ctx, []int{1}).Return(map[int]domain.SubtaskCount{1: {Total: 2, Closed: 2}}, nil).Once()
+	s.expectSubtaskListing(s.ctx, map[int][]domain.Task{
+		1: {{ID: 2, ParentID: 1, Version: 1, Status: domain.StatusDone}, {ID: 3, ParentID: 1, Version: 2, Status: domain.StatusArchived}},
+		2: nil,
+		3: nil,
+	})
+	s.mockRepo.On("DeleteTaskById", s.ctx, 3, int64(2)).Return(nil).Once()
+	s.mockRepo.On("DeleteTaskById", s.ctx, 2, int64(1)).Return(nil).Once()
+	s.mockRepo.On("DeleteTaskById", s.ctx, 1, int64(1)).Return(nil).Once()
+
+	s.Require().NoError(s.taskService.DeleteTaskById(s.ctx, 1, 1, false))
+	s.mockRepo.AssertExpectations(s.T())
+	s.Equal([]string{"3", "2", "1"}, s.auditedTargets(), "Closed subtasks are not left live under a trashed parent")
+}
+
+func (s *TaskServiceSuite) TestDeleteTaskById_OpenSubtaskBelowClosedOne() {
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 1}, nil).Once()
+	s.noSubtasks.Unset()
+	s.mockRepo.On("CountSubtasks", s.ctx, []int{1}).Return(map[int]domain.SubtaskCount{1: {Total: 1, Closed: 1}}, nil).Once()
+	s.expectSubtaskListing(s.ctx, map[int][]domain.Task{
+		1: {{ID: 2, ParentID: 1, Version: 1, Status: domain.StatusDone}},
+		2: {{ID: 5, ParentID: 2, Version: 1, Status: domain.StatusTodo}},
+		5: nil,
+	})
+
+	s.ErrorIs(s.taskService.DeleteTaskById(s.ctx, 1, 1, false), domain.ErrTaskHasOpenSubtasks)
+	s.mockRepo.AssertNotCalled(s.T(), "DeleteTaskById", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// stubs the subtask listing the cascade walks: children maps a parent id to its live subtasks
+func (s *TaskServiceSuite) expectSubtaskListing(ctx context.Context, children map[int][]domain.Task) {
+	for parent, tasks := range children {
+		query := domain.TaskQuery{ParentID: parent, SortBy: "id", Limit: domain.MaxTaskPageSize}
+		s.mockRepo.On("QueryTasks", ctx, query).Return(tasks, int64(len(tasks)), nil).Once()
+	}
+}
+
+func (s *TaskServiceSuite) TestDeleteTaskById_Cascade() {
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 4}, nil).Once()
+	s.expectSubtaskListing(s.ctx, map[int][]domain.Task{
+		1: {{ID: 2, ParentID: 1, Version: 3}},
+		2: {{ID: 5, ParentID: 2, Version: 1, Status: domain.StatusDone}},
+		5: nil,
+	})
+	s.mockRepo.On("DeleteTaskById", s.ctx, 1, int64(4)).Return(nil).Once()
+	s.mockRepo.On("DeleteTaskById", s.ctx, 2, int64(3)).Return(nil).Once()
+	s.mockRepo.On("DeleteTaskById", s.ctx, 5, int64(1)).Return(nil).Once()
+
+	s.Require().NoError(s.taskService.DeleteTaskById(s.ctx, 1, 4, true))
+	s.mockRepo.AssertExpectations(s.T())
+	s.Equal([]string{"5", "2", "1"}, s.auditedTargets(), "Each deleted task gets its own audit entry, deepest first")
+}
+
+// the targets of the task deletions audited so far, in order
+func (s *TaskServiceSuite) auditedTargets() []string {
+	var deleted []string
+	for _, entry := range s.mockAudit.appended() {
+		s.Equal(domain.AuditTaskDelete, entry.Action)
+		deleted = append(deleted, entry.TargetID)
+	}
+	return deleted
+}
+
+func (s *TaskServiceSuite) TestDeleteTaskById_CascadeFailureKeepsParent() {
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 1}, nil).Once()
+	s.expectSubtaskListing(s.ctx, map[int][]domain.Task{
+		1: {{ID: 2, ParentID: 1, Version: 1}, {ID: 3, ParentID: 1, Version: 1}},
+		2: nil,
+		3: nil,
+	})
+	s.mockRepo.On("DeleteTaskById", s.ctx, 3, int64(1)).Return(nil).Once()
+	s.mockRepo.On("DeleteTaskById", s.ctx, 2, int64(1)).Return(errors.New("db down")).Once()
+
+	s.Error(s.taskService.DeleteTaskById(s.ctx, 1, 1, true))
+	s.mockRepo.AssertNotCalled(s.T(), "DeleteTaskById", mock.Anything, 1, mock.Anything)
+	s.Equal([]string{"3"}, s.auditedTargets(), "The parent stays live while a subtask below it does")
+}
+
+func (s *TaskServiceSuite) TestDeleteTaskById_CascadeReloadsChangedSubtask() {
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 1}, nil).Once()
+	s.expectSubtaskListing(s.ctx, map[int][]domain.Task{1: {{ID: 2, ParentID: 1, Version: 1}}, 2: nil})
+	s.mockRepo.On("DeleteTaskById", s.ctx, 1, int64(1)).Return(nil).Once()
+	s.mockRepo.On("DeleteTaskById", s.ctx, 2, int64(1)).Return(domain.ErrTaskVersionMismatch).Once()
+	s.mockRepo.On("GetTaskById", s.ctx, 2).Return(domain.Task{ID: 2, ParentID: 1, Version: 2}, nil).Once()
+	s.mockRepo.On("DeleteTaskById", s.ctx, 2, int64(2)).Return(nil).Once()
+
+	s.Require().NoError(s.taskService.DeleteTaskById(s.ctx, 1, 1, true))
+	s.mockRepo.AssertExpectations(s.T())
+	s.Len(s.mockAudit.appended(), 2)
+}
+
+func (s *TaskServiceSuite) TestDeleteTaskById_CascadeOtherUsersSubtask() {
+	ctx := s.asUser("alice")
+	s.mockRepo.On("GetTaskById", ctx, 1).Return(domain.Task{ID: 1, CreatedBy: "alice", Version: 1}, nil).Once()
+	s.expectSubtaskListing(ctx, map[int][]domain.Task{1: {{ID: 2, ParentID: 1, CreatedBy: "bob", Version: 1}}, 2: nil})
+
+	s.ErrorIs(s.taskService.DeleteTaskById(ctx, 1, 1, true), domain.ErrForbidden)
+	s.mockRepo.AssertNotCalled(s.T(), "DeleteTaskById", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestListSubtasks() {
+	ctx := s.asUser("alice")
+	s.mockRepo.On("GetTaskById", ctx, 1).Return(domain.Task{ID: 1, CreatedBy: "alice"}, nil).Once()
+	expectedQuery := domain.TaskQuery{ParentID: 1, VisibleTo: "alice", SortBy: "id", Limit: domain.DefaultTaskPageSize}
+	s.mockRepo.On("QueryTasks", ctx, expectedQuery).Return([]domain.Task{{ID: 2, ParentID: 1}}, int64(1), nil).Once()
+
+	page, err := s.taskService.ListSubtasks(ctx, 1, domain.TaskQuery{})
+	s.Require().NoError(err)
+	s.Require().Len(page.Tasks, 1)
+	s.NotNil(page.Tasks[0].Progress)
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestListSubtasks_InvisibleParent() {
+	ctx := s.asUser("alice")
+	s.mockRepo.On("GetTaskById", ctx, 3).Return(domain.Task{ID: 3, CreatedBy: "bob"}, nil).Once()
+
+	_, err := s.taskService.ListSubtasks(ctx, 3, domain.TaskQuery{})
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+	s.mockRepo.AssertNotCalled(s.T(), "QueryTasks", mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestAddChecklistItem() {
+	current := domain.Task{ID: 1, Version: 2, Checklist: []domain.ChecklistItem{{ID: 1, Text: "Draft", Done: true}}}
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(current, nil).Once()
+	checklist := []domain.ChecklistItem{{ID: 1, Text: "Draft", Done: true}, {ID: 2, Text: "Review"}}
+	s.mockRepo.On("UpdateTask", s.ctx, 1, int64(2), &domain.Task{Checklist: checklist}).Run(func(args mock.Arguments) {
+		args.Get(3).(*domain.Task).Version = 3
+	}).Return(nil).Once()
+
+	task, err := s.taskService.AddChecklistItem(s.ctx, 1, "Review")
+	s.Require().NoError(err)
+	s.Equal(checklist, task.Checklist)
+	s.EqualValues(3, task.Version)
+	s.Equal(&domain.TaskProgress{ChecklistDone: 1, ChecklistTotal: 2, Percent: 50}, task.Progress)
+
+	audited := s.mockAudit.appended()
+	s.Require().Len(audited, 1)
+	s.Equal([]domain.FieldChange{{Field: "checklist", Before: "[x] Draft", After: "[x] Draft\n[ ] Review"}}, audited[0].Changes)
+}
+
+func (s *TaskServiceSuite) TestSetChecklistItemDone() {
+	current := domain.Task{ID: 1, Version: 1, Checklist: []domain.ChecklistItem{{ID: 1, Text: "Draft"}}}
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(current, nil)
+	s.mockRepo.On("UpdateTask", s.ctx, 1, int64(1), &domain.Task{Checklist: []domain.ChecklistItem{{ID: 1, Text: "Draft", Done: true}}}).Return(nil).Once()
+
+	task, err := s.taskService.SetChecklistItemDone(s.ctx, 1, 1, true)
+	s.Require().NoError(err)
+	s.True(task.Checklist[0].Done)
+
+	_, err = s.taskService.SetChecklistItemDone(s.ctx, 1, 1, false)
+	s.Require().NoError(err, "Unticking an unticked item is not an error")
+	s.mockRepo.AssertNumberOfCalls(s.T(), "UpdateTask", 1)
+
+	_, err = s.taskService.SetChecklistItemDone(s.ctx, 1, 9, true)
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+}
+
+func (s *TaskServiceSuite) TestRemoveChecklistItem_LastItemClearsChecklist() {
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 1, Checklist: []domain.ChecklistItem{{ID: 1, Text: "Only"}}}, nil).Once()
+	s.mockRepo.On("UpdateTask", s.ctx, 1, int64(1), &domain.Task{Checklist: []domain.ChecklistItem{}}).Return(nil).Once()
+
+	task, err := s.taskService.RemoveChecklistItem(s.ctx, 1, 1)
+	s.Require().NoError(err)
+	s.Empty(task.Checklist)
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestReorderChecklist() {
+	current := domain.Task{ID: 1, Version: 1, Checklist: []domain.ChecklistItem{{ID: 1, Text: "One"}, {ID: 2, Text: "Two"}}}
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(current, nil)
+	s.mockRepo.On("UpdateTask", s.ctx, 1, int64(1), &domain.Task{Checklist: []domain.ChecklistItem{{ID: 2, Text: "Two"}, {ID: 1, Text: "One"}}}).Return(nil).Once()
+
+	task, err := s.taskService.ReorderChecklist(s.ctx, 1, []int{2, 1})
+	s.Require().NoError(err)
+	s.Equal(2, task.Checklist[0].ID)
+
+	_, err = s.taskService.ReorderChecklist(s.ctx, 1, []int{2})
+	s.ErrorIs(err, domain.ErrInvalidChecklist)
+	s.mockRepo.AssertNumberOfCalls(s.T(), "UpdateTask", 1)
+}
+
+func (s *TaskServiceSuite) TestChecklistEdit_OtherUsersTask() {
+	ctx := s.asUser("alice")
+	s.mockRepo.On("GetTaskById", ctx, 3).Return(domain.Task{ID: 3, CreatedBy: "bob"}, nil).Once()
+
+	_, err := s.taskService.AddChecklistItem(ctx, 3, "Mine now")
+	s.ErrorIs(err, domain.ErrForbidden)
+	s.mockRepo.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// stubs one read of the tasks matching query, as the service makes it when it needs every match
+func (s *TaskServiceSuite) expectLookup(ctx context.Context, query domain.TaskQuery, tasks ...domain.Task) {
+	query.SortBy, query.Limit = "id", domain.MaxTaskPageSize
+	s.mockRepo.On("QueryTasks", ctx, query).Return(tasks, int64(len(tasks)), nil).Once()
+}
+
+func (s *TaskServiceSuite) TestCreateTask_WithBlockers() {
+	ctx := s.asUser("alice")
+	s.expectLookup(ctx, domain.TaskQuery{IDs: []int{2, 5}}, domain.Task{ID: 2, CreatedBy: "alice"}, domain.Task{ID: 5, AssignedTo: "alice"})
+	newTask := &domain.Task{Title: "Deploy", BlockedBy: []int{5, 2, 5}}
+	s.mockRepo.On("CreateTask", ctx, newTask).Return(nil).Once()
+
+	s.Require().NoError(s.taskService.CreateTask(ctx, newTask))
+	s.Equal([]int{2, 5}, newTask.BlockedBy)
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestCreateTask_InvalidBlockers() {
+	ctx := s.asUser("alice")
+	s.expectLookup(ctx, domain.TaskQuery{IDs: []int{2, 404}}, domain.Task{ID: 2, CreatedBy: "alice"})
+	s.expectLookup(ctx, domain.TaskQuery{IDs: []int{3}}, domain.Task{ID: 3, CreatedBy: "bob"})
+
+	s.ErrorIs(s.taskService.CreateTask(ctx, &domain.Task{Title: "A", BlockedBy: []int{2, 404}}), domain.ErrInvalidDependency)
+	s.ErrorIs(s.taskService.CreateTask(ctx, &domain.Task{Title: "B", BlockedBy: []int{3}}), domain.ErrInvalidDependency, "Someone else's task looks missing")
+	s.ErrorIs(s.taskService.CreateTask(ctx, &domain.Task{Title: "C", BlockedBy: []int{-1}}), domain.ErrInvalidDependency)
+	s.mockRepo.AssertNotCalled(s.T(), "CreateTask", mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_DoneWhileBlocked() {
+	s.mockRepo.On("GetTaskById", s.ctx, 3).Return(domain.Task{ID: 3, Version: 1, Status: domain.StatusInProgress, BlockedBy: []int{1, 2}}, nil).Once()
+	s.expectLookup(s.ctx, domain.TaskQuery{IDs: []int{1, 2}}, domain.Task{ID: 1, Status: domain.StatusDone}, domain.Task{ID: 2, Status: domain.StatusBlocked})
+
+	err := s.taskService.UpdateTask(s.ctx, 3, 1, &domain.Task{Status: domain.StatusDone})
+	s.ErrorIs(err, domain.ErrTaskBlocked)
+	s.Contains(err.Error(), "open task(s) [2]")
+	s.mockRepo.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_DoneOnceBlockersClosed() {
+	s.mockRepo.On("GetTaskById", s.ctx, 3).Return(domain.Task{ID: 3, Version: 1, Status: domain.StatusInProgress, BlockedBy: []int{1, 2, 9}}, nil).Once()
+	// 9 was deleted, so the lookup leaves it out
+	s.expectLookup(s.ctx, domain.TaskQuery{IDs: []int{1, 2, 9}}, domain.Task{ID: 1, Status: domain.StatusDone}, domain.Task{ID: 2, Status: domain.StatusArchived})
+	update := &domain.Task{Status: domain.StatusDone, BlockedBy: []int{}}
+	s.mockRepo.On("UpdateTask", s.ctx, 3, int64(1), &domain.Task{Status: domain.StatusDone}).Return(nil).Once()
+
+	s.Require().NoError(s.taskService.UpdateTask(s.ctx, 3, 1, update))
+	s.Nil(update.BlockedBy, "Blockers are only changed through their own operations")
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestAddDependencies() {
+	s.mockRepo.On("GetTaskById", s.ctx, 3).Return(domain.Task{ID: 3, Version: 2, BlockedBy: []int{1}}, nil).Once()
+	s.expectLookup(s.ctx, domain.TaskQuery{IDs: []int{2}}, domain.Task{ID: 2, BlockedBy: []int{4}})
+	s.expectLookup(s.ctx, domain.TaskQuery{IDs: []int{4}}, domain.Task{ID: 4})
+	s.mockRepo.On("UpdateTask", s.ctx, 3, int64(2), &domain.Task{BlockedBy: []int{1, 2}}).Return(nil).Once()
+
+	task, err := s.taskService.AddDependencies(s.ctx, 3, []int{2, 1})
+	s.Require().NoError(err)
+	s.Equal([]int{1, 2}, task.BlockedBy)
+	s.mockRepo.AssertExpectations(s.T())
+
+	audited := s.mockAudit.appended()
+	s.Require().Len(audited, 1)
+	s.Equal([]domain.FieldChange{{Field: "blockedby", Before: "1", After: "1,2"}}, audited[0].Changes)
+}
+
+func (s *TaskServiceSuite) TestAddDependencies_Cycle() {
+	s.mockRepo.On("GetTaskById", s.ctx, 1).Return(domain.Task{ID: 1, Version: 1}, nil).Once()
+	s.expectLookup(s.ctx, domain.TaskQuery{IDs: []int{2}}, domain.Task{ID: 2, BlockedBy: []int{3}})
+	s.expectLookup(s.ctx, domain.TaskQuery{IDs: []int{3}}, domain.Task{ID: 3, BlockedBy: []int{1}})
+	s.expectLookup(s.ctx, domain.TaskQuery{IDs: []int{1}}, domain.Task{ID: 1})
+
+	_, err := s.taskService.AddDependencies(s.ctx, 1, []int{2})
+	s.ErrorIs(err, domain.ErrDependencyCycle)
+	s.mockRepo.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestAddDependencies_InvalidBlockers() {
+	ctx := s.asUser("alice")
+	s.mockRepo.On("GetTaskById", ctx, 3).Return(domain.Task{ID: 3, CreatedBy: "alice", Version: 1}, nil)
+	s.expectLookup(ctx, domain.TaskQuery{IDs: []int{5}}, domain.Task{ID: 5, CreatedBy: "bob"})
+
+	_, err := s.taskService.AddDependencies(ctx, 3, []int{5})
+	s.ErrorIs(err, domain.ErrInvalidDependency, "Someone else's task looks missing")
+	_, err = s.taskService.AddDependencies(ctx, 3, []int{3})
+	s.ErrorIs(err, domain.ErrInvalidDependency, "A task cannot block itself")
+	s.mockRepo.AssertNotCalled(s.T(), "UpdateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestRemoveDependencies() {
+	s.mockRepo.On("GetTaskById", s.ctx, 3).Return(domain.Task{ID: 3, Version: 1, BlockedBy: []int{1}}, nil)
+	s.mockRepo.On("UpdateTask", s.ctx, 3, int64(1), &domain.Task{BlockedBy: []int{}}).Return(nil).Once()
+
+	task, err := s.taskService.RemoveDependencies(s.ctx, 3, []int{1, 7})
+	s.Require().NoError(err)
+	s.Empty(task.BlockedBy)
+
+	_, err = s.taskService.RemoveDependencies(s.ctx, 3, []int{7})
+	s.Require().NoError(err, "Removing a blocker the task does not have is not an error")
+	s.mockRepo.AssertNumberOfCalls(s.T(), "UpdateTask", 1)
+}
+
+func (s *TaskServiceSuite) TestDependencyGraph() {
+	ctx := s.asUser("alice")
+	s.mockRepo.On("GetTaskById", ctx, 3).Return(domain.Task{ID: 3, Title: "Release", CreatedBy: "alice", BlockedBy: []int{1, 2}}, nil).Once()
+	s.expectLookup(ctx, domain.TaskQuery{IDs: []int{1, 2}},
+		domain.Task{ID: 1, Title: "Build", Status: domain.StatusDone, CreatedBy: "alice"},
+		domain.Task{ID: 2, Title: "Secret", CreatedBy: "bob", BlockedBy: []int{1}})
+	s.expectLookup(ctx, domain.TaskQuery{WaitingOn: []int{3}}, domain.Task{ID: 4, Title: "Announce", AssignedTo: "alice", BlockedBy: []int{3, 2}})
+	s.expectLookup(ctx, domain.TaskQuery{WaitingOn: []int{4}})
+
+	graph, err := s.taskService.DependencyGraph(ctx, 3)
+	s.Require().NoError(err)
+	s.Equal(domain.DependencyGraph{
+		Task:       domain.DependencyNode{ID: 3, Title: "Release"},
+		Upstream:   []domain.DependencyNode{{ID: 1, Title: "Build", Status: domain.StatusDone}, {ID: 2, Hidden: true}},
+		Downstream: []domain.DependencyNode{{ID: 4, Title: "Announce"}},
+		Edges: []domain.DependencyEdge{
+			{Blocker: 1, Blocked: 2},
+			{Blocker: 1, Blocked: 3}, {Blocker: 2, Blocked: 3},
+			{Blocker: 3, Blocked: 4}, {Blocker: 2, Blocked: 4},
+		},
+	}, graph)
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestDependencyGraph_InvisibleTask() {
+	ctx := s.asUser("alice")
+	s.mockRepo.On("GetTaskById", ctx, 3).Return(domain.Task{ID: 3, CreatedBy: "bob"}, nil).Once()
+
+	_, err := s.taskService.DependencyGraph(ctx, 3)
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+	s.mockRepo.AssertNotCalled(s.T(), "QueryTasks", mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestCreateTask_Recurring() {
+	due := time.Date(2025, 7, 7, 9, 0, 0, 0, time.UTC)
+	newTask := &domain.Task{Title: "Ops review", DueDate: due, Recurrence: &domain.Recurrence{Frequency: "Weekly", ByWeekday: []string{"mo"}, Occurrence: 9}}
+	s.mockRepo.On("CreateTask", s.ctx, newTask).Return(nil).Once()
+
+	s.Require().NoError(s.taskService.CreateTask(s.ctx, newTask))
+	s.Equal(&domain.Recurrence{Frequency: domain.FrequencyWeekly, Interval: 1, ByWeekday: []string{"MO"}, Occurrence: 1}, newTask.Recurrence)
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestCreateTask_InvalidRecurrence() {
+	weekly := domain.Recurrence{Frequency: domain.FrequencyWeekly}
+	s.ErrorIs(s.taskService.CreateTask(s.ctx, &domain.Task{Title: "No due date", Recurrence: &weekly}), domain.ErrInvalidRecurrence)
+	s.ErrorIs(s.taskService.CreateTask(s.ctx, &domain.Task{Title: "Yearly", DueDate: time.Now(), Recurrence: &domain.Recurrence{Frequency: "yearly"}}), domain.ErrInvalidRecurrence)
+	s.mockRepo.AssertNotCalled(s.T(), "CreateTask", mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_DoneCreatesNextOccurrence() {
+	due := time.Date(2025, 7, 7, 9, 0, 0, 0, time.UTC)
+	current := domain.Task{
+		ID: 4, Version: 2, Title: "Ops review", DueDate: due, Status: domain.StatusInProgress, CreatedBy: "alice",
+		Checklist:  []domain.ChecklistItem{{ID: 1, Text: "Check backups", Done: true}},
+		Recurrence: &domain.Recurrence{Frequency: domain.FrequencyWeekly, Interval: 1, Occurrence: 1},
+	}
+	s.mockRepo.On("GetTaskById", s.ctx, 4).Return(current, nil).Once()
+	s.mockRepo.On("UpdateTask", s.ctx, 4, int64(2), &domain.Task{Status: domain.StatusDone}).Run(func(args mock.Arguments) {
+		args.Get(3).(*domain.Task).Version = 3
+	}).Return(nil).Once()
+	// the rule leaves the finished task only once its successor is stored
+	s.mockRepo.On("UpdateTask", mock.Anything, 4, int64(3), &domain.Task{Recurrence: &domain.Recurrence{}}).Run(func(args mock.Arguments) {
+		args.Get(3).(*domain.Task).Version = 4
+	}).Return(nil).Once()
+	next := &domain.Task{
+		Title: "Ops review", DueDate: due.AddDate(0, 0, 7), Status: domain.StatusTodo, CreatedBy: "alice",
+		Checklist:  []domain.ChecklistItem{{ID: 1, Text: "Check backups"}},
+		Recurrence: &domain.Recurrence{Frequency: domain.FrequencyWeekly, Interval: 1, Occurrence: 2},
+	}
+	s.mockRepo.On("CreateTask", mock.Anything, next).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.Task).ID = 5
+	}).Return(nil).Once()
+
+	update := &domain.Task{Status: domain.StatusDone}
+	s.Require().NoError(s.taskService.UpdateTask(s.ctx, 4, 2, update))
+	s.mockRepo.AssertExpectations(s.T())
+	s.Equal(int64(4), update.Version, "The caller gets the version after the rule was cleared")
+
+	audited := s.mockAudit.appended()
+	s.Require().Len(audited, 2)
+	s.Equal([]domain.FieldChange{
+		{Field: "status", Before: "in_progress", After: "done"},
+		{Field: "recurrence", Before: "FREQ=WEEKLY;INTERVAL=1", After: ""},
+	}, audited[0].Changes, "The rule moves on to the next occurrence")
+	s.Equal(domain.AuditTaskCreate, audited[1].Action)
+	s.Equal("5", audited[1].TargetID)
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_NextOccurrenceFailureKeepsRule() {
+	due := time.Date(2025, 7, 7, 9, 0, 0, 0, time.UTC)
+	current := domain.Task{ID: 4, Version: 2, DueDate: due, Status: domain.StatusInProgress,
+		Recurrence: &domain.Recurrence{Frequency: domain.FrequencyWeekly, Interval: 1, Occurrence: 1}}
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.mockRepo.On("GetTaskById", ctx, 4).Return(current, nil).Once()
+	s.mockRepo.On("UpdateTask", ctx, 4, int64(2), &domain.Task{Status: domain.StatusDone}).Run(func(args mock.Arguments) {
+		cancel() // the client goes away once the task is closed
+	}).Return(nil).Once()
+	createFailure := errors.New("database unavailable")
+	s.mockRepo.On("CreateTask", mock.MatchedBy(func(c context.Context) bool { return c.Err() == nil }), mock.Anything).Return(createFailure).Once()
+
+	err := s.taskService.UpdateTask(ctx, 4, 2, &domain.Task{Status: domain.StatusDone})
+	s.ErrorIs(err, createFailure)
+	s.mockRepo.AssertExpectations(s.T())
+	s.mockRepo.AssertNumberOfCalls(s.T(), "UpdateTask", 1)
+
+	audited := s.mockAudit.appended()
+	s.Require().Len(audited, 1)
+	s.Equal([]domain.FieldChange{{Field: "status", Before: "in_progress", After: "done"}}, audited[0].Changes, "The rule stays so the series can be retried")
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_DoneEndsSeries() {
+	due := time.Date(2025, 7, 7, 9, 0, 0, 0, time.UTC)
+	current := domain.Task{ID: 4, Version: 1, DueDate: due, Status: domain.StatusInProgress,
+		Recurrence: &domain.Recurrence{Frequency: domain.FrequencyDaily, Interval: 1, Count: 3, Occurrence: 3}}
+	s.mockRepo.On("GetTaskById", s.ctx, 4).Return(current, nil).Once()
+	s.mockRepo.On("UpdateTask", s.ctx, 4, int64(1), &domain.Task{Status: domain.StatusDone, Recurrence: &domain.Recurrence{}}).Return(nil).Once()
+
+	s.Require().NoError(s.taskService.UpdateTask(s.ctx, 4, 1, &domain.Task{Status: domain.StatusDone}))
+	s.mockRepo.AssertNotCalled(s.T(), "CreateTask", mock.Anything, mock.Anything)
+}
+
+func (s *TaskServiceSuite) TestUpdateTask_ReplacesRecurrence() {
+	current := domain.Task{ID: 4, Version: 1, DueDate: time.Now(), Recurrence: &domain.Recurrence{Frequency: domain.FrequencyDaily, Interval: 1, Occurrence: 3}}
+	s.mockRepo.On("GetTaskById", s.ctx, 4).Return(current, nil).Once()
+	s.mockRepo.On("UpdateTask", s.ctx, 4, int64(1), &domain.Task{Recurrence: &domain.Recurrence{Frequency: domain.FrequencyMonthly, Interval: 1, Occurrence: 3}}).Return(nil).Once()
+
+	s.Require().NoError(s.taskService.UpdateTask(s.ctx, 4, 1, &domain.Task{Recurrence: &domain.Recurrence{Frequency: "monthly"}}))
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *TaskServiceSuite) TestPreviewOccurrences() {
+	ctx := s.asUser("alice")
+	due := time.Date(2025, 7, 7, 9, 0, 0, 0, time.UTC)
+	s.mockRepo.On("GetTaskById", ctx, 4).Return(domain.Task{ID: 4, CreatedBy: "alice", DueDate: due,
+		Recurrence: &domain.Recurrence{Frequency: domain.FrequencyMonthly, Interval: 1, Occurrence: 1}}, nil).Once()
+	s.mockRepo.On("GetTaskById", ctx, 5).Return(domain.Task{ID: 5, CreatedBy: "bob"}, nil).Once()
+
+	occurrences, err := s.taskService.PreviewOccurrences(ctx, 4, 0)
+	s.Require().NoError(err)
+	s.Len(occurrences, domain.DefaultOccurrencePreview)
+	s.Equal(domain.Occurrence{Occurrence: 5, DueDate: time.Date(2025, 11, 7, 9, 0, 0, 0, time.UTC)}, occurrences[4])
+
+	_, err = s.taskService.PreviewOccurrences(ctx, 5, 3)
+	s.Equal(domain.KindNotFound, domain.KindOf(err))
+	_, err = s.taskService.PreviewOccurrences(ctx, 4, domain.MaxOccurrencePreview+1)
+	s.Equal(domain.KindBadRequest, domain.KindOf(err))
+}
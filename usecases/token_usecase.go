@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+)
+
+// signs short-lived access tokens; infrastructure.JwtToken is the implementation
+type AccessTokenSigner interface {
+	GenerateToken(user *domain.User) (string, error)
+}
+
+type TokenService interface {
+	IssueTokens(ctx context.Context, user *domain.User) (domain.TokenPair, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (domain.TokenPair, error)
+	Logout(ctx context.Context, refreshToken, accessJTI string, accessExpiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+type tokenService struct {
+	tokenRepo  interfaces.TokenRepository
+	userRepo   interfaces.UserRepository
+	signer     AccessTokenSigner
+	refreshTTL time.Duration
+	logger     *slog.Logger
+}
+
+func NewTokenService(repo interfaces.TokenRepository, ur interfaces.UserRepository, signer AccessTokenSigner, refreshTTL time.Duration, logger *slog.Logger) TokenService {
+	return &tokenService{
+		tokenRepo:  repo,
+		userRepo:   ur,
+		signer:     signer,
+		refreshTTL: refreshTTL,
+		logger:     logger,
+	}
+}
+
+// refresh tokens are only ever stored as this digest
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *tokenService) IssueTokens(ctx context.Context, user *domain.User) (domain.TokenPair, error) {
+	access, err := s.signer.GenerateToken(user)
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return domain.TokenPair{}, err
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(raw)
+
+	err = s.tokenRepo.StoreRefreshToken(ctx, domain.RefreshToken{
+		TokenHash: hashRefreshToken(refresh),
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(s.refreshTTL).UTC(),
+	})
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+	return domain.TokenPair{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+// trades a refresh token for a new pair; the old refresh token stops working. The new access token
+// carries the user's role as stored now, so a promotion shows up at the next refresh.
+func (s *tokenService) RefreshTokens(ctx context.Context, refreshToken string) (domain.TokenPair, error) {
+	if refreshToken == "" {
+		return domain.TokenPair{}, domain.ErrInvalidRefreshToken
+	}
+	stored, err := s.tokenRepo.ConsumeRefreshToken(ctx, hashRefreshToken(refreshToken))
+	if errors.Is(err, domain.ErrInvalidRefreshToken) {
+		// a spent token showing up again can mean it was stolen
+		s.logger.WarnContext(ctx, "refresh token rejected")
+	}
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+	role, err := s.userRepo.GetRole(ctx, stored.Username)
+	if domain.KindOf(err) == domain.KindNotFound {
+		return domain.TokenPair{}, domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+	return s.IssueTokens(ctx, &domain.User{Username: stored.Username, Role: role})
+}
+
+// revokes the caller's access token and, if given, their refresh token
+func (s *tokenService) Logout(ctx context.Context, refreshToken, accessJTI string, accessExpiresAt time.Time) error {
+	if refreshToken != "" {
+		_, err := s.tokenRepo.ConsumeRefreshToken(ctx, hashRefreshToken(refreshToken))
+		if err != nil && !errors.Is(err, domain.ErrInvalidRefreshToken) {
+			return err
+		}
+	}
+	if accessJTI != "" {
+		if err := s.tokenRepo.RevokeAccessToken(ctx, accessJTI, accessExpiresAt); err != nil {
+			return err
+		}
+	}
+	s.logger.InfoContext(ctx, "logged out", slog.Bool("refresh_token_revoked", refreshToken != ""))
+	return nil
+}
+
+func (s *tokenService) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return s.tokenRepo.IsAccessTokenRevoked(ctx, jti)
+}
@@ -0,0 +1,197 @@
+package services_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"task7/domain"
+	services "task7/usecases"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockTokenRepository) StoreRefreshToken(ctx context.Context, token domain.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) ConsumeRefreshToken(ctx context.Context, tokenHash string) (domain.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(domain.RefreshToken), args.Error(1)
+}
+
+func (m *MockTokenRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	args := m.Called(ctx, jti, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockAccessTokenSigner struct {
+	mock.Mock
+}
+
+func (m *MockAccessTokenSigner) GenerateToken(user *domain.User) (string, error) {
+	args := m.Called(user)
+	return args.String(0), args.Error(1)
+}
+
+type TokenServiceSuite struct {
+	suite.Suite
+	mockRepo     *MockTokenRepository
+	mockUsers    *MockUserRepository
+	mockSigner   *MockAccessTokenSigner
+	ctx          context.Context
+	tokenService services.TokenService
+}
+
+func (s *TokenServiceSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.mockRepo = new(MockTokenRepository)
+	s.mockUsers = new(MockUserRepository)
+	s.mockSigner = new(MockAccessTokenSigner)
+	s.tokenService = services.NewTokenService(s.mockRepo, s.mockUsers, s.mockSigner, 24*time.Hour, slog.New(slog.DiscardHandler))
+}
+
+func (s *TokenServiceSuite) TearDownTest() {
+	s.mockRepo.AssertExpectations(s.T())
+	s.mockUsers.AssertExpectations(s.T())
+	s.mockSigner.AssertExpectations(s.T())
+}
+
+func TestTokenServiceSuite(t *testing.T) {
+	suite.Run(t, new(TokenServiceSuite))
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *TokenServiceSuite) TestIssueTokens_StoresOnlyHash() {
+	user := &domain.User{Username: "alice", Role: "regular"}
+	s.mockSigner.On("GenerateToken", user).Return("access", nil).Once()
+
+	var stored domain.RefreshToken
+	s.mockRepo.On("StoreRefreshToken", s.ctx, mock.AnythingOfType("domain.RefreshToken")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(domain.RefreshToken) }).
+		Return(nil).Once()
+
+	tokens, err := s.tokenService.IssueTokens(s.ctx, user)
+	s.Require().NoError(err)
+	s.Equal("access", tokens.AccessToken)
+	s.NotEmpty(tokens.RefreshToken)
+	s.NotEqual(tokens.RefreshToken, stored.TokenHash, "The raw refresh token must never be stored")
+	s.Equal(sha256Hex(tokens.RefreshToken), stored.TokenHash)
+	s.Equal("alice", stored.Username)
+	s.WithinDuration(time.Now().Add(24*time.Hour), stored.ExpiresAt, 5*time.Second)
+}
+
+func (s *TokenServiceSuite) TestIssueTokens_SignerError() {
+	user := &domain.User{Username: "alice"}
+	s.mockSigner.On("GenerateToken", user).Return("", errors.New("signing failed")).Once()
+
+	_, err := s.tokenService.IssueTokens(s.ctx, user)
+	s.Error(err)
+	s.mockRepo.AssertNotCalled(s.T(), "StoreRefreshToken", mock.Anything, mock.Anything)
+}
+
+func (s *TokenServiceSuite) TestRefreshTokens_Rotates() {
+	stored := domain.RefreshToken{TokenHash: sha256Hex("old"), Username: "alice", ExpiresAt: time.Now().Add(time.Hour)}
+	s.mockRepo.On("ConsumeRefreshToken", s.ctx, sha256Hex("old")).Return(stored, nil).Once()
+	s.mockUsers.On("GetRole", s.ctx, "alice").Return("admin", nil).Once()
+	s.mockSigner.On("GenerateToken", &domain.User{Username: "alice", Role: "admin"}).Return("new-access", nil).Once()
+	s.mockRepo.On("StoreRefreshToken", s.ctx, mock.AnythingOfType("domain.RefreshToken")).Return(nil).Once()
+
+	tokens, err := s.tokenService.RefreshTokens(s.ctx, "old")
+	s.Require().NoError(err)
+	s.Equal("new-access", tokens.AccessToken)
+	s.NotEqual("old", tokens.RefreshToken, "A fresh refresh token is issued on every use")
+}
+
+func (s *TokenServiceSuite) TestRefreshTokens_PicksUpPromotion() {
+	user := &domain.User{Username: "bob", Role: "regular"}
+	s.mockSigner.On("GenerateToken", user).Return("regular-access", nil).Once()
+	var stored domain.RefreshToken
+	s.mockRepo.On("StoreRefreshToken", s.ctx, mock.AnythingOfType("domain.RefreshToken")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(domain.RefreshToken) }).
+		Return(nil)
+	tokens, err := s.tokenService.IssueTokens(s.ctx, user)
+	s.Require().NoError(err)
+
+	// promoted through PUT /promote after logging in
+	s.mockRepo.On("ConsumeRefreshToken", s.ctx, sha256Hex(tokens.RefreshToken)).Return(stored, nil).Once()
+	s.mockUsers.On("GetRole", s.ctx, "bob").Return("admin", nil).Once()
+	s.mockSigner.On("GenerateToken", &domain.User{Username: "bob", Role: "admin"}).Return("admin-access", nil).Once()
+
+	refreshed, err := s.tokenService.RefreshTokens(s.ctx, tokens.RefreshToken)
+	s.Require().NoError(err)
+	s.Equal("admin-access", refreshed.AccessToken, "The new access token carries the role the user has now")
+}
+
+func (s *TokenServiceSuite) TestRefreshTokens_UserGone() {
+	stored := domain.RefreshToken{TokenHash: sha256Hex("old"), Username: "ghost", ExpiresAt: time.Now().Add(time.Hour)}
+	s.mockRepo.On("ConsumeRefreshToken", s.ctx, sha256Hex("old")).Return(stored, nil).Once()
+	s.mockUsers.On("GetRole", s.ctx, "ghost").Return("", domain.NewNotFound("user not found")).Once()
+
+	_, err := s.tokenService.RefreshTokens(s.ctx, "old")
+	s.ErrorIs(err, domain.ErrInvalidRefreshToken)
+	s.mockSigner.AssertNotCalled(s.T(), "GenerateToken", mock.Anything)
+}
+
+func (s *TokenServiceSuite) TestRefreshTokens_Invalid() {
+	s.mockRepo.On("ConsumeRefreshToken", s.ctx, sha256Hex("spent")).Return(domain.RefreshToken{}, domain.ErrInvalidRefreshToken).Once()
+
+	_, err := s.tokenService.RefreshTokens(s.ctx, "spent")
+	s.ErrorIs(err, domain.ErrInvalidRefreshToken)
+	s.mockSigner.AssertNotCalled(s.T(), "GenerateToken", mock.Anything)
+}
+
+func (s *TokenServiceSuite) TestRefreshTokens_Empty() {
+	_, err := s.tokenService.RefreshTokens(s.ctx, "")
+	s.ErrorIs(err, domain.ErrInvalidRefreshToken)
+}
+
+func (s *TokenServiceSuite) TestLogout_RevokesBothTokens() {
+	expiresAt := time.Now().Add(10 * time.Minute)
+	s.mockRepo.On("ConsumeRefreshToken", s.ctx, sha256Hex("refresh")).Return(domain.RefreshToken{}, nil).Once()
+	s.mockRepo.On("RevokeAccessToken", s.ctx, "jti-1", expiresAt).Return(nil).Once()
+
+	s.NoError(s.tokenService.Logout(s.ctx, "refresh", "jti-1", expiresAt))
+}
+
+func (s *TokenServiceSuite) TestLogout_UnknownRefreshTokenStillRevokesAccess() {
+	expiresAt := time.Now().Add(10 * time.Minute)
+	s.mockRepo.On("ConsumeRefreshToken", s.ctx, sha256Hex("unknown")).Return(domain.RefreshToken{}, domain.ErrInvalidRefreshToken).Once()
+	s.mockRepo.On("RevokeAccessToken", s.ctx, "jti-1", expiresAt).Return(nil).Once()
+
+	s.NoError(s.tokenService.Logout(s.ctx, "unknown", "jti-1", expiresAt))
+}
+
+func (s *TokenServiceSuite) TestLogout_AccessTokenOnly() {
+	expiresAt := time.Now().Add(10 * time.Minute)
+	s.mockRepo.On("RevokeAccessToken", s.ctx, "jti-1", expiresAt).Return(nil).Once()
+
+	s.NoError(s.tokenService.Logout(s.ctx, "", "jti-1", expiresAt))
+	s.mockRepo.AssertNotCalled(s.T(), "ConsumeRefreshToken", mock.Anything, mock.Anything)
+}
+
+func (s *TokenServiceSuite) TestIsRevoked() {
+	s.mockRepo.On("IsAccessTokenRevoked", s.ctx, "jti-1").Return(true, nil).Once()
+
+	revoked, err := s.tokenService.IsRevoked(s.ctx, "jti-1")
+	s.NoError(err)
+	s.True(revoked)
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"task7/domain"
+	"task7/repository/interfaces"
+	"time"
+)
+
+// permanently removes tasks that have been in the trash for longer than the retention period, along
+// with their comments and attachments, and takes them off their projects' task counts
+type TrashPurger struct {
+	taskRepo       interfaces.TaskRepository
+	projectRepo    interfaces.ProjectRepository
+	commentRepo    interfaces.CommentRepository
+	attachmentRepo interfaces.AttachmentRepository
+	blobs          interfaces.BlobStore
+	retention      time.Duration
+	logger         *slog.Logger
+}
+
+func NewTrashPurger(tr interfaces.TaskRepository, pr interfaces.ProjectRepository, cr interfaces.CommentRepository, ar interfaces.AttachmentRepository, blobs interfaces.BlobStore, retention time.Duration, logger *slog.Logger) *TrashPurger {
+	return &TrashPurger{
+		taskRepo:       tr,
+		projectRepo:    pr,
+		commentRepo:    cr,
+		attachmentRepo: ar,
+		blobs:          blobs,
+		retention:      retention,
+		logger:         logger,
+	}
+}
+
+// one pass over the trash; returns how many tasks were removed
+func (p *TrashPurger) PurgeOnce(ctx context.Context) (int64, error) {
+	cutoff := time.Now().Add(-p.retention)
+	purged, err := p.taskRepo.PurgeDeletedTasks(ctx, cutoff)
+	if len(purged) > 0 {
+		p.logger.InfoContext(ctx, "purged deleted tasks", slog.Int("count", len(purged)), slog.Time("deleted_before", cutoff))
+	}
+	// whatever was purged before a failure still leaves its comments and attachments behind; nothing
+	// points at them once the task is gone, so they are cleared now or never
+	var cleanupErrs []error
+	uncount := map[string]int{}
+	for _, task := range purged {
+		if err := p.cleanUp(context.WithoutCancel(ctx), task.ID); err != nil {
+			cleanupErrs = append(cleanupErrs, err)
+		}
+		if task.ProjectID != "" {
+			uncount[task.ProjectID]++
+		}
+	}
+	for projectID, n := range uncount {
+		err := p.projectRepo.AdjustTaskCount(context.WithoutCancel(ctx), projectID, -n)
+		if err != nil && domain.KindOf(err) != domain.KindNotFound {
+			cleanupErrs = append(cleanupErrs, fmt.Errorf("uncounting %d purged tasks on project %s: %w", n, projectID, err))
+		}
+	}
+	return int64(len(purged)), errors.Join(err, errors.Join(cleanupErrs...))
+}
+
+// removes the comments and the attachments, content first, of purged task id
+func (p *TrashPurger) cleanUp(ctx context.Context, id int) error {
+	attachments, err := p.attachmentRepo.ListAttachments(ctx, id)
+	if err != nil {
+		return fmt.Errorf("listing the attachments of purged task %d: %w", id, err)
+	}
+	for _, attachment := range attachments {
+		if err := p.blobs.Delete(ctx, attachment.ID); err != nil {
+			return fmt.Errorf("deleting attachment %s of purged task %d: %w", attachment.ID, id, err)
+		}
+		if err := p.attachmentRepo.DeleteAttachment(ctx, attachment.ID); err != nil {
+			return fmt.Errorf("deleting attachment %s of purged task %d: %w", attachment.ID, id, err)
+		}
+	}
+	if err := p.commentRepo.DeleteTaskComments(ctx, id); err != nil {
+		return fmt.Errorf("deleting the comments of purged task %d: %w", id, err)
+	}
+	return nil
+}
+
+// purges right away and then every interval until ctx is cancelled. A failed pass is logged and
+// retried at the next tick.
+func (p *TrashPurger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := p.PurgeOnce(ctx); err != nil && ctx.Err() == nil {
+			p.logger.ErrorContext(ctx, "purging deleted tasks", slog.Any("error", err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
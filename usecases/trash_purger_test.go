@@ -0,0 +1,101 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"task7/domain"
+	services "task7/usecases"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// matches a purge cutoff retention before the time of the call
+func cutoffAround(retention time.Duration) interface{} {
+	return mock.MatchedBy(func(cutoff time.Time) bool {
+		want := time.Now().Add(-retention)
+		return cutoff.After(want.Add(-time.Minute)) && !cutoff.After(want)
+	})
+}
+
+func TestTrashPurger_PurgeOnce(t *testing.T) {
+	repo := new(MockTaskRepository)
+	var logs bytes.Buffer
+	purger := services.NewTrashPurger(repo, new(MockProjectRepository), new(MockCommentRepository), new(MockAttachmentRepository), new(MockBlobStore), 30*24*time.Hour, slog.New(slog.NewJSONHandler(&logs, nil)))
+	repo.On("PurgeDeletedTasks", mock.Anything, cutoffAround(30*24*time.Hour)).Return(nil, nil).Once()
+
+	purged, err := purger.PurgeOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+	assert.Empty(t, logs.String(), "An empty pass is not worth a log line")
+	repo.AssertExpectations(t)
+}
+
+func TestTrashPurger_PurgeOnceRemovesCommentsAndAttachments(t *testing.T) {
+	repo, projects, comments, attachments, blobs := new(MockTaskRepository), new(MockProjectRepository), new(MockCommentRepository), new(MockAttachmentRepository), new(MockBlobStore)
+	var logs bytes.Buffer
+	purger := services.NewTrashPurger(repo, projects, comments, attachments, blobs, time.Hour, slog.New(slog.NewJSONHandler(&logs, nil)))
+	repo.On("PurgeDeletedTasks", mock.Anything, cutoffAround(time.Hour)).Return([]domain.Task{{ID: 3, ProjectID: "p1"}, {ID: 5}}, nil).Once()
+	projects.On("AdjustTaskCount", mock.Anything, "p1", -1).Return(nil).Once()
+	attachments.On("ListAttachments", mock.Anything, 3).Return([]domain.Attachment{{ID: "a1", TaskID: 3}, {ID: "a2", TaskID: 3}}, nil).Once()
+	attachments.On("ListAttachments", mock.Anything, 5).Return(nil, nil).Once()
+	for _, id := range []string{"a1", "a2"} {
+		blobs.On("Delete", mock.Anything, id).Return(nil).Once()
+		attachments.On("DeleteAttachment", mock.Anything, id).Return(nil).Once()
+	}
+	comments.On("DeleteTaskComments", mock.Anything, 3).Return(nil).Once()
+	comments.On("DeleteTaskComments", mock.Anything, 5).Return(nil).Once()
+
+	purged, err := purger.PurgeOnce(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, purged)
+	assert.Contains(t, logs.String(), `"msg":"purged deleted tasks","count":2`)
+	blobs.AssertExpectations(t)
+	attachments.AssertExpectations(t)
+	comments.AssertExpectations(t)
+	projects.AssertExpectations(t)
+}
+
+func TestTrashPurger_PurgeOnceCleanupFailure(t *testing.T) {
+	repo, projects, comments, attachments, blobs := new(MockTaskRepository), new(MockProjectRepository), new(MockCommentRepository), new(MockAttachmentRepository), new(MockBlobStore)
+	purger := services.NewTrashPurger(repo, projects, comments, attachments, blobs, time.Hour, slog.New(slog.DiscardHandler))
+	repo.On("PurgeDeletedTasks", mock.Anything, mock.Anything).Return([]domain.Task{{ID: 3}, {ID: 5}}, nil).Once()
+	attachments.On("ListAttachments", mock.Anything, 3).Return(nil, errors.New("disk on fire")).Once()
+	attachments.On("ListAttachments", mock.Anything, 5).Return(nil, nil).Once()
+	comments.On("DeleteTaskComments", mock.Anything, 5).Return(nil).Once()
+
+	purged, err := purger.PurgeOnce(context.Background())
+	assert.ErrorContains(t, err, "purged task 3")
+	assert.EqualValues(t, 2, purged)
+	comments.AssertExpectations(t)
+	comments.AssertNotCalled(t, "DeleteTaskComments", mock.Anything, 3)
+}
+
+func TestTrashPurger_Run(t *testing.T) {
+	repo := new(MockTaskRepository)
+	var logs bytes.Buffer
+	purger := services.NewTrashPurger(repo, new(MockProjectRepository), new(MockCommentRepository), new(MockAttachmentRepository), new(MockBlobStore), time.Hour, slog.New(slog.NewJSONHandler(&logs, nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	repo.On("PurgeDeletedTasks", mock.Anything, cutoffAround(time.Hour)).Return(nil, errors.New("disk on fire")).Once()
+	repo.On("PurgeDeletedTasks", mock.Anything, cutoffAround(time.Hour)).Return(nil, nil).Run(func(mock.Arguments) { cancel() }).Once()
+
+	done := make(chan struct{})
+	go func() {
+		purger.Run(ctx, time.Millisecond)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop after its context was cancelled")
+	}
+
+	assert.Contains(t, logs.String(), "disk on fire", "A failed pass is logged and retried")
+	repo.AssertExpectations(t)
+}
@@ -1,34 +1,67 @@
 package services
 
 import (
-    "task7/domain"
-    "task7/repository/interfaces"
+	"context"
+	"log/slog"
+	"task7/domain"
+	"task7/repository/interfaces"
 )
 
 type UserService interface {
-    RegisterUser(user *domain.User) error
-    LoginUser(user *domain.User) (domain.User, error)
-	PromoteUser(username string) error
-} 
+	RegisterUser(ctx context.Context, user *domain.User) error
+	LoginUser(ctx context.Context, user *domain.User) (domain.User, error)
+	PromoteUser(ctx context.Context, username string) error
+}
 
-type userService struct {   // one type of userService to implement the interface
-    userRepo interfaces.UserRepository // can be any db as long as it implements UserRepository interface
+type userService struct { // one type of userService to implement the interface
+	userRepo  interfaces.UserRepository // can be any db as long as it implements UserRepository interface
+	auditRepo interfaces.AuditRepository
+	logger    *slog.Logger
 }
 
-func NewUserService(repo interfaces.UserRepository) UserService { // object creation , new type implementer 
-    return &userService{
-        userRepo: repo,
-    }
+func NewUserService(repo interfaces.UserRepository, audit interfaces.AuditRepository, logger *slog.Logger) UserService { // object creation , new type implementer
+	return &userService{
+		userRepo:  repo,
+		auditRepo: audit,
+		logger:    logger,
+	}
 }
 
-func (s *userService) RegisterUser(user *domain.User) error {
-    return s.userRepo.RegisterUser(user)
+// registration is public, so unless an authenticated caller is present the new user is the actor
+func (s *userService) RegisterUser(ctx context.Context, user *domain.User) error {
+	if err := s.userRepo.RegisterUser(ctx, user); err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "user registered", slog.String("new_user", user.Username), slog.String("role", user.Role))
+	actor, ok := domain.ActorFromContext(ctx)
+	if !ok {
+		actor = domain.Actor{Username: user.Username, Role: user.Role}
+	}
+	recordAudit(ctx, s.auditRepo, s.logger, domain.NewUserAuditEntry(domain.AuditUserRegister, actor, user.Username))
+	return nil
 }
 
-func (s *userService) LoginUser(user *domain.User) (domain.User, error) {
-    return s.userRepo.LoginUser(user)
+// an unknown user and a wrong password look the same to the caller
+func (s *userService) LoginUser(ctx context.Context, user *domain.User) (domain.User, error) {
+	found, err := s.userRepo.LoginUser(ctx, user)
+	if kind := domain.KindOf(err); err != nil && (kind == domain.KindNotFound || kind == domain.KindUnauthorized) {
+		s.logger.WarnContext(ctx, "login failed", slog.String("attempted_user", user.Username), slog.String("reason", err.Error()))
+		recordAudit(ctx, s.auditRepo, s.logger, domain.NewUserAuditEntry(domain.AuditLoginFailed, domain.Actor{Username: user.Username}, user.Username))
+		return domain.User{}, &domain.Error{Kind: domain.KindUnauthorized, Message: "invalid username or password", Err: err}
+	}
+	if err != nil {
+		return found, err
+	}
+	recordAudit(ctx, s.auditRepo, s.logger, domain.NewUserAuditEntry(domain.AuditUserLogin, domain.Actor{Username: user.Username, Role: found.Role}, user.Username))
+	return found, nil
 }
 
-func (s *userService) PromoteUser (username string) error {
-	return s.userRepo.PromoteUser(username)
-}
\ No newline at end of file
+func (s *userService) PromoteUser(ctx context.Context, username string) error {
+	if err := s.userRepo.PromoteUser(ctx, username); err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "user promoted to admin", slog.String("promoted_user", username))
+	actor, _ := domain.ActorFromContext(ctx)
+	recordAudit(ctx, s.auditRepo, s.logger, domain.NewUserAuditEntry(domain.AuditUserPromote, actor, username))
+	return nil
+}
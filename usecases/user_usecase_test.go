@@ -1,7 +1,9 @@
 package services_test
 
 import (
+	"context"
 	"errors"
+	"log/slog"
 	"task7/domain"
 	services "task7/usecases"
 	"testing"
@@ -16,30 +18,47 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) RegisterUser(user *domain.User) error {
-	args := m.Called(user)
+func (m *MockUserRepository) RegisterUser(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) LoginUser(user *domain.User) (domain.User, error) {
-	args := m.Called(user)
+func (m *MockUserRepository) LoginUser(ctx context.Context, user *domain.User) (domain.User, error) {
+	args := m.Called(ctx, user)
 	return args.Get(0).(domain.User), args.Error(1)
 }
 
-func (m *MockUserRepository) PromoteUser(username string) error {
-	args := m.Called(username)
+func (m *MockUserRepository) PromoteUser(ctx context.Context, username string) error {
+	args := m.Called(ctx, username)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) GetRole(ctx context.Context, username string) (string, error) {
+	args := m.Called(ctx, username)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserRepository) FindUsernames(ctx context.Context, usernames []string) ([]string, error) {
+	args := m.Called(ctx, usernames)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
 type UserServiceSuite struct {
 	suite.Suite
 	mockRepo    *MockUserRepository
+	mockAudit   *MockAuditRepository
+	ctx         context.Context
 	userService services.UserService
 }
 
 func (s *UserServiceSuite) SetupTest() {
+	s.ctx = context.Background()
 	s.mockRepo = new(MockUserRepository)
-	s.userService = services.NewUserService(s.mockRepo)
+	s.mockAudit = newRecordingAuditRepository()
+	s.userService = services.NewUserService(s.mockRepo, s.mockAudit, slog.New(slog.DiscardHandler))
 }
 
 func TestUserServiceSuite(t *testing.T) {
@@ -52,11 +71,17 @@ func (s *UserServiceSuite) TestRegisterUser_Success() {
 		PasswordHash: "password123",
 	}
 
-	s.mockRepo.On("RegisterUser", user).Return(nil).Once()
+	s.mockRepo.On("RegisterUser", s.ctx, user).Return(nil).Once()
 
-	err := s.userService.RegisterUser(user)
+	err := s.userService.RegisterUser(s.ctx, user)
 	s.NoError(err, "RegisterUser should not return an error on success")
 	s.mockRepo.AssertExpectations(s.T())
+
+	audited := s.mockAudit.appended()
+	s.Require().Len(audited, 1)
+	s.Equal(domain.AuditUserRegister, audited[0].Action)
+	s.Equal("newuser", audited[0].Actor, "Self-registration is attributed to the new user")
+	s.Equal("newuser", audited[0].TargetID)
 }
 
 func (s *UserServiceSuite) TestRegisterUser_RepositoryError() {
@@ -66,12 +91,13 @@ func (s *UserServiceSuite) TestRegisterUser_RepositoryError() {
 	}
 	repoError := errors.New("database registration failed")
 
-	s.mockRepo.On("RegisterUser", user).Return(repoError).Once()
+	s.mockRepo.On("RegisterUser", s.ctx, user).Return(repoError).Once()
 
-	err := s.userService.RegisterUser(user)
+	err := s.userService.RegisterUser(s.ctx, user)
 	s.Error(err, "RegisterUser should return an error when repository fails")
 	s.Equal(repoError, err, "Error returned should be the repository error")
 	s.mockRepo.AssertExpectations(s.T())
+	s.Empty(s.mockAudit.appended())
 }
 
 func (s *UserServiceSuite) TestLoginUser_Success() {
@@ -86,13 +112,19 @@ func (s *UserServiceSuite) TestLoginUser_Success() {
 		PasswordHash: "hashedpassword",
 	}
 
-	s.mockRepo.On("LoginUser", loginCreds).Return(expectedUser, nil).Once()
+	s.mockRepo.On("LoginUser", s.ctx, loginCreds).Return(expectedUser, nil).Once()
 
-	loggedInUser, err := s.userService.LoginUser(loginCreds)
+	loggedInUser, err := s.userService.LoginUser(s.ctx, loginCreds)
 	s.NoError(err, "LoginUser should not return an error on successful login")
 	assert.Equal(s.T(), expectedUser.Username, loggedInUser.Username, "Logged in user username should match")
 	assert.Equal(s.T(), expectedUser.Role, loggedInUser.Role, "Logged in user role should match")
 	s.mockRepo.AssertExpectations(s.T())
+
+	audited := s.mockAudit.appended()
+	s.Require().Len(audited, 1)
+	s.Equal(domain.AuditUserLogin, audited[0].Action)
+	s.Equal("existinguser", audited[0].Actor)
+	s.Equal("regular", audited[0].ActorRole)
 }
 
 func (s *UserServiceSuite) TestLoginUser_RepositoryError() {
@@ -102,33 +134,63 @@ func (s *UserServiceSuite) TestLoginUser_RepositoryError() {
 	}
 	repoError := errors.New("user not found or invalid credentials")
 
-	s.mockRepo.On("LoginUser", loginCreds).Return(domain.User{}, repoError).Once()
+	s.mockRepo.On("LoginUser", s.ctx, loginCreds).Return(domain.User{}, repoError).Once()
 
-	loggedInUser, err := s.userService.LoginUser(loginCreds)
+	loggedInUser, err := s.userService.LoginUser(s.ctx, loginCreds)
 	s.Error(err, "LoginUser should return an error on repository failure")
 	s.Equal(repoError, err, "Error returned should be the repository error")
 	s.Equal(domain.User{}, loggedInUser, "Logged in user should be empty on error")
 	s.mockRepo.AssertExpectations(s.T())
 }
 
+func (s *UserServiceSuite) TestLoginUser_BadCredentialsLookAlike() {
+	for _, repoError := range []error{domain.NewNotFound("user not found"), &domain.Error{Kind: domain.KindUnauthorized, Message: "incorrect password"}} {
+		loginCreds := &domain.User{Username: "someone", PasswordHash: "anypass"}
+		s.mockRepo.On("LoginUser", s.ctx, loginCreds).Return(domain.User{}, repoError).Once()
+
+		_, err := s.userService.LoginUser(s.ctx, loginCreds)
+		s.Equal(domain.KindUnauthorized, domain.KindOf(err))
+		s.ErrorIs(err, repoError, "The repository error should stay in the chain for logs")
+
+		var typed *domain.Error
+		s.Require().ErrorAs(err, &typed)
+		s.Equal("invalid username or password", typed.Message)
+	}
+	s.mockRepo.AssertExpectations(s.T())
+
+	audited := s.mockAudit.appended()
+	s.Require().Len(audited, 2)
+	s.Equal(domain.AuditLoginFailed, audited[0].Action)
+	s.Equal("someone", audited[0].TargetID)
+}
+
 func (s *UserServiceSuite) TestPromoteUser_Success() {
 	username := "user_to_promote"
 
-	s.mockRepo.On("PromoteUser", username).Return(nil).Once()
+	ctx := domain.WithActor(s.ctx, domain.Actor{Username: "root", Role: "admin"})
 
-	err := s.userService.PromoteUser(username)
+	s.mockRepo.On("PromoteUser", ctx, username).Return(nil).Once()
+
+	err := s.userService.PromoteUser(ctx, username)
 	s.NoError(err, "PromoteUser should not return an error on success")
 	s.mockRepo.AssertExpectations(s.T())
+
+	audited := s.mockAudit.appended()
+	s.Require().Len(audited, 1)
+	s.Equal(domain.AuditUserPromote, audited[0].Action)
+	s.Equal("root", audited[0].Actor)
+	s.Equal(username, audited[0].TargetID)
 }
 
 func (s *UserServiceSuite) TestPromoteUser_RepositoryError() {
 	username := "non_existent_user"
 	repoError := errors.New("user not found for promotion")
 
-	s.mockRepo.On("PromoteUser", username).Return(repoError).Once()
+	s.mockRepo.On("PromoteUser", s.ctx, username).Return(repoError).Once()
 
-	err := s.userService.PromoteUser(username)
+	err := s.userService.PromoteUser(s.ctx, username)
 	s.Error(err, "PromoteUser should return an error when repository fails")
 	s.Equal(repoError, err, "Error returned should be the repository error")
 	s.mockRepo.AssertExpectations(s.T())
+	s.Empty(s.mockAudit.appended())
 }